@@ -43,13 +43,15 @@ func SearchFiles(ctx context.Context, opts *FileSearchOptions) ([]*packages.Pack
 
 	cond = cond.And(builder.In("package.id", builder.Select("package_property.ref_id").Where(versionPropsCond).From("package_property")))
 
-	var filePropsCond builder.Cond = builder.Eq{
-		"package_property.ref_type": packages.PropertyTypeFile,
-		"package_property.name":     conda_module.PropertySubdir,
-		"package_property.value":    opts.Subdir,
-	}
+	if opts.Subdir != "" {
+		var filePropsCond builder.Cond = builder.Eq{
+			"package_property.ref_type": packages.PropertyTypeFile,
+			"package_property.name":     conda_module.PropertySubdir,
+			"package_property.value":    opts.Subdir,
+		}
 
-	cond = cond.And(builder.In("package_file.id", builder.Select("package_property.ref_id").Where(filePropsCond).From("package_property")))
+		cond = cond.And(builder.In("package_file.id", builder.Select("package_property.ref_id").Where(filePropsCond).From("package_property")))
+	}
 
 	sess := db.GetEngine(ctx).
 		Select("package_file.*").
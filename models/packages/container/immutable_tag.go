@@ -0,0 +1,86 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"xorm.io/builder"
+)
+
+var ErrImmutableTagRuleNotExist = util.NewNotExistErrorf("immutable tag rule does not exist")
+
+func init() {
+	db.RegisterModel(new(ImmutableTagRule))
+}
+
+// ImmutableTagRule prevents tags matching the pattern from being overwritten or deleted.
+// The rule applies to a single image or to all images of the owner if Image is empty.
+type ImmutableTagRule struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"INDEX NOT NULL"`
+	Image       string             `xorm:"NOT NULL DEFAULT ''"`
+	Pattern     string             `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL DEFAULT 0"`
+}
+
+func (ImmutableTagRule) TableName() string {
+	return "package_container_immutable_tag_rule"
+}
+
+// Matches checks if the rule matches the tag of the image
+func (r *ImmutableTagRule) Matches(image, tag string) bool {
+	if r.Image != "" && r.Image != strings.ToLower(image) {
+		return false
+	}
+
+	g, err := glob.Compile(r.Pattern)
+	if err != nil {
+		log.Error("ImmutableTagRule [%d]: invalid pattern %q: %v", r.ID, r.Pattern, err)
+		return false
+	}
+	return g.Match(strings.ToLower(tag))
+}
+
+func InsertImmutableTagRule(ctx context.Context, r *ImmutableTagRule) error {
+	r.Image = strings.ToLower(r.Image)
+	return db.Insert(ctx, r)
+}
+
+func GetImmutableTagRulesByOwner(ctx context.Context, ownerID int64) ([]*ImmutableTagRule, error) {
+	rules := make([]*ImmutableTagRule, 0, 10)
+	return rules, db.GetEngine(ctx).Where(builder.Eq{"owner_id": ownerID}).OrderBy("image, pattern").Find(&rules)
+}
+
+func DeleteImmutableTagRule(ctx context.Context, ownerID, ruleID int64) error {
+	n, err := db.GetEngine(ctx).Where(builder.Eq{"id": ruleID, "owner_id": ownerID}).Delete(&ImmutableTagRule{})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrImmutableTagRuleNotExist
+	}
+	return nil
+}
+
+// IsTagImmutable checks if any rule of the owner makes the tag of the image immutable
+func IsTagImmutable(ctx context.Context, ownerID int64, image, tag string) (bool, error) {
+	rules, err := GetImmutableTagRulesByOwner(ctx, ownerID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range rules {
+		if r.Matches(image, tag) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableTagRuleMatches(t *testing.T) {
+	cases := []struct {
+		Rule     *ImmutableTagRule
+		Image    string
+		Tag      string
+		Expected bool
+	}{
+		{&ImmutableTagRule{Pattern: "v*"}, "image", "v1.0", true},
+		{&ImmutableTagRule{Pattern: "v*"}, "other/image", "V2", true},
+		{&ImmutableTagRule{Pattern: "v*"}, "image", "latest", false},
+		{&ImmutableTagRule{Pattern: "release-{1,2}.*"}, "image", "release-2.0", true},
+		{&ImmutableTagRule{Pattern: "release-{1,2}.*"}, "image", "release-3.0", false},
+		{&ImmutableTagRule{Image: "image", Pattern: "*"}, "Image", "main", true},
+		{&ImmutableTagRule{Image: "image", Pattern: "*"}, "other", "main", false},
+		{&ImmutableTagRule{Pattern: "[invalid"}, "image", "[invalid", false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, c.Rule.Matches(c.Image, c.Tag), "pattern %q, image %q, tag %q", c.Rule.Pattern, c.Image, c.Tag)
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 type SearchOptions struct {
 	OwnerID  int64
+	Name     string
 	FileType string
 	Platform string
 	RVersion string
@@ -30,14 +31,22 @@ func (opts *SearchOptions) toConds() builder.Cond {
 		"package_version.is_internal": false,
 	}
 
+	if opts.Name != "" {
+		cond = cond.And(builder.Eq{"package.lower_name": strings.ToLower(opts.Name)})
+	}
 	if opts.Filename != "" {
 		cond = cond.And(builder.Eq{"package_file.lower_name": strings.ToLower(opts.Filename)})
 	}
 
+	return cond.And(opts.filePropertiesCond("package_file.id"))
+}
+
+// filePropertiesCond matches the file referenced by fileIDColumn if it has the searched type, platform and R version
+func (opts *SearchOptions) filePropertiesCond(fileIDColumn string) builder.Cond {
 	var propsCond builder.Cond = builder.Eq{
 		"package_property.ref_type": packages.PropertyTypeFile,
 	}
-	propsCond = propsCond.And(builder.Expr("package_property.ref_id = package_file.id"))
+	propsCond = propsCond.And(builder.Expr("package_property.ref_id = " + fileIDColumn))
 
 	count := 1
 	propsCondBlock := builder.Eq{"package_property.name": cran_module.PropertyType}.And(builder.Eq{"package_property.value": opts.FileType})
@@ -46,32 +55,55 @@ func (opts *SearchOptions) toConds() builder.Cond {
 		count += 2
 		propsCondBlock = propsCondBlock.
 			Or(builder.Eq{"package_property.name": cran_module.PropertyPlatform}.And(builder.Eq{"package_property.value": opts.Platform})).
-			Or(builder.Eq{"package_property.name": cran_module.PropertyRVersion}.And(builder.Eq{"package_property.value": opts.RVersion}))
+			Or(builder.Eq{"package_property.name": cran_module.PropertyRVersion}.And(rVersionCond(opts.RVersion)))
 	}
 
 	propsCond = propsCond.And(propsCondBlock)
 
-	cond = cond.And(builder.Eq{
+	return builder.Eq{
 		strconv.Itoa(count): builder.Select("COUNT(*)").Where(propsCond).From("package_property"),
-	})
+	}
+}
 
-	return cond
+// rVersionCond matches the normalized R version.
+// Binaries uploaded before the R version got normalized store the full major.minor.patch version.
+func rVersionCond(rversion string) builder.Cond {
+	return builder.Eq{"package_property.value": rversion}.Or(builder.Like{"package_property.value", rversion + ".%"})
 }
 
-func SearchLatestVersions(ctx context.Context, opts *SearchOptions) ([]*packages.PackageVersion, error) {
+// newerVersionsQuery selects the newer versions of the same package which have a file of the searched type, platform and R version.
+// A version without such a newer version is the latest one for the searched files.
+func (opts *SearchOptions) newerVersionsQuery() *builder.Builder {
+	return builder.Select("pv2.id").
+		From("package_version pv2").
+		InnerJoin("package_file pf2", "pf2.version_id = pv2.id").
+		Where(builder.Expr("package_version.package_id = pv2.package_id AND pv2.is_internal = ? AND (package_version.created_unix < pv2.created_unix OR (package_version.created_unix = pv2.created_unix AND package_version.id < pv2.id))", false).
+			And(opts.filePropertiesCond("pf2.id")))
+}
+
+func searchVersions(ctx context.Context, cond builder.Cond) ([]*packages.PackageVersion, error) {
 	sess := db.GetEngine(ctx).
 		Table("package_version").
 		Select("package_version.*").
-		Join("LEFT", "package_version pv2", builder.Expr("package_version.package_id = pv2.package_id AND pv2.is_internal = ? AND (package_version.created_unix < pv2.created_unix OR (package_version.created_unix = pv2.created_unix AND package_version.id < pv2.id))", false)).
 		Join("INNER", "package", "package.id = package_version.package_id").
 		Join("INNER", "package_file", "package_file.version_id = package_version.id").
-		Where(opts.toConds().And(builder.Expr("pv2.id IS NULL"))).
-		Asc("package.name")
+		Where(cond).
+		Asc("package.name", "package_version.created_unix", "package_version.id")
 
 	pvs := make([]*packages.PackageVersion, 0, 10)
 	return pvs, sess.Find(&pvs)
 }
 
+// SearchLatestVersions gets the latest version of every package which has a matching file
+func SearchLatestVersions(ctx context.Context, opts *SearchOptions) ([]*packages.PackageVersion, error) {
+	return searchVersions(ctx, opts.toConds().And(builder.NotExists(opts.newerVersionsQuery())))
+}
+
+// SearchArchivedVersions gets all versions with a matching file which are superseded by a newer version
+func SearchArchivedVersions(ctx context.Context, opts *SearchOptions) ([]*packages.PackageVersion, error) {
+	return searchVersions(ctx, opts.toConds().And(builder.Exists(opts.newerVersionsQuery())))
+}
+
 func SearchFile(ctx context.Context, opts *SearchOptions) (*packages.PackageFile, error) {
 	sess := db.GetEngine(ctx).
 		Table("package_version").
@@ -0,0 +1,47 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
+
+	"xorm.io/builder"
+)
+
+// GetPublishedSnapshotVersion gets the snapshot version which is published as the distribution
+// The prefix is compared in Go because "_" is a wildcard in LIKE patterns.
+func GetPublishedSnapshotVersion(ctx context.Context, ownerID int64, distribution string) (*packages.PackageVersion, error) {
+	cond := builder.Eq{
+		"package.owner_id":            ownerID,
+		"package.type":                packages.TypeDebian,
+		"package.lower_name":          debian_module.RepositoryPackage,
+		"package.is_internal":         true,
+		"package_version.is_internal": true,
+		"package_file.lower_name":     "inrelease",
+		"package_file.composite_key":  distribution,
+	}
+
+	pvs := make([]*packages.PackageVersion, 0, 2)
+	if err := db.GetEngine(ctx).
+		Table("package_version").
+		Select("package_version.*").
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Join("INNER", "package_file", "package_file.version_id = package_version.id").
+		Where(cond).
+		Find(&pvs); err != nil {
+		return nil, err
+	}
+
+	for _, pv := range pvs {
+		if strings.HasPrefix(pv.LowerVersion, debian_module.SnapshotVersionPrefix) {
+			return pv, nil
+		}
+	}
+	return nil, packages.ErrPackageNotExist
+}
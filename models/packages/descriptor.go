@@ -12,8 +12,11 @@ import (
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/packages/alpine"
+	"code.gitea.io/gitea/modules/packages/ansible"
+	"code.gitea.io/gitea/modules/packages/arch"
 	"code.gitea.io/gitea/modules/packages/cargo"
 	"code.gitea.io/gitea/modules/packages/chef"
+	"code.gitea.io/gitea/modules/packages/cocoapods"
 	"code.gitea.io/gitea/modules/packages/composer"
 	"code.gitea.io/gitea/modules/packages/conan"
 	"code.gitea.io/gitea/modules/packages/conda"
@@ -21,7 +24,10 @@ import (
 	"code.gitea.io/gitea/modules/packages/cran"
 	"code.gitea.io/gitea/modules/packages/debian"
 	"code.gitea.io/gitea/modules/packages/helm"
+	"code.gitea.io/gitea/modules/packages/hex"
+	"code.gitea.io/gitea/modules/packages/huggingface"
 	"code.gitea.io/gitea/modules/packages/maven"
+	"code.gitea.io/gitea/modules/packages/nix"
 	"code.gitea.io/gitea/modules/packages/npm"
 	"code.gitea.io/gitea/modules/packages/nuget"
 	"code.gitea.io/gitea/modules/packages/pub"
@@ -29,6 +35,7 @@ import (
 	"code.gitea.io/gitea/modules/packages/rpm"
 	"code.gitea.io/gitea/modules/packages/rubygems"
 	"code.gitea.io/gitea/modules/packages/swift"
+	"code.gitea.io/gitea/modules/packages/terraform"
 	"code.gitea.io/gitea/modules/packages/vagrant"
 	"code.gitea.io/gitea/modules/util"
 
@@ -144,10 +151,18 @@ func GetPackageDescriptor(ctx context.Context, pv *PackageVersion) (*PackageDesc
 	switch p.Type {
 	case TypeAlpine:
 		metadata = &alpine.VersionMetadata{}
+	case TypeAnsible:
+		metadata = &ansible.Metadata{}
+	case TypeArch:
+		metadata = &arch.VersionMetadata{}
+	case TypeBazel:
+		// bazel cache entries have no metadata
 	case TypeCargo:
 		metadata = &cargo.Metadata{}
 	case TypeChef:
 		metadata = &chef.Metadata{}
+	case TypeCocoaPods:
+		metadata = &cocoapods.Metadata{}
 	case TypeComposer:
 		metadata = &composer.Metadata{}
 	case TypeConan:
@@ -164,14 +179,24 @@ func GetPackageDescriptor(ctx context.Context, pv *PackageVersion) (*PackageDesc
 		// generic packages have no metadata
 	case TypeGo:
 		// go packages have no metadata
+	case TypeGradle:
+		// gradle build cache entries have no metadata
 	case TypeHelm:
 		metadata = &helm.Metadata{}
+	case TypeHex:
+		metadata = &hex.Metadata{}
+	case TypeLFS:
+		// git lfs objects have no metadata
+	case TypeNix:
+		metadata = &nix.Metadata{}
 	case TypeNuGet:
 		metadata = &nuget.Metadata{}
 	case TypeNpm:
 		metadata = &npm.Metadata{}
 	case TypeMaven:
 		metadata = &maven.Metadata{}
+	case TypeModel:
+		metadata = &huggingface.Metadata{}
 	case TypePub:
 		metadata = &pub.Metadata{}
 	case TypePyPI:
@@ -182,6 +207,10 @@ func GetPackageDescriptor(ctx context.Context, pv *PackageVersion) (*PackageDesc
 		metadata = &rubygems.Metadata{}
 	case TypeSwift:
 		metadata = &swift.Metadata{}
+	case TypeTerraformModule:
+		metadata = &terraform.ModuleMetadata{}
+	case TypeTerraformProvider:
+		metadata = &terraform.ProviderMetadata{}
 	case TypeVagrant:
 		metadata = &vagrant.Metadata{}
 	default:
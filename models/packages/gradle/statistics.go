@@ -0,0 +1,72 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gradle
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(CacheStatistics))
+}
+
+// CacheStatistics counts the requests of the build cache of an owner which could or could not be served
+type CacheStatistics struct {
+	ID      int64 `xorm:"pk autoincr"`
+	OwnerID int64 `xorm:"UNIQUE NOT NULL"`
+	Hits    int64 `xorm:"NOT NULL DEFAULT 0"`
+	Misses  int64 `xorm:"NOT NULL DEFAULT 0"`
+}
+
+func (CacheStatistics) TableName() string {
+	return "package_gradle_cache_statistics"
+}
+
+// HitRate returns the percentage of requests which could be served from the cache
+func (s *CacheStatistics) HitRate() int64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return s.Hits * 100 / (s.Hits + s.Misses)
+}
+
+// IncrementHits increments the hit counter of the owner
+func IncrementHits(ctx context.Context, ownerID int64) error {
+	return increment(ctx, ownerID, "hits")
+}
+
+// IncrementMisses increments the miss counter of the owner
+func IncrementMisses(ctx context.Context, ownerID int64) error {
+	return increment(ctx, ownerID, "misses")
+}
+
+func increment(ctx context.Context, ownerID int64, column string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		n, err := db.GetEngine(ctx).Where(builder.Eq{"owner_id": ownerID}).Incr(column).Update(&CacheStatistics{})
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+
+		s := &CacheStatistics{OwnerID: ownerID}
+		if column == "hits" {
+			s.Hits = 1
+		} else {
+			s.Misses = 1
+		}
+		return db.Insert(ctx, s)
+	})
+}
+
+// GetAllCacheStatistics gets the statistics of all owners
+func GetAllCacheStatistics(ctx context.Context) ([]*CacheStatistics, error) {
+	stats := make([]*CacheStatistics, 0, 10)
+	return stats, db.GetEngine(ctx).OrderBy("owner_id").Find(&stats)
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package huggingface
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/optional"
+	huggingface_module "code.gitea.io/gitea/modules/packages/huggingface"
+
+	"xorm.io/builder"
+)
+
+// GetFileByBlobHash gets a file of the owner with the specific content
+// The not yet committed large files are included, so an object is only uploaded once.
+func GetFileByBlobHash(ctx context.Context, ownerID int64, hashSHA256 string) (*packages_model.PackageFile, error) {
+	pf := &packages_model.PackageFile{}
+	has, err := db.GetEngine(ctx).
+		Table("package_file").
+		Select("package_file.*").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Join("INNER", "package_blob", "package_blob.id = package_file.blob_id").
+		Where(builder.Eq{
+			"package.owner_id":         ownerID,
+			"package.type":             packages_model.TypeModel,
+			"package_blob.hash_sha256": hashSHA256,
+		}).
+		Get(pf)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, packages_model.ErrPackageFileNotExist
+	}
+	return pf, nil
+}
+
+// GetVersionByCommit gets the revision of the repository with the specific commit id
+func GetVersionByCommit(ctx context.Context, packageID int64, commit string) (*packages_model.PackageVersion, error) {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		PackageID:  packageID,
+		IsInternal: optional.Some(false),
+		Properties: map[string]string{
+			huggingface_module.PropertyCommit: commit,
+		},
+		Paginator: db.NewAbsoluteListOptions(0, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pvs) == 0 {
+		return nil, packages_model.ErrPackageNotExist
+	}
+	return pvs[0], nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfs
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+var (
+	ErrLockNotExist      = util.NewNotExistErrorf("lock does not exist")
+	ErrLockAlreadyExists = util.NewAlreadyExistErrorf("path is already locked")
+)
+
+func init() {
+	db.RegisterModel(new(Lock))
+}
+
+// Lock prevents other users from pushing changes to a path of a project
+type Lock struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Project     string             `xorm:"UNIQUE(s) NOT NULL"`
+	Path        string             `xorm:"UNIQUE(s) NOT NULL"`
+	UserID      int64              `xorm:"INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL DEFAULT 0"`
+}
+
+func (Lock) TableName() string {
+	return "package_lfs_lock"
+}
+
+// CleanPath normalizes the path of a lock
+func CleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+}
+
+// CreateLock locks the path of the project
+func CreateLock(ctx context.Context, l *Lock) (*Lock, error) {
+	l.Project = strings.ToLower(l.Project)
+	l.Path = CleanPath(l.Path)
+
+	return l, db.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := GetLockByPath(ctx, l.OwnerID, l.Project, l.Path)
+		if err == nil {
+			*l = *existing
+			return ErrLockAlreadyExists
+		} else if err != ErrLockNotExist {
+			return err
+		}
+
+		return db.Insert(ctx, l)
+	})
+}
+
+// GetLockByID gets a lock of the project by its id
+func GetLockByID(ctx context.Context, ownerID int64, project string, id int64) (*Lock, error) {
+	l := &Lock{}
+	has, err := db.GetEngine(ctx).
+		Where(builder.Eq{"id": id, "owner_id": ownerID, "project": strings.ToLower(project)}).
+		Get(l)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrLockNotExist
+	}
+	return l, nil
+}
+
+// GetLockByPath gets the lock of the path of the project
+func GetLockByPath(ctx context.Context, ownerID int64, project, p string) (*Lock, error) {
+	l := &Lock{}
+	has, err := db.GetEngine(ctx).
+		Where(builder.Eq{"owner_id": ownerID, "project": strings.ToLower(project), "path": CleanPath(p)}).
+		Get(l)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, ErrLockNotExist
+	}
+	return l, nil
+}
+
+// GetLocks gets the locks of the project ordered by id
+// The list starts at the lock with the cursor id.
+func GetLocks(ctx context.Context, ownerID int64, project string, cursor int64, limit int) ([]*Lock, error) {
+	locks := make([]*Lock, 0, limit)
+	return locks, db.GetEngine(ctx).
+		Where(builder.Eq{"owner_id": ownerID, "project": strings.ToLower(project)}.And(builder.Gte{"id": cursor})).
+		OrderBy("id").
+		Limit(limit).
+		Find(&locks)
+}
+
+// DeleteLock removes the lock
+func DeleteLock(ctx context.Context, id int64) error {
+	_, err := db.DeleteByID[Lock](ctx, id)
+	return err
+}
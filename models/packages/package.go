@@ -30,33 +30,48 @@ type Type string
 
 // List of supported packages
 const (
-	TypeAlpine    Type = "alpine"
-	TypeCargo     Type = "cargo"
-	TypeChef      Type = "chef"
-	TypeComposer  Type = "composer"
-	TypeConan     Type = "conan"
-	TypeConda     Type = "conda"
-	TypeContainer Type = "container"
-	TypeCran      Type = "cran"
-	TypeDebian    Type = "debian"
-	TypeGeneric   Type = "generic"
-	TypeGo        Type = "go"
-	TypeHelm      Type = "helm"
-	TypeMaven     Type = "maven"
-	TypeNpm       Type = "npm"
-	TypeNuGet     Type = "nuget"
-	TypePub       Type = "pub"
-	TypePyPI      Type = "pypi"
-	TypeRpm       Type = "rpm"
-	TypeRubyGems  Type = "rubygems"
-	TypeSwift     Type = "swift"
-	TypeVagrant   Type = "vagrant"
+	TypeAlpine            Type = "alpine"
+	TypeAnsible           Type = "ansible"
+	TypeArch              Type = "arch"
+	TypeBazel             Type = "bazel"
+	TypeCargo             Type = "cargo"
+	TypeChef              Type = "chef"
+	TypeCocoaPods         Type = "cocoapods"
+	TypeComposer          Type = "composer"
+	TypeConan             Type = "conan"
+	TypeConda             Type = "conda"
+	TypeContainer         Type = "container"
+	TypeCran              Type = "cran"
+	TypeDebian            Type = "debian"
+	TypeGeneric           Type = "generic"
+	TypeGo                Type = "go"
+	TypeGradle            Type = "gradle"
+	TypeHelm              Type = "helm"
+	TypeHex               Type = "hex"
+	TypeLFS               Type = "lfs"
+	TypeMaven             Type = "maven"
+	TypeModel             Type = "model"
+	TypeNix               Type = "nix"
+	TypeNpm               Type = "npm"
+	TypeNuGet             Type = "nuget"
+	TypePub               Type = "pub"
+	TypePyPI              Type = "pypi"
+	TypeRpm               Type = "rpm"
+	TypeRubyGems          Type = "rubygems"
+	TypeSwift             Type = "swift"
+	TypeTerraformModule   Type = "terraform_module"
+	TypeTerraformProvider Type = "terraform_provider"
+	TypeVagrant           Type = "vagrant"
 )
 
 var TypeList = []Type{
 	TypeAlpine,
+	TypeAnsible,
+	TypeArch,
+	TypeBazel,
 	TypeCargo,
 	TypeChef,
+	TypeCocoaPods,
 	TypeComposer,
 	TypeConan,
 	TypeConda,
@@ -65,8 +80,13 @@ var TypeList = []Type{
 	TypeDebian,
 	TypeGeneric,
 	TypeGo,
+	TypeGradle,
 	TypeHelm,
+	TypeHex,
+	TypeLFS,
 	TypeMaven,
+	TypeModel,
+	TypeNix,
 	TypeNpm,
 	TypeNuGet,
 	TypePub,
@@ -74,6 +94,8 @@ var TypeList = []Type{
 	TypeRpm,
 	TypeRubyGems,
 	TypeSwift,
+	TypeTerraformModule,
+	TypeTerraformProvider,
 	TypeVagrant,
 }
 
@@ -82,10 +104,18 @@ func (pt Type) Name() string {
 	switch pt {
 	case TypeAlpine:
 		return "Alpine"
+	case TypeAnsible:
+		return "Ansible"
+	case TypeArch:
+		return "Arch"
+	case TypeBazel:
+		return "Bazel"
 	case TypeCargo:
 		return "Cargo"
 	case TypeChef:
 		return "Chef"
+	case TypeCocoaPods:
+		return "CocoaPods"
 	case TypeComposer:
 		return "Composer"
 	case TypeConan:
@@ -102,10 +132,20 @@ func (pt Type) Name() string {
 		return "Generic"
 	case TypeGo:
 		return "Go"
+	case TypeGradle:
+		return "Gradle"
 	case TypeHelm:
 		return "Helm"
+	case TypeHex:
+		return "Hex"
+	case TypeLFS:
+		return "Git LFS"
 	case TypeMaven:
 		return "Maven"
+	case TypeModel:
+		return "Hugging Face"
+	case TypeNix:
+		return "Nix"
 	case TypeNpm:
 		return "npm"
 	case TypeNuGet:
@@ -120,6 +160,10 @@ func (pt Type) Name() string {
 		return "RubyGems"
 	case TypeSwift:
 		return "Swift"
+	case TypeTerraformModule:
+		return "Terraform Module"
+	case TypeTerraformProvider:
+		return "Terraform Provider"
 	case TypeVagrant:
 		return "Vagrant"
 	}
@@ -131,10 +175,18 @@ func (pt Type) SVGName() string {
 	switch pt {
 	case TypeAlpine:
 		return "gitea-alpine"
+	case TypeAnsible:
+		return "gitea-ansible"
+	case TypeArch:
+		return "gitea-arch"
+	case TypeBazel:
+		return "gitea-bazel"
 	case TypeCargo:
 		return "gitea-cargo"
 	case TypeChef:
 		return "gitea-chef"
+	case TypeCocoaPods:
+		return "gitea-cocoapods"
 	case TypeComposer:
 		return "gitea-composer"
 	case TypeConan:
@@ -151,10 +203,20 @@ func (pt Type) SVGName() string {
 		return "octicon-package"
 	case TypeGo:
 		return "gitea-go"
+	case TypeGradle:
+		return "gitea-gradle"
 	case TypeHelm:
 		return "gitea-helm"
+	case TypeHex:
+		return "gitea-hex"
+	case TypeLFS:
+		return "gitea-lfs"
 	case TypeMaven:
 		return "gitea-maven"
+	case TypeModel:
+		return "gitea-huggingface"
+	case TypeNix:
+		return "gitea-nix"
 	case TypeNpm:
 		return "gitea-npm"
 	case TypeNuGet:
@@ -169,6 +231,8 @@ func (pt Type) SVGName() string {
 		return "gitea-rubygems"
 	case TypeSwift:
 		return "gitea-swift"
+	case TypeTerraformModule, TypeTerraformProvider:
+		return "gitea-terraform"
 	case TypeVagrant:
 		return "gitea-vagrant"
 	}
@@ -0,0 +1,53 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// CacheEntry is an entry of a build cache with the information needed to evict it.
+// Build caches store every entry as a package version with a single file.
+type CacheEntry struct {
+	VersionID        int64
+	Name             string
+	Size             int64
+	CreatedUnix      timeutil.TimeStamp
+	LastDownloadUnix timeutil.TimeStamp
+}
+
+// LastActivityUnix returns the time the entry was last stored or downloaded
+func (e *CacheEntry) LastActivityUnix() timeutil.TimeStamp {
+	if e.LastDownloadUnix > e.CreatedUnix {
+		return e.LastDownloadUnix
+	}
+	return e.CreatedUnix
+}
+
+// GetCacheEntries gets all cache entries of the owner
+// The creation time of an entry is the time its file was stored because entries may be overwritten.
+func GetCacheEntries(ctx context.Context, ownerID int64, packageType Type) ([]*CacheEntry, error) {
+	entries := make([]*CacheEntry, 0, 10)
+	return entries, db.GetEngine(ctx).
+		Table("package_version").
+		Select("package_version.id AS version_id, package.lower_name AS name, package_blob.size, package_file.created_unix, package_version.last_download_unix").
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Join("INNER", "package_file", "package_file.version_id = package_version.id").
+		Join("INNER", "package_blob", "package_blob.id = package_file.blob_id").
+		Where("package.owner_id = ? AND package.type = ? AND package_version.is_internal = ?", ownerID, packageType, false).
+		Find(&entries)
+}
+
+// GetOwnerIDsByType gets the ids of all owners which have packages of the type
+func GetOwnerIDsByType(ctx context.Context, packageType Type) ([]int64, error) {
+	ownerIDs := make([]int64, 0, 10)
+	return ownerIDs, db.GetEngine(ctx).
+		Table("package").
+		Distinct("owner_id").
+		Where("type = ?", packageType).
+		Find(&ownerIDs)
+}
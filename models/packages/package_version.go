@@ -25,15 +25,16 @@ func init() {
 
 // PackageVersion represents a package version
 type PackageVersion struct {
-	ID            int64              `xorm:"pk autoincr"`
-	PackageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
-	CreatorID     int64              `xorm:"NOT NULL DEFAULT 0"`
-	Version       string             `xorm:"NOT NULL"`
-	LowerVersion  string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
-	CreatedUnix   timeutil.TimeStamp `xorm:"created INDEX NOT NULL"`
-	IsInternal    bool               `xorm:"INDEX NOT NULL DEFAULT false"`
-	MetadataJSON  string             `xorm:"metadata_json LONGTEXT"`
-	DownloadCount int64              `xorm:"NOT NULL DEFAULT 0"`
+	ID               int64              `xorm:"pk autoincr"`
+	PackageID        int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatorID        int64              `xorm:"NOT NULL DEFAULT 0"`
+	Version          string             `xorm:"NOT NULL"`
+	LowerVersion     string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix      timeutil.TimeStamp `xorm:"created INDEX NOT NULL"`
+	IsInternal       bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	MetadataJSON     string             `xorm:"metadata_json LONGTEXT"`
+	DownloadCount    int64              `xorm:"NOT NULL DEFAULT 0"`
+	LastDownloadUnix timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
 }
 
 // GetOrInsertVersion inserts a version. If the same version exist already ErrDuplicatePackageVersion is returned
@@ -64,12 +65,20 @@ func UpdateVersion(ctx context.Context, pv *PackageVersion) error {
 	return err
 }
 
-// IncrementDownloadCounter increments the download counter of a version
+// IncrementDownloadCounter increments the download counter of a version and records the download time
 func IncrementDownloadCounter(ctx context.Context, versionID int64) error {
-	_, err := db.GetEngine(ctx).Exec("UPDATE `package_version` SET `download_count` = `download_count` + 1 WHERE `id` = ?", versionID)
+	_, err := db.GetEngine(ctx).Exec("UPDATE `package_version` SET `download_count` = `download_count` + 1, `last_download_unix` = ? WHERE `id` = ?", timeutil.TimeStampNow(), versionID)
 	return err
 }
 
+// LastActivityUnix returns the time of the last download or the creation time if the version was never downloaded
+func (pv *PackageVersion) LastActivityUnix() timeutil.TimeStamp {
+	if pv.LastDownloadUnix > pv.CreatedUnix {
+		return pv.LastDownloadUnix
+	}
+	return pv.CreatedUnix
+}
+
 // GetVersionByID gets a version by id
 func GetVersionByID(ctx context.Context, versionID int64) (*PackageVersion, error) {
 	pv := &PackageVersion{}
@@ -0,0 +1,928 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package alpine
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+	"code.gitea.io/gitea/modules/zstd"
+)
+
+// The apk-tools v3 package and index format is the "Alpine database" (ADB) format
+// https://gitlab.alpinelinux.org/alpine/apk-tools/-/blob/master/doc/apk-v3.5.scd
+// https://gitlab.alpinelinux.org/alpine/apk-tools/-/blob/master/src/adb.h
+// https://gitlab.alpinelinux.org/alpine/apk-tools/-/blob/master/src/apk_adb.h
+
+var ErrInvalidADB = util.NewInvalidArgumentErrorf("ADB file is invalid")
+
+const (
+	PackageFormatV3 = 3
+
+	adbMagic           = "ADB."
+	adbMagicDeflate    = "ADBd"
+	adbMagicCompressed = "ADBc"
+
+	ADBSchemaIndex   uint32 = 0x78646e69 // indx
+	ADBSchemaPackage uint32 = 0x676b6370 // pckg
+
+	adbBlockADB  = 0
+	adbBlockSig  = 1
+	adbBlockData = 2
+	adbBlockExt  = 3
+
+	adbBlockAlignment = 8
+
+	adbTypeSpecial uint32 = 0x00000000
+	adbTypeInt     uint32 = 0x10000000
+	adbTypeInt32   uint32 = 0x20000000
+	adbTypeInt64   uint32 = 0x30000000
+	adbTypeBlob8   uint32 = 0x80000000
+	adbTypeBlob16  uint32 = 0x90000000
+	adbTypeBlob32  uint32 = 0xa0000000
+	adbTypeArray   uint32 = 0xd0000000
+	adbTypeObject  uint32 = 0xe0000000
+	adbTypeMask    uint32 = 0xf0000000
+	adbValueMask   uint32 = 0x0fffffff
+
+	adbNull uint32 = 0
+
+	adbHashSHA512 = 0x03
+
+	// dependency fields
+	adbDepName    = 0x01
+	adbDepVersion = 0x02
+	adbDepMatch   = 0x03
+
+	// package info fields
+	adbPkgInfoName             = 0x01
+	adbPkgInfoVersion          = 0x02
+	adbPkgInfoHashes           = 0x03
+	adbPkgInfoDescription      = 0x04
+	adbPkgInfoArch             = 0x05
+	adbPkgInfoLicense          = 0x06
+	adbPkgInfoOrigin           = 0x07
+	adbPkgInfoMaintainer       = 0x08
+	adbPkgInfoURL              = 0x09
+	adbPkgInfoRepoCommit       = 0x0a
+	adbPkgInfoBuildTime        = 0x0b
+	adbPkgInfoInstalledSize    = 0x0c
+	adbPkgInfoFileSize         = 0x0d
+	adbPkgInfoProviderPriority = 0x0e
+	adbPkgInfoDepends          = 0x0f
+	adbPkgInfoProvides         = 0x10
+	adbPkgInfoReplaces         = 0x11
+	adbPkgInfoInstallIf        = 0x12
+
+	// index fields
+	adbIndexDescription = 0x01
+	adbIndexPackages    = 0x02
+
+	// package fields
+	adbPackagePkgInfo = 0x01
+
+	// dependency version match flags
+	versionEqual    = 1
+	versionLess     = 2
+	versionGreater  = 4
+	versionFuzzy    = 8
+	versionConflict = 16
+	versionAny      = versionEqual | versionLess | versionGreater
+)
+
+// adbWriter builds the content of an ADB block
+type adbWriter struct {
+	buf []byte
+}
+
+func newADBWriter() *adbWriter {
+	// The block starts with the header which contains the root value
+	return &adbWriter{buf: make([]byte, 8, 4096)}
+}
+
+func (w *adbWriter) align(n int) {
+	for len(w.buf)%n != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *adbWriter) writeInt(v uint64) uint32 {
+	if v <= uint64(adbValueMask) {
+		return adbTypeInt | uint32(v)
+	}
+	if v <= 0xffffffff {
+		w.align(4)
+		offset := uint32(len(w.buf))
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v))
+		return adbTypeInt32 | offset
+	}
+	w.align(8)
+	offset := uint32(len(w.buf))
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, v)
+	return adbTypeInt64 | offset
+}
+
+func (w *adbWriter) writeBlob(b []byte) uint32 {
+	if len(b) == 0 {
+		return adbNull
+	}
+
+	var offset uint32
+	var typ uint32
+	switch {
+	case len(b) <= 0xff:
+		offset, typ = uint32(len(w.buf)), adbTypeBlob8
+		w.buf = append(w.buf, uint8(len(b)))
+	case len(b) <= 0xffff:
+		w.align(2)
+		offset, typ = uint32(len(w.buf)), adbTypeBlob16
+		w.buf = binary.LittleEndian.AppendUint16(w.buf, uint16(len(b)))
+	default:
+		w.align(4)
+		offset, typ = uint32(len(w.buf)), adbTypeBlob32
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(b)))
+	}
+	w.buf = append(w.buf, b...)
+	return typ | offset
+}
+
+func (w *adbWriter) writeString(s string) uint32 {
+	return w.writeBlob([]byte(s))
+}
+
+// writeObject writes an object. The fields are indexed by their field number.
+func (w *adbWriter) writeObject(fields []uint32) uint32 {
+	return w.writeValues(adbTypeObject, fields)
+}
+
+func (w *adbWriter) writeArray(items []uint32) uint32 {
+	return w.writeValues(adbTypeArray, append([]uint32{adbNull}, items...))
+}
+
+func (w *adbWriter) writeValues(typ uint32, values []uint32) uint32 {
+	n := len(values)
+	for n > 1 && values[n-1] == adbNull {
+		n--
+	}
+
+	w.align(4)
+	offset := uint32(len(w.buf))
+	// The first entry holds the number of entries including itself
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(max(n, 1)))
+	for _, v := range values[1:max(n, 1)] {
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+	}
+	return typ | offset
+}
+
+// finish sets the root value and returns the block content
+func (w *adbWriter) finish(root uint32) []byte {
+	w.buf[0] = 0 // compat version
+	w.buf[1] = 0 // version
+	binary.LittleEndian.PutUint32(w.buf[4:], root)
+	return w.buf
+}
+
+// adbReader reads values from the content of an ADB block
+type adbReader struct {
+	data []byte
+}
+
+func (r *adbReader) root() (uint32, error) {
+	if len(r.data) < 8 || r.data[0] != 0 {
+		return 0, ErrInvalidADB
+	}
+	return binary.LittleEndian.Uint32(r.data[4:]), nil
+}
+
+func (r *adbReader) deref(v uint32, size int) ([]byte, error) {
+	offset := int(v & adbValueMask)
+	if offset+size > len(r.data) {
+		return nil, ErrInvalidADB
+	}
+	return r.data[offset : offset+size], nil
+}
+
+func (r *adbReader) readInt(v uint32) (uint64, error) {
+	switch v & adbTypeMask {
+	case adbTypeSpecial:
+		return 0, nil
+	case adbTypeInt:
+		return uint64(v & adbValueMask), nil
+	case adbTypeInt32:
+		b, err := r.deref(v, 4)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.LittleEndian.Uint32(b)), nil
+	case adbTypeInt64:
+		b, err := r.deref(v, 8)
+		if err != nil {
+			return 0, err
+		}
+		return binary.LittleEndian.Uint64(b), nil
+	}
+	return 0, ErrInvalidADB
+}
+
+func (r *adbReader) readBlob(v uint32) ([]byte, error) {
+	var header []byte
+	var err error
+	var length int
+	switch v & adbTypeMask {
+	case adbTypeSpecial:
+		return nil, nil
+	case adbTypeBlob8:
+		if header, err = r.deref(v, 1); err == nil {
+			length = int(header[0])
+		}
+	case adbTypeBlob16:
+		if header, err = r.deref(v, 2); err == nil {
+			length = int(binary.LittleEndian.Uint16(header))
+		}
+	case adbTypeBlob32:
+		if header, err = r.deref(v, 4); err == nil {
+			length = int(binary.LittleEndian.Uint32(header))
+		}
+	default:
+		return nil, ErrInvalidADB
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.deref(v, len(header)+length)
+	if err != nil {
+		return nil, err
+	}
+	return b[len(header):], nil
+}
+
+func (r *adbReader) readString(v uint32) (string, error) {
+	b, err := r.readBlob(v)
+	return string(b), err
+}
+
+// readObject reads an object. The returned fields are indexed by their field number.
+func (r *adbReader) readObject(v uint32) ([]uint32, error) {
+	return r.readValues(v, adbTypeObject)
+}
+
+func (r *adbReader) readArray(v uint32) ([]uint32, error) {
+	values, err := r.readValues(v, adbTypeArray)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	return values[1:], nil
+}
+
+func (r *adbReader) readValues(v, typ uint32) ([]uint32, error) {
+	if v == adbNull {
+		return nil, nil
+	}
+	if v&adbTypeMask != typ {
+		return nil, ErrInvalidADB
+	}
+	b, err := r.deref(v, 4)
+	if err != nil {
+		return nil, err
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if n == 0 {
+		return nil, ErrInvalidADB
+	}
+	if b, err = r.deref(v, 4*n); err != nil {
+		return nil, err
+	}
+	values := make([]uint32, n)
+	for i := 1; i < n; i++ {
+		values[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return values, nil
+}
+
+func objectField(fields []uint32, field int) uint32 {
+	if field < len(fields) {
+		return fields[field]
+	}
+	return adbNull
+}
+
+// writeADBBlock writes a block with a header and the padding needed for the alignment of the next block
+func writeADBBlock(w io.Writer, typ uint32, content []byte) error {
+	size := 4 + len(content)
+	if err := binary.Write(w, binary.LittleEndian, typ<<30|uint32(size)); err != nil {
+		return err
+	}
+	if _, err := w.Write(content); err != nil {
+		return err
+	}
+	if padding := (adbBlockAlignment - size%adbBlockAlignment) % adbBlockAlignment; padding > 0 {
+		if _, err := w.Write(make([]byte, padding)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readADBBlock reads the next block and returns its type and content
+func readADBBlock(r io.Reader) (uint32, []byte, error) {
+	var typeSize uint32
+	if err := binary.Read(r, binary.LittleEndian, &typeSize); err != nil {
+		return 0, nil, err
+	}
+	typ, size := typeSize>>30, int64(typeSize&0x3fffffff)
+	headerSize := int64(4)
+	if typ == adbBlockExt {
+		var ext struct {
+			Reserved uint32
+			Size     uint64
+		}
+		if err := binary.Read(r, binary.LittleEndian, &ext); err != nil {
+			return 0, nil, err
+		}
+		typ, size, headerSize = typeSize&0x3fffffff, int64(ext.Size), 16
+	}
+	if size < headerSize || size > 1<<30 {
+		return 0, nil, ErrInvalidADB
+	}
+
+	content := make([]byte, size-headerSize)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return 0, nil, err
+	}
+	if padding := (adbBlockAlignment - size%adbBlockAlignment) % adbBlockAlignment; padding > 0 {
+		if _, err := io.CopyN(io.Discard, r, padding); err != nil && err != io.EOF {
+			return 0, nil, err
+		}
+	}
+	return typ, content, nil
+}
+
+// WriteADBFile writes an ADB file with the schema and block content.
+// If a private key is provided, the content gets signed.
+func WriteADBFile(w io.Writer, schema uint32, content []byte, privKey *rsa.PrivateKey) error {
+	if _, err := io.WriteString(w, adbMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, schema); err != nil {
+		return err
+	}
+	if err := writeADBBlock(w, adbBlockADB, content); err != nil {
+		return err
+	}
+	if privKey == nil {
+		return nil
+	}
+
+	signature, err := signADB(schema, content, privKey)
+	if err != nil {
+		return err
+	}
+	return writeADBBlock(w, adbBlockSig, signature)
+}
+
+// ADBKeyID computes the identifier of the public key used in signature blocks
+func ADBKeyID(pubKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha512.Sum512(der)
+	return sum[:16], nil
+}
+
+// signADB creates a v0 signature block which signs the schema, the signature header and the digest of the content
+func signADB(schema uint32, content []byte, privKey *rsa.PrivateKey) ([]byte, error) {
+	keyID, err := ADBKeyID(&privKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{0, adbHashSHA512}, keyID...)
+
+	md := sha512.Sum512(content)
+
+	h := sha512.New()
+	_ = binary.Write(h, binary.LittleEndian, schema)
+	h.Write(header)
+	h.Write(md[:])
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privKey, crypto.SHA512, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, signature...), nil
+}
+
+// ADBIndexEntry is a package listed in an ADB index
+type ADBIndexEntry struct {
+	Name            string
+	Version         string
+	Architecture    string
+	FileSize        int64
+	VersionMetadata *VersionMetadata
+	FileMetadata    *FileMetadata
+}
+
+// CreateADBIndex creates the content of an ADB index (Packages.adb)
+func CreateADBIndex(description string, entries []*ADBIndexEntry) []byte {
+	sorted := make([]*ADBIndexEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	w := newADBWriter()
+
+	packages := make([]uint32, 0, len(sorted))
+	for _, e := range sorted {
+		packages = append(packages, w.writePackageInfo(e))
+	}
+
+	index := make([]uint32, adbIndexPackages+1)
+	index[adbIndexDescription] = w.writeString(description)
+	index[adbIndexPackages] = w.writeArray(packages)
+
+	return w.finish(w.writeObject(index))
+}
+
+// CreateADBPackage creates the content of an ADB package (.apk) with the package info of the entry.
+// The package contains no files.
+func CreateADBPackage(e *ADBIndexEntry) []byte {
+	w := newADBWriter()
+
+	pkg := make([]uint32, adbPackagePkgInfo+1)
+	pkg[adbPackagePkgInfo] = w.writePackageInfo(e)
+
+	return w.finish(w.writeObject(pkg))
+}
+
+// ADBIndex is the content of an ADB index file (Packages.adb)
+type ADBIndex struct {
+	Description string
+	Entries     []*ADBIndexEntry
+	// SignatureKeyIDs contains the key id of every signature block
+	SignatureKeyIDs [][]byte
+}
+
+// ReadADBIndex reads an ADB index file (Packages.adb)
+func ReadADBIndex(r io.Reader) (*ADBIndex, error) {
+	sr, closer, err := openADBStream(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	var header struct {
+		Magic  [4]byte
+		Schema uint32
+	}
+	if err := binary.Read(sr, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != adbMagic || header.Schema != ADBSchemaIndex {
+		return nil, ErrInvalidADB
+	}
+
+	typ, content, err := readADBBlock(sr)
+	if err != nil {
+		return nil, err
+	}
+	if typ != adbBlockADB {
+		return nil, ErrInvalidADB
+	}
+
+	ar := &adbReader{data: content}
+
+	root, err := ar.root()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := ar.readObject(root)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &ADBIndex{}
+	if index.Description, err = ar.readString(objectField(fields, adbIndexDescription)); err != nil {
+		return nil, err
+	}
+
+	packages, err := ar.readArray(objectField(fields, adbIndexPackages))
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range packages {
+		info, err := ar.readObject(v)
+		if err != nil {
+			return nil, err
+		}
+		p, uniqueID, err := ar.readPackageInfo(info)
+		if err != nil {
+			return nil, err
+		}
+		if len(uniqueID) > 0 {
+			p.FileMetadata.Checksum = formatADBChecksum(uniqueID)
+		}
+		fileSize, err := ar.readInt(objectField(info, adbPkgInfoFileSize))
+		if err != nil {
+			return nil, err
+		}
+
+		index.Entries = append(index.Entries, &ADBIndexEntry{
+			Name:            p.Name,
+			Version:         p.Version,
+			Architecture:    p.FileMetadata.Architecture,
+			FileSize:        int64(fileSize),
+			VersionMetadata: &p.VersionMetadata,
+			FileMetadata:    &p.FileMetadata,
+		})
+	}
+
+	for {
+		typ, block, err := readADBBlock(sr)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// a v0 signature starts with the version, the hash algorithm and the key id
+		if typ == adbBlockSig && len(block) >= 18 {
+			index.SignatureKeyIDs = append(index.SignatureKeyIDs, block[2:18])
+		}
+	}
+
+	return index, nil
+}
+
+func (w *adbWriter) writePackageInfo(e *ADBIndexEntry) uint32 {
+	fields := make([]uint32, adbPkgInfoInstallIf+1)
+	fields[adbPkgInfoName] = w.writeString(e.Name)
+	fields[adbPkgInfoVersion] = w.writeString(e.Version)
+	if checksum := e.FileMetadata.Checksum; len(checksum) > 2 {
+		if hash, err := base64.StdEncoding.DecodeString(checksum[2:]); err == nil {
+			fields[adbPkgInfoHashes] = w.writeBlob(hash)
+		}
+	}
+	fields[adbPkgInfoDescription] = w.writeString(e.VersionMetadata.Description)
+	fields[adbPkgInfoArch] = w.writeString(e.Architecture)
+	fields[adbPkgInfoLicense] = w.writeString(e.VersionMetadata.License)
+	fields[adbPkgInfoOrigin] = w.writeString(e.FileMetadata.Origin)
+	fields[adbPkgInfoMaintainer] = w.writeString(e.VersionMetadata.Maintainer)
+	fields[adbPkgInfoURL] = w.writeString(e.VersionMetadata.ProjectURL)
+	if commit, err := hex.DecodeString(e.FileMetadata.CommitHash); err == nil {
+		fields[adbPkgInfoRepoCommit] = w.writeBlob(commit)
+	}
+	if e.FileMetadata.BuildDate > 0 {
+		fields[adbPkgInfoBuildTime] = w.writeInt(uint64(e.FileMetadata.BuildDate))
+	}
+	if e.FileMetadata.Size > 0 {
+		fields[adbPkgInfoInstalledSize] = w.writeInt(uint64(e.FileMetadata.Size))
+	}
+	if e.FileSize > 0 {
+		fields[adbPkgInfoFileSize] = w.writeInt(uint64(e.FileSize))
+	}
+	if e.FileMetadata.ProviderPriority > 0 {
+		fields[adbPkgInfoProviderPriority] = w.writeInt(uint64(e.FileMetadata.ProviderPriority))
+	}
+	fields[adbPkgInfoDepends] = w.writeDependencies(splitDependencies(e.FileMetadata.Dependencies))
+	fields[adbPkgInfoProvides] = w.writeDependencies(splitDependencies(e.FileMetadata.Provides))
+	fields[adbPkgInfoInstallIf] = w.writeDependencies(strings.Fields(e.FileMetadata.InstallIf))
+	return w.writeObject(fields)
+}
+
+func splitDependencies(values []string) []string {
+	deps := make([]string, 0, len(values))
+	for _, v := range values {
+		deps = append(deps, strings.Fields(v)...)
+	}
+	return deps
+}
+
+func (w *adbWriter) writeDependencies(deps []string) uint32 {
+	if len(deps) == 0 {
+		return adbNull
+	}
+
+	sort.Strings(deps)
+
+	items := make([]uint32, 0, len(deps))
+	for _, dep := range deps {
+		name, version, match := parseDependency(dep)
+
+		fields := make([]uint32, adbDepMatch+1)
+		fields[adbDepName] = w.writeString(name)
+		if match != versionAny {
+			fields[adbDepVersion] = w.writeString(version)
+			if match != versionEqual {
+				fields[adbDepMatch] = w.writeInt(uint64(match))
+			}
+		}
+		items = append(items, w.writeObject(fields))
+	}
+	return w.writeArray(items)
+}
+
+var dependencyOperators = []struct {
+	Operator string
+	Match    int
+}{
+	{"<=", versionLess | versionEqual},
+	{">=", versionGreater | versionEqual},
+	{"<", versionLess},
+	{">", versionGreater},
+	{"=", versionEqual},
+	{"~", versionFuzzy | versionEqual},
+}
+
+// parseDependency splits a dependency like "name>=1.0" into its parts
+func parseDependency(dep string) (string, string, int) {
+	conflict := 0
+	if strings.HasPrefix(dep, "!") {
+		dep, conflict = dep[1:], versionConflict
+	}
+
+	i := strings.IndexAny(dep, "<>=~")
+	if i == -1 {
+		return dep, "", versionAny | conflict
+	}
+	name, rest := dep[:i], dep[i:]
+	for _, op := range dependencyOperators {
+		if strings.HasPrefix(rest, op.Operator) {
+			return name, rest[len(op.Operator):], op.Match | conflict
+		}
+	}
+	return dep, "", versionAny | conflict
+}
+
+// formatDependency is the reverse of parseDependency
+func formatDependency(name, version string, match int) string {
+	var sb strings.Builder
+	if match&versionConflict != 0 {
+		sb.WriteString("!")
+	}
+	sb.WriteString(name)
+	match &^= versionConflict
+	if match == versionAny || version == "" {
+		return sb.String()
+	}
+	for _, op := range dependencyOperators {
+		if op.Match == match {
+			sb.WriteString(op.Operator)
+			break
+		}
+	}
+	sb.WriteString(version)
+	return sb.String()
+}
+
+func (r *adbReader) readDependencies(v uint32) ([]string, error) {
+	items, err := r.readArray(v)
+	if err != nil {
+		return nil, err
+	}
+	deps := make([]string, 0, len(items))
+	for _, item := range items {
+		fields, err := r.readObject(item)
+		if err != nil {
+			return nil, err
+		}
+		name, err := r.readString(objectField(fields, adbDepName))
+		if err != nil {
+			return nil, err
+		}
+		version, err := r.readString(objectField(fields, adbDepVersion))
+		if err != nil {
+			return nil, err
+		}
+		match := versionAny
+		if version != "" {
+			match = versionEqual
+		}
+		if m, err := r.readInt(objectField(fields, adbDepMatch)); err != nil {
+			return nil, err
+		} else if m != 0 {
+			match = int(m)
+		}
+		deps = append(deps, formatDependency(name, version, match))
+	}
+	return deps, nil
+}
+
+// readPackageInfo reads the package info object of a package or an index entry.
+// The unique id of the package is returned separately because it's optional in packages.
+func (r *adbReader) readPackageInfo(info []uint32) (*Package, []byte, error) {
+	var err error
+
+	p := &Package{
+		FileMetadata: FileMetadata{
+			PackageFormat: PackageFormatV3,
+		},
+	}
+
+	stringFields := []struct {
+		Field int
+		Value *string
+	}{
+		{adbPkgInfoName, &p.Name},
+		{adbPkgInfoVersion, &p.Version},
+		{adbPkgInfoDescription, &p.VersionMetadata.Description},
+		{adbPkgInfoArch, &p.FileMetadata.Architecture},
+		{adbPkgInfoLicense, &p.VersionMetadata.License},
+		{adbPkgInfoOrigin, &p.FileMetadata.Origin},
+		{adbPkgInfoMaintainer, &p.VersionMetadata.Maintainer},
+		{adbPkgInfoURL, &p.VersionMetadata.ProjectURL},
+	}
+	for _, s := range stringFields {
+		if *s.Value, err = r.readString(objectField(info, s.Field)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	intFields := []struct {
+		Field int
+		Value *int64
+	}{
+		{adbPkgInfoBuildTime, &p.FileMetadata.BuildDate},
+		{adbPkgInfoInstalledSize, &p.FileMetadata.Size},
+		{adbPkgInfoProviderPriority, &p.FileMetadata.ProviderPriority},
+	}
+	for _, i := range intFields {
+		n, err := r.readInt(objectField(info, i.Field))
+		if err != nil {
+			return nil, nil, err
+		}
+		*i.Value = int64(n)
+	}
+
+	commit, err := r.readBlob(objectField(info, adbPkgInfoRepoCommit))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(commit) > 0 {
+		p.FileMetadata.CommitHash = hex.EncodeToString(commit)
+	}
+
+	if p.FileMetadata.Dependencies, err = r.readDependencies(objectField(info, adbPkgInfoDepends)); err != nil {
+		return nil, nil, err
+	}
+	if p.FileMetadata.Provides, err = r.readDependencies(objectField(info, adbPkgInfoProvides)); err != nil {
+		return nil, nil, err
+	}
+	installIf, err := r.readDependencies(objectField(info, adbPkgInfoInstallIf))
+	if err != nil {
+		return nil, nil, err
+	}
+	p.FileMetadata.InstallIf = strings.Join(installIf, " ")
+
+	uniqueID, err := r.readBlob(objectField(info, adbPkgInfoHashes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, uniqueID, nil
+}
+
+func formatADBChecksum(uniqueID []byte) string {
+	prefix := "Q2"
+	if len(uniqueID) == sha1.Size {
+		prefix = "Q1"
+	}
+	return prefix + base64.StdEncoding.EncodeToString(uniqueID)
+}
+
+// openADBStream handles the optional compression of an ADB file
+func openADBStream(br *bufio.Reader) (io.Reader, func(), error) {
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch string(magic) {
+	case adbMagic:
+		return br, func() {}, nil
+	case adbMagicDeflate:
+		_, _ = br.Discard(4)
+		fr := flate.NewReader(br)
+		return fr, func() { fr.Close() }, nil
+	case adbMagicCompressed:
+		var header [6]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return nil, nil, err
+		}
+		switch header[4] {
+		case 0:
+			return br, func() {}, nil
+		case 1:
+			fr := flate.NewReader(br)
+			return fr, func() { fr.Close() }, nil
+		case 2:
+			zr, err := zstd.NewReader(br)
+			if err != nil {
+				return nil, nil, err
+			}
+			return zr, func() { zr.Close() }, nil
+		}
+	}
+	return nil, nil, ErrInvalidADB
+}
+
+// parsePackageV3 parses an apk-tools v3 package
+func parsePackageV3(br *bufio.Reader) (*Package, error) {
+	r, closer, err := openADBStream(br)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	var header struct {
+		Magic  [4]byte
+		Schema uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != adbMagic || header.Schema != ADBSchemaPackage {
+		return nil, ErrInvalidADB
+	}
+
+	typ, content, err := readADBBlock(r)
+	if err != nil {
+		return nil, err
+	}
+	if typ != adbBlockADB {
+		return nil, ErrInvalidADB
+	}
+
+	ar := &adbReader{data: content}
+
+	root, err := ar.root()
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := ar.readObject(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := ar.readObject(objectField(pkg, adbPackagePkgInfo))
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, ErrMissingPKGINFOFile
+	}
+
+	p, uniqueID, err := ar.readPackageInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unique id of a package is stored in the package info. It's the hash of the ADB block.
+	if len(uniqueID) == 0 {
+		sum := sha256.Sum256(content)
+		uniqueID = sum[:]
+	}
+	p.FileMetadata.Checksum = formatADBChecksum(uniqueID)
+
+	// drain the reader
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+
+	if p.Name == "" {
+		return nil, ErrInvalidName
+	}
+	if p.Version == "" {
+		return nil, ErrInvalidVersion
+	}
+	if !validation.IsValidURL(p.VersionMetadata.ProjectURL) {
+		p.VersionMetadata.ProjectURL = ""
+	}
+
+	return p, nil
+}
+
+func isADB(br *bufio.Reader) bool {
+	magic, err := br.Peek(3)
+	return err == nil && bytes.Equal(magic, []byte("ADB"))
+}
@@ -0,0 +1,302 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package alpine
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createADBPackageContent(name, version string) []byte {
+	w := newADBWriter()
+
+	info := make([]uint32, adbPkgInfoInstallIf+1)
+	info[adbPkgInfoName] = w.writeString(name)
+	info[adbPkgInfoVersion] = w.writeString(version)
+	info[adbPkgInfoDescription] = w.writeString(packageDescription)
+	info[adbPkgInfoArch] = w.writeString("x86_64")
+	info[adbPkgInfoLicense] = w.writeString("MIT")
+	info[adbPkgInfoOrigin] = w.writeString("origin")
+	info[adbPkgInfoMaintainer] = w.writeString(packageMaintainer)
+	info[adbPkgInfoURL] = w.writeString(packageProjectURL)
+	info[adbPkgInfoRepoCommit] = w.writeBlob([]byte{0x11, 0x11})
+	info[adbPkgInfoBuildTime] = w.writeInt(1678834800)
+	info[adbPkgInfoInstalledSize] = w.writeInt(123456)
+	info[adbPkgInfoDepends] = w.writeDependencies([]string{"so:libc.musl-x86_64.so.1", "common>=1.0"})
+	info[adbPkgInfoProvides] = w.writeDependencies([]string{"cmd:gitea=" + version})
+
+	pkg := make([]uint32, adbPackagePkgInfo+1)
+	pkg[adbPackagePkgInfo] = w.writeObject(info)
+
+	return w.finish(w.writeObject(pkg))
+}
+
+func TestParsePackageV3(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteADBFile(&buf, ADBSchemaPackage, createADBPackageContent(packageName, packageVersion), nil))
+	// add a data block which must be ignored
+	require.NoError(t, writeADBBlock(&buf, adbBlockData, []byte{1, 2, 3}))
+
+	check := func(t *testing.T, p *Package) {
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, packageDescription, p.VersionMetadata.Description)
+		assert.Equal(t, packageMaintainer, p.VersionMetadata.Maintainer)
+		assert.Equal(t, packageProjectURL, p.VersionMetadata.ProjectURL)
+		assert.Equal(t, "MIT", p.VersionMetadata.License)
+		assert.Equal(t, "x86_64", p.FileMetadata.Architecture)
+		assert.Equal(t, "origin", p.FileMetadata.Origin)
+		assert.Equal(t, "1111", p.FileMetadata.CommitHash)
+		assert.EqualValues(t, 1678834800, p.FileMetadata.BuildDate)
+		assert.EqualValues(t, 123456, p.FileMetadata.Size)
+		assert.ElementsMatch(t, []string{"common>=1.0", "so:libc.musl-x86_64.so.1"}, p.FileMetadata.Dependencies)
+		assert.ElementsMatch(t, []string{"cmd:gitea=" + packageVersion}, p.FileMetadata.Provides)
+		assert.Equal(t, PackageFormatV3, p.FileMetadata.PackageFormat)
+		assert.Regexp(t, `\AQ2`, p.FileMetadata.Checksum)
+	}
+
+	t.Run("Uncompressed", func(t *testing.T) {
+		p, err := ParsePackage(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		check(t, p)
+	})
+
+	t.Run("Deflate", func(t *testing.T) {
+		var compressed bytes.Buffer
+		compressed.WriteString(adbMagicDeflate)
+		fw, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+		fw.Write(buf.Bytes())
+		fw.Close()
+
+		p, err := ParsePackage(&compressed)
+		require.NoError(t, err)
+		check(t, p)
+	})
+
+	t.Run("InvalidSchema", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteADBFile(&buf, ADBSchemaIndex, createADBPackageContent(packageName, packageVersion), nil))
+
+		p, err := ParsePackage(&buf)
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidADB)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteADBFile(&buf, ADBSchemaPackage, createADBPackageContent("", packageVersion), nil))
+
+		p, err := ParsePackage(&buf)
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidName)
+	})
+}
+
+func TestCreateADBIndex(t *testing.T) {
+	content := CreateADBIndex("test/main", []*ADBIndexEntry{
+		{
+			Name:            "pkg-b",
+			Version:         "1.0",
+			Architecture:    "x86_64",
+			FileSize:        0xffffffff + 1,
+			VersionMetadata: &VersionMetadata{Description: "B"},
+			FileMetadata:    &FileMetadata{Checksum: "Q1GfcV5dUrPr1xIRekqPYGehdxjL8=", Dependencies: []string{"pkg-a"}},
+		},
+		{
+			Name:            "pkg-a",
+			Version:         "2.0",
+			Architecture:    "noarch",
+			FileSize:        100,
+			VersionMetadata: &VersionMetadata{},
+			FileMetadata:    &FileMetadata{Checksum: "Q1GfcV5dUrPr1xIRekqPYGehdxjL8=", InstallIf: "pkg-b !pkg-c"},
+		},
+	})
+
+	r := &adbReader{data: content}
+	root, err := r.root()
+	require.NoError(t, err)
+	index, err := r.readObject(root)
+	require.NoError(t, err)
+
+	description, err := r.readString(objectField(index, adbIndexDescription))
+	require.NoError(t, err)
+	assert.Equal(t, "test/main", description)
+
+	packages, err := r.readArray(objectField(index, adbIndexPackages))
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+
+	info, err := r.readObject(packages[0])
+	require.NoError(t, err)
+	name, _ := r.readString(objectField(info, adbPkgInfoName))
+	assert.Equal(t, "pkg-a", name)
+	hash, _ := r.readBlob(objectField(info, adbPkgInfoHashes))
+	assert.Len(t, hash, 20)
+	installIf, err := r.readDependencies(objectField(info, adbPkgInfoInstallIf))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"!pkg-c", "pkg-b"}, installIf)
+
+	info, err = r.readObject(packages[1])
+	require.NoError(t, err)
+	size, err := r.readInt(objectField(info, adbPkgInfoFileSize))
+	require.NoError(t, err)
+	assert.EqualValues(t, 0xffffffff+1, size)
+	depends, err := r.readDependencies(objectField(info, adbPkgInfoDepends))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg-a"}, depends)
+
+	t.Run("Signature", func(t *testing.T) {
+		privKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, WriteADBFile(&buf, ADBSchemaIndex, content, privKey))
+
+		br := bufio.NewReader(&buf)
+		magic := make([]byte, 8)
+		_, err = br.Read(magic)
+		require.NoError(t, err)
+		assert.Equal(t, adbMagic, string(magic[:4]))
+		assert.Equal(t, ADBSchemaIndex, binary.LittleEndian.Uint32(magic[4:]))
+
+		typ, block, err := readADBBlock(br)
+		require.NoError(t, err)
+		assert.EqualValues(t, adbBlockADB, typ)
+		assert.Equal(t, content, block)
+
+		typ, signature, err := readADBBlock(br)
+		require.NoError(t, err)
+		assert.EqualValues(t, adbBlockSig, typ)
+
+		keyID, err := ADBKeyID(&privKey.PublicKey)
+		require.NoError(t, err)
+		assert.EqualValues(t, adbHashSHA512, signature[1])
+		assert.Equal(t, keyID, signature[2:18])
+
+		md := sha512.Sum512(content)
+		h := sha512.New()
+		binary.Write(h, binary.LittleEndian, ADBSchemaIndex)
+		h.Write(signature[:18])
+		h.Write(md[:])
+		assert.NoError(t, rsa.VerifyPKCS1v15(&privKey.PublicKey, crypto.SHA512, h.Sum(nil), signature[18:]))
+	})
+}
+
+func TestCreateADBPackage(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteADBFile(&buf, ADBSchemaPackage, CreateADBPackage(&ADBIndexEntry{
+		Name:            packageName,
+		Version:         packageVersion,
+		Architecture:    "x86_64",
+		VersionMetadata: &VersionMetadata{Description: packageDescription},
+		FileMetadata:    &FileMetadata{Size: 123456, Dependencies: []string{"common>=1.0"}},
+	}), nil))
+
+	p, err := ParsePackage(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, packageName, p.Name)
+	assert.Equal(t, packageVersion, p.Version)
+	assert.Equal(t, packageDescription, p.VersionMetadata.Description)
+	assert.Equal(t, "x86_64", p.FileMetadata.Architecture)
+	assert.EqualValues(t, 123456, p.FileMetadata.Size)
+	assert.Equal(t, []string{"common>=1.0"}, p.FileMetadata.Dependencies)
+	assert.Equal(t, PackageFormatV3, p.FileMetadata.PackageFormat)
+}
+
+func TestReadADBIndex(t *testing.T) {
+	content := CreateADBIndex("test/main", []*ADBIndexEntry{
+		{
+			Name:            "pkg-a",
+			Version:         "2.0",
+			Architecture:    "x86_64",
+			FileSize:        100,
+			VersionMetadata: &VersionMetadata{Description: "A"},
+			FileMetadata:    &FileMetadata{Checksum: "Q1GfcV5dUrPr1xIRekqPYGehdxjL8=", Dependencies: []string{"pkg-b>=1.0"}},
+		},
+	})
+
+	check := func(t *testing.T, index *ADBIndex) {
+		assert.Equal(t, "test/main", index.Description)
+		require.Len(t, index.Entries, 1)
+		e := index.Entries[0]
+		assert.Equal(t, "pkg-a", e.Name)
+		assert.Equal(t, "2.0", e.Version)
+		assert.Equal(t, "x86_64", e.Architecture)
+		assert.EqualValues(t, 100, e.FileSize)
+		assert.Equal(t, "A", e.VersionMetadata.Description)
+		assert.Equal(t, "Q1GfcV5dUrPr1xIRekqPYGehdxjL8=", e.FileMetadata.Checksum)
+		assert.Equal(t, []string{"pkg-b>=1.0"}, e.FileMetadata.Dependencies)
+	}
+
+	t.Run("Unsigned", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteADBFile(&buf, ADBSchemaIndex, content, nil))
+
+		index, err := ReadADBIndex(&buf)
+		require.NoError(t, err)
+		check(t, index)
+		assert.Empty(t, index.SignatureKeyIDs)
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		privKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, WriteADBFile(&buf, ADBSchemaIndex, content, privKey))
+
+		index, err := ReadADBIndex(&buf)
+		require.NoError(t, err)
+		check(t, index)
+
+		keyID, err := ADBKeyID(&privKey.PublicKey)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{keyID}, index.SignatureKeyIDs)
+	})
+
+	t.Run("InvalidSchema", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteADBFile(&buf, ADBSchemaPackage, content, nil))
+
+		index, err := ReadADBIndex(&buf)
+		assert.Nil(t, index)
+		assert.ErrorIs(t, err, ErrInvalidADB)
+	})
+}
+
+func TestParseDependency(t *testing.T) {
+	cases := []struct {
+		Dependency string
+		Name       string
+		Version    string
+		Match      int
+	}{
+		{"gitea", "gitea", "", versionAny},
+		{"so:libc.musl-x86_64.so.1", "so:libc.musl-x86_64.so.1", "", versionAny},
+		{"gitea=1.0", "gitea", "1.0", versionEqual},
+		{"gitea>=1.0", "gitea", "1.0", versionGreater | versionEqual},
+		{"gitea<1.0", "gitea", "1.0", versionLess},
+		{"gitea~1.0", "gitea", "1.0", versionFuzzy | versionEqual},
+		{"!gitea", "gitea", "", versionAny | versionConflict},
+		{"!gitea<1.0", "gitea", "1.0", versionLess | versionConflict},
+	}
+
+	for _, c := range cases {
+		name, version, match := parseDependency(c.Dependency)
+		assert.Equal(t, c.Name, name, c.Dependency)
+		assert.Equal(t, c.Version, version, c.Dependency)
+		assert.Equal(t, c.Match, match, c.Dependency)
+		assert.Equal(t, c.Dependency, formatDependency(name, version, match))
+	}
+}
@@ -68,6 +68,7 @@ type FileMetadata struct {
 	Provides         []string `json:"provides,omitempty"`
 	Dependencies     []string `json:"dependencies,omitempty"`
 	ProviderPriority int64    `json:"provider_priority,omitempty"`
+	PackageFormat    int      `json:"package_format,omitempty"`
 }
 
 // ParsePackage parses the Alpine package file
@@ -76,6 +77,11 @@ func ParsePackage(r io.Reader) (*Package, error) {
 
 	br := bufio.NewReader(r) // needed for gzip Multistream
 
+	// apk-tools v3 packages are ADB files
+	if isADB(br) {
+		return parsePackageV3(br)
+	}
+
 	h := sha1.New()
 
 	gzr, err := gzip.NewReader(&teeByteReader{br, h})
@@ -0,0 +1,212 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package ansible
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	manifestFilename = "MANIFEST.json"
+	maxFileSize      = 1 << 20
+)
+
+var (
+	ErrMissingManifestFile = util.NewInvalidArgumentErrorf("MANIFEST.json file is missing")
+	ErrInvalidNamespace    = util.NewInvalidArgumentErrorf("collection namespace is invalid")
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("collection name is invalid")
+	ErrInvalidVersion      = util.NewInvalidArgumentErrorf("collection version is invalid")
+
+	// https://docs.ansible.com/ansible/latest/dev_guide/collections_galaxy_meta.html
+	namePattern = regexp.MustCompile(`\A[a-z][a-z0-9_]{1,63}\z`)
+)
+
+// IsValidName checks if the namespace or name of a collection is valid
+func IsValidName(name string) bool {
+	return namePattern.MatchString(name) && !strings.Contains(name, "__")
+}
+
+// PackageName gets the package name of a collection
+func PackageName(namespace, name string) string {
+	return namespace + "." + name
+}
+
+// SplitPackageName splits the package name into namespace and name of the collection
+func SplitPackageName(packageName string) (string, string) {
+	namespace, name, _ := strings.Cut(packageName, ".")
+	return namespace, name
+}
+
+// Filename gets the file name of the collection artifact
+func Filename(namespace, name, version string) string {
+	return fmt.Sprintf("%s-%s-%s.tar.gz", namespace, name, version)
+}
+
+// ParseFilename gets the namespace, name and version of a collection from the artifact file name
+func ParseFilename(filename string) (string, string, string, bool) {
+	base, ok := strings.CutSuffix(filename, ".tar.gz")
+	if !ok {
+		return "", "", "", false
+	}
+	parts := strings.SplitN(base, "-", 3)
+	if len(parts) != 3 || !IsValidName(parts[0]) || !IsValidName(parts[1]) || parts[2] == "" {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// Package represents an Ansible collection
+type Package struct {
+	Namespace string
+	Name      string
+	Version   string
+	Metadata  *Metadata
+}
+
+// Metadata represents the metadata of an Ansible collection
+type Metadata struct {
+	Authors       []string          `json:"authors,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	License       []string          `json:"license,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	Dependencies  map[string]string `json:"dependencies,omitempty"`
+	RepositoryURL string            `json:"repository_url,omitempty"`
+	Documentation string            `json:"documentation_url,omitempty"`
+	ProjectURL    string            `json:"project_url,omitempty"`
+	IssuesURL     string            `json:"issues_url,omitempty"`
+	Readme        string            `json:"readme,omitempty"`
+}
+
+// https://docs.ansible.com/ansible/latest/dev_guide/collections_galaxy_meta.html
+type collectionManifest struct {
+	CollectionInfo struct {
+		Namespace     string            `json:"namespace"`
+		Name          string            `json:"name"`
+		Version       string            `json:"version"`
+		Authors       []string          `json:"authors"`
+		Readme        string            `json:"readme"`
+		Tags          []string          `json:"tags"`
+		Description   string            `json:"description"`
+		License       []string          `json:"license"`
+		LicenseFile   string            `json:"license_file"`
+		Dependencies  map[string]string `json:"dependencies"`
+		Repository    string            `json:"repository"`
+		Documentation string            `json:"documentation"`
+		Homepage      string            `json:"homepage"`
+		Issues        string            `json:"issues"`
+	} `json:"collection_info"`
+}
+
+// ParsePackage parses the collection artifact and extracts the metadata from the MANIFEST.json file
+func ParsePackage(r io.Reader) (*Package, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, util.NewInvalidArgumentErrorf("collection artifact is not gzip compressed: %v", err)
+	}
+	defer gzr.Close()
+
+	var p *Package
+	readmes := make(map[string]string)
+
+	tr := tar.NewReader(gzr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, util.NewInvalidArgumentErrorf("collection artifact is invalid: %v", err)
+		}
+
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := strings.TrimPrefix(hd.Name, "./")
+		if name == manifestFilename {
+			manifest, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+			if err != nil {
+				return nil, err
+			}
+			p, err = ParseManifest(manifest)
+			if err != nil {
+				return nil, err
+			}
+		} else if strings.HasSuffix(strings.ToLower(name), ".md") && !strings.Contains(name, "/") {
+			content, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+			if err != nil {
+				return nil, err
+			}
+			readmes[name] = string(content)
+		}
+	}
+
+	if p == nil {
+		return nil, ErrMissingManifestFile
+	}
+
+	p.Metadata.Readme = readmes[p.Metadata.Readme]
+
+	return p, nil
+}
+
+// ParseManifest parses the MANIFEST.json file of a collection.
+// The Readme field of the returned metadata contains the path of the readme file.
+func ParseManifest(data []byte) (*Package, error) {
+	var m collectionManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, util.NewInvalidArgumentErrorf("MANIFEST.json is invalid: %v", err)
+	}
+
+	ci := m.CollectionInfo
+	if !IsValidName(ci.Namespace) {
+		return nil, ErrInvalidNamespace
+	}
+	if !IsValidName(ci.Name) {
+		return nil, ErrInvalidName
+	}
+	if _, err := version.NewSemver(ci.Version); err != nil {
+		return nil, ErrInvalidVersion
+	}
+
+	for _, u := range []*string{&ci.Repository, &ci.Documentation, &ci.Homepage, &ci.Issues} {
+		if !validation.IsValidURL(*u) {
+			*u = ""
+		}
+	}
+
+	license := ci.License
+	if len(license) == 0 && ci.LicenseFile != "" {
+		license = []string{ci.LicenseFile}
+	}
+
+	return &Package{
+		Namespace: ci.Namespace,
+		Name:      ci.Name,
+		Version:   ci.Version,
+		Metadata: &Metadata{
+			Authors:       ci.Authors,
+			Description:   ci.Description,
+			License:       license,
+			Tags:          ci.Tags,
+			Dependencies:  ci.Dependencies,
+			RepositoryURL: ci.Repository,
+			Documentation: ci.Documentation,
+			ProjectURL:    ci.Homepage,
+			IssuesURL:     ci.Issues,
+			Readme:        ci.Readme,
+		},
+	}, nil
+}
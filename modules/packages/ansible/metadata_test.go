@@ -0,0 +1,126 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package ansible
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	namespace      = "gitea"
+	name           = "test_collection"
+	packageVersion = "1.0.0"
+	description    = "Test Description"
+	readme         = "# Test Collection"
+	repository     = "https://gitea.io/gitea/gitea"
+)
+
+func createArchive(files map[string]string) io.Reader {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for filename, content := range files {
+		hdr := &tar.Header{
+			Name: filename,
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}
+		tw.WriteHeader(hdr)
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	zw.Close()
+	return &buf
+}
+
+func TestParsePackage(t *testing.T) {
+	t.Run("MissingManifestFile", func(t *testing.T) {
+		data := createArchive(map[string]string{"README.md": readme})
+
+		p, err := ParsePackage(data)
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrMissingManifestFile)
+	})
+
+	t.Run("InvalidArchive", func(t *testing.T) {
+		p, err := ParsePackage(bytes.NewReader([]byte("dummy")))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		data := createArchive(map[string]string{
+			"MANIFEST.json": `{"collection_info":{"namespace":"` + namespace + `","name":"` + name + `","version":"` + packageVersion + `","authors":["Gitea"],"readme":"README.md","description":"` + description + `","license":["MIT"],"dependencies":{"community.general":">=1.0.0"},"repository":"` + repository + `","homepage":"invalid"}}`,
+			"README.md":     readme,
+		})
+
+		p, err := ParsePackage(data)
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+		assert.Equal(t, namespace, p.Namespace)
+		assert.Equal(t, name, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, []string{"Gitea"}, p.Metadata.Authors)
+		assert.Equal(t, description, p.Metadata.Description)
+		assert.Equal(t, []string{"MIT"}, p.Metadata.License)
+		assert.Equal(t, map[string]string{"community.general": ">=1.0.0"}, p.Metadata.Dependencies)
+		assert.Equal(t, repository, p.Metadata.RepositoryURL)
+		assert.Empty(t, p.Metadata.ProjectURL)
+		assert.Equal(t, readme, p.Metadata.Readme)
+	})
+}
+
+func TestParseManifest(t *testing.T) {
+	cases := []struct {
+		Manifest string
+		Expected error
+	}{
+		{`{"collection_info":{"namespace":"Gitea","name":"` + name + `","version":"` + packageVersion + `"}}`, ErrInvalidNamespace},
+		{`{"collection_info":{"namespace":"` + namespace + `","name":"test__collection","version":"` + packageVersion + `"}}`, ErrInvalidName},
+		{`{"collection_info":{"namespace":"` + namespace + `","name":"` + name + `","version":"1.x"}}`, ErrInvalidVersion},
+	}
+
+	for _, c := range cases {
+		p, err := ParseManifest([]byte(c.Manifest))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, c.Expected)
+	}
+
+	p, err := ParseManifest([]byte(`{"collection_info":{"namespace":"` + namespace + `","name":"` + name + `","version":"` + packageVersion + `","license_file":"LICENSE"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"LICENSE"}, p.Metadata.License)
+}
+
+func TestPackageName(t *testing.T) {
+	packageName := PackageName(namespace, name)
+	assert.Equal(t, "gitea.test_collection", packageName)
+
+	ns, n := SplitPackageName(packageName)
+	assert.Equal(t, namespace, ns)
+	assert.Equal(t, name, n)
+
+	filename := Filename(namespace, name, packageVersion)
+	assert.Equal(t, "gitea-test_collection-1.0.0.tar.gz", filename)
+
+	ns, n, v, ok := ParseFilename(filename)
+	assert.True(t, ok)
+	assert.Equal(t, namespace, ns)
+	assert.Equal(t, name, n)
+	assert.Equal(t, packageVersion, v)
+
+	_, _, v, ok = ParseFilename("gitea-test_collection-1.0.0-beta.1.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, "1.0.0-beta.1", v)
+
+	_, _, _, ok = ParseFilename("gitea-test_collection.tar.gz")
+	assert.False(t, ok)
+}
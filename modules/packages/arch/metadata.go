@@ -0,0 +1,249 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"archive/tar"
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	ErrMissingPKGINFOFile  = util.NewInvalidArgumentErrorf(".PKGINFO file is missing")
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion      = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidArchitecture = util.NewInvalidArgumentErrorf("package architecture is invalid")
+)
+
+const (
+	PropertyRepository   = "arch.repository"
+	PropertyArchitecture = "arch.architecture"
+	PropertyMetadata     = "arch.metadata"
+	PropertyFiles        = "arch.files"
+	PropertySignature    = "arch.signature"
+
+	SettingKeyPrivate = "arch.key.private"
+	SettingKeyPublic  = "arch.key.public"
+
+	RepositoryPackage = "_arch"
+	RepositoryVersion = "_repository"
+
+	AnyArch = "any"
+
+	PackageExtension   = ".pkg.tar.zst"
+	SignatureExtension = ".sig"
+)
+
+var (
+	namePattern         = regexp.MustCompile(`\A[a-zA-Z0-9@_+][a-zA-Z0-9@._+-]*\z`)
+	versionPattern      = regexp.MustCompile(`\A(?:[0-9]+:)?[a-zA-Z0-9._+~]+-[0-9]+(?:\.[0-9]+)?\z`)
+	architecturePattern = regexp.MustCompile(`\A[a-zA-Z0-9_]+\z`)
+)
+
+// https://man.archlinux.org/man/PKGBUILD.5
+
+// Package represents an Arch package
+type Package struct {
+	Name            string
+	Version         string
+	VersionMetadata VersionMetadata
+	FileMetadata    FileMetadata
+	Files           []string
+}
+
+// VersionMetadata of an Arch package
+type VersionMetadata struct {
+	Base        string   `json:"base,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ProjectURL  string   `json:"project_url,omitempty"`
+	Licenses    []string `json:"licenses,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+// FileMetadata of an Arch package
+type FileMetadata struct {
+	Architecture      string   `json:"architecture"`
+	Packager          string   `json:"packager,omitempty"`
+	BuildDate         int64    `json:"build_date,omitempty"`
+	InstalledSize     int64    `json:"installed_size,omitempty"`
+	Provides          []string `json:"provides,omitempty"`
+	Dependencies      []string `json:"dependencies,omitempty"`
+	OptDependencies   []string `json:"opt_dependencies,omitempty"`
+	MakeDependencies  []string `json:"make_dependencies,omitempty"`
+	CheckDependencies []string `json:"check_dependencies,omitempty"`
+	Conflicts         []string `json:"conflicts,omitempty"`
+	Replaces          []string `json:"replaces,omitempty"`
+	Backup            []string `json:"backup,omitempty"`
+}
+
+// Filename gets the name of the package file
+func (p *Package) Filename() string {
+	return FormatFilename(p.Name, p.Version, p.FileMetadata.Architecture)
+}
+
+// FormatFilename gets the file name used by makepkg for the package
+func FormatFilename(name, version, architecture string) string {
+	return name + "-" + version + "-" + architecture + PackageExtension
+}
+
+// IsValidArchitecture checks if the value can be used as architecture
+func IsValidArchitecture(architecture string) bool {
+	return architecturePattern.MatchString(architecture)
+}
+
+// ParsePackage parses the zstd compressed Arch package file
+// The package file list is collected too because it is needed for the files database.
+func ParsePackage(r io.Reader) (*Package, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var p *Package
+	files := make([]string, 0, 10)
+
+	tr := tar.NewReader(zr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hd.Name == ".PKGINFO" {
+			p, err = ParsePackageInfo(tr)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// The metadata files of makepkg are not part of the installed files
+		if strings.HasPrefix(hd.Name, ".") && !strings.Contains(hd.Name, "/") {
+			continue
+		}
+
+		files = append(files, hd.Name)
+	}
+
+	if p == nil {
+		return nil, ErrMissingPKGINFOFile
+	}
+
+	p.Files = files
+
+	return p, nil
+}
+
+// ParsePackageInfo parses a .PKGINFO file to retrieve the metadata of an Arch package
+func ParsePackageInfo(r io.Reader) (*Package, error) {
+	p := &Package{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.IndexRune(line, '=')
+		if i == -1 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "pkgname":
+			p.Name = value
+		case "pkgbase":
+			p.VersionMetadata.Base = value
+		case "pkgver":
+			p.Version = value
+		case "pkgdesc":
+			p.VersionMetadata.Description = value
+		case "url":
+			p.VersionMetadata.ProjectURL = value
+		case "builddate":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				p.FileMetadata.BuildDate = n
+			}
+		case "packager":
+			p.FileMetadata.Packager = value
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				p.FileMetadata.InstalledSize = n
+			}
+		case "arch":
+			p.FileMetadata.Architecture = value
+		case "license":
+			p.VersionMetadata.Licenses = appendValue(p.VersionMetadata.Licenses, value)
+		case "group":
+			p.VersionMetadata.Groups = appendValue(p.VersionMetadata.Groups, value)
+		case "provides":
+			p.FileMetadata.Provides = appendValue(p.FileMetadata.Provides, value)
+		case "depend":
+			p.FileMetadata.Dependencies = appendValue(p.FileMetadata.Dependencies, value)
+		case "optdepend":
+			p.FileMetadata.OptDependencies = appendValue(p.FileMetadata.OptDependencies, value)
+		case "makedepend":
+			p.FileMetadata.MakeDependencies = appendValue(p.FileMetadata.MakeDependencies, value)
+		case "checkdepend":
+			p.FileMetadata.CheckDependencies = appendValue(p.FileMetadata.CheckDependencies, value)
+		case "conflict":
+			p.FileMetadata.Conflicts = appendValue(p.FileMetadata.Conflicts, value)
+		case "replaces":
+			p.FileMetadata.Replaces = appendValue(p.FileMetadata.Replaces, value)
+		case "backup":
+			p.FileMetadata.Backup = appendValue(p.FileMetadata.Backup, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !namePattern.MatchString(p.Name) {
+		return nil, ErrInvalidName
+	}
+
+	if !versionPattern.MatchString(p.Version) {
+		return nil, ErrInvalidVersion
+	}
+
+	if !IsValidArchitecture(p.FileMetadata.Architecture) {
+		return nil, ErrInvalidArchitecture
+	}
+
+	if p.VersionMetadata.Base == "" {
+		p.VersionMetadata.Base = p.Name
+	}
+
+	if !validation.IsValidURL(p.VersionMetadata.ProjectURL) {
+		p.VersionMetadata.ProjectURL = ""
+	}
+
+	return p, nil
+}
+
+func appendValue(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	return append(values, value)
+}
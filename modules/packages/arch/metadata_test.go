@@ -0,0 +1,148 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName        = "gitea"
+	packageVersion     = "1:1.0.1-2"
+	packageDescription = "Package Description"
+	packageProjectURL  = "https://gitea.io"
+	packagePackager    = "KN4CK3R <dummy@gitea.io>"
+)
+
+func createPKGINFOContent(name, version string) []byte {
+	return []byte(`# Generated by makepkg
+pkgname = ` + name + `
+pkgbase = gitea-base
+pkgver = ` + version + `
+pkgdesc = ` + packageDescription + `
+url = ` + packageProjectURL + `
+builddate = 1678834800
+packager = ` + packagePackager + `
+size = 123456
+arch = x86_64
+license = MIT
+group = tools
+depend = glibc
+depend = git
+optdepend = openssh: ssh support
+makedepend = go
+provides = gitea-server
+conflict = gitea-bin
+backup = etc/gitea/app.ini`)
+}
+
+func TestParsePackage(t *testing.T) {
+	createPackage := func(files map[string][]byte) io.Reader {
+		var buf bytes.Buffer
+		zw, _ := zstd.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+
+		for _, name := range []string{".PKGINFO", ".MTREE", "usr/", "usr/bin/", "usr/bin/gitea"} {
+			content, ok := files[name]
+			if !ok {
+				continue
+			}
+			hdr := &tar.Header{
+				Name: name,
+				Mode: 0o600,
+				Size: int64(len(content)),
+			}
+			tw.WriteHeader(hdr)
+			tw.Write(content)
+		}
+
+		tw.Close()
+		zw.Close()
+
+		return &buf
+	}
+
+	t.Run("MissingPKGINFOFile", func(t *testing.T) {
+		data := createPackage(map[string][]byte{"usr/bin/gitea": {}})
+
+		pp, err := ParsePackage(data)
+		assert.Nil(t, pp)
+		assert.ErrorIs(t, err, ErrMissingPKGINFOFile)
+	})
+
+	t.Run("InvalidPKGINFOFile", func(t *testing.T) {
+		data := createPackage(map[string][]byte{".PKGINFO": createPKGINFOContent("", packageVersion)})
+
+		pp, err := ParsePackage(data)
+		assert.Nil(t, pp)
+		assert.ErrorIs(t, err, ErrInvalidName)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		data := createPackage(map[string][]byte{
+			".PKGINFO":      createPKGINFOContent(packageName, packageVersion),
+			".MTREE":        {},
+			"usr/":          {},
+			"usr/bin/":      {},
+			"usr/bin/gitea": []byte("binary"),
+		})
+
+		p, err := ParsePackage(data)
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, []string{"usr/", "usr/bin/", "usr/bin/gitea"}, p.Files)
+		assert.Equal(t, "gitea-1:1.0.1-2-x86_64.pkg.tar.zst", p.Filename())
+	})
+}
+
+func TestParsePackageInfo(t *testing.T) {
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "-gitea", ".gitea", "gi/tea"} {
+			p, err := ParsePackageInfo(bytes.NewReader(createPKGINFOContent(name, packageVersion)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"", "1.0.1", "1.0.1-a", "1.0-1-2"} {
+			p, err := ParsePackageInfo(bytes.NewReader(createPKGINFOContent(packageName, version)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		p, err := ParsePackageInfo(bytes.NewReader(createPKGINFOContent(packageName, packageVersion)))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, "gitea-base", p.VersionMetadata.Base)
+		assert.Equal(t, packageDescription, p.VersionMetadata.Description)
+		assert.Equal(t, packageProjectURL, p.VersionMetadata.ProjectURL)
+		assert.Equal(t, []string{"MIT"}, p.VersionMetadata.Licenses)
+		assert.Equal(t, []string{"tools"}, p.VersionMetadata.Groups)
+		assert.Equal(t, "x86_64", p.FileMetadata.Architecture)
+		assert.Equal(t, packagePackager, p.FileMetadata.Packager)
+		assert.EqualValues(t, 1678834800, p.FileMetadata.BuildDate)
+		assert.EqualValues(t, 123456, p.FileMetadata.InstalledSize)
+		assert.Equal(t, []string{"glibc", "git"}, p.FileMetadata.Dependencies)
+		assert.Equal(t, []string{"openssh: ssh support"}, p.FileMetadata.OptDependencies)
+		assert.Equal(t, []string{"go"}, p.FileMetadata.MakeDependencies)
+		assert.Equal(t, []string{"gitea-server"}, p.FileMetadata.Provides)
+		assert.Equal(t, []string{"gitea-bin"}, p.FileMetadata.Conflicts)
+		assert.Equal(t, []string{"etc/gitea/app.ini"}, p.FileMetadata.Backup)
+	})
+}
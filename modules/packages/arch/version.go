@@ -0,0 +1,147 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"strings"
+)
+
+// CompareVersions compares two package versions like vercmp of pacman.
+// It returns -1 if a is older than b, 1 if a is newer than b and 0 if both are equal.
+// https://gitlab.archlinux.org/pacman/pacman/-/blob/master/lib/libalpm/version.c
+func CompareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	epoch1, version1, release1 := parseVersion(a)
+	epoch2, version2, release2 := parseVersion(b)
+
+	if c := compareSegments(epoch1, epoch2); c != 0 {
+		return c
+	}
+	if c := compareSegments(version1, version2); c != 0 {
+		return c
+	}
+	if release1 != "" && release2 != "" {
+		return compareSegments(release1, release2)
+	}
+	return 0
+}
+
+// parseVersion splits a version in the form [epoch:]version[-release]
+func parseVersion(v string) (epoch, version, release string) {
+	i := 0
+	for i < len(v) && isDigit(v[i]) {
+		i++
+	}
+
+	epoch = "0"
+	version = v
+	if i < len(v) && v[i] == ':' {
+		if i > 0 {
+			epoch = v[:i]
+		}
+		version = v[i+1:]
+	}
+
+	if pos := strings.LastIndexByte(version, '-'); pos != -1 {
+		version, release = version[:pos], version[pos+1:]
+	}
+	return epoch, version, release
+}
+
+// compareSegments compares the alphanumeric segments of two version strings like rpmvercmp
+func compareSegments(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	one, two := 0, 0
+	for one < len(a) && two < len(b) {
+		start1, start2 := one, two
+		for one < len(a) && !isAlnum(a[one]) {
+			one++
+		}
+		for two < len(b) && !isAlnum(b[two]) {
+			two++
+		}
+		if one == len(a) || two == len(b) {
+			break
+		}
+
+		// a different length of the separators decides the comparison
+		if one-start1 != two-start2 {
+			if one-start1 < two-start2 {
+				return -1
+			}
+			return 1
+		}
+
+		end1, end2 := one, two
+		isNumber := isDigit(a[one])
+		if isNumber {
+			for end1 < len(a) && isDigit(a[end1]) {
+				end1++
+			}
+			for end2 < len(b) && isDigit(b[end2]) {
+				end2++
+			}
+		} else {
+			for end1 < len(a) && isAlpha(a[end1]) {
+				end1++
+			}
+			for end2 < len(b) && isAlpha(b[end2]) {
+				end2++
+			}
+		}
+
+		// segments of different types: a number is newer than letters
+		if two == end2 {
+			if isNumber {
+				return 1
+			}
+			return -1
+		}
+
+		segment1, segment2 := a[one:end1], b[two:end2]
+		if isNumber {
+			segment1 = strings.TrimLeft(segment1, "0")
+			segment2 = strings.TrimLeft(segment2, "0")
+			if len(segment1) != len(segment2) {
+				if len(segment1) < len(segment2) {
+					return -1
+				}
+				return 1
+			}
+		}
+		if c := strings.Compare(segment1, segment2); c != 0 {
+			return c
+		}
+
+		one, two = end1, end2
+	}
+
+	if one == len(a) && two == len(b) {
+		return 0
+	}
+
+	// a remaining alpha segment never beats an empty string: 1.0alpha is older than 1.0
+	if (one == len(a) && !isAlpha(b[two])) || (one < len(a) && isAlpha(a[one])) {
+		return -1
+	}
+	return 1
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || isAlpha(c)
+}
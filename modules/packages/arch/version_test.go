@@ -0,0 +1,44 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareVersions(t *testing.T) {
+	// the orderings documented in the vercmp manpage
+	for _, versions := range [][]string{
+		{"1.0a", "1.0b", "1.0beta", "1.0p", "1.0pre", "1.0rc", "1.0", "1.0.a", "1.0.1"},
+		{"1", "1.0", "1.1", "1.1.1", "1.2", "2.0", "3.0.0"},
+	} {
+		for i := 0; i < len(versions)-1; i++ {
+			assert.Equal(t, -1, CompareVersions(versions[i], versions[i+1]), "%s < %s", versions[i], versions[i+1])
+			assert.Equal(t, 1, CompareVersions(versions[i+1], versions[i]), "%s > %s", versions[i+1], versions[i])
+		}
+	}
+
+	cases := []struct {
+		A        string
+		B        string
+		Expected int
+	}{
+		{"1.5.0", "1.5.0", 0},
+		{"1.5.0-1", "1.5.0-2", -1},
+		{"1.5.0-1", "1.5.0", 0},
+		{"1.5-1", "1.5.1-1", -1},
+		{"1.0.10-1", "1.0.9-1", 1},
+		{"1.0.010", "1.0.10", 0},
+		{"1.0_1", "1.0.1", 0},
+		{"1.0..1", "1.0.1", 1},
+		{"0:1.0", "1.0", 0},
+		{"1:1.0-1", "2.0-1", 1},
+		{"1:1.0", "2:0.1", -1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, CompareVersions(c.A, c.B), "%s <=> %s", c.A, c.B)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package bazel
+
+import (
+	"regexp"
+)
+
+// Kind is the kind of a cache entry. Its value is used as package name of the entries.
+// https://bazel.build/remote/caching#http-caching
+type Kind string
+
+const (
+	// KindCAS is the content addressable store holding output files
+	KindCAS Kind = "cas"
+	// KindAC is the action cache holding action results
+	KindAC Kind = "ac"
+)
+
+var digestPattern = regexp.MustCompile(`\A[a-f0-9]{64}\z`)
+
+// IsValidDigest checks if the value is a lowercase hex encoded sha256 digest
+func IsValidDigest(digest string) bool {
+	return digestPattern.MatchString(digest)
+}
@@ -18,6 +18,12 @@ import (
 const (
 	KeyBits          = 4096
 	SettingPublicPem = "chef.public_pem"
+
+	PropertyDeprecated  = "chef.deprecated"
+	PropertyReplacement = "chef.replacement"
+	PropertySourceURL   = "chef.source_url"
+	PropertyIssuesURL   = "chef.issues_url"
+	PropertyYanked      = "chef.yanked"
 )
 
 var (
@@ -29,6 +35,11 @@ var (
 	versionPattern = regexp.MustCompile(`\A\d+\.\d+(?:\.\d+)?\z`)
 )
 
+// IsValidName checks if the cookbook name is valid
+func IsValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
 // Package represents a Chef package
 type Package struct {
 	Name     string
@@ -107,7 +118,7 @@ func ParseChefMetadata(r io.Reader) (*Package, error) {
 		return nil, err
 	}
 
-	if !namePattern.MatchString(cm.Name) {
+	if !IsValidName(cm.Name) {
 		return nil, ErrInvalidName
 	}
 
@@ -0,0 +1,187 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cocoapods
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+var (
+	ErrPodspecFileTooLarge  = util.NewInvalidArgumentErrorf("podspec file is too large")
+	ErrInvalidPodspec       = util.NewInvalidArgumentErrorf("podspec file is invalid")
+	ErrInvalidName          = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion       = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidArchiveFormat = util.NewInvalidArgumentErrorf("source archive format is not supported")
+)
+
+const maxPodspecFileSize = 512 * 1024
+
+var (
+	namePattern    = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9_.+-]*\z`)
+	versionPattern = regexp.MustCompile(`\A[0-9]+(?:\.[0-9]+)*(?:-[0-9A-Za-z.-]+)?\z`)
+)
+
+// SourceArchiveExtensions lists the archive types the http source of CocoaPods can extract
+// https://github.com/CocoaPods/cocoapods-downloader/blob/master/lib/cocoapods-downloader/http.rb
+var SourceArchiveExtensions = []string{".zip", ".tar.gz", ".tgz", ".tar.bz2", ".tbz", ".tar.xz", ".txz", ".tar"}
+
+// Package represents a CocoaPods package
+type Package struct {
+	Name     string
+	Version  string
+	Metadata *Metadata
+}
+
+// Metadata represents the metadata of a CocoaPods package
+type Metadata struct {
+	Summary      string              `json:"summary,omitempty"`
+	Description  string              `json:"description,omitempty"`
+	ProjectURL   string              `json:"project_url,omitempty"`
+	License      string              `json:"license,omitempty"`
+	Authors      []string            `json:"authors,omitempty"`
+	Platforms    map[string]string   `json:"platforms,omitempty"`
+	Dependencies map[string][]string `json:"dependencies,omitempty"`
+	Deprecated   bool                `json:"deprecated,omitempty"`
+	Podspec      map[string]any      `json:"podspec"`
+}
+
+type podspecPackage struct {
+	Name         string              `json:"name"`
+	Version      string              `json:"version"`
+	Summary      string              `json:"summary"`
+	Description  string              `json:"description"`
+	Homepage     string              `json:"homepage"`
+	License      any                 `json:"license"`
+	Authors      any                 `json:"authors"`
+	Platforms    map[string]any      `json:"platforms"`
+	Dependencies map[string][]string `json:"dependencies"`
+	Deprecated   bool                `json:"deprecated"`
+}
+
+// ParsePodspec parses the JSON representation of a podspec
+// https://guides.cocoapods.org/syntax/podspec.html
+func ParsePodspec(r io.Reader) (*Package, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPodspecFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPodspecFileSize {
+		return nil, ErrPodspecFileTooLarge
+	}
+
+	var spec podspecPackage
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, ErrInvalidPodspec
+	}
+
+	var podspec map[string]any
+	if err := json.Unmarshal(data, &podspec); err != nil {
+		return nil, ErrInvalidPodspec
+	}
+
+	if !namePattern.MatchString(spec.Name) {
+		return nil, ErrInvalidName
+	}
+
+	if !versionPattern.MatchString(spec.Version) {
+		return nil, ErrInvalidVersion
+	}
+
+	if !validation.IsValidURL(spec.Homepage) {
+		spec.Homepage = ""
+	}
+
+	platforms := make(map[string]string, len(spec.Platforms))
+	for platform, target := range spec.Platforms {
+		if s, ok := target.(string); ok {
+			platforms[platform] = s
+		} else {
+			platforms[platform] = ""
+		}
+	}
+
+	return &Package{
+		Name:    spec.Name,
+		Version: spec.Version,
+		Metadata: &Metadata{
+			Summary:      spec.Summary,
+			Description:  spec.Description,
+			ProjectURL:   spec.Homepage,
+			License:      parseLicense(spec.License),
+			Authors:      parseAuthors(spec.Authors),
+			Platforms:    platforms,
+			Dependencies: spec.Dependencies,
+			Deprecated:   spec.Deprecated,
+			Podspec:      podspec,
+		},
+	}, nil
+}
+
+// The license is either the name of the license or an object with a type field
+func parseLicense(license any) string {
+	switch v := license.(type) {
+	case string:
+		return v
+	case map[string]any:
+		if t, ok := v["type"].(string); ok {
+			return t
+		}
+	}
+	return ""
+}
+
+// The authors are either a single name, a list of names or a map of names to emails
+func parseAuthors(authors any) []string {
+	var names []string
+	switch v := authors.(type) {
+	case string:
+		names = append(names, v)
+	case []any:
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+	case map[string]any:
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	return names
+}
+
+// SourceArchiveExtension gets the supported archive extension of the file name
+func SourceArchiveExtension(filename string) (string, error) {
+	filename = strings.ToLower(filename)
+	for _, ext := range SourceArchiveExtensions {
+		if strings.HasSuffix(filename, ext) {
+			return ext, nil
+		}
+	}
+	return "", ErrInvalidArchiveFormat
+}
+
+// ShardPrefix gets the prefix of the pod in the sharded trunk layout
+// The trunk uses the first three characters of the md5 hash of the name.
+// https://github.com/CocoaPods/Core/blob/master/lib/cocoapods-core/source/metadata.rb
+func ShardPrefix(name string) []string {
+	hash := md5.Sum([]byte(name))
+	sum := hex.EncodeToString(hash[:])
+	return []string{sum[0:1], sum[1:2], sum[2:3]}
+}
+
+// PodspecPath gets the path of the podspec in the trunk layout
+func PodspecPath(name, version string) string {
+	return "Specs/" + strings.Join(ShardPrefix(name), "/") + "/" + name + "/" + version + "/" + name + ".podspec.json"
+}
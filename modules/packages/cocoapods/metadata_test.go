@@ -0,0 +1,95 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cocoapods
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName    = "GiteaKit"
+	packageVersion = "1.0.1"
+	packageSummary = "Gitea client library"
+	projectURL     = "https://gitea.io"
+)
+
+func createPodspec(name, version string) string {
+	return `{
+  "name": "` + name + `",
+  "version": "` + version + `",
+  "summary": "` + packageSummary + `",
+  "homepage": "` + projectURL + `",
+  "license": { "type": "MIT", "file": "LICENSE" },
+  "authors": { "KN4CK3R": "dummy@gitea.io" },
+  "platforms": { "ios": "13.0", "osx": "10.15" },
+  "source": { "git": "https://gitea.io/gitea/gitea-kit.git", "tag": "` + version + `" },
+  "source_files": "Sources/**/*.swift",
+  "dependencies": { "Alamofire": ["~> 5.0"] }
+}`
+}
+
+func TestParsePodspec(t *testing.T) {
+	t.Run("InvalidJSON", func(t *testing.T) {
+		p, err := ParsePodspec(strings.NewReader("{"))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidPodspec)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "Gitea Kit", ".GiteaKit", "Gitea/Kit"} {
+			p, err := ParsePodspec(strings.NewReader(createPodspec(name, packageVersion)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"", "v1.0", "1.0 beta"} {
+			p, err := ParsePodspec(strings.NewReader(createPodspec(packageName, version)))
+			assert.Nil(t, p)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		p, err := ParsePodspec(strings.NewReader(createPodspec(packageName, packageVersion)))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, packageSummary, p.Metadata.Summary)
+		assert.Equal(t, projectURL, p.Metadata.ProjectURL)
+		assert.Equal(t, "MIT", p.Metadata.License)
+		assert.Equal(t, []string{"KN4CK3R"}, p.Metadata.Authors)
+		assert.Equal(t, map[string]string{"ios": "13.0", "osx": "10.15"}, p.Metadata.Platforms)
+		assert.Equal(t, map[string][]string{"Alamofire": {"~> 5.0"}}, p.Metadata.Dependencies)
+		assert.Equal(t, "Sources/**/*.swift", p.Metadata.Podspec["source_files"])
+	})
+}
+
+func TestSourceArchiveExtension(t *testing.T) {
+	for filename, expected := range map[string]string{
+		"source.zip":     ".zip",
+		"source.TAR.GZ":  ".tar.gz",
+		"source.tar.bz2": ".tar.bz2",
+		"source.tar":     ".tar",
+	} {
+		ext, err := SourceArchiveExtension(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ext)
+	}
+
+	_, err := SourceArchiveExtension("source.rar")
+	assert.ErrorIs(t, err, ErrInvalidArchiveFormat)
+}
+
+func TestPodspecPath(t *testing.T) {
+	// md5("AFNetworking") = a75d452377f3996bdc4b623a5df25820
+	assert.Equal(t, []string{"a", "7", "5"}, ShardPrefix("AFNetworking"))
+	assert.Equal(t, "Specs/a/7/5/AFNetworking/4.0.1/AFNetworking.podspec.json", PodspecPath("AFNetworking", "4.0.1"))
+}
@@ -0,0 +1,250 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conan
+
+import (
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+// commonSettings are the keys which are looked up in the settings of a package.
+// All other unprefixed keys are looked up in the options.
+// https://github.com/conan-io/conan/blob/release/1.66/conans/search/search.py
+var commonSettings = []string{"os", "os_build", "compiler", "arch", "arch_build", "build_type"}
+
+// Query is a parsed package query like "os=Linux AND (arch=x86 OR arch=x86_64)"
+type Query interface {
+	Match(info *Conaninfo) bool
+}
+
+type andQuery []Query
+
+func (q andQuery) Match(info *Conaninfo) bool {
+	for _, sub := range q {
+		if !sub.Match(info) {
+			return false
+		}
+	}
+	return true
+}
+
+type orQuery []Query
+
+func (q orQuery) Match(info *Conaninfo) bool {
+	for _, sub := range q {
+		if sub.Match(info) {
+			return true
+		}
+	}
+	return false
+}
+
+type matchAllQuery struct{}
+
+func (matchAllQuery) Match(*Conaninfo) bool {
+	return true
+}
+
+type propertyQuery struct {
+	Key     string
+	Value   string
+	Pattern *regexp.Regexp
+	Negate  bool
+}
+
+func (q *propertyQuery) Match(info *Conaninfo) bool {
+	value, has := q.lookup(info)
+
+	var matches bool
+	if !has {
+		matches = q.Value == "None"
+	} else if q.Pattern != nil {
+		matches = q.Pattern.MatchString(value)
+	} else {
+		matches = q.Value == value
+	}
+	return matches != q.Negate
+}
+
+func (q *propertyQuery) lookup(info *Conaninfo) (string, bool) {
+	if key, ok := strings.CutPrefix(q.Key, "settings."); ok {
+		value, has := info.Settings[key]
+		return value, has
+	}
+	if key, ok := strings.CutPrefix(q.Key, "options."); ok {
+		value, has := info.Options[key]
+		return value, has
+	}
+	for _, setting := range commonSettings {
+		if q.Key == setting || strings.HasPrefix(q.Key, setting+".") {
+			value, has := info.Settings[q.Key]
+			return value, has
+		}
+	}
+	value, has := info.Options[q.Key]
+	return value, has
+}
+
+// ParseQuery parses a package query.
+// Conditions have the form key=value or key!=value and can be combined with AND, OR and parentheses.
+// Values can be quoted and may contain * as wildcard. The value None matches missing keys.
+// An empty query matches all packages.
+func ParseQuery(query string) (Query, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return matchAllQuery{}, nil
+	}
+
+	p := &queryParser{tokens: tokens}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, util.NewInvalidArgumentErrorf("unexpected %q in query", p.tokens[p.pos])
+	}
+	return q, nil
+}
+
+func tokenizeQuery(query string) ([]string, error) {
+	var tokens []string
+
+	var sb strings.Builder
+	inQuotes := false
+	flush := func() {
+		if sb.Len() > 0 {
+			tokens = append(tokens, sb.String())
+			sb.Reset()
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			sb.WriteRune(r)
+		case inQuotes:
+			sb.WriteRune(r)
+		case r == ' ' || r == '\t':
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, util.NewInvalidArgumentErrorf("unterminated quote in query")
+	}
+	flush()
+
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *queryParser) parseOr() (Query, error) {
+	q, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	qs := orQuery{q}
+	for strings.EqualFold(p.peek(), "OR") {
+		p.pos++
+		q, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
+	}
+	if len(qs) == 1 {
+		return qs[0], nil
+	}
+	return qs, nil
+}
+
+func (p *queryParser) parseAnd() (Query, error) {
+	q, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	qs := andQuery{q}
+	for strings.EqualFold(p.peek(), "AND") {
+		p.pos++
+		q, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		qs = append(qs, q)
+	}
+	if len(qs) == 1 {
+		return qs[0], nil
+	}
+	return qs, nil
+}
+
+func (p *queryParser) parseTerm() (Query, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, util.NewInvalidArgumentErrorf("unexpected end of query")
+	case token == "(":
+		p.pos++
+		q, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, util.NewInvalidArgumentErrorf("missing ) in query")
+		}
+		p.pos++
+		return q, nil
+	case token == ")" || strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR"):
+		return nil, util.NewInvalidArgumentErrorf("unexpected %q in query", token)
+	}
+	p.pos++
+	return parseCondition(token)
+}
+
+func parseCondition(token string) (Query, error) {
+	key, value, ok := strings.Cut(token, "=")
+	if !ok {
+		return nil, util.NewInvalidArgumentErrorf("invalid condition %q in query", token)
+	}
+
+	q := &propertyQuery{}
+	if k, ok := strings.CutSuffix(key, "!"); ok {
+		key = k
+		q.Negate = true
+	}
+	if key == "" {
+		return nil, util.NewInvalidArgumentErrorf("invalid condition %q in query", token)
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+
+	q.Key = key
+	q.Value = value
+	if strings.Contains(value, "*") {
+		q.Pattern = regexp.MustCompile(`\A` + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, `.*`) + `\z`)
+	}
+	return q, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conan
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	info := &Conaninfo{
+		Settings: map[string]string{
+			"os":               "Linux",
+			"arch":             "x86_64",
+			"compiler":         "Visual Studio",
+			"compiler.version": "16",
+			"build_type":       "Release",
+		},
+		Options: map[string]string{
+			"shared": "True",
+			"fPIC":   "False",
+		},
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		cases := []struct {
+			Query    string
+			Expected bool
+		}{
+			{"", true},
+			{"os=Linux", true},
+			{"os=Windows", false},
+			{"os=Linux AND arch=x86_64 AND shared=True", true},
+			{"os=Linux AND arch=x86_64 AND shared=False", false},
+			{"os=Windows OR shared=True", true},
+			{"os=Windows AND (arch=x86 OR arch=x86_64)", false},
+			{"os=Linux AND (arch=x86 OR arch=x86_64)", true},
+			{"(os=Linux)", true},
+			{`compiler="Visual Studio"`, true},
+			{"compiler.version=16", true},
+			{"compiler.version=1*", true},
+			{"arch=x86*", true},
+			{"arch=arm*", false},
+			{"settings.os=Linux", true},
+			{"options.shared=True", true},
+			{"options.os=Linux", false},
+			{"os!=Windows", true},
+			{"shared!=True", false},
+			{"os.subsystem=None", true},
+			{"missing=None", true},
+			{"missing=True", false},
+			{"os=Linux and shared=True", true},
+		}
+
+		for _, c := range cases {
+			q, err := ParseQuery(c.Query)
+			assert.NoError(t, err, "query: %s", c.Query)
+			assert.Equal(t, c.Expected, q.Match(info), "query: %s", c.Query)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, query := range []string{
+			"os",
+			"=Linux",
+			"os=Linux AND",
+			"OR os=Linux",
+			"(os=Linux",
+			"os=Linux)",
+			"os=Linux arch=x86_64",
+			`compiler="Visual Studio`,
+		} {
+			q, err := ParseQuery(query)
+			assert.Nil(t, q, "query: %s", query)
+			assert.ErrorIs(t, err, util.ErrInvalidArgument, "query: %s", query)
+		}
+	})
+}
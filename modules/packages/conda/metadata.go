@@ -51,14 +51,17 @@ type VersionMetadata struct {
 
 // FileMetadata represents the metadata of a Conda package file
 type FileMetadata struct {
-	IsCondaPackage bool     `json:"is_conda"`
-	Architecture   string   `json:"architecture,omitempty"`
-	NoArch         string   `json:"noarch,omitempty"`
-	Build          string   `json:"build,omitempty"`
-	BuildNumber    int64    `json:"build_number,omitempty"`
-	Dependencies   []string `json:"dependencies,omitempty"`
-	Platform       string   `json:"platform,omitempty"`
-	Timestamp      int64    `json:"timestamp,omitempty"`
+	IsCondaPackage bool        `json:"is_conda"`
+	Architecture   string      `json:"architecture,omitempty"`
+	NoArch         string      `json:"noarch,omitempty"`
+	Build          string      `json:"build,omitempty"`
+	BuildNumber    int64       `json:"build_number,omitempty"`
+	Dependencies   []string    `json:"dependencies,omitempty"`
+	Constrains     []string    `json:"constrains,omitempty"`
+	Platform       string      `json:"platform,omitempty"`
+	Timestamp      int64       `json:"timestamp,omitempty"`
+	TrackFeatures  string      `json:"track_features,omitempty"`
+	RunExports     *RunExports `json:"run_exports,omitempty"`
 }
 
 type index struct {
@@ -69,11 +72,13 @@ type index struct {
 	Build         string   `json:"build"`
 	BuildNumber   int64    `json:"build_number"`
 	Dependencies  []string `json:"depends"`
+	Constrains    []string `json:"constrains"`
 	License       string   `json:"license"`
 	LicenseFamily string   `json:"license_family"`
 	Platform      string   `json:"platform"`
 	Subdir        string   `json:"subdir"`
 	Timestamp     int64    `json:"timestamp"`
+	TrackFeatures string   `json:"track_features"`
 }
 
 type about struct {
@@ -131,6 +136,7 @@ func ParsePackageConda(r io.ReaderAt, size int64) (*Package, error) {
 func parsePackageTar(r io.Reader) (*Package, error) {
 	var i *index
 	var a *about
+	var re *RunExports
 
 	tr := tar.NewReader(r)
 	for {
@@ -146,7 +152,13 @@ func parsePackageTar(r io.Reader) (*Package, error) {
 			continue
 		}
 
-		if hdr.Name == "info/index.json" {
+		// The info files are stored before the content files, stop at the first content file
+		if i != nil && !strings.HasPrefix(hdr.Name, "info/") {
+			break
+		}
+
+		switch hdr.Name {
+		case "info/index.json":
 			if err := json.NewDecoder(tr).Decode(&i); err != nil {
 				return nil, err
 			}
@@ -158,11 +170,7 @@ func parsePackageTar(r io.Reader) (*Package, error) {
 			if !checkVersion(i.Version) {
 				return nil, ErrInvalidVersion
 			}
-
-			if a != nil {
-				break // stop loop if both files were found
-			}
-		} else if hdr.Name == "info/about.json" {
+		case "info/about.json":
 			if err := json.NewDecoder(tr).Decode(&a); err != nil {
 				return nil, err
 			}
@@ -176,10 +184,16 @@ func parsePackageTar(r io.Reader) (*Package, error) {
 			if !validation.IsValidURL(a.DocumentationURL) {
 				a.DocumentationURL = ""
 			}
-
-			if i != nil {
-				break // stop loop if both files were found
+		case "info/run_exports.json":
+			if err := json.NewDecoder(tr).Decode(&re); err != nil {
+				return nil, err
 			}
+		default:
+			continue
+		}
+
+		if i != nil && a != nil && re != nil {
+			break // stop loop if all files were found
 		}
 	}
 
@@ -204,13 +218,16 @@ func parsePackageTar(r io.Reader) (*Package, error) {
 			DocumentationURL: a.DocumentationURL,
 		},
 		FileMetadata: &FileMetadata{
-			Architecture: i.Architecture,
-			NoArch:       i.NoArch,
-			Build:        i.Build,
-			BuildNumber:  i.BuildNumber,
-			Dependencies: i.Dependencies,
-			Platform:     i.Platform,
-			Timestamp:    i.Timestamp,
+			Architecture:  i.Architecture,
+			NoArch:        i.NoArch,
+			Build:         i.Build,
+			BuildNumber:   i.BuildNumber,
+			Dependencies:  i.Dependencies,
+			Constrains:    i.Constrains,
+			Platform:      i.Platform,
+			Timestamp:     i.Timestamp,
+			TrackFeatures: i.TrackFeatures,
+			RunExports:    re,
 		},
 	}, nil
 }
@@ -101,6 +101,24 @@ func TestParsePackage(t *testing.T) {
 		assert.Equal(t, documentationURL, p.VersionMetadata.DocumentationURL)
 	})
 
+	t.Run("RunExports", func(t *testing.T) {
+		buf := createArchive(map[string][]byte{
+			"info/index.json":       []byte(`{"name":"` + packageName + `","version":"` + packageVersion + `","depends":["python >=3.8"],"constrains":["other <2"]}`),
+			"info/run_exports.json": []byte(`{"weak":["` + packageName + ` >=1.0.1,<2.0a0"],"strong_constrains":["other >=1"]}`),
+		})
+
+		p, err := parsePackageTar(buf)
+		assert.NotNil(t, p)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"python >=3.8"}, p.FileMetadata.Dependencies)
+		assert.Equal(t, []string{"other <2"}, p.FileMetadata.Constrains)
+		assert.NotNil(t, p.FileMetadata.RunExports)
+		assert.Equal(t, []string{packageName + " >=1.0.1,<2.0a0"}, p.FileMetadata.RunExports.Weak)
+		assert.Equal(t, []string{"other >=1"}, p.FileMetadata.RunExports.StrongConstrains)
+		assert.Empty(t, p.FileMetadata.RunExports.Strong)
+	})
+
 	t.Run(".tar.bz2", func(t *testing.T) {
 		tarArchive := createArchive(map[string][]byte{
 			"info/index.json": []byte(`{"name":"` + packageName + `","version":"` + packageVersion + `"}`),
@@ -0,0 +1,264 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	RepositoryPackage = "_conda"
+	RepositoryVersion = "_repository"
+
+	PatchInstructionsFilename = "patch_instructions.json"
+
+	// revokedDependency is added to revoked packages to make them uninstallable
+	revokedDependency = "package_has_been_revoked"
+)
+
+var (
+	ErrInvalidPatchInstructions = util.SilentWrap{Message: "patch instructions are invalid", Err: util.ErrInvalidArgument}
+
+	patchableFields = container.SetOf("depends", "constrains", "license", "license_family", "track_features")
+)
+
+// https://docs.conda.io/projects/conda-build/en/stable/concepts/generating-index.html#repodata-json
+
+type RepoData struct {
+	Info          RepoDataInfo            `json:"info"`
+	Packages      map[string]*PackageInfo `json:"packages"`
+	PackagesConda map[string]*PackageInfo `json:"packages.conda"`
+	Removed       map[string]*PackageInfo `json:"removed"`
+}
+
+type RepoDataInfo struct {
+	Subdir string `json:"subdir"`
+}
+
+type PackageInfo struct {
+	Name          string   `json:"name"`
+	Version       string   `json:"version"`
+	NoArch        string   `json:"noarch"`
+	Subdir        string   `json:"subdir"`
+	Timestamp     int64    `json:"timestamp"`
+	Build         string   `json:"build"`
+	BuildNumber   int64    `json:"build_number"`
+	Dependencies  []string `json:"depends"`
+	Constrains    []string `json:"constrains,omitempty"`
+	License       string   `json:"license"`
+	LicenseFamily string   `json:"license_family"`
+	TrackFeatures string   `json:"track_features,omitempty"`
+	Revoked       bool     `json:"revoked,omitempty"`
+	HashMD5       string   `json:"md5"`
+	HashSHA256    string   `json:"sha256"`
+	Size          int64    `json:"size"`
+}
+
+// RunExports are the dependencies a package adds to packages which are built against it
+// https://docs.conda.io/projects/conda-build/en/stable/resources/define-metadata.html#export-runtime-requirements
+type RunExports struct {
+	Weak             []string `json:"weak,omitempty"`
+	Strong           []string `json:"strong,omitempty"`
+	NoArch           []string `json:"noarch,omitempty"`
+	WeakConstrains   []string `json:"weak_constrains,omitempty"`
+	StrongConstrains []string `json:"strong_constrains,omitempty"`
+}
+
+// IsEmpty checks if the package exports no dependencies
+func (re *RunExports) IsEmpty() bool {
+	return re == nil || len(re.Weak)+len(re.Strong)+len(re.NoArch)+len(re.WeakConstrains)+len(re.StrongConstrains) == 0
+}
+
+// RunExportsData is the content of a run_exports.json file of a subdir
+// https://github.com/conda/ceps/blob/main/cep-0012.md
+type RunExportsData struct {
+	Info          RepoDataInfo                      `json:"info"`
+	Packages      map[string]*RunExportsDataPackage `json:"packages"`
+	PackagesConda map[string]*RunExportsDataPackage `json:"packages.conda"`
+}
+
+type RunExportsDataPackage struct {
+	RunExports *RunExports `json:"run_exports"`
+}
+
+// ChannelData is the content of the channeldata.json file of a channel
+// https://docs.conda.io/projects/conda-build/en/stable/concepts/generating-index.html#channeldata-json
+type ChannelData struct {
+	ChannelDataVersion int                            `json:"channeldata_version"`
+	Packages           map[string]*ChannelDataPackage `json:"packages"`
+	Subdirs            []string                       `json:"subdirs"`
+}
+
+type ChannelDataPackage struct {
+	Description string                 `json:"description,omitempty"`
+	DevURL      string                 `json:"dev_url,omitempty"`
+	DocURL      string                 `json:"doc_url,omitempty"`
+	Home        string                 `json:"home,omitempty"`
+	License     string                 `json:"license,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	RunExports  map[string]*RunExports `json:"run_exports"`
+	Subdirs     []string               `json:"subdirs"`
+	Timestamp   int64                  `json:"timestamp,omitempty"`
+	Version     string                 `json:"version"`
+}
+
+// PatchInstructions modify the repodata of a subdir without changing the package files
+// https://github.com/conda-forge/conda-forge-repodata-patches-feedstock
+type PatchInstructions struct {
+	PatchInstructionsVersion int                      `json:"patch_instructions_version"`
+	Packages                 map[string]*PackagePatch `json:"packages"`
+	PackagesConda            map[string]*PackagePatch `json:"packages.conda"`
+	Remove                   []string                 `json:"remove"`
+	Revoke                   []string                 `json:"revoke"`
+}
+
+// PackagePatch contains the fields of a package entry to replace
+type PackagePatch struct {
+	Dependencies  *[]string `json:"depends,omitempty"`
+	Constrains    *[]string `json:"constrains,omitempty"`
+	License       *string   `json:"license,omitempty"`
+	LicenseFamily *string   `json:"license_family,omitempty"`
+	TrackFeatures *string   `json:"track_features,omitempty"`
+}
+
+func (pp *PackagePatch) apply(pi *PackageInfo) {
+	if pp.Dependencies != nil {
+		pi.Dependencies = slices.Clone(*pp.Dependencies)
+	}
+	if pp.Constrains != nil {
+		pi.Constrains = slices.Clone(*pp.Constrains)
+	}
+	if pp.License != nil {
+		pi.License = *pp.License
+	}
+	if pp.LicenseFamily != nil {
+		pi.LicenseFamily = *pp.LicenseFamily
+	}
+	if pp.TrackFeatures != nil {
+		pi.TrackFeatures = *pp.TrackFeatures
+	}
+}
+
+// ParsePatchInstructions parses and validates patch instructions.
+// Only the package fields supported by PackagePatch can be patched.
+func ParsePatchInstructions(r io.Reader) (*PatchInstructions, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var pi *PatchInstructions
+	if err := json.Unmarshal(content, &pi); err != nil || pi == nil {
+		return nil, ErrInvalidPatchInstructions
+	}
+
+	// Reject fields which can't be patched instead of ignoring them silently
+	var raw struct {
+		Packages      map[string]map[string]any `json:"packages"`
+		PackagesConda map[string]map[string]any `json:"packages.conda"`
+	}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, ErrInvalidPatchInstructions
+	}
+	for _, patches := range []map[string]map[string]any{raw.Packages, raw.PackagesConda} {
+		for _, patch := range patches {
+			for field := range patch {
+				if !patchableFields.Contains(field) {
+					return nil, util.SilentWrap{Message: fmt.Sprintf("field %s can not be patched", field), Err: util.ErrInvalidArgument}
+				}
+			}
+		}
+	}
+
+	if pi.PatchInstructionsVersion == 0 {
+		pi.PatchInstructionsVersion = 1
+	}
+	if pi.PatchInstructionsVersion > 2 {
+		return nil, util.SilentWrap{Message: "patch instructions version is not supported", Err: util.ErrInvalidArgument}
+	}
+
+	if pi.Packages == nil {
+		pi.Packages = map[string]*PackagePatch{}
+	}
+	if pi.PackagesConda == nil {
+		pi.PackagesConda = map[string]*PackagePatch{}
+	}
+	if pi.Remove == nil {
+		pi.Remove = []string{}
+	}
+	if pi.Revoke == nil {
+		pi.Revoke = []string{}
+	}
+
+	for _, patches := range []map[string]*PackagePatch{pi.Packages, pi.PackagesConda} {
+		for filename, patch := range patches {
+			if patch == nil || !isPackageFilename(filename) {
+				return nil, ErrInvalidPatchInstructions
+			}
+		}
+	}
+	for _, filename := range append(slices.Clone(pi.Remove), pi.Revoke...) {
+		if !isPackageFilename(filename) {
+			return nil, ErrInvalidPatchInstructions
+		}
+	}
+
+	return pi, nil
+}
+
+func isPackageFilename(filename string) bool {
+	return !strings.ContainsAny(filename, "/\\") && (strings.HasSuffix(filename, ".tar.bz2") || strings.HasSuffix(filename, ".conda"))
+}
+
+// ApplyPatchInstructions applies the patch instructions like conda does
+// https://github.com/conda/conda/blob/main/conda/core/subdir_data.py
+func (rd *RepoData) ApplyPatchInstructions(pi *PatchInstructions) {
+	findPackage := func(filename string) (*PackageInfo, map[string]*PackageInfo) {
+		if pkg, ok := rd.Packages[filename]; ok {
+			return pkg, rd.Packages
+		}
+		if pkg, ok := rd.PackagesConda[filename]; ok {
+			return pkg, rd.PackagesConda
+		}
+		return nil, nil
+	}
+
+	for filename, patch := range pi.Packages {
+		if pkg, ok := rd.Packages[filename]; ok {
+			patch.apply(pkg)
+		}
+		// Since version 2 a patch of a .tar.bz2 file applies to the .conda file of the same build too
+		if pi.PatchInstructionsVersion > 1 {
+			if pkg, ok := rd.PackagesConda[strings.TrimSuffix(filename, ".tar.bz2")+".conda"]; ok {
+				patch.apply(pkg)
+			}
+		}
+	}
+	for filename, patch := range pi.PackagesConda {
+		if pkg, ok := rd.PackagesConda[filename]; ok {
+			patch.apply(pkg)
+		}
+	}
+
+	for _, filename := range pi.Revoke {
+		if pkg, _ := findPackage(filename); pkg != nil {
+			pkg.Revoked = true
+			pkg.Dependencies = append(pkg.Dependencies, revokedDependency)
+		}
+	}
+
+	for _, filename := range pi.Remove {
+		if pkg, packages := findPackage(filename); pkg != nil {
+			delete(packages, filename)
+			rd.Removed[filename] = pkg
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePatchInstructions(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		pi, err := ParsePatchInstructions(strings.NewReader(`{"packages":{"gitea-1.0-0.tar.bz2":{"depends":["python >=3.8,<3.12"]}},"remove":["gitea-0.9-0.conda"]}`))
+		assert.NoError(t, err)
+		assert.NotNil(t, pi)
+		assert.Equal(t, 1, pi.PatchInstructionsVersion)
+		assert.Len(t, pi.Packages, 1)
+		assert.Empty(t, pi.PackagesConda)
+		assert.Equal(t, []string{"gitea-0.9-0.conda"}, pi.Remove)
+		assert.Empty(t, pi.Revoke)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cases := []string{
+			`invalid`,
+			`{"patch_instructions_version":3}`,
+			`{"packages":{"gitea-1.0-0.tar.bz2":{"name":"other"}}}`,
+			`{"packages":{"../gitea-1.0-0.tar.bz2":{"depends":[]}}}`,
+			`{"packages":{"gitea-1.0-0.zip":{"depends":[]}}}`,
+			`{"revoke":["gitea"]}`,
+		}
+		for _, c := range cases {
+			pi, err := ParsePatchInstructions(strings.NewReader(c))
+			assert.Nil(t, pi, c)
+			assert.ErrorIs(t, err, util.ErrInvalidArgument, c)
+		}
+	})
+}
+
+func TestApplyPatchInstructions(t *testing.T) {
+	createRepoData := func() *RepoData {
+		return &RepoData{
+			Packages: map[string]*PackageInfo{
+				"a-1.0-0.tar.bz2": {Name: "a", Dependencies: []string{"python"}, License: "MIT"},
+				"b-1.0-0.tar.bz2": {Name: "b"},
+			},
+			PackagesConda: map[string]*PackageInfo{
+				"a-1.0-0.conda": {Name: "a", Dependencies: []string{"python"}},
+				"c-1.0-0.conda": {Name: "c"},
+			},
+			Removed: map[string]*PackageInfo{},
+		}
+	}
+
+	depends := []string{"python >=3.8"}
+	license := "Apache-2.0"
+
+	t.Run("Version1", func(t *testing.T) {
+		rd := createRepoData()
+		rd.ApplyPatchInstructions(&PatchInstructions{
+			PatchInstructionsVersion: 1,
+			Packages: map[string]*PackagePatch{
+				"a-1.0-0.tar.bz2": {Dependencies: &depends, License: &license},
+			},
+			Remove: []string{"b-1.0-0.tar.bz2", "not-existing-1.0-0.conda"},
+			Revoke: []string{"c-1.0-0.conda"},
+		})
+
+		assert.Equal(t, depends, rd.Packages["a-1.0-0.tar.bz2"].Dependencies)
+		assert.Equal(t, license, rd.Packages["a-1.0-0.tar.bz2"].License)
+		assert.Equal(t, []string{"python"}, rd.PackagesConda["a-1.0-0.conda"].Dependencies)
+
+		assert.NotContains(t, rd.Packages, "b-1.0-0.tar.bz2")
+		assert.Contains(t, rd.Removed, "b-1.0-0.tar.bz2")
+		assert.Len(t, rd.Removed, 1)
+
+		assert.True(t, rd.PackagesConda["c-1.0-0.conda"].Revoked)
+		assert.Equal(t, []string{revokedDependency}, rd.PackagesConda["c-1.0-0.conda"].Dependencies)
+	})
+
+	t.Run("Version2", func(t *testing.T) {
+		rd := createRepoData()
+		rd.ApplyPatchInstructions(&PatchInstructions{
+			PatchInstructionsVersion: 2,
+			Packages: map[string]*PackagePatch{
+				"a-1.0-0.tar.bz2": {Dependencies: &depends},
+			},
+		})
+
+		assert.Equal(t, depends, rd.Packages["a-1.0-0.tar.bz2"].Dependencies)
+		assert.Equal(t, depends, rd.PackagesConda["a-1.0-0.conda"].Dependencies)
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import "time"
+
+// Registry notifications in the format of the Docker distribution registry
+// https://distribution.github.io/distribution/about/notifications/
+
+const (
+	ContentTypeEventEnvelope = "application/vnd.docker.distribution.events.v1+json"
+
+	// BlobMediaType is the media type reported for layer and config blobs
+	BlobMediaType = "application/octet-stream"
+
+	EventActionPull   = "pull"
+	EventActionPush   = "push"
+	EventActionMount  = "mount"
+	EventActionDelete = "delete"
+)
+
+// EventEnvelope is the body of a notification request
+type EventEnvelope struct {
+	Events []*Event `json:"events"`
+}
+
+// Event describes a single registry action
+type Event struct {
+	ID        string       `json:"id"`
+	Timestamp time.Time    `json:"timestamp"`
+	Action    string       `json:"action"`
+	Target    EventTarget  `json:"target"`
+	Request   EventRequest `json:"request"`
+	Actor     EventActor   `json:"actor"`
+	Source    EventSource  `json:"source"`
+}
+
+// EventTarget describes the manifest or blob the action was performed on
+type EventTarget struct {
+	MediaType      string `json:"mediaType,omitempty"`
+	Size           int64  `json:"size,omitempty"`
+	Digest         string `json:"digest,omitempty"`
+	Length         int64  `json:"length,omitempty"`
+	Repository     string `json:"repository,omitempty"`
+	FromRepository string `json:"fromRepository,omitempty"`
+	URL            string `json:"url,omitempty"`
+	Tag            string `json:"tag,omitempty"`
+}
+
+// EventRequest describes the request which caused the event
+type EventRequest struct {
+	ID        string `json:"id,omitempty"`
+	Addr      string `json:"addr,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Method    string `json:"method,omitempty"`
+	UserAgent string `json:"useragent,omitempty"`
+}
+
+// EventActor describes the user who performed the action
+type EventActor struct {
+	Name string `json:"name,omitempty"`
+}
+
+// EventSource describes the registry instance which generated the event
+type EventSource struct {
+	Addr       string `json:"addr,omitempty"`
+	InstanceID string `json:"instanceID,omitempty"`
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cran
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+)
+
+// ArchivedFile is a superseded source package file stored in src/contrib/Archive/{name}/
+type ArchivedFile struct {
+	Name     string
+	Filename string
+	Size     int64
+	Modified int64
+}
+
+// R serialization constants
+// https://github.com/wch/r-source/blob/trunk/src/main/serialize.c
+const (
+	sexpSymbol    = 1
+	sexpPairList  = 2
+	sexpChar      = 9
+	sexpLogical   = 10
+	sexpInteger   = 13
+	sexpReal      = 14
+	sexpString    = 16
+	sexpVector    = 19
+	sexpNilValue  = 254
+	flagIsObject  = 1 << 8
+	flagHasAttr   = 1 << 9
+	flagHasTag    = 1 << 10
+	flagUTF8Level = 1 << 3 << 12
+)
+
+type rdsAttribute struct {
+	Name  string
+	Value func(w *rdsWriter)
+}
+
+type rdsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *rdsWriter) writeInt(v int32) {
+	if w.err == nil {
+		w.err = binary.Write(w.w, binary.BigEndian, v)
+	}
+}
+
+func (w *rdsWriter) writeFlags(sexp int32, isObject bool, attributes []rdsAttribute) {
+	flags := sexp
+	if isObject {
+		flags |= flagIsObject
+	}
+	if len(attributes) > 0 {
+		flags |= flagHasAttr
+	}
+	w.writeInt(flags)
+}
+
+func (w *rdsWriter) writeAttributes(attributes []rdsAttribute) {
+	if len(attributes) == 0 {
+		return
+	}
+	for _, attr := range attributes {
+		w.writeInt(sexpPairList | flagHasTag)
+		w.writeInt(sexpSymbol)
+		w.writeChar(attr.Name)
+		attr.Value(w)
+	}
+	w.writeInt(sexpNilValue)
+}
+
+func (w *rdsWriter) writeChar(s string) {
+	w.writeInt(sexpChar | flagUTF8Level)
+	w.writeInt(int32(len(s)))
+	if w.err == nil {
+		_, w.err = io.WriteString(w.w, s)
+	}
+}
+
+func (w *rdsWriter) writeStrings(values []string, attributes ...rdsAttribute) {
+	w.writeFlags(sexpString, false, attributes)
+	w.writeInt(int32(len(values)))
+	for _, v := range values {
+		w.writeChar(v)
+	}
+	w.writeAttributes(attributes)
+}
+
+func (w *rdsWriter) writeIntegers(values []int32, class []string) {
+	attributes := classAttributes(class)
+	w.writeFlags(sexpInteger, len(class) > 0, attributes)
+	w.writeInt(int32(len(values)))
+	for _, v := range values {
+		w.writeInt(v)
+	}
+	w.writeAttributes(attributes)
+}
+
+func (w *rdsWriter) writeLogicals(values []bool) {
+	w.writeFlags(sexpLogical, false, nil)
+	w.writeInt(int32(len(values)))
+	for _, v := range values {
+		if v {
+			w.writeInt(1)
+		} else {
+			w.writeInt(0)
+		}
+	}
+}
+
+func (w *rdsWriter) writeReals(values []float64, class []string) {
+	attributes := classAttributes(class)
+	w.writeFlags(sexpReal, len(class) > 0, attributes)
+	w.writeInt(int32(len(values)))
+	for _, v := range values {
+		if w.err == nil {
+			w.err = binary.Write(w.w, binary.BigEndian, math.Float64bits(v))
+		}
+	}
+	w.writeAttributes(attributes)
+}
+
+func (w *rdsWriter) writeList(names []string, items []func(w *rdsWriter), isObject bool, attributes ...rdsAttribute) {
+	attributes = append([]rdsAttribute{{Name: "names", Value: func(w *rdsWriter) { w.writeStrings(names) }}}, attributes...)
+
+	w.writeFlags(sexpVector, isObject, attributes)
+	w.writeInt(int32(len(items)))
+	for _, item := range items {
+		item(w)
+	}
+	w.writeAttributes(attributes)
+}
+
+func classAttributes(class []string) []rdsAttribute {
+	if len(class) == 0 {
+		return nil
+	}
+	return []rdsAttribute{{Name: "class", Value: func(w *rdsWriter) { w.writeStrings(class) }}}
+}
+
+// WriteArchiveIndex writes the src/contrib/Meta/archive.rds file.
+// It is a named list with a data.frame per package which contains the file.info() columns of the archived files.
+// remotes::install_version uses the row names ({name}/{filename}) to locate a specific version.
+func WriteArchiveIndex(w io.Writer, files []*ArchivedFile) error {
+	filesByName := make(map[string][]*ArchivedFile)
+	for _, f := range files {
+		filesByName[f.Name] = append(filesByName[f.Name], f)
+	}
+
+	names := make([]string, 0, len(filesByName))
+	for name := range filesByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gzw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(gzw)
+
+	rw := &rdsWriter{w: bw}
+
+	// XDR format, serialization version 2, written by R 3.5.0, readable by R 2.3.0
+	if _, err := io.WriteString(bw, "X\n"); err != nil {
+		return err
+	}
+	rw.writeInt(2)
+	rw.writeInt(3<<16 | 5<<8)
+	rw.writeInt(2<<16 | 3<<8)
+
+	items := make([]func(w *rdsWriter), 0, len(names))
+	for _, name := range names {
+		items = append(items, archiveDataFrameWriter(filesByName[name]))
+	}
+	rw.writeList(names, items, false)
+
+	if rw.err != nil {
+		return rw.err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+func archiveDataFrameWriter(files []*ArchivedFile) func(w *rdsWriter) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Modified < files[j].Modified
+	})
+
+	rowNames := make([]string, 0, len(files))
+	sizes := make([]float64, 0, len(files))
+	isDirs := make([]bool, 0, len(files))
+	modes := make([]int32, 0, len(files))
+	times := make([]float64, 0, len(files))
+	ids := make([]int32, 0, len(files))
+	users := make([]string, 0, len(files))
+	for _, f := range files {
+		rowNames = append(rowNames, f.Name+"/"+f.Filename)
+		sizes = append(sizes, float64(f.Size))
+		isDirs = append(isDirs, false)
+		modes = append(modes, 0o644)
+		times = append(times, float64(f.Modified))
+		ids = append(ids, 0)
+		users = append(users, "root")
+	}
+
+	timeClass := []string{"POSIXct", "POSIXt"}
+
+	return func(w *rdsWriter) {
+		w.writeList(
+			[]string{"size", "isdir", "mode", "mtime", "ctime", "atime", "uid", "gid", "uname", "grname"},
+			[]func(w *rdsWriter){
+				func(w *rdsWriter) { w.writeReals(sizes, nil) },
+				func(w *rdsWriter) { w.writeLogicals(isDirs) },
+				func(w *rdsWriter) { w.writeIntegers(modes, []string{"octmode"}) },
+				func(w *rdsWriter) { w.writeReals(times, timeClass) },
+				func(w *rdsWriter) { w.writeReals(times, timeClass) },
+				func(w *rdsWriter) { w.writeReals(times, timeClass) },
+				func(w *rdsWriter) { w.writeIntegers(ids, nil) },
+				func(w *rdsWriter) { w.writeIntegers(ids, nil) },
+				func(w *rdsWriter) { w.writeStrings(users) },
+				func(w *rdsWriter) { w.writeStrings(users) },
+			},
+			true,
+			rdsAttribute{Name: "class", Value: func(w *rdsWriter) { w.writeStrings([]string{"data.frame"}) }},
+			rdsAttribute{Name: "row.names", Value: func(w *rdsWriter) { w.writeStrings(rowNames) }},
+		)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cran
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteArchiveIndex(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteArchiveIndex(&buf, []*ArchivedFile{
+		{Name: packageName, Filename: packageName + "_1.0.0.tar.gz", Size: 12, Modified: 1700000000},
+		{Name: packageName, Filename: packageName + "_0.9.tar.gz", Size: 10, Modified: 1600000000},
+		{Name: "other", Filename: "other_1.0.tar.gz", Size: 5, Modified: 1650000000},
+	})
+	assert.NoError(t, err)
+
+	gzr, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(gzr)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "X\n", string(content[:2]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint32(content[2:]))
+
+	// The list contains a data.frame per package
+	assert.EqualValues(t, sexpVector|flagHasAttr, binary.BigEndian.Uint32(content[14:]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint32(content[18:]))
+	assert.EqualValues(t, sexpVector|flagIsObject|flagHasAttr, binary.BigEndian.Uint32(content[22:]))
+
+	// Archived files are referenced by their row names
+	gitea1 := bytes.Index(content, []byte(packageName+"/"+packageName+"_1.0.0.tar.gz"))
+	gitea09 := bytes.Index(content, []byte(packageName+"/"+packageName+"_0.9.tar.gz"))
+	other := bytes.Index(content, []byte("other/other_1.0.tar.gz"))
+	assert.Positive(t, gitea1)
+	assert.Positive(t, gitea09)
+	assert.Positive(t, other)
+	assert.Less(t, gitea1, other, "packages should be sorted by name")
+	assert.Less(t, gitea09, gitea1, "files should be sorted by modification time")
+}
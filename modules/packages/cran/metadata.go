@@ -29,6 +29,8 @@ var (
 	ErrMissingDescriptionFile = util.NewInvalidArgumentErrorf("DESCRIPTION file is missing")
 	ErrInvalidName            = util.NewInvalidArgumentErrorf("package name is invalid")
 	ErrInvalidVersion         = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidPlatform        = util.NewInvalidArgumentErrorf("platform is invalid")
+	ErrInvalidRVersion        = util.NewInvalidArgumentErrorf("R version is invalid")
 )
 
 var (
@@ -36,6 +38,8 @@ var (
 	namePattern          = regexp.MustCompile(`\A[a-zA-Z][a-zA-Z0-9\.]*[a-zA-Z0-9]\z`)
 	versionPattern       = regexp.MustCompile(`\A[0-9]+(?:[.\-][0-9]+){1,3}\z`)
 	authorReplacePattern = regexp.MustCompile(`[\[\(].+?[\]\)]`)
+	platformPattern      = regexp.MustCompile(`\A[a-z0-9_\-]+(?:/[a-z0-9_\-]+)?\z`)
+	rVersionPattern      = regexp.MustCompile(`\A([0-9]+\.[0-9]+)(?:\.[0-9]+)?\z`)
 )
 
 // Package represents a CRAN package
@@ -60,6 +64,30 @@ type Metadata struct {
 	NeedsCompilation bool     `json:"needs_compilation"`
 }
 
+// ValidatePlatform checks the binary platform. macOS platforms contain the flavor like "macosx/big-sur-arm64".
+func ValidatePlatform(platform string) error {
+	if !platformPattern.MatchString(platform) {
+		return ErrInvalidPlatform
+	}
+	return nil
+}
+
+// NormalizeRVersion reduces the R version to major.minor.
+// Binary packages are compatible with all patch releases of the same R version.
+func NormalizeRVersion(rversion string) (string, error) {
+	m := rVersionPattern.FindStringSubmatch(rversion)
+	if m == nil {
+		return "", ErrInvalidRVersion
+	}
+	return m[1], nil
+}
+
+// MatchesRVersion checks if the stored R version of a binary belongs to the normalized R version.
+// Binaries uploaded before the R version got normalized store the full major.minor.patch version.
+func MatchesRVersion(stored, rversion string) bool {
+	return stored == rversion || strings.HasPrefix(stored, rversion+".")
+}
+
 type ReaderReaderAt interface {
 	io.Reader
 	io.ReaderAt
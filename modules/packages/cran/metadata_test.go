@@ -150,3 +150,41 @@ func TestParseDescription(t *testing.T) {
 		assert.True(t, p.Metadata.NeedsCompilation)
 	})
 }
+
+func TestNormalizeRVersion(t *testing.T) {
+	for _, c := range []struct {
+		RVersion string
+		Expected string
+	}{
+		{"4.2", "4.2"},
+		{"4.3.1", "4.3"},
+		{"10.12.0", "10.12"},
+	} {
+		v, err := NormalizeRVersion(c.RVersion)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Expected, v)
+	}
+
+	for _, rversion := range []string{"", "4", "4.", "4.x", "4.3.1.2", "../4.3"} {
+		v, err := NormalizeRVersion(rversion)
+		assert.Empty(t, v)
+		assert.ErrorIs(t, err, ErrInvalidRVersion)
+	}
+}
+
+func TestMatchesRVersion(t *testing.T) {
+	assert.True(t, MatchesRVersion("4.3", "4.3"))
+	assert.True(t, MatchesRVersion("4.3.1", "4.3"))
+	assert.False(t, MatchesRVersion("4.30.1", "4.3"))
+	assert.False(t, MatchesRVersion("4.2.1", "4.3"))
+}
+
+func TestValidatePlatform(t *testing.T) {
+	for _, platform := range []string{"windows", "macosx", "macosx/big-sur-arm64"} {
+		assert.NoError(t, ValidatePlatform(platform))
+	}
+
+	for _, platform := range []string{"", "Windows", "macosx/", "../windows", "a/b/c"} {
+		assert.ErrorIs(t, ValidatePlatform(platform), ErrInvalidPlatform)
+	}
+}
@@ -33,6 +33,11 @@ const (
 	RepositoryPackage = "_debian"
 	RepositoryVersion = "_repository"
 
+	// SnapshotVersionPrefix is the prefix of the internal versions holding a snapshot
+	SnapshotVersionPrefix = "_snapshot_"
+	// PropertySnapshotDistribution contains the distribution a snapshot was created from
+	PropertySnapshotDistribution = "debian.snapshot.distribution"
+
 	controlTar = "control.tar"
 )
 
@@ -0,0 +1,365 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/keybase/go-crypto/openpgp/clearsign"
+)
+
+const (
+	// ArchitectureSource is the pseudo architecture used for source packages
+	ArchitectureSource = "source"
+
+	// PropertySourceFiles contains the files referenced by a source control file
+	PropertySourceFiles = "debian.source.files"
+
+	// IncomingVersion is the internal version holding source files uploaded before their source control file
+	IncomingVersion = "_incoming"
+)
+
+var (
+	ErrInvalidSourceControl = util.NewInvalidArgumentErrorf("source control file is invalid")
+	ErrInvalidChanges       = util.NewInvalidArgumentErrorf("changes file is invalid")
+	ErrInvalidFilename      = util.NewInvalidArgumentErrorf("filename is invalid")
+)
+
+// SourceFile is a file referenced by a source control or changes file
+type SourceFile struct {
+	Name   string
+	Size   int64
+	MD5    string
+	SHA1   string
+	SHA256 string
+}
+
+// SourcePackage represents a Debian source package described by a .dsc file
+type SourcePackage struct {
+	Name     string
+	Version  string
+	Control  string
+	Files    []*SourceFile
+	Metadata *Metadata
+}
+
+// Changes represents a Debian upload described by a .changes file
+type Changes struct {
+	Source        string
+	Version       string
+	Distribution  string
+	Architectures []string
+	Files         []*SourceFile
+}
+
+type controlField struct {
+	Key   string
+	Value string
+}
+
+// parseControlParagraph parses a single deb822 paragraph. Continuation lines are
+// joined with a newline and keep their leading whitespace.
+func parseControlParagraph(r io.Reader) ([]*controlField, error) {
+	fields := make([]*controlField, 0, 20)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				break
+			}
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(fields) == 0 {
+				return nil, ErrInvalidSourceControl
+			}
+			last := fields[len(fields)-1]
+			last.Value += "\n" + line
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) < 2 {
+			return nil, ErrInvalidSourceControl
+		}
+
+		fields = append(fields, &controlField{
+			Key:   strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// stripSignature returns the signed content of a clearsigned message or the data itself if it is not signed
+func stripSignature(data []byte) []byte {
+	if b, _ := clearsign.Decode(data); b != nil {
+		return b.Plaintext
+	}
+	return data
+}
+
+// parseFileList parses the multiline file lists (Files, Checksums-Sha1, Checksums-Sha256) and merges them into files
+func parseFileList(files map[string]*SourceFile, order *[]string, value string, fieldsPerLine int, set func(*SourceFile, string)) error {
+	for _, line := range strings.Split(value, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) != fieldsPerLine {
+			return ErrInvalidSourceControl
+		}
+
+		name := parts[len(parts)-1]
+		if !isValidFilename(name) {
+			return ErrInvalidFilename
+		}
+
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || size < 0 {
+			return ErrInvalidSourceControl
+		}
+
+		f, ok := files[name]
+		if !ok {
+			f = &SourceFile{Name: name, Size: size}
+			files[name] = f
+			*order = append(*order, name)
+		} else if f.Size != size {
+			return ErrInvalidSourceControl
+		}
+
+		set(f, strings.ToLower(parts[0]))
+	}
+	return nil
+}
+
+func isValidFilename(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+// ParseSourceControlFile parses a (optionally clearsigned) Debian source control file
+// https://manpages.debian.org/bookworm/dpkg-dev/dsc.5.en.html
+func ParseSourceControlFile(r io.Reader) (*SourcePackage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := parseControlParagraph(bytes.NewReader(stripSignature(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &SourcePackage{
+		Metadata: &Metadata{},
+	}
+
+	files := make(map[string]*SourceFile)
+	order := make([]string, 0, 3)
+
+	var control strings.Builder
+	for _, field := range fields {
+		switch field.Key {
+		case "Files":
+			err = parseFileList(files, &order, field.Value, 3, func(f *SourceFile, hash string) { f.MD5 = hash })
+		case "Checksums-Sha1":
+			err = parseFileList(files, &order, field.Value, 3, func(f *SourceFile, hash string) { f.SHA1 = hash })
+		case "Checksums-Sha256":
+			err = parseFileList(files, &order, field.Value, 3, func(f *SourceFile, hash string) { f.SHA256 = hash })
+		default:
+			key := field.Key
+			switch key {
+			case "Source":
+				// The Sources index uses "Package" for the source package name
+				key = "Package"
+				p.Name = field.Value
+			case "Version":
+				p.Version = field.Value
+			case "Maintainer":
+				a, err := mail.ParseAddress(field.Value)
+				if err != nil || a.Name == "" {
+					p.Metadata.Maintainer = field.Value
+				} else {
+					p.Metadata.Maintainer = a.Name
+				}
+			case "Homepage":
+				if validation.IsValidURL(field.Value) {
+					p.Metadata.ProjectURL = field.Value
+				}
+			case "Build-Depends":
+				dependencies := strings.Split(strings.ReplaceAll(field.Value, "\n", ""), ",")
+				for i := range dependencies {
+					dependencies[i] = strings.TrimSpace(dependencies[i])
+				}
+				p.Metadata.Dependencies = dependencies
+			}
+			control.WriteString(key)
+			control.WriteByte(':')
+			if !strings.HasPrefix(field.Value, "\n") {
+				control.WriteByte(' ')
+			}
+			control.WriteString(field.Value)
+			control.WriteByte('\n')
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !namePattern.MatchString(p.Name) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.Version) {
+		return nil, ErrInvalidVersion
+	}
+	if len(order) == 0 {
+		return nil, ErrInvalidSourceControl
+	}
+
+	for _, name := range order {
+		f := files[name]
+		if f.SHA256 == "" && f.MD5 == "" {
+			return nil, ErrInvalidSourceControl
+		}
+		if !strings.HasPrefix(name, p.Name+"_") {
+			return nil, ErrInvalidFilename
+		}
+		p.Files = append(p.Files, f)
+	}
+
+	p.Control = strings.TrimSpace(control.String())
+
+	return p, nil
+}
+
+// ParseChangesFile parses a (optionally clearsigned) Debian changes file
+// https://manpages.debian.org/bookworm/dpkg-dev/deb-changes.5.en.html
+func ParseChangesFile(r io.Reader) (*Changes, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := parseControlParagraph(bytes.NewReader(stripSignature(data)))
+	if err != nil {
+		return nil, ErrInvalidChanges
+	}
+
+	c := &Changes{}
+
+	files := make(map[string]*SourceFile)
+	order := make([]string, 0, 5)
+
+	for _, field := range fields {
+		switch field.Key {
+		case "Source":
+			// The field may contain the source version in parentheses
+			if parts := strings.Fields(field.Value); len(parts) > 0 {
+				c.Source = parts[0]
+			}
+		case "Version":
+			c.Version = field.Value
+		case "Distribution":
+			c.Distribution = field.Value
+		case "Architecture":
+			c.Architectures = strings.Fields(field.Value)
+		case "Files":
+			// md5 size section priority filename
+			err = parseFileList(files, &order, field.Value, 5, func(f *SourceFile, hash string) { f.MD5 = hash })
+		case "Checksums-Sha1":
+			err = parseFileList(files, &order, field.Value, 3, func(f *SourceFile, hash string) { f.SHA1 = hash })
+		case "Checksums-Sha256":
+			err = parseFileList(files, &order, field.Value, 3, func(f *SourceFile, hash string) { f.SHA256 = hash })
+		}
+		if err != nil {
+			return nil, ErrInvalidChanges
+		}
+	}
+
+	if !namePattern.MatchString(c.Source) || !versionPattern.MatchString(c.Version) || len(order) == 0 {
+		return nil, ErrInvalidChanges
+	}
+
+	for _, name := range order {
+		c.Files = append(c.Files, files[name])
+	}
+
+	return c, nil
+}
+
+// ParseSourceFilesProperty parses the value of the PropertySourceFiles property
+func ParseSourceFilesProperty(value string) []*SourceFile {
+	files := make([]*SourceFile, 0, 3)
+	for _, line := range strings.Split(value, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(parts[2], 10, 64)
+		f := &SourceFile{
+			Size: size,
+			Name: parts[3],
+		}
+		if parts[0] != "-" {
+			f.MD5 = parts[0]
+		}
+		if parts[1] != "-" {
+			f.SHA256 = parts[1]
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// FormatSourceFilesProperty creates the value of the PropertySourceFiles property
+func FormatSourceFilesProperty(files []*SourceFile) string {
+	var sb strings.Builder
+	for _, f := range files {
+		md5 := f.MD5
+		if md5 == "" {
+			md5 = "-"
+		}
+		sha256 := f.SHA256
+		if sha256 == "" {
+			sha256 = "-"
+		}
+		sb.WriteString(md5)
+		sb.WriteByte(' ')
+		sb.WriteString(sha256)
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.FormatInt(f.Size, 10))
+		sb.WriteByte(' ')
+		sb.WriteString(f.Name)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Matches tests if the file matches the size and available checksums
+func (f *SourceFile) Matches(size int64, md5, sha256 string) bool {
+	if f.Size != size {
+		return false
+	}
+	if f.SHA256 != "" {
+		return f.SHA256 == sha256
+	}
+	return f.MD5 == md5
+}
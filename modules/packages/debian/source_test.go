@@ -0,0 +1,169 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/keybase/go-crypto/openpgp"
+	"github.com/keybase/go-crypto/openpgp/clearsign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sourceControl = `Format: 3.0 (quilt)
+Source: gitea
+Binary: gitea
+Architecture: any
+Version: 0:1.0.1-te~st
+Maintainer: KN4CK3R <kn4ck3r@gitea.io>
+Homepage: https://gitea.io
+Build-Depends: debhelper-compat (= 13),
+ golang
+Package-List:
+ gitea deb web optional arch=any
+Checksums-Sha256:
+ 0000000000000000000000000000000000000000000000000000000000000001 10 gitea_1.0.1.orig.tar.gz
+ 0000000000000000000000000000000000000000000000000000000000000002 20 gitea_1.0.1-te~st.debian.tar.xz
+Files:
+ 00000000000000000000000000000001 10 gitea_1.0.1.orig.tar.gz
+ 00000000000000000000000000000002 20 gitea_1.0.1-te~st.debian.tar.xz
+`
+
+func clearsignContent(t *testing.T, content string) string {
+	e, err := openpgp.NewEntity("", "", "", nil)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, e.PrivateKey, nil)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.String()
+}
+
+func TestParseSourceControlFile(t *testing.T) {
+	t.Run("InvalidName", func(t *testing.T) {
+		p, err := ParseSourceControlFile(strings.NewReader(strings.Replace(sourceControl, "Source: gitea", "Source: -cd", 1)))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidName)
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		p, err := ParseSourceControlFile(strings.NewReader(strings.Replace(sourceControl, "Version: 0:1.0.1-te~st", "Version: 1_0", 1)))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidVersion)
+	})
+
+	t.Run("InvalidFilename", func(t *testing.T) {
+		p, err := ParseSourceControlFile(strings.NewReader(strings.ReplaceAll(sourceControl, "gitea_1.0.1.orig.tar.gz", "../gitea_1.0.1.orig.tar.gz")))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidFilename)
+
+		p, err = ParseSourceControlFile(strings.NewReader(strings.ReplaceAll(sourceControl, "gitea_1.0.1.orig.tar.gz", "other_1.0.1.orig.tar.gz")))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidFilename)
+	})
+
+	t.Run("MissingFiles", func(t *testing.T) {
+		content := sourceControl[:strings.Index(sourceControl, "Checksums-Sha256:")]
+
+		p, err := ParseSourceControlFile(strings.NewReader(content))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidSourceControl)
+	})
+
+	check := func(t *testing.T, p *SourcePackage) {
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, packageAuthor, p.Metadata.Maintainer)
+		assert.Equal(t, projectURL, p.Metadata.ProjectURL)
+		assert.Equal(t, []string{"debhelper-compat (= 13)", "golang"}, p.Metadata.Dependencies)
+
+		assert.True(t, strings.HasPrefix(p.Control, "Format: 3.0 (quilt)\nPackage: gitea\n"))
+		assert.Contains(t, p.Control, "Package-List:\n gitea deb web optional arch=any")
+		assert.NotContains(t, p.Control, "Files:")
+		assert.NotContains(t, p.Control, "Checksums-Sha256:")
+
+		require.Len(t, p.Files, 2)
+		assert.Equal(t, "gitea_1.0.1.orig.tar.gz", p.Files[0].Name)
+		assert.EqualValues(t, 10, p.Files[0].Size)
+		assert.Equal(t, "00000000000000000000000000000001", p.Files[0].MD5)
+		assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000001", p.Files[0].SHA256)
+		assert.Equal(t, "gitea_1.0.1-te~st.debian.tar.xz", p.Files[1].Name)
+		assert.EqualValues(t, 20, p.Files[1].Size)
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		p, err := ParseSourceControlFile(strings.NewReader(sourceControl))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		check(t, p)
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		p, err := ParseSourceControlFile(strings.NewReader(clearsignContent(t, sourceControl)))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+
+		check(t, p)
+	})
+}
+
+func TestParseChangesFile(t *testing.T) {
+	content := `Format: 1.8
+Source: gitea (1.0.1)
+Binary: gitea
+Architecture: source amd64
+Version: 1.0.1-1
+Distribution: bookworm
+Files:
+ 00000000000000000000000000000001 10 web optional gitea_1.0.1-1.dsc
+ 00000000000000000000000000000002 20 web optional gitea_1.0.1-1_amd64.deb
+Checksums-Sha256:
+ 0000000000000000000000000000000000000000000000000000000000000001 10 gitea_1.0.1-1.dsc
+ 0000000000000000000000000000000000000000000000000000000000000002 20 gitea_1.0.1-1_amd64.deb
+`
+
+	t.Run("Invalid", func(t *testing.T) {
+		c, err := ParseChangesFile(strings.NewReader(strings.Replace(content, "Version: 1.0.1-1", "", 1)))
+		assert.Nil(t, c)
+		assert.ErrorIs(t, err, ErrInvalidChanges)
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		c, err := ParseChangesFile(strings.NewReader(clearsignContent(t, content)))
+		assert.NoError(t, err)
+		assert.NotNil(t, c)
+
+		assert.Equal(t, "gitea", c.Source)
+		assert.Equal(t, "1.0.1-1", c.Version)
+		assert.Equal(t, "bookworm", c.Distribution)
+		assert.Equal(t, []string{"source", "amd64"}, c.Architectures)
+		require.Len(t, c.Files, 2)
+		assert.Equal(t, "gitea_1.0.1-1.dsc", c.Files[0].Name)
+		assert.Equal(t, "00000000000000000000000000000001", c.Files[0].MD5)
+		assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000002", c.Files[1].SHA256)
+	})
+}
+
+func TestSourceFilesProperty(t *testing.T) {
+	files := []*SourceFile{
+		{Name: "a_1.orig.tar.gz", Size: 1, MD5: "m1", SHA256: "s1"},
+		{Name: "a_1-1.debian.tar.xz", Size: 2, MD5: "m2"},
+	}
+
+	parsed := ParseSourceFilesProperty(FormatSourceFilesProperty(files))
+	assert.Equal(t, files, parsed)
+
+	assert.True(t, parsed[0].Matches(1, "", "s1"))
+	assert.False(t, parsed[0].Matches(1, "m1", "s2"))
+	assert.True(t, parsed[1].Matches(2, "m2", "s2"))
+	assert.False(t, parsed[1].Matches(3, "m2", "s2"))
+}
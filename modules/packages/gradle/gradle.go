@@ -0,0 +1,24 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gradle
+
+import (
+	"regexp"
+)
+
+// CachePackageName is the name of the package holding the build cache entries.
+// Every entry is stored as a version of this package named by its key.
+const CachePackageName = "build-cache"
+
+// SettingCacheMaxSize is the user setting holding the size cap of the build cache of an owner
+const SettingCacheMaxSize = "gradle.cache_max_size"
+
+// Gradle uses hex encoded hashes as cache keys
+// https://docs.gradle.org/current/userguide/build_cache.html#sec:build_cache_configure_remote
+var keyPattern = regexp.MustCompile(`\A[a-f0-9]{32,128}\z`)
+
+// IsValidKey checks if the value is a valid build cache key
+func IsValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
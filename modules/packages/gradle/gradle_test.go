@@ -0,0 +1,20 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gradle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidKey(t *testing.T) {
+	assert.True(t, IsValidKey("b9800d3d2a2ef8e2b5d2ac3e9a1e5e7a"))
+	assert.True(t, IsValidKey(strings.Repeat("a", 64)))
+	assert.False(t, IsValidKey(strings.ToUpper("b9800d3d2a2ef8e2b5d2ac3e9a1e5e7a")))
+	assert.False(t, IsValidKey("b9800d3d2a2ef8e2"))
+	assert.False(t, IsValidKey(strings.Repeat("a", 129)))
+	assert.False(t, IsValidKey("../00d3d2a2ef8e2b5d2ac3e9a1e5e7a"))
+}
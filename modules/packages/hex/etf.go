@@ -0,0 +1,142 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ContentTypeErlang is the content type of API responses in the Erlang external term format
+const ContentTypeErlang = "application/vnd.hex+erlang"
+
+// https://www.erlang.org/doc/apps/erts/erl_ext_dist.html
+const (
+	etfVersion         = 131
+	etfSmallIntegerExt = 97
+	etfIntegerExt      = 98
+	etfNilExt          = 106
+	etfListExt         = 108
+	etfBinaryExt       = 109
+	etfSmallBigExt     = 110
+	etfMapExt          = 116
+	etfSmallAtomUTF8   = 119
+)
+
+// EncodeTerm encodes a value in the Erlang external term format used by the Hex API.
+// Strings are encoded as binaries, nil and booleans as atoms and maps must have string keys.
+func EncodeTerm(v any) ([]byte, error) {
+	return appendTerm([]byte{etfVersion}, v)
+}
+
+func appendTerm(b []byte, v any) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return appendAtom(b, "nil"), nil
+	case bool:
+		if t {
+			return appendAtom(b, "true"), nil
+		}
+		return appendAtom(b, "false"), nil
+	case string:
+		b = append(b, etfBinaryExt)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(t)))
+		return append(b, t...), nil
+	case int:
+		return appendInteger(b, int64(t)), nil
+	case int64:
+		return appendInteger(b, t), nil
+	case []string:
+		l := make([]any, 0, len(t))
+		for _, s := range t {
+			l = append(l, s)
+		}
+		return appendList(b, l)
+	case []any:
+		return appendList(b, t)
+	case []map[string]any:
+		l := make([]any, 0, len(t))
+		for _, m := range t {
+			l = append(l, m)
+		}
+		return appendList(b, l)
+	case map[string]string:
+		m := make(map[string]any, len(t))
+		for k, v := range t {
+			m[k] = v
+		}
+		return appendMap(b, m)
+	case map[string]any:
+		return appendMap(b, t)
+	}
+	return nil, fmt.Errorf("unsupported term type %T", v)
+}
+
+func appendAtom(b []byte, atom string) []byte {
+	b = append(b, etfSmallAtomUTF8, byte(len(atom)))
+	return append(b, atom...)
+}
+
+func appendInteger(b []byte, i int64) []byte {
+	if i >= 0 && i <= math.MaxUint8 {
+		return append(b, etfSmallIntegerExt, byte(i))
+	}
+	if i >= math.MinInt32 && i <= math.MaxInt32 {
+		b = append(b, etfIntegerExt)
+		return binary.BigEndian.AppendUint32(b, uint32(int32(i)))
+	}
+
+	sign := byte(0)
+	u := uint64(i)
+	if i < 0 {
+		sign = 1
+		u = uint64(-i)
+	}
+	var digits []byte
+	for u > 0 {
+		digits = append(digits, byte(u))
+		u >>= 8
+	}
+	b = append(b, etfSmallBigExt, byte(len(digits)), sign)
+	return append(b, digits...)
+}
+
+func appendList(b []byte, l []any) ([]byte, error) {
+	if len(l) == 0 {
+		return append(b, etfNilExt), nil
+	}
+
+	b = append(b, etfListExt)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(l)))
+	for _, e := range l {
+		var err error
+		if b, err = appendTerm(b, e); err != nil {
+			return nil, err
+		}
+	}
+	return append(b, etfNilExt), nil
+}
+
+func appendMap(b []byte, m map[string]any) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b = append(b, etfMapExt)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(m)))
+	for _, k := range keys {
+		var err error
+		if b, err = appendTerm(b, k); err != nil {
+			return nil, err
+		}
+		if b, err = appendTerm(b, m[k]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
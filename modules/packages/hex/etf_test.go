@@ -0,0 +1,36 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeTerm(t *testing.T) {
+	cases := []struct {
+		Value    any
+		Expected []byte
+	}{
+		{nil, []byte{131, 119, 3, 'n', 'i', 'l'}},
+		{true, []byte{131, 119, 4, 't', 'r', 'u', 'e'}},
+		{"ab", []byte{131, 109, 0, 0, 0, 2, 'a', 'b'}},
+		{5, []byte{131, 97, 5}},
+		{-1, []byte{131, 98, 255, 255, 255, 255}},
+		{int64(1) << 40, []byte{131, 110, 6, 0, 0, 0, 0, 0, 0, 1}},
+		{[]string{}, []byte{131, 106}},
+		{[]string{"a"}, []byte{131, 108, 0, 0, 0, 1, 109, 0, 0, 0, 1, 'a', 106}},
+		{map[string]any{"b": 1, "a": "x"}, []byte{131, 116, 0, 0, 0, 2, 109, 0, 0, 0, 1, 'a', 109, 0, 0, 0, 1, 'x', 109, 0, 0, 0, 1, 'b', 97, 1}},
+	}
+
+	for _, c := range cases {
+		b, err := EncodeTerm(c.Value)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Expected, b, "%v", c.Value)
+	}
+
+	_, err := EncodeTerm(1.5)
+	assert.Error(t, err)
+}
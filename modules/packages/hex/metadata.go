@@ -0,0 +1,249 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+const (
+	KeyBits           = 4096
+	SettingKeyPrivate = "hex.key.private"
+	SettingKeyPublic  = "hex.key.public"
+
+	PropertyInnerChecksum = "hex.inner_checksum"
+
+	tarballVersion = "3"
+	maxFileSize    = 1 << 20
+)
+
+var (
+	ErrMissingFile      = util.NewInvalidArgumentErrorf("tarball is missing a required file")
+	ErrInvalidTarball   = util.NewInvalidArgumentErrorf("tarball version is not supported")
+	ErrChecksumMismatch = util.NewInvalidArgumentErrorf("tarball checksum does not match")
+	ErrInvalidMetadata  = util.NewInvalidArgumentErrorf("metadata.config is invalid")
+	ErrInvalidName      = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion   = util.NewInvalidArgumentErrorf("package version is invalid")
+
+	// https://github.com/hexpm/hexpm/blob/main/lib/hexpm/repository/package.ex
+	namePattern = regexp.MustCompile(`\A[a-z][a-z0-9_]{0,127}\z`)
+	// Hex requires strict semantic versions
+	versionPattern = regexp.MustCompile(`\A\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?\z`)
+)
+
+// IsValidName checks if the package name is valid
+func IsValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Filename gets the file name of the package tarball
+func Filename(name, version string) string {
+	return name + "-" + version + ".tar"
+}
+
+// Package represents a Hex package
+type Package struct {
+	Name          string
+	Version       string
+	InnerChecksum string
+	Metadata      *Metadata
+}
+
+// Metadata represents the metadata of a Hex package
+type Metadata struct {
+	App          string            `json:"app,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Licenses     []string          `json:"licenses,omitempty"`
+	Links        map[string]string `json:"links,omitempty"`
+	BuildTools   []string          `json:"build_tools,omitempty"`
+	Elixir       string            `json:"elixir,omitempty"`
+	Requirements []*Requirement    `json:"requirements,omitempty"`
+}
+
+// Requirement represents a dependency of a Hex package
+type Requirement struct {
+	Name        string `json:"name"`
+	App         string `json:"app,omitempty"`
+	Requirement string `json:"requirement"`
+	Optional    bool   `json:"optional,omitempty"`
+	Repository  string `json:"repository,omitempty"`
+}
+
+// ParsePackage parses a Hex tarball and verifies its inner checksum.
+// The tarball is read twice because the contents archive is hashed after the other files.
+// https://github.com/hexpm/specifications/blob/main/package_tarball.md
+func ParsePackage(r io.ReadSeeker) (*Package, error) {
+	files := map[string][]byte{}
+	hasContents := false
+
+	if err := walkTarball(r, func(hd *tar.Header, tr *tar.Reader) error {
+		switch hd.Name {
+		case "VERSION", "CHECKSUM", "metadata.config":
+			data, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+			if err != nil {
+				return err
+			}
+			files[hd.Name] = data
+		case "contents.tar.gz":
+			hasContents = true
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"VERSION", "CHECKSUM", "metadata.config"} {
+		if _, ok := files[name]; !ok {
+			return nil, ErrMissingFile
+		}
+	}
+	if !hasContents {
+		return nil, ErrMissingFile
+	}
+
+	if strings.TrimSpace(string(files["VERSION"])) != tarballVersion {
+		return nil, ErrInvalidTarball
+	}
+
+	// The inner checksum is the hash of the concatenated VERSION, metadata.config and contents.tar.gz files
+	h := sha256.New()
+	h.Write(files["VERSION"])
+	h.Write(files["metadata.config"])
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := walkTarball(r, func(hd *tar.Header, tr *tar.Reader) error {
+		if hd.Name == "contents.tar.gz" {
+			_, err := io.Copy(h, tr)
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	innerChecksum := EncodeChecksum(h.Sum(nil))
+	if !strings.EqualFold(strings.TrimSpace(string(files["CHECKSUM"])), innerChecksum) {
+		return nil, ErrChecksumMismatch
+	}
+
+	p, err := ParseMetadata(files["metadata.config"])
+	if err != nil {
+		return nil, err
+	}
+	p.InnerChecksum = innerChecksum
+
+	return p, nil
+}
+
+func walkTarball(r io.Reader, fn func(*tar.Header, *tar.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return util.NewInvalidArgumentErrorf("tarball is invalid: %v", err)
+		}
+
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := fn(hd, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// ParseMetadata parses the metadata.config file of a Hex tarball
+func ParseMetadata(data []byte) (*Package, error) {
+	terms, err := ParseTerms(string(data))
+	if err != nil {
+		return nil, util.NewInvalidArgumentErrorf("metadata.config is invalid: %v", err)
+	}
+
+	values := make(map[string]any, len(terms))
+	for _, term := range terms {
+		t, ok := term.(Tuple)
+		if !ok || len(t) != 2 {
+			return nil, ErrInvalidMetadata
+		}
+		key, ok := termString(t[0])
+		if !ok {
+			return nil, ErrInvalidMetadata
+		}
+		values[key] = t[1]
+	}
+
+	name, _ := termString(values["name"])
+	if !IsValidName(name) {
+		return nil, ErrInvalidName
+	}
+	v, _ := termString(values["version"])
+	if !versionPattern.MatchString(v) {
+		return nil, ErrInvalidVersion
+	}
+
+	m := &Metadata{
+		Licenses:   termStringList(values["licenses"]),
+		BuildTools: termStringList(values["build_tools"]),
+	}
+	m.App, _ = termString(values["app"])
+	m.Description, _ = termString(values["description"])
+	m.Elixir, _ = termString(values["elixir"])
+
+	if links, ok := termProplist(values["links"]); ok {
+		m.Links = make(map[string]string, len(links))
+		for title, value := range links {
+			if u, ok := termString(value); ok && validation.IsValidURL(u) {
+				m.Links[title] = u
+			}
+		}
+	}
+
+	if requirements, ok := termProplist(values["requirements"]); ok {
+		for dependency, value := range requirements {
+			props, ok := termProplist(value)
+			if !ok {
+				return nil, ErrInvalidMetadata
+			}
+			req := &Requirement{
+				Name: dependency,
+			}
+			req.App, _ = termString(props["app"])
+			req.Requirement, _ = termString(props["requirement"])
+			req.Repository, _ = termString(props["repository"])
+			if optional, ok := props["optional"].(Atom); ok {
+				req.Optional = optional == "true"
+			}
+			m.Requirements = append(m.Requirements, req)
+		}
+		sort.Slice(m.Requirements, func(i, j int) bool {
+			return m.Requirements[i].Name < m.Requirements[j].Name
+		})
+	}
+
+	return &Package{
+		Name:     name,
+		Version:  v,
+		Metadata: m,
+	}, nil
+}
+
+// EncodeChecksum encodes a checksum in uppercase hex like the Hex tools
+func EncodeChecksum(sum []byte) string {
+	return strings.ToUpper(hex.EncodeToString(sum))
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	packageName    = "gitea_test"
+	packageVersion = "1.0.0"
+	description    = "Test Description"
+	metadataConfig = `{<<"app">>,<<"gitea_test">>}.
+{<<"build_tools">>,[<<"mix">>]}.
+{<<"description">>,<<"` + description + `">>}.
+{<<"elixir">>,<<"~> 1.15">>}.
+{<<"files">>,[<<"lib">>,<<"lib/gitea_test.ex">>,<<"mix.exs">>]}.
+{<<"licenses">>,[<<"MIT">>]}.
+{<<"links">>,[{<<"GitHub">>,<<"https://gitea.io">>},{<<"Invalid">>,<<"dummy">>}]}.
+{<<"name">>,<<"` + packageName + `">>}.
+{<<"requirements">>,
+ [{<<"jason">>,
+   [{<<"app">>,<<"jason">>},
+    {<<"optional">>,true},
+    {<<"requirement">>,<<"~> 1.4">>},
+    {<<"repository">>,<<"hexpm">>}]},
+  {<<"decimal">>,
+   [{<<"app">>,<<"decimal">>},
+    {<<"optional">>,false},
+    {<<"requirement">>,<<"~> 2.0">>},
+    {<<"repository">>,<<"hexpm">>}]}]}.
+{<<"version">>,<<"` + packageVersion + `">>}.
+`
+)
+
+func createTarball(files map[string]string, order ...string) *bytes.Reader {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, filename := range order {
+		content := files[filename]
+		hdr := &tar.Header{
+			Name: filename,
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}
+		tw.WriteHeader(hdr)
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return bytes.NewReader(buf.Bytes())
+}
+
+func createPackageFiles(checksum string) map[string]string {
+	files := map[string]string{
+		"VERSION":         "3",
+		"metadata.config": metadataConfig,
+		"contents.tar.gz": "contents",
+	}
+	if checksum == "" {
+		sum := sha256.Sum256([]byte(files["VERSION"] + files["metadata.config"] + files["contents.tar.gz"]))
+		checksum = EncodeChecksum(sum[:])
+	}
+	files["CHECKSUM"] = checksum
+	return files
+}
+
+func TestParsePackage(t *testing.T) {
+	t.Run("MissingFile", func(t *testing.T) {
+		files := createPackageFiles("")
+
+		p, err := ParsePackage(createTarball(files, "VERSION", "CHECKSUM", "metadata.config"))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrMissingFile)
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		files := createPackageFiles("")
+		files["VERSION"] = "2"
+
+		p, err := ParsePackage(createTarball(files, "VERSION", "CHECKSUM", "metadata.config", "contents.tar.gz"))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrInvalidTarball)
+	})
+
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		files := createPackageFiles("0000")
+
+		p, err := ParsePackage(createTarball(files, "VERSION", "CHECKSUM", "metadata.config", "contents.tar.gz"))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		files := createPackageFiles("")
+
+		// the contents archive may be located before the other files
+		p, err := ParsePackage(createTarball(files, "contents.tar.gz", "VERSION", "CHECKSUM", "metadata.config"))
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+		assert.Equal(t, packageName, p.Name)
+		assert.Equal(t, packageVersion, p.Version)
+		assert.Equal(t, files["CHECKSUM"], p.InnerChecksum)
+		assert.Equal(t, description, p.Metadata.Description)
+		assert.Equal(t, "gitea_test", p.Metadata.App)
+		assert.Equal(t, "~> 1.15", p.Metadata.Elixir)
+		assert.Equal(t, []string{"MIT"}, p.Metadata.Licenses)
+		assert.Equal(t, []string{"mix"}, p.Metadata.BuildTools)
+		assert.Equal(t, map[string]string{"GitHub": "https://gitea.io"}, p.Metadata.Links)
+		assert.Len(t, p.Metadata.Requirements, 2)
+		assert.Equal(t, &Requirement{Name: "decimal", App: "decimal", Requirement: "~> 2.0", Repository: "hexpm"}, p.Metadata.Requirements[0])
+		assert.Equal(t, &Requirement{Name: "jason", App: "jason", Requirement: "~> 1.4", Optional: true, Repository: "hexpm"}, p.Metadata.Requirements[1])
+	})
+}
+
+func TestParseMetadata(t *testing.T) {
+	cases := []struct {
+		Config   string
+		Expected error
+	}{
+		{`{<<"name">>,<<"Invalid">>}. {<<"version">>,<<"1.0.0">>}.`, ErrInvalidName},
+		{`{<<"name">>,<<"valid">>}. {<<"version">>,<<"1.0">>}.`, ErrInvalidVersion},
+		{`{<<"name">>,<<"valid">>}`, util.ErrInvalidArgument},
+		{`<<"name">>.`, ErrInvalidMetadata},
+	}
+
+	for _, c := range cases {
+		p, err := ParseMetadata([]byte(c.Config))
+		assert.Nil(t, p)
+		assert.ErrorIs(t, err, c.Expected)
+	}
+}
+
+func TestParseTerms(t *testing.T) {
+	terms, err := ParseTerms(`% comment
+{<<"key">>, [1, -2, 'quoted atom', atom, "string", <<"esc\"aped"/utf8>>, {}, []]}.`)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{
+		Tuple{Binary("key"), List{int64(1), int64(-2), Atom("quoted atom"), Atom("atom"), "string", Binary(`esc"aped`), Tuple{}, List{}}},
+	}, terms)
+
+	_, err = ParseTerms(`{<<"key">>, [1, 2}.`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The registry resources are protobuf messages described in
+// https://github.com/hexpm/specifications/blob/main/registry-v2.md
+// They are small enough to be encoded by hand instead of using generated code.
+
+// NamesEntry is a package in the names resource
+type NamesEntry struct {
+	Name      string
+	UpdatedAt time.Time
+}
+
+// VersionsEntry is a package in the versions resource
+type VersionsEntry struct {
+	Name     string
+	Versions []string
+}
+
+// Release is a release in the package resource
+type Release struct {
+	Version       string
+	InnerChecksum []byte
+	OuterChecksum []byte
+	Dependencies  []*Requirement
+}
+
+// EncodeNames encodes the names resource listing all packages of the repository
+func EncodeNames(repository string, packages []*NamesEntry) []byte {
+	var b []byte
+	for _, p := range packages {
+		var pb []byte
+		pb = protowire.AppendTag(pb, 1, protowire.BytesType)
+		pb = protowire.AppendString(pb, p.Name)
+
+		var ts []byte
+		ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(p.UpdatedAt.Unix()))
+		ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(p.UpdatedAt.Nanosecond()))
+
+		pb = protowire.AppendTag(pb, 2, protowire.BytesType)
+		pb = protowire.AppendBytes(pb, ts)
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, pb)
+	}
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendString(b, repository)
+}
+
+// EncodeVersions encodes the versions resource listing all versions of all packages of the repository
+func EncodeVersions(repository string, packages []*VersionsEntry) []byte {
+	var b []byte
+	for _, p := range packages {
+		var pb []byte
+		pb = protowire.AppendTag(pb, 1, protowire.BytesType)
+		pb = protowire.AppendString(pb, p.Name)
+		for _, v := range p.Versions {
+			pb = protowire.AppendTag(pb, 2, protowire.BytesType)
+			pb = protowire.AppendString(pb, v)
+		}
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, pb)
+	}
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendString(b, repository)
+}
+
+// EncodePackage encodes the package resource listing all releases of a package
+func EncodePackage(repository, name string, releases []*Release) []byte {
+	var b []byte
+	for _, r := range releases {
+		var rb []byte
+		rb = protowire.AppendTag(rb, 1, protowire.BytesType)
+		rb = protowire.AppendString(rb, r.Version)
+		rb = protowire.AppendTag(rb, 2, protowire.BytesType)
+		rb = protowire.AppendBytes(rb, r.InnerChecksum)
+		for _, d := range r.Dependencies {
+			var db []byte
+			db = protowire.AppendTag(db, 1, protowire.BytesType)
+			db = protowire.AppendString(db, d.Name)
+			db = protowire.AppendTag(db, 2, protowire.BytesType)
+			db = protowire.AppendString(db, d.Requirement)
+			if d.Optional {
+				db = protowire.AppendTag(db, 3, protowire.VarintType)
+				db = protowire.AppendVarint(db, protowire.EncodeBool(true))
+			}
+			if d.App != "" && d.App != d.Name {
+				db = protowire.AppendTag(db, 4, protowire.BytesType)
+				db = protowire.AppendString(db, d.App)
+			}
+			if d.Repository != "" {
+				db = protowire.AppendTag(db, 5, protowire.BytesType)
+				db = protowire.AppendString(db, d.Repository)
+			}
+
+			rb = protowire.AppendTag(rb, 3, protowire.BytesType)
+			rb = protowire.AppendBytes(rb, db)
+		}
+		rb = protowire.AppendTag(rb, 5, protowire.BytesType)
+		rb = protowire.AppendBytes(rb, r.OuterChecksum)
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, rb)
+	}
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	return protowire.AppendString(b, repository)
+}
+
+// EncodeSigned wraps a payload and its signature in the Signed message
+func EncodeSigned(payload, signature []byte) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, payload)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendBytes(b, signature)
+}
+
+// DecodeSigned gets the payload and the signature of a Signed message
+func DecodeSigned(data []byte) ([]byte, []byte, error) {
+	var payload, signature []byte
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch num {
+		case 1:
+			payload = v
+		case 2:
+			signature = v
+		}
+	}
+	if payload == nil {
+		return nil, nil, errors.New("signed message has no payload")
+	}
+	return payload, signature, nil
+}
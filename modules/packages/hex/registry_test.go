@@ -0,0 +1,99 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeFields decodes the top level fields of a message
+func decodeFields(t *testing.T, data []byte) map[protowire.Number][]any {
+	fields := map[protowire.Number][]any{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		assert.Positive(t, n)
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			assert.Positive(t, n)
+			data = data[n:]
+			fields[num] = append(fields[num], v)
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			assert.Positive(t, n)
+			data = data[n:]
+			fields[num] = append(fields[num], v)
+		default:
+			t.Fatalf("unexpected wire type %v", typ)
+		}
+	}
+	return fields
+}
+
+func TestEncodeNames(t *testing.T) {
+	updated := time.Unix(1700000000, 5)
+
+	fields := decodeFields(t, EncodeNames("gitea", []*NamesEntry{{Name: packageName, UpdatedAt: updated}}))
+	assert.Equal(t, []byte("gitea"), fields[2][0])
+	assert.Len(t, fields[1], 1)
+
+	pkg := decodeFields(t, fields[1][0].([]byte))
+	assert.Equal(t, []byte(packageName), pkg[1][0])
+
+	ts := decodeFields(t, pkg[2][0].([]byte))
+	assert.Equal(t, uint64(1700000000), ts[1][0])
+	assert.Equal(t, uint64(5), ts[2][0])
+}
+
+func TestEncodeVersions(t *testing.T) {
+	fields := decodeFields(t, EncodeVersions("gitea", []*VersionsEntry{{Name: packageName, Versions: []string{"1.0.0", "1.1.0"}}}))
+	assert.Equal(t, []byte("gitea"), fields[2][0])
+
+	pkg := decodeFields(t, fields[1][0].([]byte))
+	assert.Equal(t, []byte(packageName), pkg[1][0])
+	assert.Equal(t, []any{[]byte("1.0.0"), []byte("1.1.0")}, pkg[2])
+}
+
+func TestEncodePackage(t *testing.T) {
+	fields := decodeFields(t, EncodePackage("gitea", packageName, []*Release{
+		{
+			Version:       packageVersion,
+			InnerChecksum: []byte{1},
+			OuterChecksum: []byte{2},
+			Dependencies: []*Requirement{
+				{Name: "jason", App: "jason", Requirement: "~> 1.4", Optional: true, Repository: "hexpm"},
+			},
+		},
+	}))
+	assert.Equal(t, []byte(packageName), fields[2][0])
+	assert.Equal(t, []byte("gitea"), fields[3][0])
+
+	release := decodeFields(t, fields[1][0].([]byte))
+	assert.Equal(t, []byte(packageVersion), release[1][0])
+	assert.Equal(t, []byte{1}, release[2][0])
+	assert.Equal(t, []byte{2}, release[5][0])
+
+	dependency := decodeFields(t, release[3][0].([]byte))
+	assert.Equal(t, []byte("jason"), dependency[1][0])
+	assert.Equal(t, []byte("~> 1.4"), dependency[2][0])
+	assert.Equal(t, uint64(1), dependency[3][0])
+	assert.Nil(t, dependency[4])
+	assert.Equal(t, []byte("hexpm"), dependency[5][0])
+}
+
+func TestSigned(t *testing.T) {
+	payload, signature, err := DecodeSigned(EncodeSigned([]byte("payload"), []byte("signature")))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+	assert.Equal(t, []byte("signature"), signature)
+
+	_, _, err = DecodeSigned([]byte{0xff})
+	assert.Error(t, err)
+}
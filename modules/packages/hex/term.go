@@ -0,0 +1,286 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The metadata.config file of a Hex tarball contains Erlang terms like {<<"name">>,<<"package">>}.
+// Only the subset of the term syntax written by the Hex tools is supported.
+
+// Binary represents an Erlang binary like <<"value">>
+type Binary string
+
+// Atom represents an Erlang atom like true
+type Atom string
+
+// Tuple represents an Erlang tuple like {a, b}
+type Tuple []any
+
+// List represents an Erlang list like [a, b]
+type List []any
+
+type termParser struct {
+	data string
+	pos  int
+}
+
+// ParseTerms parses a sequence of Erlang terms each terminated by a dot
+func ParseTerms(data string) ([]any, error) {
+	p := &termParser{data: data}
+
+	var terms []any
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.data) {
+			return terms, nil
+		}
+
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipWhitespace()
+		if err := p.expect('.'); err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, term)
+	}
+}
+
+func (p *termParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid term at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *termParser) skipWhitespace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			// comment until end of line
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if !unicode.IsSpace(rune(c)) {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *termParser) expect(c byte) error {
+	if p.pos >= len(p.data) || p.data[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *termParser) parseTerm() (any, error) {
+	p.skipWhitespace()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end")
+	}
+
+	c := p.data[p.pos]
+	switch {
+	case c == '{':
+		p.pos++
+		elems, err := p.parseSequence('}')
+		return Tuple(elems), err
+	case c == '[':
+		p.pos++
+		elems, err := p.parseSequence(']')
+		return List(elems), err
+	case c == '<':
+		return p.parseBinary()
+	case c == '"':
+		s, err := p.parseString()
+		return s, err
+	case c == '\'':
+		s, err := p.parseQuoted('\'')
+		return Atom(s), err
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseInteger()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for p.pos < len(p.data) && (isAtomChar(p.data[p.pos])) {
+			p.pos++
+		}
+		return Atom(p.data[start:p.pos]), nil
+	}
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+func isAtomChar(c byte) bool {
+	return c == '_' || c == '@' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *termParser) parseSequence(end byte) ([]any, error) {
+	elems := []any{}
+
+	p.skipWhitespace()
+	if p.pos < len(p.data) && p.data[p.pos] == end {
+		p.pos++
+		return elems, nil
+	}
+
+	for {
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, term)
+
+		p.skipWhitespace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unexpected end")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case end:
+			p.pos++
+			return elems, nil
+		default:
+			return nil, p.errorf("expected ',' or %q", end)
+		}
+	}
+}
+
+func (p *termParser) parseBinary() (any, error) {
+	if !strings.HasPrefix(p.data[p.pos:], "<<") {
+		return nil, p.errorf("expected binary")
+	}
+	p.pos += 2
+
+	p.skipWhitespace()
+	var s string
+	if p.pos < len(p.data) && p.data[p.pos] == '"' {
+		var err error
+		if s, err = p.parseString(); err != nil {
+			return nil, err
+		}
+		// Strings may be encoded as utf8 like <<"value"/utf8>>
+		if strings.HasPrefix(p.data[p.pos:], "/utf8") {
+			p.pos += len("/utf8")
+		}
+		p.skipWhitespace()
+	}
+
+	if !strings.HasPrefix(p.data[p.pos:], ">>") {
+		return nil, p.errorf("expected end of binary")
+	}
+	p.pos += 2
+
+	return Binary(s), nil
+}
+
+func (p *termParser) parseString() (string, error) {
+	return p.parseQuoted('"')
+}
+
+func (p *termParser) parseQuoted(quote byte) (string, error) {
+	if err := p.expect(quote); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				return "", p.errorf("unexpected end")
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *termParser) parseInteger() (any, error) {
+	start := p.pos
+	if p.data[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	i, err := strconv.ParseInt(p.data[start:p.pos], 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid integer")
+	}
+	return i, nil
+}
+
+// termString gets the string value of a binary or string term
+func termString(term any) (string, bool) {
+	switch v := term.(type) {
+	case Binary:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+// termProplist converts a list of two-element tuples with string keys into a map
+func termProplist(term any) (map[string]any, bool) {
+	l, ok := term.(List)
+	if !ok {
+		return nil, false
+	}
+	m := make(map[string]any, len(l))
+	for _, e := range l {
+		t, ok := e.(Tuple)
+		if !ok || len(t) != 2 {
+			return nil, false
+		}
+		key, ok := termString(t[0])
+		if !ok {
+			return nil, false
+		}
+		m[key] = t[1]
+	}
+	return m, true
+}
+
+// termStringList converts a list of binary or string terms
+func termStringList(term any) []string {
+	l, ok := term.(List)
+	if !ok {
+		return nil
+	}
+	s := make([]string, 0, len(l))
+	for _, e := range l {
+		if v, ok := termString(e); ok {
+			s = append(s, v)
+		}
+	}
+	return s
+}
@@ -0,0 +1,326 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package huggingface
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	PropertyCommit = "huggingface.commit"
+	// PropertyRepoType marks a package as model or dataset repository
+	PropertyRepoType = "huggingface.repo_type"
+
+	RepoTypeModel   = "model"
+	RepoTypeDataset = "dataset"
+
+	// UploadPackage is the name of the internal package holding not yet committed large files
+	UploadPackage = "_huggingface"
+	// UploadVersion is the internal version holding large files uploaded before the commit which references them
+	UploadVersion = "_upload"
+
+	DefaultRevision = "main"
+
+	ReadmeFilename = "README.md"
+
+	UploadModeRegular = "regular"
+	UploadModeLFS     = "lfs"
+)
+
+var (
+	ErrInvalidCommit    = util.NewInvalidArgumentErrorf("commit is invalid")
+	ErrInvalidFilePath  = util.NewInvalidArgumentErrorf("file path is invalid")
+	ErrInvalidObjectID  = util.NewInvalidArgumentErrorf("object id is invalid")
+	ErrCommitTooLarge   = util.NewInvalidArgumentErrorf("commit is too large")
+	ErrReadmeIsTooLarge = util.NewInvalidArgumentErrorf("model card is too large")
+)
+
+const (
+	// maxRegularFileSize is the size from which on the client is asked to upload a file as large file
+	maxRegularFileSize = 10 * 1024 * 1024
+	maxCommitSize      = 64 * 1024 * 1024
+	maxReadmeSize      = 1 * 1024 * 1024
+	maxFilePathLength  = 255
+)
+
+var (
+	namePattern     = regexp.MustCompile(`\A[a-zA-Z0-9](?:[a-zA-Z0-9._-]*[a-zA-Z0-9])?\z`)
+	revisionPattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9._-]*\z`)
+	oidPattern      = regexp.MustCompile(`\A[a-f0-9]{64}\z`)
+	commitPattern   = regexp.MustCompile(`\A[a-f0-9]{40}\z`)
+)
+
+// Metadata represents the metadata of a revision of a model or dataset repository
+type Metadata struct {
+	Summary     string         `json:"summary,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Readme      string         `json:"readme,omitempty"`
+	License     string         `json:"license,omitempty"`
+	LibraryName string         `json:"library_name,omitempty"`
+	PipelineTag string         `json:"pipeline_tag,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	CardData    map[string]any `json:"card_data,omitempty"`
+}
+
+// IsValidRepoType checks if the repository type is supported
+func IsValidRepoType(repoType string) bool {
+	return repoType == RepoTypeModel || repoType == RepoTypeDataset
+}
+
+// IsValidName checks if the value can be used as namespace or name of a repository
+func IsValidName(name string) bool {
+	return len(name) <= 96 && namePattern.MatchString(name) && !strings.Contains(name, "--") && !strings.Contains(name, "..")
+}
+
+// IsValidRevision checks if the value can be used as revision
+// Revisions starting with an underscore are reserved for internal versions.
+func IsValidRevision(revision string) bool {
+	return len(revision) <= 255 && revisionPattern.MatchString(revision)
+}
+
+// IsValidOID checks if the value is a sha256 object id
+func IsValidOID(oid string) bool {
+	return oidPattern.MatchString(oid)
+}
+
+// IsCommitOID checks if the value looks like a commit id
+func IsCommitOID(revision string) bool {
+	return commitPattern.MatchString(revision)
+}
+
+// IsValidFilePath checks if the value is a relative path inside the repository
+func IsValidFilePath(p string) bool {
+	if p == "" || len(p) > maxFilePathLength || strings.ContainsAny(p, "\\\x00") {
+		return false
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == "" || part == "." || part == ".." || part == ".git" {
+			return false
+		}
+	}
+	return true
+}
+
+// UploadMode gets the mode the client should use to upload the file
+// Large and binary files are uploaded in advance and referenced by the commit.
+func UploadMode(size int64, sample []byte) string {
+	if size > maxRegularFileSize || bytes.IndexByte(sample, 0) != -1 {
+		return UploadModeLFS
+	}
+	return UploadModeRegular
+}
+
+// Commit represents the operations of a commit
+type Commit struct {
+	Summary        string
+	Description    string
+	Files          []*CommitFile
+	LFSFiles       []*CommitLFSFile
+	DeletedFiles   []string
+	DeletedFolders []string
+}
+
+// CommitFile is a file which content is part of the commit
+type CommitFile struct {
+	Path    string
+	Content []byte
+}
+
+// CommitLFSFile is a file which content was uploaded in advance
+type CommitLFSFile struct {
+	Path string
+	OID  string
+	Size int64
+}
+
+type commitLine struct {
+	Key   string `json:"key"`
+	Value struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Path        string `json:"path"`
+		Content     string `json:"content"`
+		Encoding    string `json:"encoding"`
+		Algo        string `json:"algo"`
+		OID         string `json:"oid"`
+		Size        int64  `json:"size"`
+	} `json:"value"`
+}
+
+// ParseCommit parses the NDJSON payload of the commit API
+// https://github.com/huggingface/huggingface_hub/blob/main/src/huggingface_hub/_commit_api.py
+func ParseCommit(r io.Reader) (*Commit, error) {
+	lr := &io.LimitedReader{R: r, N: maxCommitSize + 1}
+
+	c := &Commit{}
+
+	scanner := bufio.NewScanner(lr)
+	scanner.Buffer(nil, maxCommitSize)
+	for scanner.Scan() {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var line commitLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			return nil, ErrInvalidCommit
+		}
+
+		v := line.Value
+
+		switch line.Key {
+		case "header":
+			c.Summary = v.Summary
+			c.Description = v.Description
+			continue
+		case "deletedFolder":
+			path := strings.TrimSuffix(v.Path, "/")
+			if !IsValidFilePath(path) {
+				return nil, ErrInvalidFilePath
+			}
+			c.DeletedFolders = append(c.DeletedFolders, path)
+			continue
+		}
+
+		if !IsValidFilePath(v.Path) {
+			return nil, ErrInvalidFilePath
+		}
+
+		switch line.Key {
+		case "file":
+			var content []byte
+			if v.Encoding == "base64" {
+				var err error
+				if content, err = base64.StdEncoding.DecodeString(v.Content); err != nil {
+					return nil, ErrInvalidCommit
+				}
+			} else {
+				content = []byte(v.Content)
+			}
+			c.Files = append(c.Files, &CommitFile{
+				Path:    v.Path,
+				Content: content,
+			})
+		case "lfsFile":
+			if v.Algo != "" && v.Algo != "sha256" || !IsValidOID(v.OID) {
+				return nil, ErrInvalidObjectID
+			}
+			c.LFSFiles = append(c.LFSFiles, &CommitLFSFile{
+				Path: v.Path,
+				OID:  v.OID,
+				Size: v.Size,
+			})
+		case "deletedFile":
+			c.DeletedFiles = append(c.DeletedFiles, v.Path)
+		default:
+			return nil, ErrInvalidCommit
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, ErrCommitTooLarge
+		}
+		return nil, err
+	}
+	if lr.N <= 0 {
+		return nil, ErrCommitTooLarge
+	}
+
+	return c, nil
+}
+
+// CommitOID calculates the commit id of a revision from the paths and the sha256 hashes of its files
+// Revisions with the same content share the same commit id.
+func CommitOID(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha1.New()
+	for _, p := range paths {
+		_, _ = io.WriteString(h, p+"\x00"+files[p]+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type modelCard struct {
+	License     any    `yaml:"license"`
+	LibraryName string `yaml:"library_name"`
+	PipelineTag string `yaml:"pipeline_tag"`
+	Tags        any    `yaml:"tags"`
+}
+
+// ReadModelCard reads the README.md model card without reading past the size limit
+func ReadModelCard(r io.Reader, m *Metadata) error {
+	content, err := io.ReadAll(io.LimitReader(r, maxReadmeSize+1))
+	if err != nil {
+		return err
+	}
+	return ParseModelCard(content, m)
+}
+
+// ParseModelCard fills the metadata with the content of the README.md model card
+// The YAML front matter is stored as card data and removed from the rendered readme.
+// https://huggingface.co/docs/hub/model-cards
+func ParseModelCard(content []byte, m *Metadata) error {
+	if len(content) > maxReadmeSize {
+		return ErrReadmeIsTooLarge
+	}
+
+	var card modelCard
+	body, err := markdown.ExtractMetadataBytes(content, &card)
+	if err != nil {
+		// A model card without front matter is just a readme
+		m.Readme = string(content)
+		return nil
+	}
+
+	cardData := make(map[string]any)
+	if _, err := markdown.ExtractMetadataBytes(content, &cardData); err == nil {
+		m.CardData = cardData
+	}
+
+	m.Readme = string(body)
+	if licenses := toStrings(card.License); len(licenses) > 0 {
+		m.License = licenses[0]
+	}
+	m.LibraryName = card.LibraryName
+	m.PipelineTag = card.PipelineTag
+	m.Tags = toStrings(card.Tags)
+	return nil
+}
+
+// The front matter allows a single value or a list for most fields
+func toStrings(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		values := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
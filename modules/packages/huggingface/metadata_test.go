@@ -0,0 +1,131 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package huggingface
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidation(t *testing.T) {
+	for _, name := range []string{"gitea", "bert-base.v2", "a", "Model_1"} {
+		assert.True(t, IsValidName(name), name)
+	}
+	for _, name := range []string{"", "-gitea", "gitea.", "gi--tea", "gi..tea", "gi/tea"} {
+		assert.False(t, IsValidName(name), name)
+	}
+
+	assert.True(t, IsValidRevision("main"))
+	assert.True(t, IsValidRevision("v1.0"))
+	assert.False(t, IsValidRevision(UploadVersion))
+	assert.False(t, IsValidRevision("refs/pr/1"))
+
+	for _, p := range []string{"model.safetensors", "onnx/model.onnx", ".gitattributes"} {
+		assert.True(t, IsValidFilePath(p), p)
+	}
+	for _, p := range []string{"", "/model.bin", "a//b", "../model.bin", "a/./b", ".git/config", "a\\b", strings.Repeat("a", 256)} {
+		assert.False(t, IsValidFilePath(p), p)
+	}
+}
+
+func TestUploadMode(t *testing.T) {
+	assert.Equal(t, UploadModeRegular, UploadMode(5, []byte("hello")))
+	assert.Equal(t, UploadModeLFS, UploadMode(5, []byte("he\x00lo")))
+	assert.Equal(t, UploadModeLFS, UploadMode(maxRegularFileSize+1, []byte("hello")))
+}
+
+func TestParseCommit(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+
+	t.Run("Valid", func(t *testing.T) {
+		c, err := ParseCommit(strings.NewReader(`{"key":"header","value":{"summary":"Upload model","description":""}}
+{"key":"file","value":{"content":"aGVsbG8=","path":"config.json","encoding":"base64"}}
+{"key":"lfsFile","value":{"path":"model.safetensors","algo":"sha256","oid":"` + oid + `","size":42}}
+{"key":"deletedFile","value":{"path":"old.bin"}}
+{"key":"deletedFolder","value":{"path":"onnx/"}}
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, "Upload model", c.Summary)
+		assert.Len(t, c.Files, 1)
+		assert.Equal(t, "config.json", c.Files[0].Path)
+		assert.Equal(t, []byte("hello"), c.Files[0].Content)
+		assert.Len(t, c.LFSFiles, 1)
+		assert.Equal(t, &CommitLFSFile{Path: "model.safetensors", OID: oid, Size: 42}, c.LFSFiles[0])
+		assert.Equal(t, []string{"old.bin"}, c.DeletedFiles)
+		assert.Equal(t, []string{"onnx"}, c.DeletedFolders)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, content := range []string{
+			`{`,
+			`{"key":"unknown","value":{"path":"a"}}`,
+			`{"key":"file","value":{"content":"a","path":"../a"}}`,
+			`{"key":"file","value":{"content":"!","path":"a","encoding":"base64"}}`,
+			`{"key":"lfsFile","value":{"path":"a","oid":"invalid"}}`,
+		} {
+			c, err := ParseCommit(strings.NewReader(content))
+			assert.Nil(t, c)
+			assert.Error(t, err, content)
+		}
+	})
+}
+
+func TestCommitOID(t *testing.T) {
+	a := CommitOID(map[string]string{"a": "1", "b": "2"})
+	assert.Len(t, a, 40)
+	assert.True(t, IsCommitOID(a))
+	assert.Equal(t, a, CommitOID(map[string]string{"b": "2", "a": "1"}))
+	assert.NotEqual(t, a, CommitOID(map[string]string{"a": "1", "b": "3"}))
+}
+
+func TestParseModelCard(t *testing.T) {
+	t.Run("FrontMatter", func(t *testing.T) {
+		var m Metadata
+		assert.NoError(t, ParseModelCard([]byte(`---
+license: apache-2.0
+library_name: transformers
+pipeline_tag: text-classification
+tags:
+- gitea
+- test
+---
+# Gitea Model`), &m))
+		assert.Equal(t, "# Gitea Model", m.Readme)
+		assert.Equal(t, "apache-2.0", m.License)
+		assert.Equal(t, "transformers", m.LibraryName)
+		assert.Equal(t, "text-classification", m.PipelineTag)
+		assert.Equal(t, []string{"gitea", "test"}, m.Tags)
+		assert.Equal(t, "apache-2.0", m.CardData["license"])
+	})
+
+	t.Run("Plain", func(t *testing.T) {
+		var m Metadata
+		assert.NoError(t, ParseModelCard([]byte("# Gitea Model"), &m))
+		assert.Equal(t, "# Gitea Model", m.Readme)
+		assert.Empty(t, m.License)
+		assert.Nil(t, m.CardData)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		var m Metadata
+		assert.ErrorIs(t, ParseModelCard(make([]byte, maxReadmeSize+1), &m), ErrReadmeIsTooLarge)
+	})
+}
+
+func TestReadModelCard(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		var m Metadata
+		assert.NoError(t, ReadModelCard(strings.NewReader("# Gitea Model"), &m))
+		assert.Equal(t, "# Gitea Model", m.Readme)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		var m Metadata
+		assert.ErrorIs(t, ReadModelCard(bytes.NewReader(make([]byte, 2*maxReadmeSize)), &m), ErrReadmeIsTooLarge)
+		assert.Empty(t, m.Readme)
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfs
+
+import (
+	"regexp"
+	"time"
+)
+
+// MediaType is the content type of all requests and responses of the Git LFS API
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md
+const MediaType = "application/vnd.git-lfs+json"
+
+const (
+	OperationDownload = "download"
+	OperationUpload   = "upload"
+
+	TransferBasic = "basic"
+)
+
+var (
+	oidPattern     = regexp.MustCompile(`\A[a-f0-9]{64}\z`)
+	projectPattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9._-]*\z`)
+)
+
+// IsValidOID checks if the value is a sha256 object id
+func IsValidOID(oid string) bool {
+	return oidPattern.MatchString(oid)
+}
+
+// IsValidProjectName checks if the value can be used as project namespace
+func IsValidProjectName(name string) bool {
+	return len(name) <= 255 && projectPattern.MatchString(name)
+}
+
+// Pointer identifies an object
+type Pointer struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// IsValid checks if the pointer has a valid object id and size
+func (p Pointer) IsValid() bool {
+	return IsValidOID(p.Oid) && p.Size >= 0
+}
+
+// Reference is the git ref the request is made for
+type Reference struct {
+	Name string `json:"name"`
+}
+
+// BatchRequest is a request of the batch API
+type BatchRequest struct {
+	Operation string     `json:"operation"`
+	Transfers []string   `json:"transfers,omitempty"`
+	Ref       *Reference `json:"ref,omitempty"`
+	Objects   []Pointer  `json:"objects"`
+}
+
+// BatchResponse is the response of the batch API
+type BatchResponse struct {
+	Transfer string            `json:"transfer,omitempty"`
+	Objects  []*ObjectResponse `json:"objects"`
+}
+
+// ObjectResponse contains the actions or the error of a requested object
+type ObjectResponse struct {
+	Pointer
+	Authenticated bool             `json:"authenticated,omitempty"`
+	Actions       map[string]*Link `json:"actions,omitempty"`
+	Error         *ObjectError     `json:"error,omitempty"`
+}
+
+// Link is the target of an action
+type Link struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+// ObjectError is the error of a requested object
+type ObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is the response of a failed request
+type ErrorResponse struct {
+	Message string `json:"message"`
+	Lock    *Lock  `json:"lock,omitempty"`
+}
+
+// Lock is a locked path
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/locking.md
+type Lock struct {
+	ID       string     `json:"id"`
+	Path     string     `json:"path"`
+	LockedAt time.Time  `json:"locked_at"`
+	Owner    *LockOwner `json:"owner,omitempty"`
+}
+
+// LockOwner is the user who created a lock
+type LockOwner struct {
+	Name string `json:"name"`
+}
+
+// LockCreateRequest is a request to lock a path
+type LockCreateRequest struct {
+	Path string     `json:"path"`
+	Ref  *Reference `json:"ref,omitempty"`
+}
+
+// LockResponse is the response containing a single lock
+type LockResponse struct {
+	Lock *Lock `json:"lock"`
+}
+
+// LockList is the response of listing locks
+type LockList struct {
+	Locks      []*Lock `json:"locks"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// LockVerifyRequest is a request to list the locks for verification before a push
+type LockVerifyRequest struct {
+	Cursor string     `json:"cursor,omitempty"`
+	Limit  int        `json:"limit,omitempty"`
+	Ref    *Reference `json:"ref,omitempty"`
+}
+
+// LockVerifyResponse splits the locks by their owner
+type LockVerifyResponse struct {
+	Ours       []*Lock `json:"ours"`
+	Theirs     []*Lock `json:"theirs"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// LockDeleteRequest is a request to unlock a path
+type LockDeleteRequest struct {
+	Force bool       `json:"force,omitempty"`
+	Ref   *Reference `json:"ref,omitempty"`
+}
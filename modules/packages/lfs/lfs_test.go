@@ -0,0 +1,35 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidOID(t *testing.T) {
+	assert.True(t, IsValidOID("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"))
+	assert.False(t, IsValidOID(strings.ToUpper("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393")))
+	assert.False(t, IsValidOID("4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e23"))
+	assert.False(t, IsValidOID("../a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"))
+}
+
+func TestIsValidProjectName(t *testing.T) {
+	for _, name := range []string{"project", "my-project.git", "Project_1"} {
+		assert.True(t, IsValidProjectName(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "group/project", "-project", strings.Repeat("a", 256)} {
+		assert.False(t, IsValidProjectName(name), name)
+	}
+}
+
+func TestPointerIsValid(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+
+	assert.True(t, Pointer{Oid: oid, Size: 0}.IsValid())
+	assert.False(t, Pointer{Oid: oid, Size: -1}.IsValid())
+	assert.False(t, Pointer{Oid: "invalid", Size: 1}.IsValid())
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package nix
+
+import (
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	// StoreDir is the only store directory supported by the binary cache
+	StoreDir = "/nix/store"
+
+	SettingKeyPrivate = "nix.key.private"
+	SettingKeyPublic  = "nix.key.public"
+
+	// RepositoryPackage is the name of the internal package holding not yet referenced NAR files
+	RepositoryPackage = "_nix"
+	// IncomingVersion is the internal version holding NAR files uploaded before their narinfo
+	IncomingVersion = "_incoming"
+
+	ContentTypeNarInfo   = "text/x-nix-narinfo"
+	ContentTypeCacheInfo = "text/x-nix-cache-info"
+	ContentTypeNar       = "application/x-nix-nar"
+)
+
+var (
+	ErrInvalidStorePath = util.NewInvalidArgumentErrorf("store path is invalid")
+	ErrInvalidFilename  = util.NewInvalidArgumentErrorf("NAR filename is invalid")
+	ErrInvalidNarInfo   = util.NewInvalidArgumentErrorf("narinfo is invalid")
+
+	hashPattern        = regexp.MustCompile(`\A[` + base32Alphabet + `]{32}\z`)
+	storeNamePattern   = regexp.MustCompile(`\A[a-zA-Z0-9+\-._?=]{1,211}\z`)
+	narFilenamePattern = regexp.MustCompile(`\A([` + base32Alphabet + `]{52})\.nar(?:\.xz|\.zst|\.bz2)?\z`)
+)
+
+// Metadata represents the metadata of a store path
+type Metadata struct {
+	StorePath   string   `json:"store_path"`
+	Compression string   `json:"compression"`
+	FileHash    string   `json:"file_hash"`
+	FileSize    int64    `json:"file_size"`
+	NarHash     string   `json:"nar_hash"`
+	NarSize     int64    `json:"nar_size"`
+	References  []string `json:"references,omitempty"`
+	Deriver     string   `json:"deriver,omitempty"`
+	System      string   `json:"system,omitempty"`
+	Signatures  []string `json:"signatures,omitempty"`
+	CA          string   `json:"ca,omitempty"`
+}
+
+// IsValidHash checks if the value is a store path hash
+func IsValidHash(hash string) bool {
+	return hashPattern.MatchString(hash)
+}
+
+// SplitStorePath splits a store path like /nix/store/<hash>-<name> into hash and name
+func SplitStorePath(storePath string) (string, string, error) {
+	base, ok := strings.CutPrefix(storePath, StoreDir+"/")
+	if !ok {
+		return "", "", ErrInvalidStorePath
+	}
+	return splitBaseName(base)
+}
+
+func splitBaseName(base string) (string, string, error) {
+	hash, name, ok := strings.Cut(base, "-")
+	if !ok || !IsValidHash(hash) || !storeNamePattern.MatchString(name) {
+		return "", "", ErrInvalidStorePath
+	}
+	return hash, name, nil
+}
+
+// ParseNarFilename checks the name of a NAR file and returns the hash of the file it contains
+func ParseNarFilename(filename string) (string, error) {
+	m := narFilenamePattern.FindStringSubmatch(filename)
+	if m == nil {
+		return "", ErrInvalidFilename
+	}
+	return m[1], nil
+}
+
+// https://github.com/NixOS/nix/blob/master/src/libutil/hash.cc
+const base32Alphabet = "0123456789abcdfghijklmnpqrsvwxyz"
+
+// EncodeBase32 encodes a hash in the base32 variant used by Nix
+func EncodeBase32(hash []byte) string {
+	if len(hash) == 0 {
+		return ""
+	}
+
+	l := (len(hash)*8-1)/5 + 1
+	s := make([]byte, 0, l)
+	for n := l - 1; n >= 0; n-- {
+		b := n * 5
+		i := b / 8
+		j := b % 8
+		c := hash[i] >> j
+		if i+1 < len(hash) {
+			c |= hash[i+1] << (8 - j)
+		}
+		s = append(s, base32Alphabet[c&0x1f])
+	}
+	return string(s)
+}
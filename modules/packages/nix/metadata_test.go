@@ -0,0 +1,128 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package nix
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	storeHash = "sjnnpl3an2lakq7kvp0wm2n5ylhv0gjc"
+	storeName = "hello-2.12.1"
+	storePath = StoreDir + "/" + storeHash + "-" + storeName
+	fileHash  = "1w1fff338fvdw53sqgamddn1b2xgds473pv6y13gizdbqjv4i5p3"
+	narInfo   = `StorePath: ` + storePath + `
+URL: nar/` + fileHash + `.nar.xz
+Compression: xz
+FileHash: sha256:` + fileHash + `
+FileSize: 50088
+NarHash: sha256:1v6l0q5cz81i2rp48bhrhzazbg4m5ixvdhxhyh0gbdknv1zbnqgg
+NarSize: 226560
+References: 1b8qa1zv1j7xhgwsvkmw8jvymbylfjw8-glibc-2.39-52 ` + storeHash + `-` + storeName + `
+Deriver: yi8bk2gfm3q4pgzxak3ziq0cv6kxzxgy-hello-2.12.1.drv
+Sig: cache.nixos.org-1:signature
+`
+)
+
+func TestSplitStorePath(t *testing.T) {
+	hash, name, err := SplitStorePath(storePath)
+	assert.NoError(t, err)
+	assert.Equal(t, storeHash, hash)
+	assert.Equal(t, storeName, name)
+
+	for _, path := range []string{
+		"/nix/store/" + storeHash,
+		"/other/store/" + storeHash + "-" + storeName,
+		"/nix/store/" + strings.ToUpper(storeHash) + "-" + storeName,
+		"/nix/store/" + storeHash + "-invalid/name",
+	} {
+		_, _, err := SplitStorePath(path)
+		assert.ErrorIs(t, err, ErrInvalidStorePath, path)
+	}
+}
+
+func TestParseNarFilename(t *testing.T) {
+	for _, filename := range []string{fileHash + ".nar.xz", fileHash + ".nar.zst", fileHash + ".nar"} {
+		hash, err := ParseNarFilename(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, fileHash, hash)
+	}
+
+	for _, filename := range []string{fileHash + ".tar.xz", "short.nar.xz", "../" + fileHash + ".nar.xz"} {
+		_, err := ParseNarFilename(filename)
+		assert.ErrorIs(t, err, ErrInvalidFilename)
+	}
+}
+
+func TestEncodeBase32(t *testing.T) {
+	sum := sha256.Sum256(nil)
+	assert.Equal(t, "0mdqa9w1p6cmli6976v4wi0sw9r4p5prkj7lzfd1877wk11c9c73", EncodeBase32(sum[:]))
+	assert.Empty(t, EncodeBase32(nil))
+}
+
+func TestParseNarInfo(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		n, err := ParseNarInfo(strings.NewReader(narInfo))
+		assert.NoError(t, err)
+		assert.NotNil(t, n)
+		assert.Equal(t, storePath, n.StorePath)
+		assert.Equal(t, storeHash, n.Hash())
+		assert.Equal(t, "nar/"+fileHash+".nar.xz", n.URL)
+		assert.Equal(t, "xz", n.Compression)
+		assert.Equal(t, "sha256:"+fileHash, n.FileHash)
+		assert.EqualValues(t, 50088, n.FileSize)
+		assert.EqualValues(t, 226560, n.NarSize)
+		assert.Len(t, n.References, 2)
+		assert.Equal(t, []string{"cache.nixos.org-1:signature"}, n.Signatures)
+
+		assert.Equal(t, narInfo, n.String())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, content := range []string{
+			strings.Replace(narInfo, storePath, "/tmp/invalid", 1),
+			strings.Replace(narInfo, "References: 1b8qa1zv1j7xhgwsvkmw8jvymbylfjw8-glibc-2.39-52", "References: invalid", 1),
+			strings.Replace(narInfo, "NarSize: 226560", "NarSize: 0", 1),
+			strings.Replace(narInfo, "FileHash: sha256:", "FileHash: md5:", 1),
+			"invalid",
+		} {
+			n, err := ParseNarInfo(strings.NewReader(content))
+			assert.Nil(t, n)
+			assert.ErrorIs(t, err, util.ErrInvalidArgument)
+		}
+
+		_, err := ParseNarInfo(strings.NewReader(strings.Replace(narInfo, "FileSize: 50088", "FileSize: abc", 1)))
+		assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	})
+}
+
+func TestSign(t *testing.T) {
+	n, err := ParseNarInfo(strings.NewReader(narInfo))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1;"+storePath+";sha256:1v6l0q5cz81i2rp48bhrhzazbg4m5ixvdhxhyh0gbdknv1zbnqgg;226560;"+StoreDir+"/1b8qa1zv1j7xhgwsvkmw8jvymbylfjw8-glibc-2.39-52,"+storePath, n.Fingerprint())
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	sig, err := n.Sign(FormatKey("test-1", priv))
+	assert.NoError(t, err)
+
+	name, value, _ := strings.Cut(sig, ":")
+	assert.Equal(t, "test-1", name)
+	signature, err := base64.StdEncoding.DecodeString(value)
+	assert.NoError(t, err)
+	assert.True(t, ed25519.Verify(pub, []byte(n.Fingerprint()), signature))
+
+	_, err = n.Sign("invalid")
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package nix
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+// NarInfo describes a store path and the NAR file containing it
+// https://github.com/NixOS/nix/blob/master/src/libstore/nar-info.cc
+type NarInfo struct {
+	Metadata
+	URL string
+}
+
+// Hash gets the hash part of the store path
+func (n *NarInfo) Hash() string {
+	hash, _, _ := SplitStorePath(n.StorePath)
+	return hash
+}
+
+// ParseNarInfo parses and validates a narinfo file
+func ParseNarInfo(r io.Reader) (*NarInfo, error) {
+	n := &NarInfo{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, ErrInvalidNarInfo
+		}
+
+		var err error
+		switch key {
+		case "StorePath":
+			n.StorePath = value
+		case "URL":
+			n.URL = value
+		case "Compression":
+			n.Compression = value
+		case "FileHash":
+			n.FileHash = value
+		case "FileSize":
+			n.FileSize, err = strconv.ParseInt(value, 10, 64)
+		case "NarHash":
+			n.NarHash = value
+		case "NarSize":
+			n.NarSize, err = strconv.ParseInt(value, 10, 64)
+		case "References":
+			n.References = strings.Fields(value)
+		case "Deriver":
+			if value != "unknown-deriver" {
+				n.Deriver = value
+			}
+		case "System":
+			n.System = value
+		case "Sig":
+			n.Signatures = append(n.Signatures, value)
+		case "CA":
+			n.CA = value
+		}
+		if err != nil {
+			return nil, util.NewInvalidArgumentErrorf("narinfo field %s is invalid: %v", key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, _, err := SplitStorePath(n.StorePath); err != nil {
+		return nil, err
+	}
+	for _, ref := range n.References {
+		if _, _, err := splitBaseName(ref); err != nil {
+			return nil, err
+		}
+	}
+	if n.URL == "" || n.NarHash == "" || n.NarSize <= 0 || !strings.HasPrefix(n.FileHash, "sha256:") || n.FileSize <= 0 {
+		return nil, ErrInvalidNarInfo
+	}
+	if n.Compression == "" {
+		n.Compression = "bzip2"
+	}
+
+	return n, nil
+}
+
+// String renders the narinfo in the format expected by Nix
+func (n *NarInfo) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "StorePath: %s\n", n.StorePath)
+	fmt.Fprintf(&sb, "URL: %s\n", n.URL)
+	fmt.Fprintf(&sb, "Compression: %s\n", n.Compression)
+	fmt.Fprintf(&sb, "FileHash: %s\n", n.FileHash)
+	fmt.Fprintf(&sb, "FileSize: %d\n", n.FileSize)
+	fmt.Fprintf(&sb, "NarHash: %s\n", n.NarHash)
+	fmt.Fprintf(&sb, "NarSize: %d\n", n.NarSize)
+	fmt.Fprintf(&sb, "References: %s\n", strings.Join(n.References, " "))
+	if n.Deriver != "" {
+		fmt.Fprintf(&sb, "Deriver: %s\n", n.Deriver)
+	}
+	if n.System != "" {
+		fmt.Fprintf(&sb, "System: %s\n", n.System)
+	}
+	for _, sig := range n.Signatures {
+		fmt.Fprintf(&sb, "Sig: %s\n", sig)
+	}
+	if n.CA != "" {
+		fmt.Fprintf(&sb, "CA: %s\n", n.CA)
+	}
+	return sb.String()
+}
+
+// Fingerprint creates the fingerprint of the store path which gets signed
+func (n *NarInfo) Fingerprint() string {
+	refs := make([]string, 0, len(n.References))
+	for _, ref := range n.References {
+		refs = append(refs, StoreDir+"/"+ref)
+	}
+	return fmt.Sprintf("1;%s;%s;%d;%s", n.StorePath, n.NarHash, n.NarSize, strings.Join(refs, ","))
+}
+
+// FormatKey formats a key like name:base64
+func FormatKey(name string, key []byte) string {
+	return name + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+// Sign signs the fingerprint of the narinfo with the secret key in the name:base64 format
+// and returns the signature in the same format
+func (n *NarInfo) Sign(secretKey string) (string, error) {
+	name, key, ok := strings.Cut(secretKey, ":")
+	if !ok {
+		return "", fmt.Errorf("secret key has no name")
+	}
+
+	priv, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", err
+	}
+	if len(priv) != ed25519.PrivateKeySize {
+		return "", fmt.Errorf("secret key has invalid size")
+	}
+
+	return FormatKey(name, ed25519.Sign(priv, []byte(n.Fingerprint()))), nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pub
+
+import (
+	"io"
+	"regexp"
+	"time"
+
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	PropertyRetracted    = "pub.retracted"
+	PropertyDiscontinued = "pub.discontinued"
+	PropertyReplacedBy   = "pub.replaced_by"
+	PropertyAdvisory     = "pub.advisory"
+
+	// AdvisoryEcosystem is the OSV ecosystem of Pub packages
+	AdvisoryEcosystem = "Pub"
+)
+
+var (
+	ErrInvalidAdvisory = util.NewInvalidArgumentErrorf("advisory is invalid")
+
+	advisoryIDPattern = regexp.MustCompile(`\A[A-Za-z0-9][A-Za-z0-9._:-]*\z`)
+)
+
+// Advisory is a security advisory in the OSV format
+// https://ossf.github.io/osv-schema/
+// https://github.com/dart-lang/pub/blob/master/doc/repository-spec-v2.md#get-security-advisories-for-a-package
+type Advisory struct {
+	SchemaVersion    string               `json:"schema_version,omitempty"`
+	ID               string               `json:"id"`
+	Modified         time.Time            `json:"modified"`
+	Published        time.Time            `json:"published"`
+	Withdrawn        *time.Time           `json:"withdrawn,omitempty"`
+	Aliases          []string             `json:"aliases,omitempty"`
+	Summary          string               `json:"summary,omitempty"`
+	Details          string               `json:"details,omitempty"`
+	Severity         []*AdvisorySeverity  `json:"severity,omitempty"`
+	Affected         []*AdvisoryAffected  `json:"affected"`
+	References       []*AdvisoryReference `json:"references,omitempty"`
+	DatabaseSpecific map[string]any       `json:"database_specific,omitempty"`
+}
+
+type AdvisorySeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type AdvisoryAffected struct {
+	Package  AdvisoryPackage  `json:"package"`
+	Ranges   []*AdvisoryRange `json:"ranges,omitempty"`
+	Versions []string         `json:"versions,omitempty"`
+}
+
+type AdvisoryPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type AdvisoryRange struct {
+	Type   string              `json:"type"`
+	Events []map[string]string `json:"events"`
+}
+
+type AdvisoryReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ParseAdvisory parses and validates an advisory affecting the package
+func ParseAdvisory(r io.Reader, packageName string) (*Advisory, error) {
+	var a *Advisory
+	if err := json.NewDecoder(r).Decode(&a); err != nil || a == nil {
+		return nil, ErrInvalidAdvisory
+	}
+
+	if !advisoryIDPattern.MatchString(a.ID) {
+		return nil, util.NewInvalidArgumentErrorf("advisory id is invalid")
+	}
+	if a.Summary == "" && a.Details == "" {
+		return nil, util.NewInvalidArgumentErrorf("advisory summary is missing")
+	}
+	for _, ref := range a.References {
+		if !validation.IsValidURL(ref.URL) {
+			return nil, util.NewInvalidArgumentErrorf("advisory reference url is invalid")
+		}
+	}
+
+	// Only the affected versions of this package are relevant
+	affected := make([]*AdvisoryAffected, 0, len(a.Affected))
+	for _, aa := range a.Affected {
+		if aa == nil || aa.Package.Name != packageName {
+			continue
+		}
+		aa.Package.Ecosystem = AdvisoryEcosystem
+
+		for _, v := range aa.Versions {
+			if _, err := version.NewSemver(v); err != nil {
+				return nil, util.NewInvalidArgumentErrorf("advisory version is invalid")
+			}
+		}
+		for _, r := range aa.Ranges {
+			if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+				return nil, util.NewInvalidArgumentErrorf("advisory range type is invalid")
+			}
+			for _, e := range r.Events {
+				for key, value := range e {
+					if _, err := version.NewSemver(value); err != nil && !(key == "introduced" && value == "0") {
+						return nil, util.NewInvalidArgumentErrorf("advisory range is invalid")
+					}
+				}
+			}
+		}
+		if len(aa.Versions) == 0 && len(aa.Ranges) == 0 {
+			return nil, util.NewInvalidArgumentErrorf("advisory has no affected versions")
+		}
+
+		affected = append(affected, aa)
+	}
+	if len(affected) == 0 {
+		return nil, util.NewInvalidArgumentErrorf("advisory does not affect the package")
+	}
+	a.Affected = affected
+
+	now := time.Now().UTC()
+	if a.Published.IsZero() {
+		a.Published = now
+	}
+	a.Modified = now
+
+	return a, nil
+}
+
+// ResolveVersions adds the versions matching the ranges to the explicit list of affected versions.
+// Clients like pub only evaluate the list of versions.
+func (a *Advisory) ResolveVersions(versions []string) {
+	for _, aa := range a.Affected {
+		affected := container.SetOf(aa.Versions...)
+
+		for _, r := range aa.Ranges {
+			for _, v := range versions {
+				sv, err := version.NewSemver(v)
+				if err != nil {
+					continue
+				}
+				if isInRange(sv, r.Events) {
+					affected.Add(v)
+				}
+			}
+		}
+
+		aa.Versions = util.Sorted(affected.Values())
+	}
+}
+
+// https://ossf.github.io/osv-schema/#evaluation
+func isInRange(v *version.Version, events []map[string]string) bool {
+	inRange := false
+	for _, e := range events {
+		if introduced, ok := e["introduced"]; ok {
+			if introduced == "0" {
+				inRange = true
+			} else if iv, err := version.NewSemver(introduced); err == nil && !v.LessThan(iv) {
+				inRange = true
+			}
+		}
+		if fixed, ok := e["fixed"]; ok {
+			if fv, err := version.NewSemver(fixed); err == nil && !v.LessThan(fv) {
+				inRange = false
+			}
+		}
+		if lastAffected, ok := e["last_affected"]; ok {
+			if lv, err := version.NewSemver(lastAffected); err == nil && v.GreaterThan(lv) {
+				inRange = false
+			}
+		}
+	}
+	return inRange
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pub
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAdvisory(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		a, err := ParseAdvisory(strings.NewReader(`{
+			"id": "GHSA-1234-5678-9abc",
+			"summary": "Remote code execution",
+			"affected": [
+				{"package": {"name": "other"}, "versions": ["1.0.0"]},
+				{"package": {"ecosystem": "pub", "name": "`+packageName+`"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.2.0"}]}]}
+			],
+			"references": [{"type": "ADVISORY", "url": "https://gitea.com/advisory"}]
+		}`), packageName)
+		assert.NoError(t, err)
+		assert.NotNil(t, a)
+		assert.Equal(t, "GHSA-1234-5678-9abc", a.ID)
+		assert.Len(t, a.Affected, 1)
+		assert.Equal(t, AdvisoryEcosystem, a.Affected[0].Package.Ecosystem)
+		assert.False(t, a.Published.IsZero())
+		assert.False(t, a.Modified.IsZero())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cases := []string{
+			`invalid`,
+			`{"id": "", "summary": "s", "affected": [{"package": {"name": "` + packageName + `"}, "versions": ["1.0.0"]}]}`,
+			`{"id": "id", "affected": [{"package": {"name": "` + packageName + `"}, "versions": ["1.0.0"]}]}`,
+			`{"id": "id", "summary": "s", "affected": [{"package": {"name": "other"}, "versions": ["1.0.0"]}]}`,
+			`{"id": "id", "summary": "s", "affected": [{"package": {"name": "` + packageName + `"}, "versions": ["invalid"]}]}`,
+			`{"id": "id", "summary": "s", "affected": [{"package": {"name": "` + packageName + `"}}]}`,
+			`{"id": "id", "summary": "s", "affected": [{"package": {"name": "` + packageName + `"}, "ranges": [{"type": "GIT", "events": [{"introduced": "0"}]}]}]}`,
+			`{"id": "id", "summary": "s", "affected": [{"package": {"name": "` + packageName + `"}, "versions": ["1.0.0"]}], "references": [{"url": "invalid"}]}`,
+		}
+		for _, c := range cases {
+			a, err := ParseAdvisory(strings.NewReader(c), packageName)
+			assert.Nil(t, a, c)
+			assert.ErrorIs(t, err, util.ErrInvalidArgument, c)
+		}
+	})
+}
+
+func TestResolveVersions(t *testing.T) {
+	a := &Advisory{
+		Affected: []*AdvisoryAffected{
+			{
+				Ranges: []*AdvisoryRange{
+					{
+						Type: "SEMVER",
+						Events: []map[string]string{
+							{"introduced": "1.0.0"},
+							{"fixed": "1.2.0"},
+							{"introduced": "2.0.0"},
+							{"last_affected": "2.1.0"},
+						},
+					},
+				},
+				Versions: []string{"0.1.0"},
+			},
+		},
+	}
+
+	a.ResolveVersions([]string{"0.9.0", "1.0.0", "1.1.5", "1.2.0", "1.9.0", "2.0.0", "2.1.0", "2.1.1", "invalid"})
+
+	assert.Equal(t, []string{"0.1.0", "1.0.0", "1.1.5", "2.0.0", "2.1.0"}, a.Affected[0].Versions)
+}
@@ -29,6 +29,11 @@ var namePattern = regexp.MustCompile(`\A[a-zA-Z_][a-zA-Z0-9_]*\z`)
 // https://github.com/dart-lang/pub-dev/blob/4d582302a8d10152a5cd6129f65bf4f4dbca239d/pkg/pub_package_reader/lib/pub_package_reader.dart#L143
 const maxPubspecFileSize = 128 * 1024
 
+// IsValidPackageName checks if the name is a valid Pub package name
+func IsValidPackageName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
 // Package represents a Pub package
 type Package struct {
 	Name     string
@@ -24,6 +24,10 @@ const (
 
 	RepositoryPackage = "_rpm"
 	RepositoryVersion = "_repository"
+	AdvisoryVersion   = "_advisories"
+	ModuleVersion     = "_modules"
+
+	ModuleMetadataFilename = "modules.yaml"
 )
 
 const (
@@ -0,0 +1,94 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"errors"
+	"io"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidModuleMetadata = util.NewInvalidArgumentErrorf("module metadata is invalid")
+
+// ModuleStream identifies a stream described in the module metadata
+type ModuleStream struct {
+	Name    string
+	Stream  string
+	Version uint64
+	Context string
+	Arch    string
+}
+
+// ParseModuleMetadata validates the module metadata (modules.yaml) and returns the contained module streams.
+// The file is a sequence of YAML documents of the modulemd formats.
+// https://github.com/fedora-modularity/libmodulemd/blob/main/yaml_specs/modulemd_stream_v2.yaml
+func ParseModuleMetadata(r io.Reader) ([]*ModuleStream, error) {
+	type document struct {
+		Document string `yaml:"document"`
+		Version  int    `yaml:"version"`
+		Data     struct {
+			Name    string `yaml:"name"`
+			Stream  any    `yaml:"stream"`
+			Version uint64 `yaml:"version"`
+			Context string `yaml:"context"`
+			Arch    string `yaml:"arch"`
+		} `yaml:"data"`
+	}
+
+	streams := make([]*ModuleStream, 0, 5)
+
+	documents := 0
+
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc document
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, ErrInvalidModuleMetadata
+		}
+
+		if doc.Version < 1 {
+			return nil, ErrInvalidModuleMetadata
+		}
+
+		switch doc.Document {
+		case "modulemd", "modulemd-packager":
+			if doc.Data.Name == "" || doc.Data.Stream == nil {
+				return nil, ErrInvalidModuleMetadata
+			}
+			stream, ok := doc.Data.Stream.(string)
+			if !ok {
+				// Streams like "1.0" may be parsed as numbers
+				var node yaml.Node
+				if err := node.Encode(doc.Data.Stream); err != nil {
+					return nil, ErrInvalidModuleMetadata
+				}
+				stream = node.Value
+			}
+			streams = append(streams, &ModuleStream{
+				Name:    doc.Data.Name,
+				Stream:  stream,
+				Version: doc.Data.Version,
+				Context: doc.Data.Context,
+				Arch:    doc.Data.Arch,
+			})
+		case "modulemd-defaults", "modulemd-obsoletes", "modulemd-translations":
+		default:
+			return nil, ErrInvalidModuleMetadata
+		}
+
+		documents++
+	}
+
+	if documents == 0 {
+		return nil, ErrInvalidModuleMetadata
+	}
+
+	return streams, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModuleMetadata(t *testing.T) {
+	content := `---
+document: modulemd
+version: 2
+data:
+  name: nodejs
+  stream: 20
+  version: 8090020240101
+  context: a1b2c3d4
+  arch: x86_64
+  summary: Javascript runtime
+...
+---
+document: modulemd-defaults
+version: 1
+data:
+  module: nodejs
+  stream: 20
+...
+`
+
+	streams, err := ParseModuleMetadata(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	assert.Equal(t, &ModuleStream{Name: "nodejs", Stream: "20", Version: 8090020240101, Context: "a1b2c3d4", Arch: "x86_64"}, streams[0])
+
+	for _, content := range []string{
+		``,
+		`document: unknown
+version: 1
+`,
+		`document: modulemd
+version: 2
+data:
+  summary: missing name
+`,
+	} {
+		streams, err := ParseModuleMetadata(strings.NewReader(content))
+		assert.Nil(t, streams, content)
+		assert.ErrorIs(t, err, ErrInvalidModuleMetadata, content)
+	}
+}
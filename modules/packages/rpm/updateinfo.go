@@ -0,0 +1,181 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+const (
+	AdvisoryTypeSecurity    = "security"
+	AdvisoryTypeBugfix      = "bugfix"
+	AdvisoryTypeEnhancement = "enhancement"
+	AdvisoryTypeNewPackage  = "newpackage"
+
+	// AdvisoryDateFormat is the date format used in updateinfo.xml
+	AdvisoryDateFormat = "2006-01-02 15:04:05"
+)
+
+var (
+	ErrInvalidAdvisory = util.NewInvalidArgumentErrorf("advisory is invalid")
+	ErrInvalidNEVRA    = util.NewInvalidArgumentErrorf("package NEVRA is invalid")
+
+	advisoryIDPattern = regexp.MustCompile(`\A[A-Za-z0-9][A-Za-z0-9._:-]*\z`)
+)
+
+// Advisory describes an update (errata) of packages in a repository
+// https://github.com/rpm-software-management/createrepo_c/blob/master/src/updateinfo.h
+type Advisory struct {
+	ID              string               `json:"id"`
+	Type            string               `json:"type"`
+	Status          string               `json:"status,omitempty"`
+	Title           string               `json:"title"`
+	Severity        string               `json:"severity,omitempty"`
+	Summary         string               `json:"summary,omitempty"`
+	Description     string               `json:"description,omitempty"`
+	Solution        string               `json:"solution,omitempty"`
+	Rights          string               `json:"rights,omitempty"`
+	Release         string               `json:"release,omitempty"`
+	Issued          time.Time            `json:"issued"`
+	Updated         time.Time            `json:"updated"`
+	RebootSuggested bool                 `json:"reboot_suggested,omitempty"`
+	References      []*AdvisoryReference `json:"references,omitempty"`
+	Module          *AdvisoryModule      `json:"module,omitempty"`
+	Packages        []string             `json:"packages"`
+}
+
+// AdvisoryReference links an advisory to an external resource like a CVE
+type AdvisoryReference struct {
+	Href  string `json:"href"`
+	ID    string `json:"id,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// AdvisoryModule is the module stream the packages of an advisory belong to
+type AdvisoryModule struct {
+	Name         string `json:"name"`
+	Stream       string `json:"stream"`
+	Version      uint64 `json:"version"`
+	Context      string `json:"context"`
+	Architecture string `json:"arch"`
+}
+
+// NEVRA identifies a package build by name, epoch, version, release and architecture
+type NEVRA struct {
+	Name         string
+	Epoch        string
+	Version      string
+	Release      string
+	Architecture string
+}
+
+// ParseNEVRA parses a string in the format name-[epoch:]version-release.arch
+func ParseNEVRA(s string) (*NEVRA, error) {
+	rest, arch, ok := cutLast(s, ".")
+	if !ok {
+		return nil, ErrInvalidNEVRA
+	}
+	rest, release, ok := cutLast(rest, "-")
+	if !ok {
+		return nil, ErrInvalidNEVRA
+	}
+	name, version, ok := cutLast(rest, "-")
+	if !ok {
+		return nil, ErrInvalidNEVRA
+	}
+
+	epoch := "0"
+	if e, v, ok := strings.Cut(version, ":"); ok {
+		epoch, version = e, v
+	}
+	if epoch == "" || version == "" {
+		return nil, ErrInvalidNEVRA
+	}
+
+	return &NEVRA{
+		Name:         name,
+		Epoch:        epoch,
+		Version:      version,
+		Release:      release,
+		Architecture: arch,
+	}, nil
+}
+
+// cutLast slices s around the last instance of sep. Both parts must not be empty.
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i <= 0 || i == len(s)-len(sep) {
+		return "", "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// Filename gets the conventional filename of the package build
+func (n *NEVRA) Filename() string {
+	return fmt.Sprintf("%s-%s-%s.%s.rpm", n.Name, n.Version, n.Release, n.Architecture)
+}
+
+// String formats the NEVRA
+func (n *NEVRA) String() string {
+	return fmt.Sprintf("%s-%s:%s-%s.%s", n.Name, n.Epoch, n.Version, n.Release, n.Architecture)
+}
+
+// ParseAdvisory parses and validates an advisory in JSON format
+func ParseAdvisory(r io.Reader) (*Advisory, error) {
+	var a *Advisory
+	if err := json.NewDecoder(r).Decode(&a); err != nil || a == nil {
+		return nil, ErrInvalidAdvisory
+	}
+
+	if !advisoryIDPattern.MatchString(a.ID) {
+		return nil, util.NewInvalidArgumentErrorf("advisory id is invalid")
+	}
+	switch a.Type {
+	case AdvisoryTypeSecurity, AdvisoryTypeBugfix, AdvisoryTypeEnhancement, AdvisoryTypeNewPackage:
+	default:
+		return nil, util.NewInvalidArgumentErrorf("advisory type is invalid")
+	}
+	if a.Status == "" {
+		a.Status = "final"
+	}
+	if a.Title == "" {
+		return nil, util.NewInvalidArgumentErrorf("advisory title is missing")
+	}
+	if len(a.Packages) == 0 {
+		return nil, util.NewInvalidArgumentErrorf("advisory has no packages")
+	}
+	for _, p := range a.Packages {
+		if _, err := ParseNEVRA(p); err != nil {
+			return nil, err
+		}
+	}
+	for _, ref := range a.References {
+		if !validation.IsValidURL(ref.Href) {
+			return nil, util.NewInvalidArgumentErrorf("advisory reference url is invalid")
+		}
+	}
+	if a.Module != nil && (a.Module.Name == "" || a.Module.Stream == "") {
+		return nil, util.NewInvalidArgumentErrorf("advisory module is invalid")
+	}
+
+	if a.Issued.IsZero() {
+		a.Issued = time.Now()
+	}
+	if a.Updated.IsZero() {
+		a.Updated = a.Issued
+	}
+	a.Issued = a.Issued.UTC()
+	a.Updated = a.Updated.UTC()
+
+	return a, nil
+}
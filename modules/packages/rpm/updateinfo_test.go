@@ -0,0 +1,76 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNEVRA(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected *NEVRA
+	}{
+		{"gitea-1.0.0-1.x86_64", &NEVRA{Name: "gitea", Epoch: "0", Version: "1.0.0", Release: "1", Architecture: "x86_64"}},
+		{"gitea-server-2:1.0.0-1.el9.noarch", &NEVRA{Name: "gitea-server", Epoch: "2", Version: "1.0.0", Release: "1.el9", Architecture: "noarch"}},
+		{"gitea-1.0.0.x86_64", nil},
+		{"gitea-1.0.0-1", nil},
+		{"-1.0.0-1.x86_64", nil},
+		{"gitea-:1.0.0-1.x86_64", nil},
+	}
+
+	for _, c := range cases {
+		n, err := ParseNEVRA(c.Input)
+		if c.Expected == nil {
+			assert.ErrorIs(t, err, ErrInvalidNEVRA, c.Input)
+			continue
+		}
+		assert.NoError(t, err, c.Input)
+		assert.Equal(t, c.Expected, n, c.Input)
+	}
+
+	n, _ := ParseNEVRA("gitea-1.0.0-1.x86_64")
+	assert.Equal(t, "gitea-1.0.0-1.x86_64.rpm", n.Filename())
+	assert.Equal(t, "gitea-0:1.0.0-1.x86_64", n.String())
+}
+
+func TestParseAdvisory(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		a, err := ParseAdvisory(strings.NewReader(`{
+			"id": "GITEA-2024-0001",
+			"type": "security",
+			"title": "Important: gitea security update",
+			"severity": "Important",
+			"issued": "2024-01-02T03:04:05Z",
+			"references": [{"href": "https://example.com/CVE-2024-0001", "id": "CVE-2024-0001", "type": "cve"}],
+			"packages": ["gitea-1.0.1-1.x86_64"]
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, "GITEA-2024-0001", a.ID)
+		assert.Equal(t, "final", a.Status)
+		assert.Equal(t, "2024-01-02 03:04:05", a.Issued.Format(AdvisoryDateFormat))
+		assert.Equal(t, a.Issued, a.Updated)
+		assert.Len(t, a.References, 1)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, content := range []string{
+			`{`,
+			`{"id": "../x", "type": "security", "title": "t", "packages": ["gitea-1.0.1-1.x86_64"]}`,
+			`{"id": "A-1", "type": "unknown", "title": "t", "packages": ["gitea-1.0.1-1.x86_64"]}`,
+			`{"id": "A-1", "type": "bugfix", "title": "", "packages": ["gitea-1.0.1-1.x86_64"]}`,
+			`{"id": "A-1", "type": "bugfix", "title": "t", "packages": []}`,
+			`{"id": "A-1", "type": "bugfix", "title": "t", "packages": ["gitea"]}`,
+			`{"id": "A-1", "type": "bugfix", "title": "t", "packages": ["gitea-1.0.1-1.x86_64"], "references": [{"href": "invalid"}]}`,
+		} {
+			a, err := ParseAdvisory(strings.NewReader(content))
+			assert.Nil(t, a, content)
+			assert.Error(t, err, content)
+		}
+	})
+}
@@ -25,6 +25,9 @@ var (
 	ErrInvalidVersion = util.NewInvalidArgumentErrorf("package version is invalid")
 )
 
+// PropertyOwner is a package property which contains the id of a gem owner
+const PropertyOwner = "rubygems.owner"
+
 var versionMatcher = regexp.MustCompile(`\A[0-9]+(?:\.[0-9a-zA-Z]+)*(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?\z`)
 
 // Package represents a RubyGems package
@@ -46,6 +49,7 @@ type Metadata struct {
 	ProjectURL              string               `json:"project_url,omitempty"`
 	RuntimeDependencies     []Dependency         `json:"runtime_dependencies,omitempty"`
 	DevelopmentDependencies []Dependency         `json:"development_dependencies,omitempty"`
+	MFARequired             bool                 `json:"mfa_required,omitempty"`
 }
 
 // VersionRequirement represents a version restriction
@@ -90,6 +94,7 @@ type gemspec struct {
 		ChangelogURI     string `yaml:"changelog_uri"`
 		DocumentationURI string `yaml:"documentation_uri"`
 		SourceCodeURI    string `yaml:"source_code_uri"`
+		MFARequired      string `yaml:"rubygems_mfa_required"`
 	} `yaml:"metadata"`
 	PostInstallMessage      any         `yaml:"post_install_message"`
 	RdocOptions             []any       `yaml:"rdoc_options"`
@@ -198,6 +203,8 @@ func parseMetadataFile(r io.Reader) (*Package, error) {
 		RequiredRubygemsVersion: spec.RequiredRubygemsVersion.AsVersionRequirement(),
 		DevelopmentDependencies: make([]Dependency, 0, 5),
 		RuntimeDependencies:     make([]Dependency, 0, 5),
+		// https://guides.rubygems.org/mfa-requirement-opt-in/
+		MFARequired: spec.Metadata.MFARequired == "true",
 	}
 
 	for _, gemdep := range spec.Dependencies {
@@ -6,6 +6,7 @@ package rubygems
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"encoding/base64"
 	"io"
 	"testing"
@@ -85,4 +86,17 @@ yjAbmt9LsOMp8xMamFkSQ38fP5EFjdz8LA4do2C69VvqWXAJgrPbKZb58/xZXrKoW6ttW13Bhvzi
 	assert.Len(t, rp.Metadata.DevelopmentDependencies[0].Version, 1)
 	assert.Equal(t, "~>", rp.Metadata.DevelopmentDependencies[0].Version[0].Restriction)
 	assert.Equal(t, "5.2", rp.Metadata.DevelopmentDependencies[0].Version[0].Version)
+	assert.False(t, rp.Metadata.MFARequired)
+
+	t.Run("MFARequired", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte("name: gitea\nversion:\n  version: 1.0.0\nmetadata:\n  rubygems_mfa_required: 'true'\n"))
+		zw.Close()
+
+		rp, err := parseMetadataFile(&buf)
+		assert.NoError(t, err)
+		assert.NotNil(t, rp)
+		assert.True(t, rp.Metadata.MFARequired)
+	})
 }
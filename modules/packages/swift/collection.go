@@ -0,0 +1,139 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package swift
+
+import (
+	"regexp"
+	"time"
+)
+
+// https://github.com/apple/swift-evolution/blob/main/proposals/0291-package-collections.md
+// https://github.com/apple/swift-package-manager/blob/main/Sources/PackageCollectionsModel/Formats/v1.md
+
+const CollectionFormatVersion = "1.0"
+
+var (
+	manifestPackageNamePattern = regexp.MustCompile(`Package\(\s*name:\s*"([^"]+)"`)
+	manifestTargetPattern      = regexp.MustCompile(`\.(?:target|executableTarget|testTarget|binaryTarget|systemLibrary|macro|plugin)\(\s*name:\s*"([^"]+)"`)
+	manifestProductPattern     = regexp.MustCompile(`\.(library|executable)\(\s*name:\s*"([^"]+)"`)
+)
+
+// Collection is a package collection
+type Collection struct {
+	Name          string               `json:"name"`
+	Overview      string               `json:"overview,omitempty"`
+	Keywords      []string             `json:"keywords,omitempty"`
+	Packages      []*CollectionPackage `json:"packages"`
+	FormatVersion string               `json:"formatVersion"`
+	Revision      int64                `json:"revision,omitempty"`
+	GeneratedAt   time.Time            `json:"generatedAt"`
+	GeneratedBy   *CollectionAuthor    `json:"generatedBy,omitempty"`
+}
+
+// SignedCollection is a package collection with its signature
+type SignedCollection struct {
+	*Collection
+	Signature *CollectionSignature `json:"signature"`
+}
+
+// CollectionSignature contains the JWS of the collection and information about the signing certificate
+type CollectionSignature struct {
+	Signature   string                          `json:"signature"`
+	Certificate *CollectionSignatureCertificate `json:"certificate"`
+}
+
+type CollectionSignatureCertificate struct {
+	Subject *CollectionSignatureName `json:"subject"`
+	Issuer  *CollectionSignatureName `json:"issuer"`
+}
+
+type CollectionSignatureName struct {
+	UserID             string `json:"userID,omitempty"`
+	CommonName         string `json:"commonName,omitempty"`
+	OrganizationalUnit string `json:"organizationalUnit,omitempty"`
+	Organization       string `json:"organization,omitempty"`
+}
+
+// CollectionPackage is a package of a collection
+type CollectionPackage struct {
+	URL       string               `json:"url"`
+	Identity  string               `json:"identity,omitempty"`
+	Summary   string               `json:"summary,omitempty"`
+	Keywords  []string             `json:"keywords,omitempty"`
+	Versions  []*CollectionVersion `json:"versions"`
+	ReadmeURL string               `json:"readmeURL,omitempty"`
+	License   *CollectionLicense   `json:"license,omitempty"`
+}
+
+// CollectionVersion is a version of a package in a collection
+type CollectionVersion struct {
+	Version             string                         `json:"version"`
+	Summary             string                         `json:"summary,omitempty"`
+	Manifests           map[string]*CollectionManifest `json:"manifests"`
+	DefaultToolsVersion string                         `json:"defaultToolsVersion"`
+	License             *CollectionLicense             `json:"license,omitempty"`
+	Author              *CollectionAuthor              `json:"author,omitempty"`
+	Signer              *Signer                        `json:"signer,omitempty"`
+	CreatedAt           time.Time                      `json:"createdAt"`
+}
+
+// CollectionManifest describes a Package.swift of a version
+type CollectionManifest struct {
+	ToolsVersion string               `json:"toolsVersion"`
+	PackageName  string               `json:"packageName"`
+	Targets      []*CollectionTarget  `json:"targets"`
+	Products     []*CollectionProduct `json:"products"`
+}
+
+type CollectionTarget struct {
+	Name string `json:"name"`
+}
+
+type CollectionProduct struct {
+	Name    string         `json:"name"`
+	Type    map[string]any `json:"type"`
+	Targets []string       `json:"targets"`
+}
+
+type CollectionLicense struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url"`
+}
+
+type CollectionAuthor struct {
+	Name string `json:"name"`
+}
+
+// NewCollectionManifest creates the collection representation of the manifest.
+// The manifest is not evaluated, the names of the package, targets and products are extracted from the source.
+func NewCollectionManifest(m *Manifest, defaultPackageName string) *CollectionManifest {
+	cm := &CollectionManifest{
+		ToolsVersion: m.ToolsVersion,
+		PackageName:  defaultPackageName,
+		Targets:      []*CollectionTarget{},
+		Products:     []*CollectionProduct{},
+	}
+
+	if match := manifestPackageNamePattern.FindStringSubmatch(m.Content); match != nil {
+		cm.PackageName = match[1]
+	}
+	for _, match := range manifestTargetPattern.FindAllStringSubmatch(m.Content, -1) {
+		cm.Targets = append(cm.Targets, &CollectionTarget{Name: match[1]})
+	}
+	for _, match := range manifestProductPattern.FindAllStringSubmatch(m.Content, -1) {
+		var typ map[string]any
+		if match[1] == "library" {
+			typ = map[string]any{"library": []string{"automatic"}}
+		} else {
+			typ = map[string]any{"executable": nil}
+		}
+		cm.Products = append(cm.Products, &CollectionProduct{
+			Name:    match[2],
+			Type:    typ,
+			Targets: []string{},
+		})
+	}
+
+	return cm
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package swift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCollectionManifest(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		cm := NewCollectionManifest(&Manifest{ToolsVersion: "5.7"}, "fallback")
+		assert.Equal(t, "5.7", cm.ToolsVersion)
+		assert.Equal(t, "fallback", cm.PackageName)
+		assert.Empty(t, cm.Targets)
+		assert.Empty(t, cm.Products)
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		cm := NewCollectionManifest(&Manifest{
+			ToolsVersion: "5.9",
+			Content: `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Gitea",
+    products: [
+        .library(name: "GiteaKit", targets: ["GiteaKit"]),
+        .executable(name: "gitea-cli", targets: ["CLI"]),
+    ],
+    targets: [
+        .target(name: "GiteaKit"),
+        .executableTarget(name: "CLI", dependencies: ["GiteaKit"]),
+        .testTarget(
+            name: "GiteaKitTests"
+        ),
+    ]
+)`,
+		}, "fallback")
+		assert.Equal(t, "Gitea", cm.PackageName)
+		assert.Equal(t, []*CollectionTarget{{Name: "GiteaKit"}, {Name: "CLI"}, {Name: "GiteaKitTests"}}, cm.Targets)
+		assert.Len(t, cm.Products, 2)
+		assert.Equal(t, "GiteaKit", cm.Products[0].Name)
+		assert.Contains(t, cm.Products[0].Type, "library")
+		assert.Equal(t, "gitea-cli", cm.Products[1].Name)
+		assert.Contains(t, cm.Products[1].Type, "executable")
+	})
+}
@@ -49,6 +49,7 @@ type Metadata struct {
 	License       string               `json:"license,omitempty"`
 	Author        Person               `json:"author,omitempty"`
 	Manifests     map[string]*Manifest `json:"manifests,omitempty"`
+	Signer        *Signer              `json:"signer,omitempty"`
 }
 
 // Manifest represents a Package.swift file
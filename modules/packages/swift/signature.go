@@ -0,0 +1,271 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package swift
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+// https://github.com/apple/swift-evolution/blob/main/proposals/0391-package-registry-publish.md#package-signing
+const (
+	SignatureFormatCMS = "cms-1.0.0"
+
+	PropertySignatureFormat        = "swift.signature.format"
+	PropertySourceArchiveSignature = "swift.signature.source_archive"
+	PropertyMetadataSignature      = "swift.signature.metadata"
+)
+
+var (
+	ErrInvalidSignature           = util.NewInvalidArgumentErrorf("signature is invalid")
+	ErrUnsupportedSignatureFormat = util.NewInvalidArgumentErrorf("signature format is not supported")
+	ErrUntrustedSignature         = util.NewInvalidArgumentErrorf("signing certificate is not trusted")
+
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// Signer describes the signer of a package release
+// https://github.com/apple/swift-package-manager/blob/main/Sources/PackageCollectionsModel/Formats/v1.md
+type Signer struct {
+	Type                   string `json:"type"`
+	CommonName             string `json:"commonName"`
+	OrganizationalUnitName string `json:"organizationalUnitName"`
+	OrganizationName       string `json:"organizationName"`
+}
+
+// https://www.rfc-editor.org/rfc/rfc5652
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// VerifySignature verifies a detached CMS signature of the content.
+// The signing certificate must chain up to one of the trusted roots. If roots is nil, the system roots are used.
+func VerifySignature(signature []byte, content io.Reader, roots *x509.CertPool) (*x509.Certificate, error) {
+	var ci cmsContentInfo
+	if rest, err := asn1.Unmarshal(signature, &ci); err != nil || len(rest) > 0 || !ci.ContentType.Equal(oidSignedData) {
+		return nil, ErrInvalidSignature
+	}
+
+	var sd cmsSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if len(sd.SignerInfos) != 1 || len(sd.EncapContentInfo.Content.Bytes) > 0 {
+		// Only a single signer and detached content is supported
+		return nil, ErrInvalidSignature
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil || len(certs) == 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	si := sd.SignerInfos[0]
+
+	cert := findSignerCertificate(si.SID, certs)
+	if cert == nil {
+		return nil, ErrInvalidSignature
+	}
+
+	hash, ok := hashFromOID(si.DigestAlgorithm.Algorithm)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+
+	h := hash.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return nil, err
+	}
+	digest := h.Sum(nil)
+
+	algorithm, ok := signatureAlgorithm(cert.PublicKey, hash)
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+
+	if len(si.SignedAttrs.FullBytes) > 0 {
+		messageDigest, err := getMessageDigest(si.SignedAttrs.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(messageDigest, digest) {
+			return nil, ErrInvalidSignature
+		}
+
+		// The signature is calculated over the DER encoding of the attributes as SET OF
+		signed := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+		if err := cert.CheckSignature(algorithm, signed, si.Signature); err != nil {
+			return nil, ErrInvalidSignature
+		}
+	} else if err := checkDigestSignature(cert.PublicKey, hash, digest, si.Signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, ErrUntrustedSignature
+	}
+
+	return cert, nil
+}
+
+// SignerFromCertificate extracts the signer information from the signing certificate
+func SignerFromCertificate(cert *x509.Certificate) *Signer {
+	s := &Signer{
+		Type:       "ADP",
+		CommonName: cert.Subject.CommonName,
+	}
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		s.OrganizationalUnitName = cert.Subject.OrganizationalUnit[0]
+	}
+	if len(cert.Subject.Organization) > 0 {
+		s.OrganizationName = cert.Subject.Organization[0]
+	}
+	return s
+}
+
+func findSignerCertificate(sid asn1.RawValue, certs []*x509.Certificate) *x509.Certificate {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, cert := range certs {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert
+			}
+		}
+		return nil
+	}
+
+	var ias cmsIssuerAndSerialNumber
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil
+	}
+	for _, cert := range certs {
+		if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return cert
+		}
+	}
+	return nil
+}
+
+func getMessageDigest(attrs []byte) ([]byte, error) {
+	for len(attrs) > 0 {
+		var attr cmsAttribute
+		rest, err := asn1.Unmarshal(attrs, &attr)
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+		attrs = rest
+
+		if attr.Type.Equal(oidMessageDigest) {
+			var digest []byte
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+				return nil, ErrInvalidSignature
+			}
+			return digest, nil
+		}
+	}
+	return nil, ErrInvalidSignature
+}
+
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func signatureAlgorithm(pub crypto.PublicKey, hash crypto.Hash) (x509.SignatureAlgorithm, bool) {
+	switch pub.(type) {
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256, true
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384, true
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512, true
+		}
+	case *rsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return x509.SHA256WithRSA, true
+		case crypto.SHA384:
+			return x509.SHA384WithRSA, true
+		case crypto.SHA512:
+			return x509.SHA512WithRSA, true
+		}
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
+
+func checkDigestSignature(pub crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature)
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package swift
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCertificate(t *testing.T, template, parent *x509.Certificate, pub, signer crypto.PrivateKey) *x509.Certificate {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func createTestCertificates(t *testing.T) (*x509.Certificate, *x509.Certificate, *ecdsa.PrivateKey) {
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	root := createCertificate(t, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test Signer", Organization: []string{"Gitea"}, OrganizationalUnit: []string{"Packages"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, root, &leafKey.PublicKey, rootKey)
+
+	return root, leaf, leafKey
+}
+
+func createCMSSignature(t *testing.T, content []byte, cert *x509.Certificate, key *ecdsa.PrivateKey) []byte {
+	digest := sha256.Sum256(content)
+
+	digestValue, _ := asn1.Marshal(digest[:])
+	attrs, err := asn1.MarshalWithParams([]cmsAttribute{
+		{
+			Type:   oidMessageDigest,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: digestValue},
+		},
+	}, "set")
+	require.NoError(t, err)
+
+	signedDigest := sha256.Sum256(attrs)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, signedDigest[:])
+	require.NoError(t, err)
+
+	sid, _ := asn1.Marshal(cmsIssuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+		SerialNumber: cert.SerialNumber,
+	})
+
+	sd, err := asn1.Marshal(cmsSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: cmsContentInfo{ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert.Raw},
+		SignerInfos: []cmsSignerInfo{
+			{
+				Version:            1,
+				SID:                asn1.RawValue{FullBytes: sid},
+				DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+				SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs[2:]},
+				SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+				Signature:          signature,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	ci, err := asn1.Marshal(cmsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	require.NoError(t, err)
+	return ci
+}
+
+func TestVerifySignature(t *testing.T) {
+	content := []byte("source archive content")
+
+	root, leaf, key := createTestCertificates(t)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	signature := createCMSSignature(t, content, leaf, key)
+
+	t.Run("Valid", func(t *testing.T) {
+		cert, err := VerifySignature(signature, bytes.NewReader(content), roots)
+		require.NoError(t, err)
+		assert.Equal(t, leaf.Raw, cert.Raw)
+
+		signer := SignerFromCertificate(cert)
+		assert.Equal(t, "Test Signer", signer.CommonName)
+		assert.Equal(t, "Gitea", signer.OrganizationName)
+		assert.Equal(t, "Packages", signer.OrganizationalUnitName)
+	})
+
+	t.Run("ModifiedContent", func(t *testing.T) {
+		cert, err := VerifySignature(signature, strings.NewReader("modified"), roots)
+		assert.Nil(t, cert)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Untrusted", func(t *testing.T) {
+		otherRoot, _, _ := createTestCertificates(t)
+		otherRoots := x509.NewCertPool()
+		otherRoots.AddCert(otherRoot)
+
+		cert, err := VerifySignature(signature, bytes.NewReader(content), otherRoots)
+		assert.Nil(t, cert)
+		assert.ErrorIs(t, err, ErrUntrustedSignature)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cert, err := VerifySignature([]byte("invalid"), bytes.NewReader(content), roots)
+		assert.Nil(t, cert)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+}
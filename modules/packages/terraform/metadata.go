@@ -0,0 +1,242 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	PropertyOS   = "terraform.os"
+	PropertyArch = "terraform.arch"
+
+	SettingKeyPrivate = "terraform.key.private"
+	SettingKeyPublic  = "terraform.key.public"
+
+	// ModuleArchiveFilename is the name of the stored module source archive
+	ModuleArchiveFilename = "module.tar.gz"
+
+	// DefaultProtocolVersion is the plugin protocol version assumed for providers uploaded without protocol information
+	DefaultProtocolVersion = "5.0"
+
+	// NamespaceOwner replaces the owner in the registry locations of the service discovery served at the host root.
+	// The package owner is resolved from the namespace of the module or provider address instead.
+	NamespaceOwner = "-"
+
+	maxReadmeSize = 1 << 20
+)
+
+var (
+	ErrInvalidNamespace    = util.NewInvalidArgumentErrorf("namespace is invalid")
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("name is invalid")
+	ErrInvalidSystem       = util.NewInvalidArgumentErrorf("system is invalid")
+	ErrInvalidPlatform     = util.NewInvalidArgumentErrorf("platform is invalid")
+	ErrInvalidProtocol     = util.NewInvalidArgumentErrorf("protocol version is invalid")
+	ErrMissingModuleConfig = util.NewInvalidArgumentErrorf("module archive contains no Terraform configuration files in its root")
+	ErrMissingProviderFile = util.NewInvalidArgumentErrorf("provider archive contains no provider executable")
+
+	// https://developer.hashicorp.com/terraform/internals/module-registry-protocol
+	namePattern     = regexp.MustCompile(`\A[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?\z`)
+	systemPattern   = regexp.MustCompile(`\A[0-9a-z]{1,64}\z`)
+	platformPattern = regexp.MustCompile(`\A[0-9a-z]{1,32}\z`)
+	protocolPattern = regexp.MustCompile(`\A[0-9]+\.[0-9]+\z`)
+)
+
+// ServiceDiscovery returns the locations of the module and provider registry below the base url
+// https://developer.hashicorp.com/terraform/internals/remote-service-discovery
+func ServiceDiscovery(baseURL string) map[string]string {
+	return map[string]string{
+		"modules.v1":   baseURL + "/v1/modules/",
+		"providers.v1": baseURL + "/v1/providers/",
+	}
+}
+
+// ModuleMetadata represents the metadata of a Terraform module version
+type ModuleMetadata struct {
+	Readme     string   `json:"readme,omitempty"`
+	Submodules []string `json:"submodules,omitempty"`
+	Examples   []string `json:"examples,omitempty"`
+}
+
+// ProviderMetadata represents the metadata of a Terraform provider version
+type ProviderMetadata struct {
+	Protocols []string `json:"protocols"`
+}
+
+// IsValidNamespace checks if the namespace of a module or provider address is valid
+func IsValidNamespace(namespace string) bool {
+	return namePattern.MatchString(namespace)
+}
+
+// IsValidName checks if the name of a module or the type of a provider is valid
+func IsValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// IsValidSystem checks if the target system of a module is valid like aws or azurerm
+func IsValidSystem(system string) bool {
+	return systemPattern.MatchString(system)
+}
+
+// IsValidPlatform checks if the operating system or architecture of a provider package is valid
+func IsValidPlatform(value string) bool {
+	return platformPattern.MatchString(value)
+}
+
+// ModulePackageName gets the package name of a module address
+func ModulePackageName(namespace, name, system string) string {
+	return namespace + "/" + name + "/" + system
+}
+
+// ProviderPackageName gets the package name of a provider address
+func ProviderPackageName(namespace, providerType string) string {
+	return namespace + "/" + providerType
+}
+
+// ProviderFilename gets the file name of a provider package for a platform
+func ProviderFilename(providerType, version, os, arch string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", providerType, version, os, arch)
+}
+
+// ShasumsFilename gets the file name of the checksum file of a provider version
+func ShasumsFilename(providerType, version string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", providerType, version)
+}
+
+// ParseProtocols parses a comma separated list of plugin protocol versions like 5.0,6.0
+func ParseProtocols(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return []string{DefaultProtocolVersion}, nil
+	}
+
+	protocols := make([]string, 0, 2)
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if !protocolPattern.MatchString(p) {
+			return nil, ErrInvalidProtocol
+		}
+		protocols = append(protocols, p)
+	}
+	return protocols, nil
+}
+
+// ParseModuleArchive parses a gzipped module source archive and extracts the metadata.
+// The Terraform configuration files must be located in the root of the archive.
+func ParseModuleArchive(r io.Reader) (*ModuleMetadata, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, util.NewInvalidArgumentErrorf("module archive is not gzip compressed: %v", err)
+	}
+	defer gzr.Close()
+
+	m := &ModuleMetadata{}
+
+	hasConfig := false
+	submodules := make(map[string]bool)
+	examples := make(map[string]bool)
+
+	tr := tar.NewReader(gzr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, util.NewInvalidArgumentErrorf("module archive is invalid: %v", err)
+		}
+
+		if hd.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(hd.Name, "./"))
+		dir, filename := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+
+		if dir == "" {
+			if isConfigFile(filename) {
+				hasConfig = true
+			} else if strings.EqualFold(filename, "README.md") {
+				readme, err := io.ReadAll(io.LimitReader(tr, maxReadmeSize))
+				if err != nil {
+					return nil, err
+				}
+				m.Readme = string(readme)
+			}
+			continue
+		}
+
+		if !isConfigFile(filename) {
+			continue
+		}
+
+		// Nested modules are located in modules/<name> and examples in examples/<name>
+		parts := strings.Split(dir, "/")
+		if len(parts) == 2 {
+			switch parts[0] {
+			case "modules":
+				submodules[parts[1]] = true
+			case "examples":
+				examples[parts[1]] = true
+			}
+		}
+	}
+
+	if !hasConfig {
+		return nil, ErrMissingModuleConfig
+	}
+
+	m.Submodules = sortedKeys(submodules)
+	m.Examples = sortedKeys(examples)
+
+	return m, nil
+}
+
+func isConfigFile(filename string) bool {
+	return strings.HasSuffix(filename, ".tf") || strings.HasSuffix(filename, ".tf.json")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateProviderArchive checks if the zip archive contains the executable of the provider.
+// The executable is named terraform-provider-<type> with an optional version suffix and file extension.
+func ValidateProviderArchive(r io.ReaderAt, size int64, providerType string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return util.NewInvalidArgumentErrorf("provider archive is not a zip file: %v", err)
+	}
+
+	prefix := "terraform-provider-" + providerType
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.Contains(f.Name, "/") {
+			continue
+		}
+
+		name := strings.TrimSuffix(f.Name, ".exe")
+		if name == prefix || strings.HasPrefix(name, prefix+"_") {
+			return nil
+		}
+	}
+	return ErrMissingProviderFile
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const readme = "# Module\n\nDescription"
+
+func createModuleArchive(files map[string]string) io.Reader {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for filename, content := range files {
+		hdr := &tar.Header{
+			Name: filename,
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}
+		tw.WriteHeader(hdr)
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	zw.Close()
+	return &buf
+}
+
+func createProviderArchive(files map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for filename, content := range files {
+		w, _ := zw.Create(filename)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestParseModuleArchive(t *testing.T) {
+	t.Run("InvalidArchive", func(t *testing.T) {
+		m, err := ParseModuleArchive(bytes.NewReader([]byte("dummy")))
+		assert.Nil(t, m)
+		assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	})
+
+	t.Run("MissingConfig", func(t *testing.T) {
+		data := createModuleArchive(map[string]string{
+			"README.md":            readme,
+			"sub/main.tf":          "",
+			"modules/nested/x.txt": "",
+		})
+
+		m, err := ParseModuleArchive(data)
+		assert.Nil(t, m)
+		assert.ErrorIs(t, err, ErrMissingModuleConfig)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		data := createModuleArchive(map[string]string{
+			"./main.tf":                   "",
+			"README.md":                   readme,
+			"modules/b/main.tf":           "",
+			"modules/a/variables.tf.json": "{}",
+			"modules/a/README.md":         "",
+			"examples/basic/main.tf":      "",
+		})
+
+		m, err := ParseModuleArchive(data)
+		assert.NoError(t, err)
+		assert.NotNil(t, m)
+		assert.Equal(t, readme, m.Readme)
+		assert.Equal(t, []string{"a", "b"}, m.Submodules)
+		assert.Equal(t, []string{"basic"}, m.Examples)
+	})
+}
+
+func TestValidateProviderArchive(t *testing.T) {
+	cases := []struct {
+		Files    map[string]string
+		IsValid  bool
+		Expected error
+	}{
+		{map[string]string{"terraform-provider-test": ""}, true, nil},
+		{map[string]string{"terraform-provider-test_v1.0.0": ""}, true, nil},
+		{map[string]string{"terraform-provider-test_v1.0.0.exe": ""}, true, nil},
+		{map[string]string{"terraform-provider-other": ""}, false, ErrMissingProviderFile},
+		{map[string]string{"terraform-provider-testing": ""}, false, ErrMissingProviderFile},
+		{map[string]string{"dir/terraform-provider-test": ""}, false, ErrMissingProviderFile},
+	}
+
+	for _, c := range cases {
+		r := createProviderArchive(c.Files)
+		err := ValidateProviderArchive(r, r.Size(), "test")
+		if c.IsValid {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, c.Expected)
+		}
+	}
+
+	err := ValidateProviderArchive(bytes.NewReader([]byte("dummy")), 5, "test")
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+}
+
+func TestParseProtocols(t *testing.T) {
+	protocols, err := ParseProtocols("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{DefaultProtocolVersion}, protocols)
+
+	protocols, err = ParseProtocols("5.0, 6.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5.0", "6.0"}, protocols)
+
+	_, err = ParseProtocols("5")
+	assert.ErrorIs(t, err, ErrInvalidProtocol)
+}
+
+func TestValidation(t *testing.T) {
+	assert.True(t, IsValidNamespace("hashicorp"))
+	assert.True(t, IsValidName("consul-cluster"))
+	assert.False(t, IsValidName("-consul"))
+	assert.False(t, IsValidName("con/sul"))
+	assert.True(t, IsValidSystem("aws"))
+	assert.False(t, IsValidSystem("AWS"))
+	assert.True(t, IsValidPlatform("amd64"))
+	assert.False(t, IsValidPlatform("linux_amd64"))
+}
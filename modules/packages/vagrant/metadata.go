@@ -7,16 +7,34 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"io"
+	"regexp"
 	"strings"
 
 	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/validation"
 )
 
 const (
-	PropertyProvider = "vagrant.provider"
+	PropertyProvider            = "vagrant.provider"
+	PropertyArchitecture        = "vagrant.architecture"
+	PropertyDefaultArchitecture = "vagrant.default_architecture"
+
+	// UnknownArchitecture is used by Vagrant for boxes without architecture information
+	UnknownArchitecture = "unknown"
+)
+
+var (
+	ErrInvalidArchitecture = util.NewInvalidArgumentErrorf("architecture is invalid")
+
+	architecturePattern = regexp.MustCompile(`\A[a-z0-9_]+\z`)
 )
 
+// IsValidArchitecture checks if the architecture name is usable in the box catalog like amd64 or arm64
+func IsValidArchitecture(architecture string) bool {
+	return architecturePattern.MatchString(architecture)
+}
+
 // Metadata represents the metadata of a Vagrant package
 type Metadata struct {
 	Author        string `json:"author,omitempty"`
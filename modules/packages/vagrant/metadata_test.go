@@ -108,3 +108,12 @@ func TestParseInfoFile(t *testing.T) {
 		assert.Equal(t, repositoryURL, metadata.RepositoryURL)
 	})
 }
+
+func TestIsValidArchitecture(t *testing.T) {
+	for _, architecture := range []string{"amd64", "arm64", "i386", "unknown"} {
+		assert.True(t, IsValidArchitecture(architecture))
+	}
+	for _, architecture := range []string{"", "ARM64", "arm 64", "../amd64", "amd64.box"} {
+		assert.False(t, IsValidArchitecture(architecture))
+	}
+}
@@ -57,6 +57,17 @@ func (m *Manager) AddManagedQueue(managed ManagedWorkerPoolQueue) {
 	m.Queues[m.qidCounter] = managed
 }
 
+// RemoveManagedQueue removes a queue which got shut down from the manager
+func (m *Manager) RemoveManagedQueue(managed ManagedWorkerPoolQueue) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for qid, q := range m.Queues {
+		if q == managed {
+			delete(m.Queues, qid)
+		}
+	}
+}
+
 func (m *Manager) GetManagedQueue(qid int64) ManagedWorkerPoolQueue {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -8,6 +8,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
 
 	"github.com/dustin/go-humanize"
 )
@@ -19,38 +23,75 @@ var (
 		Enabled           bool
 		ChunkedUploadPath string
 
-		LimitTotalOwnerCount int64
-		LimitTotalOwnerSize  int64
-		LimitSizeAlpine      int64
-		LimitSizeCargo       int64
-		LimitSizeChef        int64
-		LimitSizeComposer    int64
-		LimitSizeConan       int64
-		LimitSizeConda       int64
-		LimitSizeContainer   int64
-		LimitSizeCran        int64
-		LimitSizeDebian      int64
-		LimitSizeGeneric     int64
-		LimitSizeGo          int64
-		LimitSizeHelm        int64
-		LimitSizeMaven       int64
-		LimitSizeNpm         int64
-		LimitSizeNuGet       int64
-		LimitSizePub         int64
-		LimitSizePyPI        int64
-		LimitSizeRpm         int64
-		LimitSizeRubyGems    int64
-		LimitSizeSwift       int64
-		LimitSizeVagrant     int64
+		LimitTotalOwnerCount       int64
+		LimitTotalOwnerSize        int64
+		LimitSizeAlpine            int64
+		LimitSizeAnsible           int64
+		LimitSizeArch              int64
+		LimitSizeBazel             int64
+		LimitSizeCargo             int64
+		LimitSizeChef              int64
+		LimitSizeCocoaPods         int64
+		LimitSizeComposer          int64
+		LimitSizeConan             int64
+		LimitSizeConda             int64
+		LimitSizeContainer         int64
+		LimitSizeCran              int64
+		LimitSizeDebian            int64
+		LimitSizeGeneric           int64
+		LimitSizeGo                int64
+		LimitSizeGradle            int64
+		LimitSizeHelm              int64
+		LimitSizeHex               int64
+		LimitSizeLFS               int64
+		LimitSizeMaven             int64
+		LimitSizeModel             int64
+		LimitSizeNix               int64
+		LimitSizeNpm               int64
+		LimitSizeNuGet             int64
+		LimitSizePub               int64
+		LimitSizePyPI              int64
+		LimitSizeRpm               int64
+		LimitSizeRubyGems          int64
+		LimitSizeSwift             int64
+		LimitSizeTerraformModule   int64
+		LimitSizeTerraformProvider int64
+		LimitSizeVagrant           int64
 
 		DefaultRPMSignEnabled bool
+
+		BazelActionCacheTTL time.Duration
+		BazelCacheMaxSize   int64
+		GradleCacheMaxSize  int64
+
+		SwiftSigningTrustedRootsPath          string
+		SwiftCollectionSigningKeyPath         string
+		SwiftCollectionSigningCertificatePath string
+
+		ContainerNotificationEndpoints []*ContainerNotificationEndpoint `ini:"-"`
 	}{
 		Enabled:              true,
 		LimitTotalOwnerCount: -1,
+		BazelCacheMaxSize:    -1,
+		GradleCacheMaxSize:   -1,
 	}
 )
 
+// ContainerNotificationEndpoint is an endpoint which receives Docker distribution-format registry events.
+// It is configured in a [packages.container.notifications.<name>] section.
+type ContainerNotificationEndpoint struct {
+	Name              string
+	URL               string
+	Headers           map[string]string
+	Timeout           time.Duration
+	MaxAttempts       int
+	IgnoredMediaTypes []string
+	IgnoredActions    []string
+}
+
 func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
+	Packages.ContainerNotificationEndpoints = loadContainerNotificationEndpointsFrom(rootCfg)
+
 	sec, _ := rootCfg.GetSection("packages")
 	if sec == nil {
 		Packages.Storage, err = getStorage(rootCfg, "packages", "", nil)
@@ -79,8 +120,12 @@ func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
 
 	Packages.LimitTotalOwnerSize = mustBytes(sec, "LIMIT_TOTAL_OWNER_SIZE")
 	Packages.LimitSizeAlpine = mustBytes(sec, "LIMIT_SIZE_ALPINE")
+	Packages.LimitSizeAnsible = mustBytes(sec, "LIMIT_SIZE_ANSIBLE")
+	Packages.LimitSizeArch = mustBytes(sec, "LIMIT_SIZE_ARCH")
+	Packages.LimitSizeBazel = mustBytes(sec, "LIMIT_SIZE_BAZEL")
 	Packages.LimitSizeCargo = mustBytes(sec, "LIMIT_SIZE_CARGO")
 	Packages.LimitSizeChef = mustBytes(sec, "LIMIT_SIZE_CHEF")
+	Packages.LimitSizeCocoaPods = mustBytes(sec, "LIMIT_SIZE_COCOAPODS")
 	Packages.LimitSizeComposer = mustBytes(sec, "LIMIT_SIZE_COMPOSER")
 	Packages.LimitSizeConan = mustBytes(sec, "LIMIT_SIZE_CONAN")
 	Packages.LimitSizeConda = mustBytes(sec, "LIMIT_SIZE_CONDA")
@@ -89,8 +134,13 @@ func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
 	Packages.LimitSizeDebian = mustBytes(sec, "LIMIT_SIZE_DEBIAN")
 	Packages.LimitSizeGeneric = mustBytes(sec, "LIMIT_SIZE_GENERIC")
 	Packages.LimitSizeGo = mustBytes(sec, "LIMIT_SIZE_GO")
+	Packages.LimitSizeGradle = mustBytes(sec, "LIMIT_SIZE_GRADLE")
 	Packages.LimitSizeHelm = mustBytes(sec, "LIMIT_SIZE_HELM")
+	Packages.LimitSizeHex = mustBytes(sec, "LIMIT_SIZE_HEX")
+	Packages.LimitSizeLFS = mustBytes(sec, "LIMIT_SIZE_LFS")
 	Packages.LimitSizeMaven = mustBytes(sec, "LIMIT_SIZE_MAVEN")
+	Packages.LimitSizeModel = mustBytes(sec, "LIMIT_SIZE_MODEL")
+	Packages.LimitSizeNix = mustBytes(sec, "LIMIT_SIZE_NIX")
 	Packages.LimitSizeNpm = mustBytes(sec, "LIMIT_SIZE_NPM")
 	Packages.LimitSizeNuGet = mustBytes(sec, "LIMIT_SIZE_NUGET")
 	Packages.LimitSizePub = mustBytes(sec, "LIMIT_SIZE_PUB")
@@ -98,11 +148,63 @@ func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
 	Packages.LimitSizeRpm = mustBytes(sec, "LIMIT_SIZE_RPM")
 	Packages.LimitSizeRubyGems = mustBytes(sec, "LIMIT_SIZE_RUBYGEMS")
 	Packages.LimitSizeSwift = mustBytes(sec, "LIMIT_SIZE_SWIFT")
+	Packages.LimitSizeTerraformModule = mustBytes(sec, "LIMIT_SIZE_TERRAFORM_MODULE")
+	Packages.LimitSizeTerraformProvider = mustBytes(sec, "LIMIT_SIZE_TERRAFORM_PROVIDER")
 	Packages.LimitSizeVagrant = mustBytes(sec, "LIMIT_SIZE_VAGRANT")
 	Packages.DefaultRPMSignEnabled = sec.Key("DEFAULT_RPM_SIGN_ENABLED").MustBool(false)
+	Packages.BazelActionCacheTTL = sec.Key("BAZEL_ACTION_CACHE_TTL").MustDuration(0)
+	Packages.BazelCacheMaxSize = mustBytes(sec, "BAZEL_CACHE_MAX_SIZE")
+	Packages.GradleCacheMaxSize = mustBytes(sec, "GRADLE_CACHE_MAX_SIZE")
+	Packages.SwiftSigningTrustedRootsPath = mustCustomPath(sec, "SWIFT_SIGNING_TRUSTED_ROOTS")
+	Packages.SwiftCollectionSigningKeyPath = mustCustomPath(sec, "SWIFT_COLLECTION_SIGNING_KEY")
+	Packages.SwiftCollectionSigningCertificatePath = mustCustomPath(sec, "SWIFT_COLLECTION_SIGNING_CERTIFICATE")
 	return nil
 }
 
+func loadContainerNotificationEndpointsFrom(rootCfg ConfigProvider) []*ContainerNotificationEndpoint {
+	var endpoints []*ContainerNotificationEndpoint
+	for _, sec := range rootCfg.Section("packages.container.notifications").ChildSections() {
+		name := strings.TrimPrefix(sec.Name(), "packages.container.notifications.")
+		if !sec.Key("ENABLED").MustBool(true) {
+			continue
+		}
+
+		endpoint := &ContainerNotificationEndpoint{
+			Name:              name,
+			URL:               sec.Key("URL").MustString(""),
+			Headers:           make(map[string]string),
+			Timeout:           sec.Key("TIMEOUT").MustDuration(5 * time.Second),
+			MaxAttempts:       sec.Key("MAX_ATTEMPTS").MustInt(5),
+			IgnoredMediaTypes: sec.Key("IGNORED_MEDIA_TYPES").Strings(","),
+			IgnoredActions:    sec.Key("IGNORED_ACTIONS").Strings(","),
+		}
+		if endpoint.URL == "" {
+			log.Warn("Container notification endpoint %q has no URL and is ignored", name)
+			continue
+		}
+		if endpoint.MaxAttempts < 1 {
+			endpoint.MaxAttempts = 1
+		}
+		for _, key := range sec.Keys() {
+			if header, ok := strings.CutPrefix(key.Name(), "HEADER."); ok && header != "" {
+				endpoint.Headers[header] = key.Value()
+			}
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// mustCustomPath returns the path of the key. Relative paths are resolved against the custom path.
+func mustCustomPath(section ConfigSection, key string) string {
+	value := section.Key(key).MustString("")
+	if value == "" || filepath.IsAbs(value) {
+		return value
+	}
+	return filepath.Join(CustomPath, value)
+}
+
 func mustBytes(section ConfigSection, key string) int64 {
 	const noLimit = "-1"
 
@@ -5,6 +5,7 @@ package setting
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -196,3 +197,36 @@ MINIO_SECRET_ACCESS_KEY = correct_key
 	assert.EqualValues(t, "my_packages/", storage.MinioConfig.BasePath)
 	assert.True(t, storage.MinioConfig.ServeDirect)
 }
+
+func TestLoadContainerNotificationEndpoints(t *testing.T) {
+	iniStr := `
+[packages.container.notifications.listener]
+URL = https://listener.example.com/events
+TIMEOUT = 10s
+MAX_ATTEMPTS = 3
+IGNORED_MEDIA_TYPES = application/octet-stream
+IGNORED_ACTIONS = pull, mount
+HEADER.Authorization = Bearer token
+
+[packages.container.notifications.disabled]
+URL = https://disabled.example.com/events
+ENABLED = false
+
+[packages.container.notifications.no-url]
+TIMEOUT = 1s
+`
+	cfg, err := NewConfigProviderFromData(iniStr)
+	assert.NoError(t, err)
+
+	endpoints := loadContainerNotificationEndpointsFrom(cfg)
+	assert.Len(t, endpoints, 1)
+
+	endpoint := endpoints[0]
+	assert.Equal(t, "listener", endpoint.Name)
+	assert.Equal(t, "https://listener.example.com/events", endpoint.URL)
+	assert.Equal(t, 10*time.Second, endpoint.Timeout)
+	assert.Equal(t, 3, endpoint.MaxAttempts)
+	assert.Equal(t, []string{"application/octet-stream"}, endpoint.IgnoredMediaTypes)
+	assert.Equal(t, []string{"pull", "mount"}, endpoint.IgnoredActions)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token"}, endpoint.Headers)
+}
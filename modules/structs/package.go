@@ -31,3 +31,33 @@ type PackageFile struct {
 	HashSHA256 string `json:"sha256"`
 	HashSHA512 string `json:"sha512"`
 }
+
+// CreateContainerImageIndexOption options for creating a container image index from existing manifests
+type CreateContainerImageIndexOption struct {
+	// tag of the image index
+	// required: true
+	Tag string `json:"tag" binding:"Required"`
+	// tags or digests of the image manifests to include
+	// required: true
+	Manifests []string `json:"manifests" binding:"Required"`
+}
+
+// ContainerImageIndex represents a container image index
+type ContainerImageIndex struct {
+	Image     string                         `json:"image"`
+	Tag       string                         `json:"tag"`
+	Digest    string                         `json:"digest"`
+	MediaType string                         `json:"media_type"`
+	Manifests []*ContainerImageIndexManifest `json:"manifests"`
+}
+
+// ContainerImageIndexManifest represents an image manifest referenced by an image index
+type ContainerImageIndexManifest struct {
+	Digest       string `json:"digest"`
+	MediaType    string `json:"media_type"`
+	Size         int64  `json:"size"`
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	OSVersion    string `json:"os_version,omitempty"`
+}
@@ -62,6 +62,15 @@ func GetRepositoryKey(ctx *context.Context) {
 }
 
 func GetRepositoryFile(ctx *context.Context) {
+	serveRepositoryFile(ctx, alpine_service.IndexArchiveFilename)
+}
+
+// GetADBRepositoryFile serves the index used by apk-tools v3
+func GetADBRepositoryFile(ctx *context.Context) {
+	serveRepositoryFile(ctx, alpine_service.ADBIndexFilename)
+}
+
+func serveRepositoryFile(ctx *context.Context, filename string) {
 	pv, err := alpine_service.GetOrCreateRepositoryVersion(ctx, ctx.Package.Owner.ID)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
@@ -72,7 +81,7 @@ func GetRepositoryFile(ctx *context.Context) {
 		ctx,
 		pv,
 		&packages_service.PackageFileInfo{
-			Filename:     alpine_service.IndexArchiveFilename,
+			Filename:     filename,
 			CompositeKey: fmt.Sprintf("%s|%s|%s", ctx.PathParam("branch"), ctx.PathParam("repository"), ctx.PathParam("architecture")),
 		},
 	)
@@ -0,0 +1,535 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package ansible
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	ansible_module "code.gitea.io/gitea/modules/packages/ansible"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+func apiError(ctx *context.Context, status int, obj any) {
+	type Error struct {
+		Status string `json:"status"`
+		Code   string `json:"code"`
+		Title  string `json:"title"`
+		Detail string `json:"detail,omitempty"`
+	}
+
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.JSON(status, struct {
+			Errors []Error `json:"errors"`
+		}{
+			Errors: []Error{
+				{
+					Status: fmt.Sprint(status),
+					Code:   http.StatusText(status),
+					Title:  http.StatusText(status),
+					Detail: message,
+				},
+			},
+		})
+	})
+}
+
+func baseURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/ansible", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name))
+}
+
+func apiURL(ctx *context.Context) string {
+	return baseURL(ctx) + "/api/v3"
+}
+
+// APIRoot returns the available API versions
+// https://docs.ansible.com/ansible/latest/collections_guide/collections_installing.html#configuring-the-ansible-galaxy-client
+func APIRoot(ctx *context.Context) {
+	ctx.JSON(http.StatusOK, map[string]any{
+		"description":     "GALAXY REST API",
+		"current_version": "v3",
+		"available_versions": map[string]string{
+			"v3": "v3/",
+		},
+	})
+}
+
+type importTask struct {
+	ID         string     `json:"id"`
+	State      string     `json:"state"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	Error      any        `json:"error"`
+	Messages   []struct{} `json:"messages"`
+}
+
+// GetImportTask returns the state of an import task.
+// Collections are imported while uploading, so every existing task is completed.
+func GetImportTask(ctx *context.Context) {
+	pv, err := packages_model.GetVersionByID(ctx, ctx.PathParamInt64("id"))
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	p, err := packages_model.GetPackageByID(ctx, pv.PackageID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if p.OwnerID != ctx.Package.Owner.ID || p.Type != packages_model.TypeAnsible {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &importTask{
+		ID:         fmt.Sprint(pv.ID),
+		State:      "completed",
+		CreatedAt:  pv.CreatedUnix.AsLocalTime(),
+		FinishedAt: pv.CreatedUnix.AsLocalTime(),
+		Messages:   []struct{}{},
+	})
+}
+
+// packageName validates the collection address and gets the package name
+func packageName(ctx *context.Context) (string, bool) {
+	namespace := ctx.PathParam("namespace")
+	name := ctx.PathParam("name")
+
+	if !ansible_module.IsValidName(namespace) {
+		apiError(ctx, http.StatusBadRequest, ansible_module.ErrInvalidNamespace)
+		return "", false
+	}
+	if !ansible_module.IsValidName(name) {
+		apiError(ctx, http.StatusBadRequest, ansible_module.ErrInvalidName)
+		return "", false
+	}
+
+	return ansible_module.PackageName(namespace, name), true
+}
+
+// getSortedVersions gets all versions of the collection sorted descending
+func getSortedVersions(ctx *context.Context) ([]*packages_model.PackageDescriptor, bool) {
+	name, ok := packageName(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeAnsible, name)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return nil, false
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].SemVer.GreaterThan(pds[j].SemVer)
+	})
+
+	return pds, true
+}
+
+func collectionURL(ctx *context.Context, pd *packages_model.PackageDescriptor) string {
+	namespace, name := ansible_module.SplitPackageName(pd.Package.Name)
+	return fmt.Sprintf("%s/collections/%s/%s/", apiURL(ctx), namespace, name)
+}
+
+func versionURL(ctx *context.Context, pd *packages_model.PackageDescriptor) string {
+	return fmt.Sprintf("%sversions/%s/", collectionURL(ctx, pd), url.PathEscape(pd.Version.Version))
+}
+
+type versionReference struct {
+	Href    string `json:"href"`
+	Version string `json:"version"`
+}
+
+type collection struct {
+	Href           string            `json:"href"`
+	Namespace      string            `json:"namespace"`
+	Name           string            `json:"name"`
+	Deprecated     bool              `json:"deprecated"`
+	VersionsURL    string            `json:"versions_url"`
+	HighestVersion *versionReference `json:"highest_version"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// GetCollection returns information about a collection
+func GetCollection(ctx *context.Context) {
+	pds, ok := getSortedVersions(ctx)
+	if !ok {
+		return
+	}
+
+	latest := pds[0]
+	oldest := pds[len(pds)-1]
+	for _, pd := range pds {
+		if pd.Version.CreatedUnix > latest.Version.CreatedUnix {
+			latest = pd
+		}
+		if pd.Version.CreatedUnix < oldest.Version.CreatedUnix {
+			oldest = pd
+		}
+	}
+
+	namespace, name := ansible_module.SplitPackageName(pds[0].Package.Name)
+	href := collectionURL(ctx, pds[0])
+
+	ctx.JSON(http.StatusOK, &collection{
+		Href:        href,
+		Namespace:   namespace,
+		Name:        name,
+		VersionsURL: href + "versions/",
+		HighestVersion: &versionReference{
+			Href:    versionURL(ctx, pds[0]),
+			Version: pds[0].Version.Version,
+		},
+		CreatedAt: oldest.Version.CreatedUnix.AsLocalTime(),
+		UpdatedAt: latest.Version.CreatedUnix.AsLocalTime(),
+	})
+}
+
+type versionList struct {
+	Meta struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+	Links struct {
+		First    string  `json:"first"`
+		Previous *string `json:"previous"`
+		Next     *string `json:"next"`
+		Last     string  `json:"last"`
+	} `json:"links"`
+	Data []*versionListEntry `json:"data"`
+}
+
+type versionListEntry struct {
+	Version   string    `json:"version"`
+	Href      string    `json:"href"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EnumerateCollectionVersions lists the versions of a collection with limit and offset pagination
+func EnumerateCollectionVersions(ctx *context.Context) {
+	pds, ok := getSortedVersions(ctx)
+	if !ok {
+		return
+	}
+
+	limit := ctx.FormInt("limit")
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	offset := max(ctx.FormInt("offset"), 0)
+
+	pageURL := func(offset int) string {
+		return fmt.Sprintf("%sversions/?limit=%d&offset=%d", collectionURL(ctx, pds[0]), limit, offset)
+	}
+
+	result := &versionList{}
+	result.Meta.Count = len(pds)
+	result.Links.First = pageURL(0)
+	result.Links.Last = pageURL(max((len(pds)-1)/limit*limit, 0))
+	if offset > 0 {
+		previous := pageURL(max(offset-limit, 0))
+		result.Links.Previous = &previous
+	}
+	if offset+limit < len(pds) {
+		next := pageURL(offset + limit)
+		result.Links.Next = &next
+	}
+
+	result.Data = make([]*versionListEntry, 0, limit)
+	for i := offset; i < len(pds) && i < offset+limit; i++ {
+		result.Data = append(result.Data, &versionListEntry{
+			Version:   pds[i].Version.Version,
+			Href:      versionURL(ctx, pds[i]),
+			CreatedAt: pds[i].Version.CreatedUnix.AsLocalTime(),
+			UpdatedAt: pds[i].Version.CreatedUnix.AsLocalTime(),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type collectionVersion struct {
+	Version     string    `json:"version"`
+	Href        string    `json:"href"`
+	Name        string    `json:"name"`
+	DownloadURL string    `json:"download_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Namespace   struct {
+		Name string `json:"name"`
+	} `json:"namespace"`
+	Collection struct {
+		Href string `json:"href"`
+		Name string `json:"name"`
+	} `json:"collection"`
+	Artifact struct {
+		Filename string `json:"filename"`
+		Sha256   string `json:"sha256"`
+		Size     int64  `json:"size"`
+	} `json:"artifact"`
+	Metadata   *collectionVersionMetadata `json:"metadata"`
+	Signatures []struct{}                 `json:"signatures"`
+}
+
+// https://github.com/ansible/ansible/blob/devel/lib/ansible/galaxy/api.py
+type collectionVersionMetadata struct {
+	Authors       []string          `json:"authors"`
+	Description   string            `json:"description"`
+	License       []string          `json:"license"`
+	Tags          []string          `json:"tags"`
+	Dependencies  map[string]string `json:"dependencies"`
+	Repository    string            `json:"repository"`
+	Documentation string            `json:"documentation"`
+	Homepage      string            `json:"homepage"`
+	Issues        string            `json:"issues"`
+}
+
+// GetCollectionVersion returns information about a collection version including its dependencies
+func GetCollectionVersion(ctx *context.Context) {
+	name, ok := packageName(ctx)
+	if !ok {
+		return
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeAnsible, name, ctx.PathParam("version"))
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pd.Files) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+
+	namespace, collectionName := ansible_module.SplitPackageName(pd.Package.Name)
+	pfd := pd.Files[0]
+	metadata := pd.Metadata.(*ansible_module.Metadata)
+
+	dependencies := metadata.Dependencies
+	if dependencies == nil {
+		dependencies = map[string]string{}
+	}
+
+	cv := &collectionVersion{
+		Version:     pd.Version.Version,
+		Href:        versionURL(ctx, pd),
+		Name:        collectionName,
+		DownloadURL: fmt.Sprintf("%s/download/%s", baseURL(ctx), url.PathEscape(pfd.File.Name)),
+		CreatedAt:   pd.Version.CreatedUnix.AsLocalTime(),
+		UpdatedAt:   pd.Version.CreatedUnix.AsLocalTime(),
+		Metadata: &collectionVersionMetadata{
+			Authors:       metadata.Authors,
+			Description:   metadata.Description,
+			License:       metadata.License,
+			Tags:          metadata.Tags,
+			Dependencies:  dependencies,
+			Repository:    metadata.RepositoryURL,
+			Documentation: metadata.Documentation,
+			Homepage:      metadata.ProjectURL,
+			Issues:        metadata.IssuesURL,
+		},
+		Signatures: []struct{}{},
+	}
+	cv.Namespace.Name = namespace
+	cv.Collection.Href = collectionURL(ctx, pd)
+	cv.Collection.Name = collectionName
+	cv.Artifact.Filename = pfd.File.Name
+	cv.Artifact.Sha256 = pfd.Blob.HashSHA256
+	cv.Artifact.Size = pfd.Blob.Size
+
+	ctx.JSON(http.StatusOK, cv)
+}
+
+// DownloadCollection serves the collection artifact
+func DownloadCollection(ctx *context.Context) {
+	filename := ctx.PathParam("filename")
+
+	namespace, name, version, ok := ansible_module.ParseFilename(filename)
+	if !ok {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+
+	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeAnsible,
+			Name:        ansible_module.PackageName(namespace, name),
+			Version:     version,
+		},
+		&packages_service.PackageFileInfo{
+			Filename: filename,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// UploadCollection imports a collection artifact and returns the import task
+// https://docs.ansible.com/ansible/latest/dev_guide/developing_collections_distributing.html#publishing-your-collection
+func UploadCollection(ctx *context.Context) {
+	file, fileHeader, err := ctx.Req.FormFile("file")
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	buf, err := packages_module.CreateHashedBufferFromReader(file)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if sha256 := ctx.Req.FormValue("sha256"); sha256 != "" {
+		_, _, hashSHA256, _ := buf.Sums()
+		if !strings.EqualFold(sha256, hex.EncodeToString(hashSHA256)) {
+			apiError(ctx, http.StatusBadRequest, "hash mismatch")
+			return
+		}
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	p, err := ansible_module.ParsePackage(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	filename := ansible_module.Filename(p.Namespace, p.Name, p.Version)
+	if fileHeader.Filename != filename {
+		apiError(ctx, http.StatusBadRequest, fmt.Sprintf("collection artifact must be named %s", filename))
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pv, _, err := packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeAnsible,
+				Name:        ansible_module.PackageName(p.Namespace, p.Name),
+				Version:     p.Version,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata:         p.Metadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: filename,
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, map[string]string{
+		"task": fmt.Sprintf("%s/imports/collections/%d/", apiURL(ctx), pv.ID),
+	})
+}
+
+// DeleteCollectionVersion deletes a collection version
+func DeleteCollectionVersion(ctx *context.Context) {
+	name, ok := packageName(ctx)
+	if !ok {
+		return
+	}
+
+	err := packages_service.RemovePackageVersionByNameAndVersion(
+		ctx,
+		ctx.Doer,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeAnsible,
+			Name:        name,
+			Version:     ctx.PathParam("version"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -11,11 +11,17 @@ import (
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/perm"
 	"code.gitea.io/gitea/modules/log"
+	huggingface_module "code.gitea.io/gitea/modules/packages/huggingface"
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/packages/alpine"
+	"code.gitea.io/gitea/routers/api/packages/ansible"
+	"code.gitea.io/gitea/routers/api/packages/arch"
+	"code.gitea.io/gitea/routers/api/packages/bazel"
 	"code.gitea.io/gitea/routers/api/packages/cargo"
 	"code.gitea.io/gitea/routers/api/packages/chef"
+	"code.gitea.io/gitea/routers/api/packages/cocoapods"
 	"code.gitea.io/gitea/routers/api/packages/composer"
 	"code.gitea.io/gitea/routers/api/packages/conan"
 	"code.gitea.io/gitea/routers/api/packages/conda"
@@ -24,8 +30,13 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/debian"
 	"code.gitea.io/gitea/routers/api/packages/generic"
 	"code.gitea.io/gitea/routers/api/packages/goproxy"
+	"code.gitea.io/gitea/routers/api/packages/gradle"
 	"code.gitea.io/gitea/routers/api/packages/helm"
+	"code.gitea.io/gitea/routers/api/packages/hex"
+	"code.gitea.io/gitea/routers/api/packages/huggingface"
+	"code.gitea.io/gitea/routers/api/packages/lfs"
 	"code.gitea.io/gitea/routers/api/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/nix"
 	"code.gitea.io/gitea/routers/api/packages/npm"
 	"code.gitea.io/gitea/routers/api/packages/nuget"
 	"code.gitea.io/gitea/routers/api/packages/pub"
@@ -33,6 +44,7 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/rpm"
 	"code.gitea.io/gitea/routers/api/packages/rubygems"
 	"code.gitea.io/gitea/routers/api/packages/swift"
+	"code.gitea.io/gitea/routers/api/packages/terraform"
 	"code.gitea.io/gitea/routers/api/packages/vagrant"
 	"code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
@@ -140,6 +152,7 @@ func CommonRoutes() *web.Router {
 		&nuget.Auth{},
 		&conan.Auth{},
 		&chef.Auth{},
+		&hex.Auth{},
 	})
 
 	for _, repo := range defaultRepositories {
@@ -148,16 +161,30 @@ func CommonRoutes() *web.Router {
 			addGenericRoutes(repo.Name, r)
 		case "helm":
 			addHelmRoutes(repo.Name, r)
+		case "hex":
+			addHexRoutes(repo.Name, r)
+		case "lfs":
+			addLFSRoutes(repo.Name, r)
 		case "maven":
 			addMavenRoutes(repo.Name, r)
+		case "model":
+			addModelRoutes(repo.Name, r)
 		case "pypi":
 			addPyPiRoutes(repo.Name, r)
 		case "alpine":
 			addAlpineRoutes(repo.Name, r)
+		case "ansible":
+			addAnsibleRoutes(repo.Name, r)
+		case "arch":
+			addArchRoutes(repo.Name, r)
+		case "bazel":
+			addBazelRoutes(repo.Name, r)
 		case "cargo":
 			addRustRoutes(repo.Name, r)
 		case "chef":
 			addChefRoutes(repo.Name, r)
+		case "cocoapods":
+			addCocoaPodsRoutes(repo.Name, r)
 		case "composer":
 			addComposerRoutes(repo.Name, r)
 		case "conan":
@@ -166,6 +193,8 @@ func CommonRoutes() *web.Router {
 			addCranRoutes(repo.Name, r)
 		case "debian":
 			addDebianRoutes(repo.Name, r)
+		case "nix":
+			addNixRoutes(repo.Name, r)
 		case "npm":
 			addNpmRoutes(repo.Name, r)
 		case "nuget":
@@ -180,8 +209,12 @@ func CommonRoutes() *web.Router {
 			addSwiftRoutes(repo.Name, r)
 		case "vagrant":
 			addVagrantRoutes(repo.Name, r)
+		case "terraform":
+			addTerraformRoutes(repo.Name, r)
 		case "go":
 			addGoRoutes(repo.Name, r)
+		case "gradle":
+			addGradleRoutes(repo.Name, r)
 		case "conda":
 			addCondaRoutes(repo.Name, r)
 		}
@@ -368,6 +401,42 @@ func addHelmRoutes(repoName string, r *web.Router) {
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
+func addHexRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Get("/names", hex.EnumeratePackageNames)
+		r.Get("/versions", hex.EnumeratePackageVersions)
+		r.Get("/packages/{name}", hex.PackageReleases)
+		r.Get("/tarballs/{filename}", hex.DownloadTarball)
+		r.Get("/public_key", hex.GetPublicKey)
+		r.Group("/api", func() {
+			r.Post("/publish", reqPackageAccess(perm.AccessModeWrite), hex.UploadPackage)
+			r.Group("/packages/{name}", func() {
+				r.Get("", hex.PackageInfo)
+				r.Delete("/releases/{version}", reqPackageAccess(perm.AccessModeWrite), hex.DeleteRelease)
+			})
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
+func addLFSRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Group("/{project}", func() {
+			r.Post("/objects/batch", lfs.Batch(reqPackageAccess(perm.AccessModeWrite)))
+			r.Group("/objects/{oid}", func() {
+				r.Get("", lfs.DownloadObject)
+				r.Put("", reqPackageAccess(perm.AccessModeWrite), lfs.UploadObject)
+			})
+			r.Post("/verify", reqPackageAccess(perm.AccessModeWrite), lfs.VerifyObject)
+			r.Group("/locks", func() {
+				r.Get("", lfs.ListLocks)
+				r.Post("", reqPackageAccess(perm.AccessModeWrite), lfs.CreateLock)
+				r.Post("/verify", reqPackageAccess(perm.AccessModeWrite), lfs.VerifyLocks)
+				r.Post("/{id}/unlock", reqPackageAccess(perm.AccessModeWrite), lfs.Unlock)
+			})
+		}, lfs.CheckProject)
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
 func addGenericRoutes(repoName string, r *web.Router) {
 	r.Group("/repository/"+repoName, func() {
 		r.Group("/{filename}", func() {
@@ -387,6 +456,7 @@ func addAlpineRoutes(repoName string, r *web.Router) {
 			r.Put("", reqPackageAccess(perm.AccessModeWrite), alpine.UploadPackageFile)
 			r.Group("/{architecture}", func() {
 				r.Get("/APKINDEX.tar.gz", alpine.GetRepositoryFile)
+				r.Get("/Packages.adb", alpine.GetADBRepositoryFile)
 				r.Group("/{filename}", func() {
 					r.Get("", alpine.DownloadPackageFile)
 					r.Delete("", reqPackageAccess(perm.AccessModeWrite), alpine.DeletePackageFile)
@@ -396,6 +466,54 @@ func addAlpineRoutes(repoName string, r *web.Router) {
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
+func addAnsibleRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Get("/download/{filename}", ansible.DownloadCollection)
+		r.Group("/api", func() {
+			r.Get("", ansible.APIRoot)
+			r.Group("/v3", func() {
+				r.Post("/artifacts/collections", reqPackageAccess(perm.AccessModeWrite), ansible.UploadCollection)
+				r.Get("/imports/collections/{id}", ansible.GetImportTask)
+				r.Group("/collections/{namespace}/{name}", func() {
+					r.Get("", ansible.GetCollection)
+					r.Get("/versions", ansible.EnumerateCollectionVersions)
+					r.Get("/versions/{version}", ansible.GetCollectionVersion)
+					r.Delete("/versions/{version}", reqPackageAccess(perm.AccessModeWrite), ansible.DeleteCollectionVersion)
+				})
+			})
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
+func addArchRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Get("/repository.key", arch.GetRepositoryKey)
+		r.Group("/{repository}", func() {
+			r.Put("", reqPackageAccess(perm.AccessModeWrite), arch.UploadPackageFile)
+			r.Group("/{architecture}/{filename}", func() {
+				r.Get("", arch.GetPackageOrRepositoryFile)
+				r.Put("", reqPackageAccess(perm.AccessModeWrite), arch.UploadPackageSignature)
+				r.Delete("", reqPackageAccess(perm.AccessModeWrite), arch.DeletePackageFile)
+			})
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
+func addBazelRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Group("/cas/{digest}", func() {
+			r.Head("", bazel.DownloadCASEntry)
+			r.Get("", bazel.DownloadCASEntry)
+			r.Put("", reqPackageAccess(perm.AccessModeWrite), bazel.UploadCASEntry)
+		})
+		r.Group("/ac/{digest}", func() {
+			r.Head("", bazel.DownloadACEntry)
+			r.Get("", bazel.DownloadACEntry)
+			r.Put("", reqPackageAccess(perm.AccessModeWrite), bazel.UploadACEntry)
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
 func addRustRoutes(repoName string, r *web.Router) {
 	r.Group("/repository/"+repoName, func() {
 		r.Group("/api/v1/crates", func() {
@@ -431,9 +549,11 @@ func addChefRoutes(repoName string, r *web.Router) {
 					r.Get("", chef.PackageMetadata)
 					r.Group("/versions/{version}", func() {
 						r.Get("", chef.PackageVersionMetadata)
+						r.Put("", reqPackageAccess(perm.AccessModeWrite), chef.UpdatePackageVersion)
 						r.Delete("", reqPackageAccess(perm.AccessModeWrite), chef.DeletePackageVersion)
 						r.Get("/download", chef.DownloadPackage)
 					})
+					r.Put("", reqPackageAccess(perm.AccessModeWrite), chef.UpdatePackage)
 					r.Delete("", reqPackageAccess(perm.AccessModeWrite), chef.DeletePackage)
 				})
 			})
@@ -441,6 +561,21 @@ func addChefRoutes(repoName string, r *web.Router) {
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
+func addCocoaPodsRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Put("", reqPackageAccess(perm.AccessModeWrite), cocoapods.UploadPackage)
+		r.Get("/CocoaPods-version.yml", cocoapods.GetVersionFile)
+		r.Get("/all_pods.txt", cocoapods.EnumeratePods)
+		r.Get("/all_pods_versions_{x}_{y}_{z}.txt", cocoapods.EnumeratePodVersions)
+		r.Get("/deprecated_podspecs.txt", cocoapods.EnumerateDeprecatedPodspecs)
+		r.Get("/Specs/{x}/{y}/{z}/{name}/{version}/{filename}", cocoapods.GetPodspec)
+		r.Group("/Pods/{name}/{version}", func() {
+			r.Delete("", reqPackageAccess(perm.AccessModeWrite), cocoapods.DeletePackageVersion)
+			r.Get("/{filename}", cocoapods.DownloadSourceArchive)
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
 func addComposerRoutes(repoName string, r *web.Router) {
 	r.Group("/repository/"+repoName, func() {
 		r.Get("/packages.json", composer.ServiceIndex)
@@ -503,12 +638,14 @@ func addConanRoutes(repoName string, r *web.Router) {
 				r.Group("/{name}/{version}/{user}/{channel}", func() {
 					r.Delete("", reqPackageAccess(perm.AccessModeWrite), conan.DeleteRecipeV2)
 					r.Get("/search", conan.SearchPackagesV2)
+					r.Get("/list", conan.ListPackages)
 					r.Get("/latest", conan.LatestRecipeRevision)
 					r.Group("/revisions", func() {
 						r.Get("", conan.ListRecipeRevisions)
 						r.Group("/{recipe_revision}", func() {
 							r.Delete("", reqPackageAccess(perm.AccessModeWrite), conan.DeleteRecipeV2)
 							r.Get("/search", conan.SearchPackagesV2)
+							r.Get("/list", conan.ListPackages)
 							r.Group("/files", func() {
 								r.Get("", conan.ListRecipeRevisionFiles)
 								r.Group("/{filename}", func() {
@@ -547,11 +684,21 @@ func addConanRoutes(repoName string, r *web.Router) {
 func addCondaRoutes(repoName string, r *web.Router) {
 	r.Group("/repository/"+repoName, func() {
 		var (
-			downloadPattern = regexp.MustCompile(`\A(.+/)?(.+)/((?:[^/]+(?:\.tar\.bz2|\.conda))|(?:current_)?repodata\.json(?:\.bz2)?)\z`)
-			uploadPattern   = regexp.MustCompile(`\A(.+/)?([^/]+(?:\.tar\.bz2|\.conda))\z`)
+			downloadPattern    = regexp.MustCompile(`\A(.+/)?(.+)/((?:[^/]+(?:\.tar\.bz2|\.conda))|(?:current_)?repodata\.json(?:\.bz2)?|run_exports\.json(?:\.bz2)?|patch_instructions\.json)\z`)
+			channelDataPattern = regexp.MustCompile(`\A(.+/)?(channeldata\.json)\z`)
+			uploadPattern      = regexp.MustCompile(`\A(.+/)?([^/]+(?:\.tar\.bz2|\.conda))\z`)
+			patchPattern       = regexp.MustCompile(`\A(.+/)?(.+)/patch_instructions\.json\z`)
 		)
 
 		r.Get("/*", func(ctx *context.Context) {
+			if m := channelDataPattern.FindStringSubmatch(ctx.PathParam("*")); len(m) != 0 {
+				ctx.SetPathParam("channel", strings.TrimSuffix(m[1], "/"))
+				ctx.SetPathParam("filename", m[2])
+
+				conda.ChannelData(ctx)
+				return
+			}
+
 			m := downloadPattern.FindStringSubmatch(ctx.PathParam("*"))
 			if len(m) == 0 {
 				ctx.Status(http.StatusNotFound)
@@ -565,11 +712,23 @@ func addCondaRoutes(repoName string, r *web.Router) {
 			switch m[3] {
 			case "repodata.json", "repodata.json.bz2", "current_repodata.json", "current_repodata.json.bz2":
 				conda.EnumeratePackages(ctx)
+			case "run_exports.json", "run_exports.json.bz2":
+				conda.EnumerateRunExports(ctx)
+			case "patch_instructions.json":
+				conda.GetPatchInstructions(ctx)
 			default:
 				conda.DownloadPackageFile(ctx)
 			}
 		})
 		r.Put("/*", reqPackageAccess(perm.AccessModeWrite), func(ctx *context.Context) {
+			if m := patchPattern.FindStringSubmatch(ctx.PathParam("*")); len(m) != 0 {
+				ctx.SetPathParam("channel", strings.TrimSuffix(m[1], "/"))
+				ctx.SetPathParam("architecture", m[2])
+
+				conda.UploadPatchInstructions(ctx)
+				return
+			}
+
 			m := uploadPattern.FindStringSubmatch(ctx.PathParam("*"))
 			if len(m) == 0 {
 				ctx.Status(http.StatusNotFound)
@@ -581,6 +740,18 @@ func addCondaRoutes(repoName string, r *web.Router) {
 
 			conda.UploadPackageFile(ctx)
 		})
+		r.Delete("/*", reqPackageAccess(perm.AccessModeWrite), func(ctx *context.Context) {
+			m := patchPattern.FindStringSubmatch(ctx.PathParam("*"))
+			if len(m) == 0 {
+				ctx.Status(http.StatusNotFound)
+				return
+			}
+
+			ctx.SetPathParam("channel", strings.TrimSuffix(m[1], "/"))
+			ctx.SetPathParam("architecture", m[2])
+
+			conda.DeletePatchInstructions(ctx)
+		})
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
@@ -590,16 +761,21 @@ func addCranRoutes(repoName string, r *web.Router) {
 			r.Group("/contrib", func() {
 				r.Get("/PACKAGES", cran.EnumerateSourcePackages)
 				r.Get("/PACKAGES{format}", cran.EnumerateSourcePackages)
+				r.Get("/Meta/archive.rds", cran.EnumerateArchivedSourcePackages)
+				r.Get("/Archive/{packagename}/{filename}", cran.DownloadArchivedSourcePackageFile)
 				r.Get("/{filename}", cran.DownloadSourcePackageFile)
 			})
 			r.Put("", reqPackageAccess(perm.AccessModeWrite), cran.UploadSourcePackageFile)
 		})
 		r.Group("/bin", func() {
-			r.Group("/{platform}/contrib/{rversion}", func() {
+			binaryRoutes := func() {
 				r.Get("/PACKAGES", cran.EnumerateBinaryPackages)
 				r.Get("/PACKAGES{format}", cran.EnumerateBinaryPackages)
 				r.Get("/{filename}", cran.DownloadBinaryPackageFile)
-			})
+			}
+			r.Group("/{platform}/contrib/{rversion}", binaryRoutes)
+			// macOS binaries are located in a subdirectory per flavor like macosx/big-sur-arm64
+			r.Group("/{platform}/{flavor}/contrib/{rversion}", binaryRoutes)
 			r.Put("", reqPackageAccess(perm.AccessModeWrite), cran.UploadBinaryPackageFile)
 		})
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
@@ -617,10 +793,54 @@ func addVagrantRoutes(repoName string, r *web.Router) {
 				r.Get("", vagrant.DownloadPackageFile)
 				r.Put("", reqPackageAccess(perm.AccessModeWrite), vagrant.UploadPackageFile)
 			})
+			r.Group("/{version}/{architecture}/{provider}", func() {
+				r.Get("", vagrant.DownloadPackageFile)
+				r.Put("", reqPackageAccess(perm.AccessModeWrite), vagrant.UploadPackageFile)
+			})
 		})
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
+func addTerraformRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Get("/.well-known/terraform.json", terraform.ServiceDiscovery)
+		addTerraformRegistryRoutes(r)
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+	// The service discovery at the host root can't know the owner and uses these routes instead.
+	// The owner is resolved from the namespace of the module or provider address.
+	r.Group("/"+terraform_module.NamespaceOwner+"/"+repoName, func() {
+		addTerraformRegistryRoutes(r, terraform.NamespaceOwnerAssignment, context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+	})
+}
+
+func addTerraformRegistryRoutes(r *web.Router, middlewares ...any) {
+	r.Group("/v1/modules/{namespace}/{name}/{system}", func() {
+		r.Get("/versions", terraform.EnumerateModuleVersions)
+		r.Get("/download", terraform.DownloadLatestModule)
+		r.Group("/{version}", func() {
+			r.Get("/download", terraform.GetModuleDownloadLocation)
+			r.Get("/module.tar.gz", terraform.DownloadModuleArchive)
+			r.Group("", func() {
+				r.Put("", terraform.UploadModule)
+				r.Delete("", terraform.DeleteModule)
+			}, reqPackageAccess(perm.AccessModeWrite))
+		})
+	}, middlewares...)
+	r.Group("/v1/providers/{namespace}/{type}", func() {
+		r.Get("/versions", terraform.EnumerateProviderVersions)
+		r.Group("/{version}", func() {
+			r.Get("/download/{os}/{arch}", terraform.GetProviderPackage)
+			r.Get("/files/{filename}", terraform.DownloadProviderPackage)
+			r.Get("/SHA256SUMS", terraform.DownloadProviderShasums)
+			r.Get("/SHA256SUMS.sig", terraform.DownloadProviderShasumsSignature)
+			r.Group("", func() {
+				r.Put("/{os}/{arch}", terraform.UploadProviderPackage)
+				r.Delete("", terraform.DeleteProvider)
+			}, reqPackageAccess(perm.AccessModeWrite))
+		})
+	}, middlewares...)
+}
+
 func addDebianRoutes(repoName string, r *web.Router) {
 	r.Group("/repository/"+repoName, func() {
 		r.Get("/repository.key", debian.GetRepositoryKey)
@@ -634,11 +854,23 @@ func addDebianRoutes(repoName string, r *web.Router) {
 		})
 		r.Group("/pool/{distribution}/{component}", func() {
 			r.Get("/{name}_{version}_{architecture}.deb", debian.DownloadPackageFile)
+			r.Get("/source/{filename}", debian.DownloadSourceFile)
 			r.Group("", func() {
 				r.Put("/upload", debian.UploadPackageFile)
+				r.Put("/upload/{filename}", debian.UploadPackageFileByName)
 				r.Delete("/{name}/{version}/{architecture}", debian.DeletePackageFile)
 			}, reqPackageAccess(perm.AccessModeWrite))
 		})
+		r.Group("/snapshots", func() {
+			r.Get("", debian.ListSnapshots)
+			r.Get("/{snapshot}/pool/{component}/{filename}", debian.DownloadSnapshotFile)
+			r.Group("/{snapshot}", func() {
+				r.Put("", debian.CreateSnapshot)
+				r.Delete("", debian.DeleteSnapshot)
+				r.Put("/publish/{distribution}", debian.PublishSnapshot)
+				r.Delete("/publish/{distribution}", debian.UnpublishSnapshot)
+			}, reqPackageAccess(perm.AccessModeWrite))
+		})
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
@@ -676,6 +908,7 @@ func addSwiftRoutes(repoName string, r *web.Router) {
 			})
 		})
 		r.Get("/identifiers", swift.CheckAcceptMediaType(swift.AcceptJSON), swift.LookupPackageIdentifiers)
+		r.Get("/collections/{scope}", swift.PackageCollection)
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
@@ -695,6 +928,59 @@ func addMavenRoutes(repoName string, r *web.Router) {
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
 
+func addModelRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		// The client uploads large files without credentials, the upload links are signed instead
+		r.Put("/{namespace}/{name}/info/lfs/objects/{oid}", huggingface.CheckRepository(huggingface_module.RepoTypeModel), huggingface.UploadObject)
+		r.Put("/datasets/{namespace}/{name}/info/lfs/objects/{oid}", huggingface.CheckRepository(huggingface_module.RepoTypeDataset), huggingface.UploadObject)
+		r.Group("", func() {
+			r.Post("/api/repos/create", reqPackageAccess(perm.AccessModeWrite), huggingface.CreateRepository)
+			r.Group("/api/models/{namespace}/{name}", func() {
+				r.Get("", huggingface.ModelInfo)
+				r.Get("/revision/{revision}", huggingface.ModelInfo)
+				r.Post("/preupload/{revision}", reqPackageAccess(perm.AccessModeWrite), huggingface.Preupload)
+				r.Post("/commit/{revision}", reqPackageAccess(perm.AccessModeWrite), huggingface.Commit)
+			}, huggingface.CheckRepository(huggingface_module.RepoTypeModel))
+			r.Group("/api/datasets/{namespace}/{name}", func() {
+				r.Get("", huggingface.DatasetInfo)
+				r.Get("/revision/{revision}", huggingface.DatasetInfo)
+				r.Post("/preupload/{revision}", reqPackageAccess(perm.AccessModeWrite), huggingface.Preupload)
+				r.Post("/commit/{revision}", reqPackageAccess(perm.AccessModeWrite), huggingface.Commit)
+			}, huggingface.CheckRepository(huggingface_module.RepoTypeDataset))
+
+			repositoryRoutes := func() {
+				r.Group("/resolve/{revision}/*", func() {
+					r.Head("", huggingface.ResolveFile)
+					r.Get("", huggingface.ResolveFile)
+				})
+				r.Group("/info/lfs", func() {
+					r.Post("/objects/batch", huggingface.Batch)
+					r.Post("/verify", huggingface.VerifyObject)
+				}, reqPackageAccess(perm.AccessModeWrite))
+			}
+			r.Group("/{namespace}/{name}", repositoryRoutes, huggingface.CheckRepository(huggingface_module.RepoTypeModel))
+			r.Group("/datasets/{namespace}/{name}", repositoryRoutes, huggingface.CheckRepository(huggingface_module.RepoTypeDataset))
+		}, reqPackageAccess(perm.AccessModeRead))
+	}, context.UserAssignmentWeb(), context.PackageAssignment())
+}
+
+func addNixRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Get("/nix-cache-info", nix.CacheInfo)
+		r.Get("/repository.key", nix.GetRepositoryKey)
+		r.Group("/nar/{filename}", func() {
+			r.Head("", nix.DownloadNar)
+			r.Get("", nix.DownloadNar)
+			r.Put("", reqPackageAccess(perm.AccessModeWrite), nix.UploadNar)
+		})
+		r.Group("/{filename}", func() {
+			r.Head("", nix.GetNarInfo)
+			r.Get("", nix.GetNarInfo)
+			r.Put("", reqPackageAccess(perm.AccessModeWrite), nix.UploadNarInfo)
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
+
 func addNpmRoutes(repoName string, r *web.Router) {
 	r.Group("/repository/"+repoName, func() {
 		r.Group("/@{scope}/{id}", func() {
@@ -753,8 +1039,15 @@ func addPubRoutes(repoName string, r *web.Router) {
 			}, reqPackageAccess(perm.AccessModeWrite))
 			r.Group("/{id}", func() {
 				r.Get("", pub.EnumeratePackageVersions)
+				r.Get("/advisories", pub.PackageAdvisories)
 				r.Get("/files/{version}", pub.DownloadPackageFile)
 				r.Get("/{version}", pub.PackageVersionMetadata)
+				r.Group("", func() {
+					r.Put("/options", pub.SetPackageOptions)
+					r.Put("/versions/{version}/options", pub.SetVersionOptions)
+					r.Put("/advisories", pub.UploadAdvisory)
+					r.Delete("/advisories/{advisory}", pub.DeleteAdvisory)
+				}, reqPackageAccess(perm.AccessModeWrite))
 			})
 		})
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
@@ -812,6 +1105,8 @@ func addRpmRoutes(repoName string, r *web.Router) {
 			uploadPattern   = regexp.MustCompile(`\A(.*?)/upload\z`)
 			filePattern     = regexp.MustCompile(`\A(.*?)/package/([^/]+)/([^/]+)/([^/]+)(?:/([^/]+\.rpm)|)\z`)
 			repoFilePattern = regexp.MustCompile(`\A(.*?)/repodata/([^/]+)\z`)
+			advisoryPattern = regexp.MustCompile(`\A(.*?)/advisories(?:/([^/]+)|)\z`)
+			modulesPattern  = regexp.MustCompile(`\A(.*?)/modules\z`)
 		)
 
 		r.Methods("HEAD,GET,PUT,DELETE", "*", func(ctx *context.Context) {
@@ -851,6 +1146,51 @@ func addRpmRoutes(repoName string, r *web.Router) {
 				return
 			}
 
+			m = advisoryPattern.FindStringSubmatch(path)
+			if len(m) == 3 {
+				ctx.SetPathParam("group", strings.Trim(m[1], "/"))
+				ctx.SetPathParam("id", m[2])
+				if !isGetHead {
+					reqPackageAccess(perm.AccessModeWrite)(ctx)
+					if ctx.Written() {
+						return
+					}
+				}
+				switch {
+				case isGetHead && m[2] == "":
+					rpm.ListAdvisories(ctx)
+				case isGetHead:
+					rpm.GetAdvisory(ctx)
+				case isPut && m[2] == "":
+					rpm.UploadAdvisory(ctx)
+				case isDelete && m[2] != "":
+					rpm.DeleteAdvisory(ctx)
+				default:
+					ctx.Status(http.StatusMethodNotAllowed)
+				}
+				return
+			}
+
+			m = modulesPattern.FindStringSubmatch(path)
+			if len(m) == 2 {
+				ctx.SetPathParam("group", strings.Trim(m[1], "/"))
+				if !isGetHead {
+					reqPackageAccess(perm.AccessModeWrite)(ctx)
+					if ctx.Written() {
+						return
+					}
+				}
+				switch {
+				case isGetHead:
+					rpm.GetModuleMetadata(ctx)
+				case isPut:
+					rpm.UploadModuleMetadata(ctx)
+				case isDelete:
+					rpm.DeleteModuleMetadata(ctx)
+				}
+				return
+			}
+
 			m = filePattern.FindStringSubmatch(path)
 			if len(m) == 6 && (isGetHead || isDelete) {
 				ctx.SetPathParam("group", strings.Trim(m[1], "/"))
@@ -883,9 +1223,13 @@ func addRubyRoutes(repoName string, r *web.Router) {
 		r.Get("/gems/{filename}", rubygems.DownloadPackageFile)
 		r.Get("/info/{packagename}", rubygems.GetPackageInfo)
 		r.Get("/versions", rubygems.GetAllPackagesVersions)
+		r.Get("/api/v1/gems/{packagename}/owners", rubygems.GetPackageOwners)
+		r.Get("/api/v1/gems/{packagename}/owners.yaml", rubygems.GetPackageOwnersYAML)
 		r.Group("/api/v1/gems", func() {
 			r.Post("/", rubygems.UploadPackageFile)
 			r.Delete("/yank", rubygems.DeletePackage)
+			r.Post("/{packagename}/owners", rubygems.AddPackageOwner)
+			r.Delete("/{packagename}/owners", rubygems.RemovePackageOwner)
 		}, reqPackageAccess(perm.AccessModeWrite))
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
@@ -950,3 +1294,13 @@ func addGoRoutes(repoName string, r *web.Router) {
 		})
 	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
 }
+
+func addGradleRoutes(repoName string, r *web.Router) {
+	r.Group("/repository/"+repoName, func() {
+		r.Group("/cache/{key}", func() {
+			r.Head("", gradle.CheckEntry)
+			r.Get("", gradle.DownloadEntry)
+			r.Put("", reqPackageAccess(perm.AccessModeWrite), gradle.UploadEntry)
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+}
@@ -0,0 +1,374 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	arch_service "code.gitea.io/gitea/services/packages/arch"
+)
+
+const maxSignatureSize = 1 << 16
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+func GetRepositoryKey(ctx *context.Context) {
+	_, pub, err := arch_service.GetOrCreateKeyPair(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(strings.NewReader(pub), &context.ServeHeaderOptions{
+		ContentType: "application/pgp-keys",
+		Filename:    "repository.key",
+	})
+}
+
+// GetPackageOrRepositoryFile serves the package databases, the packages and their signatures
+// pacman requests the databases by the names of the symlinks created by repo-add.
+func GetPackageOrRepositoryFile(ctx *context.Context) {
+	repository := ctx.PathParam("repository")
+	filename := ctx.PathParam("filename")
+
+	name, isSignature := strings.CutSuffix(filename, arch_module.SignatureExtension)
+
+	switch name {
+	case repository + ".db", arch_service.IndexArchiveFilename(repository):
+		name = arch_service.IndexArchiveFilename(repository)
+	case repository + ".files", arch_service.FilesArchiveFilename(repository):
+		name = arch_service.FilesArchiveFilename(repository)
+	default:
+		if !strings.HasSuffix(name, arch_module.PackageExtension) {
+			apiError(ctx, http.StatusNotFound, nil)
+			return
+		}
+
+		if isSignature {
+			servePackageSignature(ctx, name)
+		} else {
+			servePackageFile(ctx, name)
+		}
+		return
+	}
+
+	if isSignature {
+		name += arch_module.SignatureExtension
+	}
+
+	pv, err := arch_service.GetOrCreateRepositoryVersion(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	s, u, pf, err := packages_service.GetFileStreamByPackageVersion(
+		ctx,
+		pv,
+		&packages_service.PackageFileInfo{
+			Filename:     name,
+			CompositeKey: fmt.Sprintf("%s|%s", repository, ctx.PathParam("architecture")),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+func servePackageFile(ctx *context.Context, filename string) {
+	pf, err := findPackageFile(ctx, filename, true)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if pf == nil {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	s, u, pf, err := packages_service.GetPackageFileStream(ctx, pf)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+func servePackageSignature(ctx *context.Context, filename string) {
+	pf, err := findPackageFile(ctx, filename, true)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if pf == nil {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeFile, pf.ID, arch_module.PropertySignature)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pps) == 0 {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(pps[0].Value)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(bytes.NewReader(sig), &context.ServeHeaderOptions{
+		ContentType:  "application/pgp-signature",
+		Filename:     filename + arch_module.SignatureExtension,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// findPackageFile searches the package file in the repository and architecture
+// If allowAnyArch is set, the packages of the 'any' architecture are searched too.
+func findPackageFile(ctx *context.Context, filename string, allowAnyArch bool) (*packages_model.PackageFile, error) {
+	repository := ctx.PathParam("repository")
+	architecture := ctx.PathParam("architecture")
+
+	opts := &packages_model.PackageFileSearchOptions{
+		OwnerID:      ctx.Package.Owner.ID,
+		PackageType:  packages_model.TypeArch,
+		Query:        filename,
+		CompositeKey: fmt.Sprintf("%s|%s", repository, architecture),
+	}
+	pfs, _, err := packages_model.SearchFiles(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(pfs) == 0 && allowAnyArch && architecture != arch_module.AnyArch {
+		opts.CompositeKey = fmt.Sprintf("%s|%s", repository, arch_module.AnyArch)
+		if pfs, _, err = packages_model.SearchFiles(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+	if len(pfs) != 1 {
+		return nil, nil
+	}
+	return pfs[0], nil
+}
+
+func UploadPackageFile(ctx *context.Context) {
+	repository := strings.TrimSpace(ctx.PathParam("repository"))
+	if repository == "" || strings.Contains(repository, "|") {
+		apiError(ctx, http.StatusBadRequest, "invalid repository")
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	pck, err := arch_module.ParsePackage(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) || err == io.EOF {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Packages uploaded without a detached signature are signed with the repository key
+	sig, err := arch_service.CreateSignature(ctx, ctx.Package.Owner.ID, buf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	fileMetadataRaw, err := json.Marshal(pck.FileMetadata)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	filesRaw, err := json.Marshal(pck.Files)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeArch,
+				Name:        pck.Name,
+				Version:     pck.Version,
+			},
+			Creator:  ctx.Doer,
+			Metadata: pck.VersionMetadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     pck.Filename(),
+				CompositeKey: fmt.Sprintf("%s|%s", repository, pck.FileMetadata.Architecture),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+			Properties: map[string]string{
+				arch_module.PropertyRepository:   repository,
+				arch_module.PropertyArchitecture: pck.FileMetadata.Architecture,
+				arch_module.PropertyMetadata:     string(fileMetadataRaw),
+				arch_module.PropertyFiles:        string(filesRaw),
+				arch_module.PropertySignature:    base64.StdEncoding.EncodeToString(sig),
+			},
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion, packages_model.ErrDuplicatePackageFile:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := arch_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, repository, pck.FileMetadata.Architecture); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// UploadPackageSignature replaces the signature created on upload with the detached signature of the packager
+func UploadPackageSignature(ctx *context.Context) {
+	name, isSignature := strings.CutSuffix(ctx.PathParam("filename"), arch_module.SignatureExtension)
+	if !isSignature || !strings.HasSuffix(name, arch_module.PackageExtension) {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	pf, err := findPackageFile(ctx, name, false)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if pf == nil {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	sig, err := io.ReadAll(io.LimitReader(ctx.Req.Body, maxSignatureSize))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := arch_service.ValidateSignature(sig); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypeFile, pf.ID, arch_module.PropertySignature); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeFile, pf.ID, arch_module.PropertySignature, base64.StdEncoding.EncodeToString(sig)); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := arch_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, ctx.PathParam("repository"), ctx.PathParam("architecture")); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func DeletePackageFile(ctx *context.Context) {
+	repository, architecture := ctx.PathParam("repository"), ctx.PathParam("architecture")
+
+	pf, err := findPackageFile(ctx, ctx.PathParam("filename"), false)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if pf == nil {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	if err := packages_service.RemovePackageFileAndVersionIfUnreferenced(ctx, ctx.Doer, pf); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := arch_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, repository, architecture); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
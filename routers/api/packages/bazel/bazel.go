@@ -0,0 +1,107 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package bazel
+
+import (
+	"errors"
+	"net/http"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	bazel_module "code.gitea.io/gitea/modules/packages/bazel"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	bazel_service "code.gitea.io/gitea/services/packages/bazel"
+)
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+// DownloadCASEntry serves an output file from the content addressable store
+func DownloadCASEntry(ctx *context.Context) {
+	downloadEntry(ctx, bazel_module.KindCAS)
+}
+
+// DownloadACEntry serves an action result from the action cache
+func DownloadACEntry(ctx *context.Context) {
+	downloadEntry(ctx, bazel_module.KindAC)
+}
+
+// https://bazel.build/remote/caching#http-caching
+func downloadEntry(ctx *context.Context, kind bazel_module.Kind) {
+	digest := ctx.PathParam("digest")
+	if !bazel_module.IsValidDigest(digest) {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	s, u, pf, err := bazel_service.GetEntryStream(ctx, ctx.Package.Owner, kind, digest)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
+		ContentType:  "application/octet-stream",
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// UploadCASEntry stores an output file in the content addressable store
+func UploadCASEntry(ctx *context.Context) {
+	uploadEntry(ctx, bazel_module.KindCAS)
+}
+
+// UploadACEntry stores an action result in the action cache
+func UploadACEntry(ctx *context.Context) {
+	uploadEntry(ctx, bazel_module.KindAC)
+}
+
+func uploadEntry(ctx *context.Context, kind bazel_module.Kind) {
+	digest := ctx.PathParam("digest")
+	if !bazel_module.IsValidDigest(digest) {
+		apiError(ctx, http.StatusBadRequest, "invalid digest")
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if err := bazel_service.UploadEntry(ctx, ctx.Doer, ctx.Package.Owner, kind, digest, buf); err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
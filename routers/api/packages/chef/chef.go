@@ -15,6 +15,7 @@ import (
 
 	"code.gitea.io/gitea/models/db"
 	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/optional"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	chef_module "code.gitea.io/gitea/modules/packages/chef"
@@ -23,6 +24,7 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	chef_service "code.gitea.io/gitea/services/packages/chef"
 )
 
 func apiError(ctx *context.Context, status int, obj any) {
@@ -65,6 +67,11 @@ func PackagesUniverse(ctx *context.Context) {
 
 	universe := make(map[string]map[string]*VersionInfo)
 	for _, pd := range pds {
+		// Deprecated cookbooks and yanked versions must not be selected by the dependency resolution
+		if chef_service.IsCookbookDeprecated(pd) || chef_service.IsVersionYanked(pd) {
+			continue
+		}
+
 		if _, ok := universe[pd.Package.Name]; !ok {
 			universe[pd.Package.Name] = make(map[string]*VersionInfo)
 		}
@@ -179,36 +186,126 @@ func PackageMetadata(ctx *context.Context) {
 		Category      string    `json:"category"`
 		LatestVersion string    `json:"latest_version"`
 		SourceURL     string    `json:"source_url"`
+		IssuesURL     string    `json:"issues_url,omitempty"`
 		CreatedAt     time.Time `json:"created_at"`
 		UpdatedAt     time.Time `json:"updated_at"`
 		Deprecated    bool      `json:"deprecated"`
+		Replacement   string    `json:"replacement,omitempty"`
 		Versions      []string  `json:"versions"`
 	}
 
-	baseURL := fmt.Sprintf("%sapi/packages/%s/chef/api/v1/cookbooks/%s/versions/", setting.AppURL, ctx.Package.Owner.Name, url.PathEscape(packageName))
+	cookbooksURL := fmt.Sprintf("%sapi/packages/%s/chef/api/v1/cookbooks/", setting.AppURL, ctx.Package.Owner.Name)
+	baseURL := cookbooksURL + url.PathEscape(packageName) + "/versions/"
 
 	versions := make([]string, 0, len(pds))
 	for _, pd := range pds {
 		versions = append(versions, baseURL+pd.Version.Version)
 	}
 
+	// Prefer the latest version which is not yanked
 	latest := pds[len(pds)-1]
+	for i := len(pds) - 1; i >= 0; i-- {
+		if !chef_service.IsVersionYanked(pds[i]) {
+			latest = pds[i]
+			break
+		}
+	}
 
 	metadata := latest.Metadata.(*chef_module.Metadata)
 
+	sourceURL := metadata.RepositoryURL
+	if u := latest.PackageProperties.GetByName(chef_module.PropertySourceURL); u != "" {
+		sourceURL = u
+	}
+
+	var replacement string
+	if r := latest.PackageProperties.GetByName(chef_module.PropertyReplacement); r != "" {
+		replacement = cookbooksURL + url.PathEscape(r)
+	}
+
 	ctx.JSON(http.StatusOK, &Result{
 		Name:          latest.Package.Name,
 		Maintainer:    metadata.Author,
 		Description:   metadata.Description,
 		LatestVersion: baseURL + latest.Version.Version,
-		SourceURL:     metadata.RepositoryURL,
+		SourceURL:     sourceURL,
+		IssuesURL:     latest.PackageProperties.GetByName(chef_module.PropertyIssuesURL),
 		CreatedAt:     latest.Version.CreatedUnix.AsLocalTime(),
 		UpdatedAt:     latest.Version.CreatedUnix.AsLocalTime(),
-		Deprecated:    false,
+		Deprecated:    chef_service.IsCookbookDeprecated(latest),
+		Replacement:   replacement,
 		Versions:      versions,
 	})
 }
 
+// UpdatePackage deprecates the cookbook or changes its urls
+func UpdatePackage(ctx *context.Context) {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeChef, ctx.PathParam("name"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var options struct {
+		Deprecated  *bool   `json:"deprecated"`
+		Replacement *string `json:"replacement"`
+		SourceURL   *string `json:"source_url"`
+		IssuesURL   *string `json:"issues_url"`
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&options); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := chef_service.UpdateCookbook(ctx, p, &chef_service.CookbookOptions{
+		Deprecated:  options.Deprecated,
+		Replacement: options.Replacement,
+		SourceURL:   options.SourceURL,
+		IssuesURL:   options.IssuesURL,
+	}); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	PackageMetadata(ctx)
+}
+
+// UpdatePackageVersion yanks or restores a cookbook version
+func UpdatePackageVersion(ctx *context.Context) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeChef, ctx.PathParam("name"), strings.ReplaceAll(ctx.PathParam("version"), "_", "."))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var options struct {
+		Yanked bool `json:"yanked"`
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&options); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := chef_service.SetVersionYanked(ctx, pv, options.Yanked); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	PackageVersionMetadata(ctx)
+}
+
 // https://github.com/chef/chef/blob/main/knife/lib/chef/knife/supermarket_show.rb
 func PackageVersionMetadata(ctx *context.Context) {
 	packageName := ctx.PathParam("name")
@@ -238,6 +335,7 @@ func PackageVersionMetadata(ctx *context.Context) {
 		File            string            `json:"file"`
 		License         string            `json:"license"`
 		Dependencies    map[string]string `json:"dependencies"`
+		Yanked          bool              `json:"yanked"`
 	}
 
 	baseURL := fmt.Sprintf("%sapi/packages/%s/chef/api/v1/cookbooks/%s", setting.AppURL, ctx.Package.Owner.Name, url.PathEscape(pd.Package.Name))
@@ -252,6 +350,7 @@ func PackageVersionMetadata(ctx *context.Context) {
 		File:            fmt.Sprintf("%s/versions/%s/download", baseURL, pd.Version.Version),
 		License:         metadata.License,
 		Dependencies:    metadata.Dependencies,
+		Yanked:          chef_service.IsVersionYanked(pd),
 	})
 }
 
@@ -0,0 +1,379 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package cocoapods
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	cocoapods_module "code.gitea.io/gitea/modules/packages/cocoapods"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"github.com/hashicorp/go-version"
+)
+
+// versionFileContent tells the CocoaPods client how the trunk is sharded
+// https://cdn.cocoapods.org/CocoaPods-version.yml
+const versionFileContent = `---
+min: 1.0.0
+last: 1.0.0
+prefix_lengths:
+- 1
+- 1
+- 1
+`
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+func baseURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/cocoapods", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name))
+}
+
+func serveText(ctx *context.Context, filename, content string) {
+	ctx.ServeContent(strings.NewReader(content), &context.ServeHeaderOptions{
+		ContentType: "text/plain; charset=utf-8",
+		Filename:    filename,
+	})
+}
+
+// GetVersionFile serves the file `pod repo add-cdn` uses to validate the trunk
+func GetVersionFile(ctx *context.Context) {
+	serveText(ctx, "CocoaPods-version.yml", versionFileContent)
+}
+
+type podVersions struct {
+	Name     string
+	Versions []*packages_model.PackageVersion
+}
+
+// getPodVersions gets all pods of the owner with their versions sorted by version
+func getPodVersions(ctx *context.Context) ([]*podVersions, error) {
+	ps, err := packages_model.GetPackagesByType(ctx, ctx.Package.Owner.ID, packages_model.TypeCocoaPods)
+	if err != nil {
+		return nil, err
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageType(ctx, ctx.Package.Owner.ID, packages_model.TypeCocoaPods)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64][]*packages_model.PackageVersion)
+	for _, pv := range pvs {
+		versions[pv.PackageID] = append(versions[pv.PackageID], pv)
+	}
+
+	pods := make([]*podVersions, 0, len(ps))
+	for _, p := range ps {
+		if len(versions[p.ID]) == 0 {
+			continue
+		}
+
+		pvs := versions[p.ID]
+		sort.Slice(pvs, func(i, j int) bool {
+			vi, erri := version.NewVersion(pvs[i].Version)
+			vj, errj := version.NewVersion(pvs[j].Version)
+			if erri != nil || errj != nil {
+				return pvs[i].Version < pvs[j].Version
+			}
+			return vi.LessThan(vj)
+		})
+
+		pods = append(pods, &podVersions{
+			Name:     p.Name,
+			Versions: pvs,
+		})
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	return pods, nil
+}
+
+// EnumeratePods serves the names of all pods
+func EnumeratePods(ctx *context.Context) {
+	pods, err := getPodVersions(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var sb strings.Builder
+	for _, pod := range pods {
+		fmt.Fprintln(&sb, pod.Name)
+	}
+
+	serveText(ctx, "all_pods.txt", sb.String())
+}
+
+// EnumerateDeprecatedPodspecs serves the paths of all deprecated podspecs
+func EnumerateDeprecatedPodspecs(ctx *context.Context) {
+	pvs, err := packages_model.GetVersionsByPackageType(ctx, ctx.Package.Owner.ID, packages_model.TypeCocoaPods)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	paths := make([]string, 0, len(pds))
+	for _, pd := range pds {
+		if pd.Metadata.(*cocoapods_module.Metadata).Deprecated {
+			paths = append(paths, cocoapods_module.PodspecPath(pd.Package.Name, pd.Version.Version))
+		}
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		fmt.Fprintln(&sb, path)
+	}
+
+	serveText(ctx, "deprecated_podspecs.txt", sb.String())
+}
+
+// EnumeratePodVersions serves the versions of all pods in the shard
+// Every line contains the name of the pod followed by its versions: `Name/1.0.0/1.1.0`
+func EnumeratePodVersions(ctx *context.Context) {
+	shard := []string{ctx.PathParam("x"), ctx.PathParam("y"), ctx.PathParam("z")}
+
+	pods, err := getPodVersions(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var sb strings.Builder
+	for _, pod := range pods {
+		if !slices.Equal(cocoapods_module.ShardPrefix(pod.Name), shard) {
+			continue
+		}
+
+		sb.WriteString(pod.Name)
+		for _, pv := range pod.Versions {
+			sb.WriteString("/")
+			sb.WriteString(pv.Version)
+		}
+		sb.WriteString("\n")
+	}
+
+	serveText(ctx, fmt.Sprintf("all_pods_versions_%s.txt", strings.Join(shard, "_")), sb.String())
+}
+
+// GetPodspec serves the podspec with the source pointing to the hosted archive
+func GetPodspec(ctx *context.Context) {
+	packageName := ctx.PathParam("name")
+	packageVersion := ctx.PathParam("version")
+
+	shard := []string{ctx.PathParam("x"), ctx.PathParam("y"), ctx.PathParam("z")}
+	if ctx.PathParam("filename") != packageName+".podspec.json" || !slices.Equal(cocoapods_module.ShardPrefix(packageName), shard) {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeCocoaPods, packageName, packageVersion)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if pd.Package.Name != packageName || len(pd.Files) == 0 {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	podspec := pd.Metadata.(*cocoapods_module.Metadata).Podspec
+	if podspec == nil {
+		podspec = make(map[string]any)
+	}
+
+	pfd := pd.Files[0]
+	podspec["source"] = map[string]string{
+		"http":   fmt.Sprintf("%s/Pods/%s/%s/%s", baseURL(ctx), url.PathEscape(pd.Package.Name), url.PathEscape(pd.Version.Version), url.PathEscape(pfd.File.Name)),
+		"sha256": pfd.Blob.HashSHA256,
+	}
+
+	data, err := json.Marshal(podspec)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(bytes.NewReader(data), &context.ServeHeaderOptions{
+		ContentType:  "application/json",
+		Filename:     ctx.PathParam("filename"),
+		LastModified: pv.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// UploadPackage stores the podspec JSON together with the source archive
+func UploadPackage(ctx *context.Context) {
+	podspec, err := openFormPart(ctx, "podspec")
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	defer podspec.Close()
+
+	pck, err := cocoapods_module.ParsePodspec(podspec)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	file, header, err := ctx.Req.FormFile("source")
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	ext, err := cocoapods_module.SourceArchiveExtension(header.Filename)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(file)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeCocoaPods,
+				Name:        pck.Name,
+				Version:     pck.Version,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata:         pck.Metadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: pck.Name + "-" + pck.Version + ext,
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// openFormPart opens the form part which can be sent as file or as value
+func openFormPart(ctx *context.Context, name string) (io.ReadCloser, error) {
+	file, _, err := ctx.Req.FormFile(name)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return io.NopCloser(strings.NewReader(ctx.Req.FormValue(name))), nil
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// DownloadSourceArchive serves the source archive referenced by the podspec
+func DownloadSourceArchive(ctx *context.Context) {
+	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeCocoaPods,
+			Name:        ctx.PathParam("name"),
+			Version:     ctx.PathParam("version"),
+		},
+		&packages_service.PackageFileInfo{
+			Filename: ctx.PathParam("filename"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// DeletePackageVersion removes the pod version
+func DeletePackageVersion(ctx *context.Context) {
+	err := packages_service.RemovePackageVersionByNameAndVersion(
+		ctx,
+		ctx.Doer,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeCocoaPods,
+			Name:        ctx.PathParam("name"),
+			Version:     ctx.PathParam("version"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
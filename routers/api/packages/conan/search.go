@@ -5,6 +5,7 @@ package conan
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	conan_model "code.gitea.io/gitea/models/packages/conan"
@@ -73,6 +74,12 @@ func SearchPackagesV2(ctx *context.Context) {
 func searchPackages(ctx *context.Context, searchAllRevisions bool) {
 	rref := ctx.Data[recipeReferenceKey].(*conan_module.RecipeReference)
 
+	query, err := conan_module.ParseQuery(ctx.FormTrim("q"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	if !searchAllRevisions && rref.Revision == "" {
 		lastRevision, err := conan_model.GetLastRecipeRevision(ctx, ctx.Package.Owner.ID, rref)
 		if err != nil {
@@ -141,7 +148,7 @@ func searchPackages(ctx *context.Context, searchAllRevisions bool) {
 				return
 			}
 			pref = pref.WithRevision(lastPackageRevision.Value)
-			infoRaw, err := conan_model.GetPackageInfo(ctx, ctx.Package.Owner.ID, pref)
+			info, err := getPackageInfo(ctx, pref)
 			if err != nil {
 				if err == conan_model.ErrPackageReferenceNotExist {
 					apiError(ctx, http.StatusNotFound, err)
@@ -150,10 +157,8 @@ func searchPackages(ctx *context.Context, searchAllRevisions bool) {
 				}
 				return
 			}
-			var info *conan_module.Conaninfo
-			if err := json.Unmarshal([]byte(infoRaw), &info); err != nil {
-				apiError(ctx, http.StatusInternalServerError, err)
-				return
+			if !query.Match(info) {
+				continue
 			}
 			result[pref.Reference] = info
 		}
@@ -161,3 +166,132 @@ func searchPackages(ctx *context.Context, searchAllRevisions bool) {
 
 	jsonResponse(ctx, http.StatusOK, result)
 }
+
+func getPackageInfo(ctx *context.Context, pref *conan_module.PackageReference) (*conan_module.Conaninfo, error) {
+	infoRaw, err := conan_model.GetPackageInfo(ctx, ctx.Package.Owner.ID, pref)
+	if err != nil {
+		return nil, err
+	}
+	var info *conan_module.Conaninfo
+	if err := json.Unmarshal([]byte(infoRaw), &info); err != nil {
+		return nil, err
+	}
+	if info == nil {
+		info = &conan_module.Conaninfo{}
+	}
+	return info, nil
+}
+
+type listPackageInfo struct {
+	Settings map[string]string `json:"settings,omitempty"`
+	Options  map[string]string `json:"options,omitempty"`
+	Requires []string          `json:"requires,omitempty"`
+}
+
+type listRevision struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+type listPackage struct {
+	Info      *listPackageInfo         `json:"info"`
+	Revisions map[string]*listRevision `json:"revisions"`
+}
+
+type listRecipeRevision struct {
+	Timestamp int64                   `json:"timestamp"`
+	Packages  map[string]*listPackage `json:"packages"`
+}
+
+type listRecipe struct {
+	Revisions map[string]*listRecipeRevision `json:"revisions"`
+}
+
+// ListPackages lists the recipe revisions with all binary packages matching the query.
+// The result has the format of "conan list --format=json" so clients can find existing binaries with a single request.
+func ListPackages(ctx *context.Context) {
+	rref := ctx.Data[recipeReferenceKey].(*conan_module.RecipeReference)
+
+	query, err := conan_module.ParseQuery(ctx.FormTrim("q"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	recipeRevisions, err := conan_model.GetRecipeRevisions(ctx, ctx.Package.Owner.ID, rref)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if rref.Revision != "" {
+		recipeRevisions = slices.DeleteFunc(recipeRevisions, func(rev *conan_model.PropertyValue) bool {
+			return rev.Value != rref.Revision
+		})
+	}
+	if len(recipeRevisions) == 0 {
+		apiError(ctx, http.StatusNotFound, conan_model.ErrRecipeReferenceNotExist)
+		return
+	}
+
+	recipe := &listRecipe{
+		Revisions: make(map[string]*listRecipeRevision, len(recipeRevisions)),
+	}
+
+	for _, recipeRevision := range recipeRevisions {
+		currentRef := rref.WithRevision(recipeRevision.Value)
+
+		packageReferences, err := conan_model.GetPackageReferences(ctx, ctx.Package.Owner.ID, currentRef)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		packages := make(map[string]*listPackage)
+		for _, packageReference := range packageReferences {
+			pref, _ := conan_module.NewPackageReference(currentRef, packageReference.Value, "")
+
+			packageRevisions, err := conan_model.GetPackageRevisions(ctx, ctx.Package.Owner.ID, pref)
+			if err != nil {
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+			if len(packageRevisions) == 0 {
+				continue
+			}
+
+			info, err := getPackageInfo(ctx, pref.WithRevision(packageRevisions[0].Value))
+			if err != nil {
+				if err == conan_model.ErrPackageReferenceNotExist {
+					continue
+				}
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+			if !query.Match(info) {
+				continue
+			}
+
+			revisions := make(map[string]*listRevision, len(packageRevisions))
+			for _, packageRevision := range packageRevisions {
+				revisions[packageRevision.Value] = &listRevision{Timestamp: int64(packageRevision.CreatedUnix)}
+			}
+
+			packages[packageReference.Value] = &listPackage{
+				Info: &listPackageInfo{
+					Settings: info.Settings,
+					Options:  info.Options,
+					Requires: info.Requires,
+				},
+				Revisions: revisions,
+			}
+		}
+
+		recipe.Revisions[recipeRevision.Value] = &listRecipeRevision{
+			Timestamp: int64(recipeRevision.CreatedUnix),
+			Packages:  packages,
+		}
+	}
+
+	jsonResponse(ctx, http.StatusOK, map[string]*listRecipe{
+		rref.WithRevision("").String(): recipe,
+	})
+}
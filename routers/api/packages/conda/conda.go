@@ -20,6 +20,7 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	conda_service "code.gitea.io/gitea/services/packages/conda"
 
 	"github.com/dsnet/compress/bzip2"
 )
@@ -37,116 +38,52 @@ func apiError(ctx *context.Context, status int, obj any) {
 }
 
 func EnumeratePackages(ctx *context.Context) {
-	type Info struct {
-		Subdir string `json:"subdir"`
-	}
-
-	type PackageInfo struct {
-		Name          string   `json:"name"`
-		Version       string   `json:"version"`
-		NoArch        string   `json:"noarch"`
-		Subdir        string   `json:"subdir"`
-		Timestamp     int64    `json:"timestamp"`
-		Build         string   `json:"build"`
-		BuildNumber   int64    `json:"build_number"`
-		Dependencies  []string `json:"depends"`
-		License       string   `json:"license"`
-		LicenseFamily string   `json:"license_family"`
-		HashMD5       string   `json:"md5"`
-		HashSHA256    string   `json:"sha256"`
-		Size          int64    `json:"size"`
-	}
-
-	type RepoData struct {
-		Info          Info                    `json:"info"`
-		Packages      map[string]*PackageInfo `json:"packages"`
-		PackagesConda map[string]*PackageInfo `json:"packages.conda"`
-		Removed       map[string]*PackageInfo `json:"removed"`
-	}
-
-	repoData := &RepoData{
-		Info: Info{
-			Subdir: ctx.PathParam("architecture"),
-		},
-		Packages:      make(map[string]*PackageInfo),
-		PackagesConda: make(map[string]*PackageInfo),
-		Removed:       make(map[string]*PackageInfo),
-	}
-
-	pfs, err := conda_model.SearchFiles(ctx, &conda_model.FileSearchOptions{
-		OwnerID: ctx.Package.Owner.ID,
-		Channel: ctx.PathParam("channel"),
-		Subdir:  repoData.Info.Subdir,
-	})
+	repoData, err := conda_service.BuildRepoData(ctx, ctx.Package.Owner.ID, ctx.PathParam("channel"), ctx.PathParam("architecture"))
 	if err != nil {
-		apiError(ctx, http.StatusInternalServerError, err)
-		return
-	}
-
-	if len(pfs) == 0 {
-		apiError(ctx, http.StatusNotFound, nil)
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, nil)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
 		return
 	}
 
-	pds := make(map[int64]*packages_model.PackageDescriptor)
-
-	for _, pf := range pfs {
-		pd, exists := pds[pf.VersionID]
-		if !exists {
-			pv, err := packages_model.GetVersionByID(ctx, pf.VersionID)
-			if err != nil {
-				apiError(ctx, http.StatusInternalServerError, err)
-				return
-			}
-
-			pd, err = packages_model.GetPackageDescriptor(ctx, pv)
-			if err != nil {
-				apiError(ctx, http.StatusInternalServerError, err)
-				return
-			}
-
-			pds[pf.VersionID] = pd
-		}
-
-		var pfd *packages_model.PackageFileDescriptor
-		for _, d := range pd.Files {
-			if d.File.ID == pf.ID {
-				pfd = d
-				break
-			}
-		}
+	serveJSON(ctx, repoData)
+}
 
-		var fileMetadata *conda_module.FileMetadata
-		if err := json.Unmarshal([]byte(pfd.Properties.GetByName(conda_module.PropertyMetadata)), &fileMetadata); err != nil {
+// EnumerateRunExports serves the run exports of the packages in the subdir
+// https://github.com/conda/ceps/blob/main/cep-0012.md
+func EnumerateRunExports(ctx *context.Context) {
+	data, err := conda_service.BuildRunExports(ctx, ctx.Package.Owner.ID, ctx.PathParam("channel"), ctx.PathParam("architecture"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, nil)
+		} else {
 			apiError(ctx, http.StatusInternalServerError, err)
-			return
 		}
+		return
+	}
 
-		versionMetadata := pd.Metadata.(*conda_module.VersionMetadata)
-
-		pi := &PackageInfo{
-			Name:          pd.PackageProperties.GetByName(conda_module.PropertyName),
-			Version:       pd.Version.Version,
-			NoArch:        fileMetadata.NoArch,
-			Subdir:        repoData.Info.Subdir,
-			Timestamp:     fileMetadata.Timestamp,
-			Build:         fileMetadata.Build,
-			BuildNumber:   fileMetadata.BuildNumber,
-			Dependencies:  fileMetadata.Dependencies,
-			License:       versionMetadata.License,
-			LicenseFamily: versionMetadata.LicenseFamily,
-			HashMD5:       pfd.Blob.HashMD5,
-			HashSHA256:    pfd.Blob.HashSHA256,
-			Size:          pfd.Blob.Size,
-		}
+	serveJSON(ctx, data)
+}
 
-		if fileMetadata.IsCondaPackage {
-			repoData.PackagesConda[pfd.File.Name] = pi
+// ChannelData serves the summary of all packages in the channel
+func ChannelData(ctx *context.Context) {
+	data, err := conda_service.BuildChannelData(ctx, ctx.Package.Owner.ID, ctx.PathParam("channel"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, nil)
 		} else {
-			repoData.Packages[pfd.File.Name] = pi
+			apiError(ctx, http.StatusInternalServerError, err)
 		}
+		return
 	}
 
+	serveJSON(ctx, data)
+}
+
+// serveJSON writes the object as plain JSON or bzip2 compressed depending on the requested filename
+func serveJSON(ctx *context.Context, obj any) {
 	resp := ctx.Resp
 
 	var w io.Writer = resp
@@ -168,11 +105,69 @@ func EnumeratePackages(ctx *context.Context) {
 
 	resp.WriteHeader(http.StatusOK)
 
-	if err := json.NewEncoder(w).Encode(repoData); err != nil {
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
 		log.Error("JSON encode: %v", err)
 	}
 }
 
+// GetPatchInstructions serves the repodata patch instructions of the subdir
+func GetPatchInstructions(ctx *context.Context) {
+	pi, err := conda_service.GetPatchInstructions(ctx, ctx.Package.Owner.ID, ctx.PathParam("channel"), ctx.PathParam("architecture"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pi)
+}
+
+// UploadPatchInstructions sets the repodata patch instructions of the subdir
+func UploadPatchInstructions(ctx *context.Context) {
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	pi, err := conda_module.ParsePatchInstructions(upload)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := conda_service.SetPatchInstructions(ctx, ctx.Package.Owner.ID, ctx.PathParam("channel"), ctx.PathParam("architecture"), pi); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, pi)
+}
+
+// DeletePatchInstructions removes the repodata patch instructions of the subdir
+func DeletePatchInstructions(ctx *context.Context) {
+	if err := conda_service.DeletePatchInstructions(ctx, ctx.Package.Owner.ID, ctx.PathParam("channel"), ctx.PathParam("architecture")); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 func UploadPackageFile(ctx *context.Context) {
 	upload, needToClose, err := ctx.UploadStream()
 	if err != nil {
@@ -5,7 +5,6 @@ package container
 
 import (
 	"context"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -192,11 +191,6 @@ func deleteBlob(ctx context.Context, ownerID int64, image, digest string) error
 	})
 }
 
-func digestFromHashSummer(h packages_module.HashSummer) string {
-	_, _, hashSHA256, _ := h.Sums()
-	return "sha256:" + hex.EncodeToString(hashSHA256)
-}
-
 func digestFromPackageBlob(pb *packages_model.PackageBlob) string {
 	return "sha256:" + pb.HashSHA256
 }
@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -34,15 +33,6 @@ import (
 	digest "github.com/opencontainers/go-digest"
 )
 
-// maximum size of a container manifest
-// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-manifests
-const maxManifestSize = 10 * 1024 * 1024
-
-var (
-	imageNamePattern = regexp.MustCompile(`\A[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*\z`)
-	referencePattern = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
-)
-
 type containerHeaders struct {
 	Status        int
 	ContentDigest string
@@ -133,7 +123,7 @@ func ReqContainerAccess(ctx *context.Context) {
 
 // VerifyImageName is a middleware which checks if the image name is allowed
 func VerifyImageName(ctx *context.Context) {
-	if !imageNamePattern.MatchString(ctx.PathParam("image")) {
+	if !container_service.ImageNamePattern.MatchString(ctx.PathParam("image")) {
 		apiErrorDefined(ctx, errNameInvalid)
 	}
 }
@@ -253,6 +243,8 @@ func InitiateUploadBlob(ctx *context.Context) {
 					return
 				}
 
+				notifyBlobEvent(ctx, container_module.EventActionMount, image, mount, blob.Blob.Size, from)
+
 				setResponseHeaders(ctx.Resp, &containerHeaders{
 					Location:      fmt.Sprintf("/v2/%s/%s/blobs/%s", ctx.Package.Owner.LowerName, image, mount),
 					ContentDigest: mount,
@@ -272,7 +264,7 @@ func InitiateUploadBlob(ctx *context.Context) {
 		}
 		defer buf.Close()
 
-		if digest != digestFromHashSummer(buf) {
+		if digest != container_service.DigestFromHashSummer(buf) {
 			apiErrorDefined(ctx, errDigestInvalid)
 			return
 		}
@@ -296,6 +288,8 @@ func InitiateUploadBlob(ctx *context.Context) {
 			return
 		}
 
+		notifyBlobEvent(ctx, container_module.EventActionPush, image, digest, buf.Size(), "")
+
 		setResponseHeaders(ctx.Resp, &containerHeaders{
 			Location:      fmt.Sprintf("/v2/%s/%s/blobs/%s", ctx.Package.Owner.LowerName, image, digest),
 			ContentDigest: digest,
@@ -417,7 +411,7 @@ func EndUploadBlob(ctx *context.Context) {
 		}
 	}
 
-	if digest != digestFromHashSummer(uploader) {
+	if digest != container_service.DigestFromHashSummer(uploader) {
 		apiErrorDefined(ctx, errDigestInvalid)
 		return
 	}
@@ -452,6 +446,8 @@ func EndUploadBlob(ctx *context.Context) {
 		return
 	}
 
+	notifyBlobEvent(ctx, container_module.EventActionPush, image, digest, uploader.Size(), "")
+
 	setResponseHeaders(ctx.Resp, &containerHeaders{
 		Location:      fmt.Sprintf("/v2/%s/%s/blobs/%s", ctx.Package.Owner.LowerName, image, digest),
 		ContentDigest: digest,
@@ -528,6 +524,8 @@ func GetBlob(ctx *context.Context) {
 		return
 	}
 
+	notifyBlobEvent(ctx, container_module.EventActionPull, ctx.PathParam("image"), ctx.PathParam("digest"), blob.Blob.Size, "")
+
 	serveBlob(ctx, blob)
 }
 
@@ -545,6 +543,8 @@ func DeleteBlob(ctx *context.Context) {
 		return
 	}
 
+	notifyBlobEvent(ctx, container_module.EventActionDelete, ctx.PathParam("image"), d, 0, "")
+
 	setResponseHeaders(ctx.Resp, &containerHeaders{
 		Status: http.StatusAccepted,
 	})
@@ -554,7 +554,7 @@ func DeleteBlob(ctx *context.Context) {
 func UploadManifest(ctx *context.Context) {
 	reference := ctx.PathParam("reference")
 
-	mci := &manifestCreationInfo{
+	mci := &container_service.ManifestCreationInfo{
 		MediaType: ctx.Req.Header.Get("Content-Type"),
 		Owner:     ctx.Package.Owner,
 		Creator:   ctx.Doer,
@@ -563,12 +563,12 @@ func UploadManifest(ctx *context.Context) {
 		IsTagged:  digest.Digest(reference).Validate() != nil,
 	}
 
-	if mci.IsTagged && !referencePattern.MatchString(reference) {
+	if mci.IsTagged && !container_service.ReferencePattern.MatchString(reference) {
 		apiErrorDefined(ctx, errManifestInvalid.WithMessage("Tag is invalid"))
 		return
 	}
 
-	maxSize := maxManifestSize + 1
+	maxSize := container_service.MaxManifestSize + 1
 	buf, err := packages_module.CreateHashedBufferFromReaderWithSize(&io.LimitedReader{R: ctx.Req.Body, N: int64(maxSize)}, maxSize)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
@@ -576,15 +576,25 @@ func UploadManifest(ctx *context.Context) {
 	}
 	defer buf.Close()
 
-	if buf.Size() > maxManifestSize {
+	if buf.Size() > container_service.MaxManifestSize {
 		apiErrorDefined(ctx, errManifestInvalid.WithMessage("Manifest exceeds maximum size").WithStatusCode(http.StatusRequestEntityTooLarge))
 		return
 	}
 
-	digest, err := processManifest(ctx, mci, buf)
+	if mci.IsTagged {
+		if err := container_service.CheckImmutableTagUpdate(ctx, ctx.Package.Owner.ID, mci.Image, reference, container_service.DigestFromHashSummer(buf)); err != nil {
+			if errors.Is(err, container_service.ErrTagImmutable) {
+				apiErrorDefined(ctx, errDenied.WithMessage("Tag is immutable"))
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	digest, err := container_service.ProcessManifest(ctx, mci, buf)
 	if err != nil {
-		var namedError *namedError
-		if errors.As(err, &namedError) {
+		if namedError := manifestError(err); namedError != nil {
 			apiErrorDefined(ctx, namedError)
 		} else if errors.Is(err, container_model.ErrContainerBlobNotExist) {
 			apiErrorDefined(ctx, errBlobUnknown)
@@ -599,6 +609,8 @@ func UploadManifest(ctx *context.Context) {
 		return
 	}
 
+	notifyManifestEvent(ctx, container_module.EventActionPush, mci.Image, reference, mci.MediaType, digest, buf.Size())
+
 	setResponseHeaders(ctx.Resp, &containerHeaders{
 		Location:      fmt.Sprintf("/v2/%s/%s/manifests/%s", ctx.Package.Owner.LowerName, mci.Image, reference),
 		ContentDigest: digest,
@@ -617,7 +629,7 @@ func getBlobSearchOptionsFromContext(ctx *context.Context) (*container_model.Blo
 
 	if digest.Digest(reference).Validate() == nil {
 		opts.Digest = reference
-	} else if referencePattern.MatchString(reference) {
+	} else if container_service.ReferencePattern.MatchString(reference) {
 		opts.Tag = reference
 	} else {
 		return nil, container_model.ErrContainerBlobNotExist
@@ -667,6 +679,8 @@ func GetManifest(ctx *context.Context) {
 		return
 	}
 
+	notifyManifestFileEvent(ctx, container_module.EventActionPull, manifest)
+
 	serveBlob(ctx, manifest)
 }
 
@@ -690,6 +704,31 @@ func DeleteManifest(ctx *context.Context) {
 		return
 	}
 
+	for _, pv := range pvs {
+		p, err := packages_model.GetPackageByID(ctx, pv.PackageID)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if immutable, err := container_service.IsVersionImmutable(ctx, p, pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		} else if immutable {
+			apiErrorDefined(ctx, errDenied.WithMessage("Tag is immutable"))
+			return
+		}
+	}
+
+	var manifest *packages_model.PackageFileDescriptor
+	if container_service.IsNotificationEnabled() {
+		// the manifest is not available anymore after the versions are removed
+		manifest, err = getManifestFromContext(ctx)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	for _, pv := range pvs {
 		if err := packages_service.RemovePackageVersion(ctx, ctx.Doer, pv); err != nil {
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -697,6 +736,10 @@ func DeleteManifest(ctx *context.Context) {
 		}
 	}
 
+	if manifest != nil {
+		notifyManifestFileEvent(ctx, container_module.EventActionDelete, manifest)
+	}
+
 	setResponseHeaders(ctx.Resp, &containerHeaders{
 		Status: http.StatusAccepted,
 	})
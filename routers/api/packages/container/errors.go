@@ -4,7 +4,10 @@
 package container
 
 import (
+	"errors"
 	"net/http"
+
+	container_service "code.gitea.io/gitea/services/packages/container"
 )
 
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#error-codes
@@ -12,6 +15,7 @@ var (
 	errBlobUnknown         = &namedError{Code: "BLOB_UNKNOWN", StatusCode: http.StatusNotFound}
 	errBlobUploadInvalid   = &namedError{Code: "BLOB_UPLOAD_INVALID", StatusCode: http.StatusBadRequest}
 	errBlobUploadUnknown   = &namedError{Code: "BLOB_UPLOAD_UNKNOWN", StatusCode: http.StatusNotFound}
+	errDenied              = &namedError{Code: "DENIED", StatusCode: http.StatusForbidden}
 	errDigestInvalid       = &namedError{Code: "DIGEST_INVALID", StatusCode: http.StatusBadRequest}
 	errManifestBlobUnknown = &namedError{Code: "MANIFEST_BLOB_UNKNOWN", StatusCode: http.StatusNotFound}
 	errManifestInvalid     = &namedError{Code: "MANIFEST_INVALID", StatusCode: http.StatusBadRequest}
@@ -50,3 +54,22 @@ func (e *namedError) WithStatusCode(statusCode int) *namedError {
 		Message:    e.Message,
 	}
 }
+
+// manifestError converts an error of the manifest processing to the matching registry error
+func manifestError(err error) *namedError {
+	var e *namedError
+	switch {
+	case errors.Is(err, container_service.ErrManifestInvalid):
+		e = errManifestInvalid
+	case errors.Is(err, container_service.ErrManifestBlobUnknown):
+		e = errManifestBlobUnknown
+	case errors.Is(err, container_service.ErrSizeInvalid):
+		e = errSizeInvalid
+	case errors.Is(err, container_service.ErrUnsupported):
+		e = errUnsupported
+	default:
+		return nil
+	}
+
+	return e.WithMessage(err.Error())
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"fmt"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/context"
+	container_service "code.gitea.io/gitea/services/packages/container"
+
+	"github.com/google/uuid"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// notifyRegistryEvent queues a Docker distribution-format event for the configured notification endpoints
+func notifyRegistryEvent(ctx *context.Context, action string, target container_module.EventTarget) {
+	if !container_service.IsNotificationEnabled() {
+		return
+	}
+
+	event := &container_module.Event{
+		Action: action,
+		Target: target,
+		Request: container_module.EventRequest{
+			ID:        uuid.NewString(),
+			Addr:      ctx.RemoteAddr(),
+			Host:      ctx.Req.Host,
+			Method:    ctx.Req.Method,
+			UserAgent: ctx.Req.UserAgent(),
+		},
+	}
+	if ctx.IsSigned {
+		event.Actor.Name = ctx.Doer.Name
+	}
+
+	container_service.Notify(event)
+}
+
+func repositoryName(ctx *context.Context, image string) string {
+	return ctx.Package.Owner.LowerName + "/" + strings.ToLower(image)
+}
+
+func notifyBlobEvent(ctx *context.Context, action, image, blobDigest string, size int64, fromRepository string) {
+	repository := repositoryName(ctx, image)
+
+	notifyRegistryEvent(ctx, action, container_module.EventTarget{
+		MediaType:      container_module.BlobMediaType,
+		Size:           size,
+		Digest:         blobDigest,
+		Length:         size,
+		Repository:     repository,
+		FromRepository: fromRepository,
+		URL:            fmt.Sprintf("%sv2/%s/blobs/%s", setting.AppURL, repository, blobDigest),
+	})
+}
+
+func notifyManifestEvent(ctx *context.Context, action, image, reference, mediaType, manifestDigest string, size int64) {
+	repository := repositoryName(ctx, image)
+
+	tag := ""
+	if digest.Digest(reference).Validate() != nil {
+		tag = reference
+	}
+
+	notifyRegistryEvent(ctx, action, container_module.EventTarget{
+		MediaType:  mediaType,
+		Size:       size,
+		Digest:     manifestDigest,
+		Length:     size,
+		Repository: repository,
+		URL:        fmt.Sprintf("%sv2/%s/manifests/%s", setting.AppURL, repository, manifestDigest),
+		Tag:        tag,
+	})
+}
+
+func notifyManifestFileEvent(ctx *context.Context, action string, pfd *packages_model.PackageFileDescriptor) {
+	notifyManifestEvent(
+		ctx,
+		action,
+		ctx.PathParam("image"),
+		ctx.PathParam("reference"),
+		pfd.Properties.GetByName(container_module.PropertyMediaType),
+		pfd.Properties.GetByName(container_module.PropertyDigest),
+		pfd.Blob.Size,
+	)
+}
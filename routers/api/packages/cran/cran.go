@@ -9,10 +9,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 
 	packages_model "code.gitea.io/gitea/models/packages"
 	cran_model "code.gitea.io/gitea/models/packages/cran"
+	"code.gitea.io/gitea/modules/log"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	cran_module "code.gitea.io/gitea/modules/packages/cran"
 	"code.gitea.io/gitea/modules/util"
@@ -35,14 +37,88 @@ func EnumerateSourcePackages(ctx *context.Context) {
 }
 
 func EnumerateBinaryPackages(ctx *context.Context) {
+	platform, rversion, ok := binaryPathParams(ctx)
+	if !ok {
+		return
+	}
+
 	enumeratePackages(ctx, ctx.PathParam("format"), &cran_model.SearchOptions{
 		OwnerID:  ctx.Package.Owner.ID,
 		FileType: cran_module.TypeBinary,
-		Platform: ctx.PathParam("platform"),
-		RVersion: ctx.PathParam("rversion"),
+		Platform: platform,
+		RVersion: rversion,
 	})
 }
 
+// binaryPathParams gets the platform and the normalized R version of the binary repository path
+func binaryPathParams(ctx *context.Context) (string, string, bool) {
+	platform := ctx.PathParam("platform")
+	if flavor := ctx.PathParam("flavor"); flavor != "" {
+		platform += "/" + flavor
+	}
+
+	rversion, err := cran_module.NormalizeRVersion(ctx.PathParam("rversion"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return "", "", false
+	}
+	return platform, rversion, true
+}
+
+// EnumerateArchivedSourcePackages serves the index of all superseded source packages
+// which is used by remotes::install_version to find older versions.
+func EnumerateArchivedSourcePackages(ctx *context.Context) {
+	opts := &cran_model.SearchOptions{
+		OwnerID:  ctx.Package.Owner.ID,
+		FileType: cran_module.TypeSource,
+	}
+
+	pvs, err := cran_model.SearchArchivedVersions(ctx, opts)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	files := make([]*cran_module.ArchivedFile, 0, len(pds))
+	for _, pd := range pds {
+		pfd := findPackageFile(pd, opts)
+		if pfd == nil {
+			continue
+		}
+
+		files = append(files, &cran_module.ArchivedFile{
+			Name:     pd.Package.Name,
+			Filename: pfd.File.Name,
+			Size:     pfd.Blob.Size,
+			Modified: int64(pfd.File.CreatedUnix),
+		})
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Resp.WriteHeader(http.StatusOK)
+
+	if err := cran_module.WriteArchiveIndex(ctx.Resp, files); err != nil {
+		log.Error("Error writing archive index: %v", err)
+	}
+}
+
+func findPackageFile(pd *packages_model.PackageDescriptor, opts *cran_model.SearchOptions) *packages_model.PackageFileDescriptor {
+	for _, d := range pd.Files {
+		if d.Properties.GetByName(cran_module.PropertyType) == opts.FileType &&
+			d.Properties.GetByName(cran_module.PropertyPlatform) == opts.Platform &&
+			(opts.FileType != cran_module.TypeBinary || cran_module.MatchesRVersion(d.Properties.GetByName(cran_module.PropertyRVersion), opts.RVersion)) {
+			return d
+		}
+	}
+	return nil
+}
+
 func enumeratePackages(ctx *context.Context, format string, opts *cran_model.SearchOptions) {
 	if format != "" && format != ".gz" {
 		apiError(ctx, http.StatusNotFound, nil)
@@ -84,15 +160,7 @@ func enumeratePackages(ctx *context.Context, format string, opts *cran_model.Sea
 			fmt.Fprintln(w)
 		}
 
-		var pfd *packages_model.PackageFileDescriptor
-		for _, d := range pd.Files {
-			if d.Properties.GetByName(cran_module.PropertyType) == opts.FileType &&
-				d.Properties.GetByName(cran_module.PropertyPlatform) == opts.Platform &&
-				d.Properties.GetByName(cran_module.PropertyRVersion) == opts.RVersion {
-				pfd = d
-				break
-			}
-		}
+		pfd := findPackageFile(pd, opts)
 
 		metadata := pd.Metadata.(*cran_module.Metadata)
 
@@ -133,9 +201,15 @@ func UploadSourcePackageFile(ctx *context.Context) {
 }
 
 func UploadBinaryPackageFile(ctx *context.Context) {
-	platform, rversion := ctx.FormTrim("platform"), ctx.FormTrim("rversion")
-	if platform == "" || rversion == "" {
-		apiError(ctx, http.StatusBadRequest, nil)
+	platform := ctx.FormTrim("platform")
+	if err := cran_module.ValidatePlatform(platform); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	// Binaries are partitioned by the R major.minor version they were built for
+	rversion, err := cran_module.NormalizeRVersion(ctx.FormTrim("rversion"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
@@ -229,12 +303,52 @@ func DownloadSourcePackageFile(ctx *context.Context) {
 	})
 }
 
+// DownloadArchivedSourcePackageFile serves a superseded source package from src/contrib/Archive/{packagename}/
+func DownloadArchivedSourcePackageFile(ctx *context.Context) {
+	opts := &cran_model.SearchOptions{
+		OwnerID:  ctx.Package.Owner.ID,
+		Name:     ctx.PathParam("packagename"),
+		FileType: cran_module.TypeSource,
+	}
+
+	pvs, err := cran_model.SearchArchivedVersions(ctx, opts)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	opts.Filename = ctx.PathParam("filename")
+
+	pf, err := cran_model.SearchFile(ctx, opts)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// The latest version is listed in PACKAGES and not part of the archive
+	if !slices.ContainsFunc(pvs, func(pv *packages_model.PackageVersion) bool { return pv.ID == pf.VersionID }) {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	servePackageFile(ctx, pf)
+}
+
 func DownloadBinaryPackageFile(ctx *context.Context) {
+	platform, rversion, ok := binaryPathParams(ctx)
+	if !ok {
+		return
+	}
+
 	downloadPackageFile(ctx, &cran_model.SearchOptions{
 		OwnerID:  ctx.Package.Owner.ID,
 		FileType: cran_module.TypeBinary,
-		Platform: ctx.PathParam("platform"),
-		RVersion: ctx.PathParam("rversion"),
+		Platform: platform,
+		RVersion: rversion,
 		Filename: ctx.PathParam("filename"),
 	})
 }
@@ -250,6 +364,10 @@ func downloadPackageFile(ctx *context.Context, opts *cran_model.SearchOptions) {
 		return
 	}
 
+	servePackageFile(ctx, pf)
+}
+
+func servePackageFile(ctx *context.Context, pf *packages_model.PackageFile) {
 	s, u, _, err := packages_service.GetPackageFileStream(ctx, pf)
 	if err != nil {
 		if errors.Is(err, util.ErrNotExist) {
@@ -45,18 +45,25 @@ func GetRepositoryKey(ctx *context.Context) {
 // https://wiki.debian.org/DebianRepository/Format#A.22Release.22_files
 // https://wiki.debian.org/DebianRepository/Format#A.22Packages.22_Indices
 func GetRepositoryFile(ctx *context.Context) {
-	pv, err := debian_service.GetOrCreateRepositoryVersion(ctx, ctx.Package.Owner.ID)
+	distribution := ctx.PathParam("distribution")
+
+	pv, isSnapshot, err := debian_service.GetRepositoryVersionForDistribution(ctx, ctx.Package.Owner.ID, distribution)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	key := ctx.PathParam("distribution")
+	key := distribution
 
 	component := ctx.PathParam("component")
 	architecture := strings.TrimPrefix(ctx.PathParam("architecture"), "binary-")
 	if component != "" && architecture != "" {
-		key += "|" + component + "|" + architecture
+		// The index files of a snapshot are not bound to a distribution
+		if isSnapshot {
+			key = component + "|" + architecture
+		} else {
+			key += "|" + component + "|" + architecture
+		}
 	}
 
 	s, u, pf, err := packages_service.GetFileStreamByPackageVersion(
@@ -81,7 +88,7 @@ func GetRepositoryFile(ctx *context.Context) {
 
 // https://wiki.debian.org/DebianRepository/Format#indices_acquisition_via_hashsums_.28by-hash.29
 func GetRepositoryFileByHash(ctx *context.Context) {
-	pv, err := debian_service.GetOrCreateRepositoryVersion(ctx, ctx.Package.Owner.ID)
+	pv, isSnapshot, err := debian_service.GetRepositoryVersionForDistribution(ctx, ctx.Package.Owner.ID, ctx.PathParam("distribution"))
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
@@ -92,11 +99,19 @@ func GetRepositoryFileByHash(ctx *context.Context) {
 		algorithm = "md5"
 	}
 
-	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+	opts := &packages_model.PackageFileSearchOptions{
 		VersionID:     pv.ID,
 		Hash:          strings.ToLower(ctx.PathParam("hash")),
 		HashAlgorithm: algorithm,
-	})
+	}
+	if isSnapshot {
+		// A snapshot contains the pool files too
+		opts.Properties = map[string]string{
+			debian_module.PropertyRepositoryIncludeInRelease: "",
+		}
+	}
+
+	pfs, _, err := packages_model.SearchFiles(ctx, opts)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
@@ -143,6 +158,56 @@ func UploadPackageFile(ctx *context.Context) {
 	}
 	defer buf.Close()
 
+	uploadBinaryPackage(ctx, distribution, component, buf)
+}
+
+// UploadPackageFileByName handles the upload of a named file like dput does.
+// Binary packages, source control files with their referenced files and changes files are supported.
+func UploadPackageFileByName(ctx *context.Context) {
+	distribution := strings.TrimSpace(ctx.PathParam("distribution"))
+	component := strings.TrimSpace(ctx.PathParam("component"))
+	if distribution == "" || component == "" {
+		apiError(ctx, http.StatusBadRequest, "invalid distribution or component")
+		return
+	}
+
+	filename := ctx.PathParam("filename")
+	if _, err := debian_service.SourceNameFromFilename(filename); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	switch {
+	case strings.HasSuffix(filename, ".deb"):
+		uploadBinaryPackage(ctx, distribution, component, buf)
+	case strings.HasSuffix(filename, ".dsc"):
+		uploadSourceControlFile(ctx, distribution, component, filename, buf)
+	case strings.HasSuffix(filename, ".changes"):
+		checkChangesFile(ctx, distribution, component, buf)
+	case strings.Contains(filename, ".tar.") || strings.HasSuffix(filename, ".diff.gz") || strings.HasSuffix(filename, ".asc"):
+		uploadSourceFile(ctx, distribution, component, filename, buf)
+	default:
+		apiError(ctx, http.StatusBadRequest, "unsupported file type")
+	}
+}
+
+func uploadBinaryPackage(ctx *context.Context, distribution, component string, buf *packages_module.HashedBuffer) {
 	pck, err := debian_module.ParsePackage(buf)
 	if err != nil {
 		if errors.Is(err, util.ErrInvalidArgument) {
@@ -206,6 +271,174 @@ func UploadPackageFile(ctx *context.Context) {
 	ctx.Status(http.StatusCreated)
 }
 
+// https://manpages.debian.org/bookworm/dpkg-dev/dsc.5.en.html
+func uploadSourceControlFile(ctx *context.Context, distribution, component, filename string, buf *packages_module.HashedBuffer) {
+	src, err := debian_module.ParseSourceControlFile(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if !strings.HasPrefix(filename, src.Name+"_") {
+		apiError(ctx, http.StatusBadRequest, debian_module.ErrInvalidFilename)
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pv, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeDebian,
+				Name:        src.Name,
+				Version:     src.Version,
+			},
+			Creator:  ctx.Doer,
+			Metadata: src.Metadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     filename,
+				CompositeKey: debian_service.SourceFileCompositeKey(distribution, component),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+			Properties: map[string]string{
+				debian_module.PropertyDistribution: distribution,
+				debian_module.PropertyComponent:    component,
+				debian_module.PropertyArchitecture: debian_module.ArchitectureSource,
+				debian_module.PropertyControl:      src.Control,
+				debian_module.PropertySourceFiles:  debian_module.FormatSourceFilesProperty(src.Files),
+			},
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion, packages_model.ErrDuplicatePackageFile:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := debian_service.ClaimIncomingSourceFiles(ctx, ctx.Doer, ctx.Package.Owner, pv, src.Name, distribution, component, src.Files); err != nil {
+		switch err {
+		case packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := debian_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, distribution, component, debian_module.ArchitectureSource); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func uploadSourceFile(ctx *context.Context, distribution, component, filename string, buf *packages_module.HashedBuffer) {
+	if err := debian_service.AddSourceFile(ctx, ctx.Doer, ctx.Package.Owner, distribution, component, filename, buf); err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, util.ErrAlreadyExist):
+			apiError(ctx, http.StatusConflict, err)
+		case err == packages_service.ErrQuotaTypeSize, err == packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := debian_service.BuildSpecificRepositoryFiles(ctx, ctx.Package.Owner.ID, distribution, component, debian_module.ArchitectureSource); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// checkChangesFile validates that every file listed in the changes file got uploaded.
+// The changes file itself is not stored.
+// https://manpages.debian.org/bookworm/dpkg-dev/deb-changes.5.en.html
+func checkChangesFile(ctx *context.Context, distribution, component string, buf *packages_module.HashedBuffer) {
+	changes, err := debian_module.ParseChangesFile(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	missing := make([]string, 0, len(changes.Files))
+	for _, f := range changes.Files {
+		// Build information files are not stored by the registry
+		if strings.HasSuffix(f.Name, ".buildinfo") {
+			continue
+		}
+
+		found, err := debian_service.ExistsUploadedFile(ctx, ctx.Package.Owner.ID, distribution, component, f)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if !found {
+			missing = append(missing, f.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		apiError(ctx, http.StatusBadRequest, fmt.Sprintf("missing or mismatching files: %s", strings.Join(missing, ", ")))
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func DownloadSourceFile(ctx *context.Context) {
+	filename := ctx.PathParam("filename")
+
+	pf, err := debian_service.GetSourceFile(ctx, ctx.Package.Owner.ID, ctx.PathParam("distribution"), ctx.PathParam("component"), filename)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) || errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, u, pf, err := packages_service.GetPackageFileStream(ctx, pf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
 func DownloadPackageFile(ctx *context.Context) {
 	name := ctx.PathParam("name")
 	version := ctx.PathParam("version")
@@ -256,18 +489,24 @@ func DeletePackageFile(ctx *context.Context) {
 			return err
 		}
 
-		pf, err := packages_model.GetFileForVersionByName(
-			ctx,
-			pv.ID,
-			fmt.Sprintf("%s_%s_%s.deb", name, version, architecture),
-			fmt.Sprintf("%s|%s", distribution, component),
-		)
-		if err != nil {
-			return err
-		}
+		if architecture == debian_module.ArchitectureSource {
+			if err := debian_service.DeleteSourcePackageFiles(ctx, pv, distribution, component); err != nil {
+				return err
+			}
+		} else {
+			pf, err := packages_model.GetFileForVersionByName(
+				ctx,
+				pv.ID,
+				fmt.Sprintf("%s_%s_%s.deb", name, version, architecture),
+				fmt.Sprintf("%s|%s", distribution, component),
+			)
+			if err != nil {
+				return err
+			}
 
-		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
-			return err
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
 		}
 
 		has, err := packages_model.HasVersionFileReferences(ctx, pv.ID)
@@ -0,0 +1,128 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	debian_service "code.gitea.io/gitea/services/packages/debian"
+)
+
+type snapshotInfo struct {
+	Name         string    `json:"name"`
+	Distribution string    `json:"distribution"`
+	Published    []string  `json:"published"`
+	Created      time.Time `json:"created"`
+}
+
+func toSnapshotInfo(s *debian_service.Snapshot) *snapshotInfo {
+	return &snapshotInfo{
+		Name:         s.Name,
+		Distribution: s.Distribution,
+		Published:    s.Published,
+		Created:      s.Created.AsLocalTime(),
+	}
+}
+
+func snapshotError(ctx *context.Context, err error) {
+	switch {
+	case errors.Is(err, debian_service.ErrSnapshotPublished), errors.Is(err, util.ErrAlreadyExist):
+		apiError(ctx, http.StatusConflict, err)
+	case errors.Is(err, util.ErrInvalidArgument):
+		apiError(ctx, http.StatusBadRequest, err)
+	case errors.Is(err, util.ErrNotExist):
+		apiError(ctx, http.StatusNotFound, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// ListSnapshots lists all snapshots of the repository
+func ListSnapshots(ctx *context.Context) {
+	snapshots, err := debian_service.GetSnapshots(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	infos := make([]*snapshotInfo, 0, len(snapshots))
+	for _, s := range snapshots {
+		infos = append(infos, toSnapshotInfo(s))
+	}
+
+	ctx.JSON(http.StatusOK, infos)
+}
+
+// CreateSnapshot freezes the current state of a distribution
+func CreateSnapshot(ctx *context.Context) {
+	distribution := ctx.FormTrim("distribution")
+	if distribution == "" {
+		apiError(ctx, http.StatusBadRequest, "invalid distribution")
+		return
+	}
+
+	s, err := debian_service.CreateSnapshot(ctx, ctx.Package.Owner.ID, ctx.PathParam("snapshot"), distribution)
+	if err != nil {
+		snapshotError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, toSnapshotInfo(s))
+}
+
+// DeleteSnapshot deletes an unpublished snapshot
+func DeleteSnapshot(ctx *context.Context) {
+	if err := debian_service.DeleteSnapshot(ctx, ctx.Package.Owner.ID, ctx.PathParam("snapshot")); err != nil {
+		snapshotError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PublishSnapshot publishes a snapshot as distribution or switches the distribution to the snapshot
+func PublishSnapshot(ctx *context.Context) {
+	if err := debian_service.PublishSnapshot(ctx, ctx.Package.Owner.ID, ctx.PathParam("snapshot"), ctx.PathParam("distribution")); err != nil {
+		snapshotError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// UnpublishSnapshot removes a distribution published from a snapshot
+func UnpublishSnapshot(ctx *context.Context) {
+	if err := debian_service.UnpublishSnapshot(ctx, ctx.Package.Owner.ID, ctx.PathParam("snapshot"), ctx.PathParam("distribution")); err != nil {
+		snapshotError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DownloadSnapshotFile serves a package file from the pool of a snapshot
+func DownloadSnapshotFile(ctx *context.Context) {
+	pf, err := debian_service.GetSnapshotPoolFile(ctx, ctx.Package.Owner.ID, ctx.PathParam("snapshot"), ctx.PathParam("component"), ctx.PathParam("filename"))
+	if err != nil {
+		snapshotError(ctx, err)
+		return
+	}
+
+	s, u, pf, err := packages_service.GetPackageFileStream(ctx, pf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
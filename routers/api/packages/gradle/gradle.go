@@ -0,0 +1,117 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gradle
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	gradle_module "code.gitea.io/gitea/modules/packages/gradle"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	gradle_service "code.gitea.io/gitea/services/packages/gradle"
+)
+
+const gradleCacheArtifactContentType = "application/vnd.gradle.build-cache-artifact.v2"
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+// DownloadEntry serves a build cache entry
+// https://docs.gradle.org/current/userguide/build_cache.html#sec:build_cache_configure_remote
+func DownloadEntry(ctx *context.Context) {
+	key := ctx.PathParam("key")
+	if !gradle_module.IsValidKey(key) {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	s, u, pf, err := gradle_service.GetEntryStream(ctx, ctx.Package.Owner, key)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
+		ContentType:  gradleCacheArtifactContentType,
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// CheckEntry checks if a build cache entry exists.
+// It does not count as a cache hit or download and does not update the access time of the entry.
+func CheckEntry(ctx *context.Context) {
+	key := ctx.PathParam("key")
+	if !gradle_module.IsValidKey(key) {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	pf, pb, err := gradle_service.GetEntry(ctx, ctx.Package.Owner, key)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", gradleCacheArtifactContentType)
+	ctx.Resp.Header().Set("Content-Length", strconv.FormatInt(pb.Size, 10))
+	ctx.Resp.Header().Set("Last-Modified", pf.CreatedUnix.Format(http.TimeFormat))
+	ctx.Status(http.StatusOK)
+}
+
+// UploadEntry stores a build cache entry
+// Gradle ignores entries rejected with 413 instead of disabling the remote cache.
+func UploadEntry(ctx *context.Context) {
+	key := ctx.PathParam("key")
+	if !gradle_module.IsValidKey(key) {
+		apiError(ctx, http.StatusBadRequest, "invalid cache key")
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if err := gradle_service.UploadEntry(ctx, ctx.Doer, ctx.Package.Owner, key, buf); err != nil {
+		switch {
+		case errors.Is(err, packages_service.ErrQuotaTypeSize):
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,319 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	hex_module "code.gitea.io/gitea/modules/packages/hex"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	hex_service "code.gitea.io/gitea/services/packages/hex"
+)
+
+// apiResponse writes the response in the Erlang term format if the client accepts it and as JSON otherwise
+func apiResponse(ctx *context.Context, status int, obj map[string]any) {
+	if !strings.Contains(ctx.Req.Header.Get("Accept"), hex_module.ContentTypeErlang) {
+		ctx.JSON(status, obj)
+		return
+	}
+
+	data, err := hex_module.EncodeTerm(obj)
+	if err != nil {
+		log.Error("EncodeTerm: %v", err)
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", hex_module.ContentTypeErlang)
+	ctx.Resp.WriteHeader(status)
+	if _, err := ctx.Resp.Write(data); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		apiResponse(ctx, status, map[string]any{
+			"status":  status,
+			"message": message,
+		})
+	})
+}
+
+func baseURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/hex", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name))
+}
+
+func serveResource(ctx *context.Context, data []byte, err error) {
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.ServeContent(bytes.NewReader(data), &context.ServeHeaderOptions{
+		ContentType: "application/octet-stream",
+	})
+}
+
+// EnumeratePackageNames serves the signed names resource
+// https://github.com/hexpm/specifications/blob/main/endpoints.md#repository
+func EnumeratePackageNames(ctx *context.Context) {
+	data, err := hex_service.BuildNames(ctx, ctx.Package.Owner)
+	serveResource(ctx, data, err)
+}
+
+// EnumeratePackageVersions serves the signed versions resource
+func EnumeratePackageVersions(ctx *context.Context) {
+	data, err := hex_service.BuildVersions(ctx, ctx.Package.Owner)
+	serveResource(ctx, data, err)
+}
+
+// PackageReleases serves the signed package resource
+func PackageReleases(ctx *context.Context) {
+	name := ctx.PathParam("name")
+	if !hex_module.IsValidName(name) {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	data, err := hex_service.BuildPackage(ctx, ctx.Package.Owner, name)
+	serveResource(ctx, data, err)
+}
+
+// GetPublicKey serves the public key used to verify the registry resources
+func GetPublicKey(ctx *context.Context) {
+	_, pub, err := hex_service.GetOrCreateKeyPair(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(strings.NewReader(pub), &context.ServeHeaderOptions{
+		ContentType: "application/x-pem-file",
+		Filename:    ctx.Package.Owner.LowerName + ".pem",
+	})
+}
+
+// DownloadTarball serves the tarball of a release
+func DownloadTarball(ctx *context.Context) {
+	filename := ctx.PathParam("filename")
+
+	base, ok := strings.CutSuffix(filename, ".tar")
+	if !ok {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+	name, version, _ := strings.Cut(base, "-")
+
+	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeHex,
+			Name:        name,
+			Version:     version,
+		},
+		&packages_service.PackageFileInfo{
+			Filename: filename,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+func releaseInfo(ctx *context.Context, pd *packages_model.PackageDescriptor) map[string]any {
+	info := map[string]any{
+		"version":     pd.Version.Version,
+		"has_docs":    false,
+		"inserted_at": pd.Version.CreatedUnix.AsTime().UTC().Format(time.RFC3339),
+		"url":         fmt.Sprintf("%s/api/packages/%s/releases/%s", baseURL(ctx), pd.Package.Name, url.PathEscape(pd.Version.Version)),
+		"html_url":    pd.VersionHTMLURL(),
+		"package_url": fmt.Sprintf("%s/api/packages/%s", baseURL(ctx), pd.Package.Name),
+	}
+	if len(pd.Files) > 0 {
+		info["checksum"] = pd.Files[0].Blob.HashSHA256
+	}
+	return info
+}
+
+// PackageInfo returns the API information about a package and its releases
+// https://github.com/hexpm/specifications/blob/main/apiary.apib
+func PackageInfo(ctx *context.Context) {
+	name := ctx.PathParam("name")
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeHex, name)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].SemVer.GreaterThan(pds[j].SemVer)
+	})
+
+	releases := make([]map[string]any, 0, len(pds))
+	for _, pd := range pds {
+		releases = append(releases, releaseInfo(ctx, pd))
+	}
+
+	metadata := pds[0].Metadata.(*hex_module.Metadata)
+
+	meta := map[string]any{
+		"description": metadata.Description,
+		"licenses":    metadata.Licenses,
+	}
+	if metadata.Links != nil {
+		meta["links"] = metadata.Links
+	}
+
+	apiResponse(ctx, http.StatusOK, map[string]any{
+		"name":       pds[0].Package.Name,
+		"repository": ctx.Package.Owner.Name,
+		"url":        fmt.Sprintf("%s/api/packages/%s", baseURL(ctx), pds[0].Package.Name),
+		"html_url":   pds[0].PackageHTMLURL(),
+		"meta":       meta,
+		"releases":   releases,
+	})
+}
+
+// UploadPackage publishes a release from a tarball
+// https://github.com/hexpm/specifications/blob/main/apiary.apib
+func UploadPackage(ctx *context.Context) {
+	upload, needsClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needsClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	p, err := hex_module.ParsePackage(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusUnprocessableEntity, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pv, _, err := packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeHex,
+				Name:        p.Name,
+				Version:     p.Version,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata:         p.Metadata,
+			VersionProperties: map[string]string{
+				hex_module.PropertyInnerChecksum: p.InnerChecksum,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: hex_module.Filename(p.Name, p.Version),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	apiResponse(ctx, http.StatusCreated, releaseInfo(ctx, pd))
+}
+
+// DeleteRelease reverts a published release
+func DeleteRelease(ctx *context.Context) {
+	err := packages_service.RemovePackageVersionByNameAndVersion(
+		ctx,
+		ctx.Doer,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeHex,
+			Name:        ctx.PathParam("name"),
+			Version:     ctx.PathParam("version"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
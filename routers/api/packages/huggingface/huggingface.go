@@ -0,0 +1,589 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package huggingface
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	huggingface_module "code.gitea.io/gitea/modules/packages/huggingface"
+	lfs_module "code.gitea.io/gitea/modules/packages/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	huggingface_service "code.gitea.io/gitea/services/packages/huggingface"
+)
+
+// uploadLinkLifetime is the time in minutes an upload link of a large file is valid
+const uploadLinkLifetime = 60
+
+// The client maps these codes of the X-Error-Code header to specific exceptions
+// https://github.com/huggingface/huggingface_hub/blob/main/src/huggingface_hub/utils/_http.py
+const (
+	errorCodeRepoNotFound     = "RepoNotFound"
+	errorCodeRevisionNotFound = "RevisionNotFound"
+	errorCodeEntryNotFound    = "EntryNotFound"
+)
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.Resp.Header().Set("X-Error-Message", message)
+		ctx.JSON(status, map[string]string{
+			"error": message,
+		})
+	})
+}
+
+func apiErrorWithCode(ctx *context.Context, status int, code string, obj any) {
+	ctx.Resp.Header().Set("X-Error-Code", code)
+	apiError(ctx, status, obj)
+}
+
+func baseURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/model", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name))
+}
+
+// repositoryURL gets the url of the repository, datasets are located below the datasets prefix like on the Hub
+func repositoryURL(ctx *context.Context, repoType, repoID string) string {
+	if repoType == huggingface_module.RepoTypeDataset {
+		return baseURL(ctx) + "/datasets/" + repoID
+	}
+	return baseURL(ctx) + "/" + repoID
+}
+
+// repoType gets the type of the repository the request is routed to
+func repoType(ctx *context.Context) string {
+	t, _ := ctx.Data["HuggingFaceRepoType"].(string)
+	return t
+}
+
+// repositoryID gets the namespace/name id of the repository
+// The git endpoints of the large file API append .git to the name.
+func repositoryID(ctx *context.Context) (string, bool) {
+	namespace := ctx.PathParam("namespace")
+	name := strings.TrimSuffix(ctx.PathParam("name"), ".git")
+	if !huggingface_module.IsValidName(namespace) || !huggingface_module.IsValidName(name) {
+		return "", false
+	}
+	return namespace + "/" + name, true
+}
+
+// CheckRepository returns a handler which validates the repository id of the request and remembers the type of the routed repository
+func CheckRepository(repoType string) func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		if _, ok := repositoryID(ctx); !ok {
+			apiErrorWithCode(ctx, http.StatusNotFound, errorCodeRepoNotFound, "invalid repository id")
+			return
+		}
+		ctx.Data["HuggingFaceRepoType"] = repoType
+	}
+}
+
+// CreateRepository reserves a model or dataset repository
+// The repository is created with an empty main revision, like the Hub does with the initial commit.
+// Models and datasets share the repository ids of the owner.
+func CreateRepository(ctx *context.Context) {
+	var req struct {
+		Name         string `json:"name"`
+		Organization string `json:"organization"`
+		Type         string `json:"type"`
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = huggingface_module.RepoTypeModel
+	}
+	if !huggingface_module.IsValidRepoType(req.Type) {
+		apiError(ctx, http.StatusBadRequest, "only model and dataset repositories are supported")
+		return
+	}
+
+	namespace := req.Organization
+	if namespace == "" {
+		namespace = ctx.Package.Owner.Name
+	}
+	if !huggingface_module.IsValidName(namespace) || !huggingface_module.IsValidName(req.Name) {
+		apiError(ctx, http.StatusBadRequest, "invalid repository id")
+		return
+	}
+	repoID := namespace + "/" + req.Name
+
+	if _, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeModel, repoID); err == nil {
+		ctx.JSON(http.StatusConflict, map[string]string{
+			"error": "repository already exists",
+			"url":   repositoryURL(ctx, req.Type, repoID),
+		})
+		return
+	} else if !errors.Is(err, util.ErrNotExist) {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, _, err := huggingface_service.CreateCommit(ctx, ctx.Doer, ctx.Package.Owner, req.Type, repoID, huggingface_module.DefaultRevision, &huggingface_module.Commit{
+		Summary: "initial commit",
+	}); err != nil {
+		handleCommitError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]string{
+		"url": repositoryURL(ctx, req.Type, repoID),
+	})
+}
+
+// getRevision gets the requested revision and its commit id
+// If no revision is requested, the default revision is used.
+func getRevision(ctx *context.Context, repoID string) (*packages_model.PackageVersion, string, bool) {
+	revision := ctx.PathParam("revision")
+	if revision == "" {
+		revision = huggingface_module.DefaultRevision
+	}
+
+	pv, err := huggingface_service.GetRevision(ctx, ctx.Package.Owner.ID, repoType(ctx), repoID, revision)
+	if err != nil {
+		if errors.Is(err, huggingface_service.ErrRepositoryNotExist) {
+			apiErrorWithCode(ctx, http.StatusNotFound, errorCodeRepoNotFound, err)
+		} else if errors.Is(err, util.ErrNotExist) {
+			apiErrorWithCode(ctx, http.StatusNotFound, errorCodeRevisionNotFound, packages_model.ErrPackageNotExist)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, "", false
+	}
+
+	commit, err := huggingface_service.GetCommit(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, "", false
+	}
+
+	return pv, commit, true
+}
+
+type sibling struct {
+	Filename string `json:"rfilename"`
+	Size     int64  `json:"size"`
+}
+
+type repositoryInfo struct {
+	ID           string         `json:"id"`
+	Author       string         `json:"author"`
+	SHA          string         `json:"sha"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	LastModified time.Time      `json:"lastModified"`
+	Private      bool           `json:"private"`
+	Disabled     bool           `json:"disabled"`
+	Gated        bool           `json:"gated"`
+	Downloads    int64          `json:"downloads"`
+	Likes        int            `json:"likes"`
+	Tags         []string       `json:"tags"`
+	CardData     map[string]any `json:"cardData,omitempty"`
+	Siblings     []*sibling     `json:"siblings"`
+}
+
+type modelInfo struct {
+	*repositoryInfo
+	ModelID     string `json:"modelId"`
+	LibraryName string `json:"library_name,omitempty"`
+	PipelineTag string `json:"pipeline_tag,omitempty"`
+}
+
+// getRepositoryInfo gets the information and the file list of the requested revision
+func getRepositoryInfo(ctx *context.Context) (*repositoryInfo, *huggingface_module.Metadata, bool) {
+	repoID, _ := repositoryID(ctx)
+
+	pv, commit, ok := getRevision(ctx, repoID)
+	if !ok {
+		return nil, nil, false
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	metadata := pd.Metadata.(*huggingface_module.Metadata)
+
+	siblings := make([]*sibling, 0, len(pd.Files))
+	lastModified := pd.Version.CreatedUnix.AsLocalTime()
+	for _, pfd := range pd.Files {
+		siblings = append(siblings, &sibling{
+			Filename: pfd.File.Name,
+			Size:     pfd.Blob.Size,
+		})
+		if t := pfd.File.CreatedUnix.AsLocalTime(); t.After(lastModified) {
+			lastModified = t
+		}
+	}
+
+	tags := metadata.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &repositoryInfo{
+		ID:           pd.Package.Name,
+		Author:       strings.SplitN(pd.Package.Name, "/", 2)[0],
+		SHA:          commit,
+		CreatedAt:    pd.Version.CreatedUnix.AsLocalTime(),
+		LastModified: lastModified,
+		Private:      setting.Service.RequireSignInView,
+		Downloads:    pd.Version.DownloadCount,
+		Tags:         tags,
+		CardData:     metadata.CardData,
+		Siblings:     siblings,
+	}, metadata, true
+}
+
+// ModelInfo serves the information and the file list of a revision of a model
+func ModelInfo(ctx *context.Context) {
+	info, metadata, ok := getRepositoryInfo(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &modelInfo{
+		repositoryInfo: info,
+		ModelID:        info.ID,
+		LibraryName:    metadata.LibraryName,
+		PipelineTag:    metadata.PipelineTag,
+	})
+}
+
+// DatasetInfo serves the information and the file list of a revision of a dataset
+func DatasetInfo(ctx *context.Context) {
+	info, _, ok := getRepositoryInfo(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, info)
+}
+
+// ResolveFile serves a file of a revision
+// The client uses a HEAD request to get the commit and the etag of the file before downloading it.
+func ResolveFile(ctx *context.Context) {
+	repoID, _ := repositoryID(ctx)
+
+	pv, commit, ok := getRevision(ctx, repoID)
+	if !ok {
+		return
+	}
+
+	path := ctx.PathParam("*")
+	if !huggingface_module.IsValidFilePath(path) {
+		apiErrorWithCode(ctx, http.StatusNotFound, errorCodeEntryNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, path, packages_model.EmptyFileKey)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiErrorWithCode(ctx, http.StatusNotFound, errorCodeEntryNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	s, u, _, err := packages_service.GetPackageBlobStream(ctx, pf, pb)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The linked headers are kept if the request is redirected to the storage
+	etag := `"` + pb.HashSHA256 + `"`
+	header := ctx.Resp.Header()
+	header.Set("X-Repo-Commit", commit)
+	header.Set("ETag", etag)
+	header.Set("X-Linked-Etag", etag)
+	header.Set("X-Linked-Size", strconv.FormatInt(pb.Size, 10))
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// Preupload tells the client which files must be uploaded in advance as large files
+func Preupload(ctx *context.Context) {
+	var req struct {
+		Files []struct {
+			Path   string `json:"path"`
+			Sample string `json:"sample"`
+			Size   int64  `json:"size"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	type fileMode struct {
+		Path         string `json:"path"`
+		UploadMode   string `json:"uploadMode"`
+		ShouldIgnore bool   `json:"shouldIgnore"`
+	}
+
+	files := make([]*fileMode, 0, len(req.Files))
+	for _, f := range req.Files {
+		if !huggingface_module.IsValidFilePath(f.Path) {
+			apiError(ctx, http.StatusBadRequest, huggingface_module.ErrInvalidFilePath)
+			return
+		}
+
+		sample, err := base64.StdEncoding.DecodeString(f.Sample)
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		files = append(files, &fileMode{
+			Path:       f.Path,
+			UploadMode: huggingface_module.UploadMode(f.Size, sample),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, map[string]any{
+		"files": files,
+	})
+}
+
+// Commit applies the operations of the commit to the revision
+func Commit(ctx *context.Context) {
+	repoID, _ := repositoryID(ctx)
+
+	revision := ctx.PathParam("revision")
+	if !huggingface_module.IsValidRevision(revision) {
+		apiErrorWithCode(ctx, http.StatusBadRequest, errorCodeRevisionNotFound, "invalid revision")
+		return
+	}
+
+	if ctx.FormBool("create_pr") {
+		apiError(ctx, http.StatusBadRequest, "pull requests are not supported")
+		return
+	}
+
+	c, err := huggingface_module.ParseCommit(ctx.Req.Body)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	_, commit, err := huggingface_service.CreateCommit(ctx, ctx.Doer, ctx.Package.Owner, repoType(ctx), repoID, revision, c)
+	if err != nil {
+		handleCommitError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]any{
+		"commitUrl":         repositoryURL(ctx, repoType(ctx), repoID) + "/commit/" + commit,
+		"commitOid":         commit,
+		"commitMessage":     c.Summary,
+		"commitDescription": c.Description,
+		"pullRequestUrl":    nil,
+	})
+}
+
+func handleCommitError(ctx *context.Context, err error) {
+	switch {
+	case errors.Is(err, util.ErrInvalidArgument):
+		apiError(ctx, http.StatusBadRequest, err)
+	case errors.Is(err, huggingface_service.ErrRepositoryNotExist):
+		apiErrorWithCode(ctx, http.StatusNotFound, errorCodeRepoNotFound, err)
+	case errors.Is(err, util.ErrNotExist):
+		apiErrorWithCode(ctx, http.StatusNotFound, errorCodeEntryNotFound, err)
+	case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+		apiError(ctx, http.StatusForbidden, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+func lfsResponse(ctx *context.Context, status int, obj any) {
+	ctx.Resp.Header().Set("Content-Type", lfs_module.MediaType)
+	ctx.Resp.WriteHeader(status)
+	if err := json.NewEncoder(ctx.Resp).Encode(obj); err != nil {
+		log.Error("JSON encode: %v", err)
+	}
+}
+
+func uploadCodeData(ownerID, doerID int64, oid string) string {
+	return fmt.Sprintf("huggingface-upload:%d:%d:%s", ownerID, doerID, oid)
+}
+
+// Batch returns the links to upload the large files
+// The client uploads the content without credentials, so the links are signed.
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md
+func Batch(ctx *context.Context) {
+	var req lfs_module.BatchRequest
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Operation != lfs_module.OperationUpload {
+		lfsResponse(ctx, http.StatusUnprocessableEntity, &lfs_module.ErrorResponse{Message: "only uploads are supported, use the resolve endpoint to download files"})
+		return
+	}
+
+	repoID, _ := repositoryID(ctx)
+	lfsURL := repositoryURL(ctx, repoType(ctx), repoID) + ".git/info/lfs"
+
+	var header map[string]string
+	if auth := ctx.Req.Header.Get("Authorization"); auth != "" {
+		header = map[string]string{"Authorization": auth}
+	}
+
+	objects := make([]*lfs_module.ObjectResponse, 0, len(req.Objects))
+	for _, p := range req.Objects {
+		obj := &lfs_module.ObjectResponse{Pointer: p}
+		objects = append(objects, obj)
+
+		if !p.IsValid() {
+			obj.Error = &lfs_module.ObjectError{Code: http.StatusUnprocessableEntity, Message: "invalid object"}
+			continue
+		}
+
+		_, pb, err := huggingface_service.GetObject(ctx, ctx.Package.Owner.ID, p.Oid)
+		if err == nil {
+			if pb.Size != p.Size {
+				obj.Error = &lfs_module.ObjectError{Code: http.StatusUnprocessableEntity, Message: "object size does not match"}
+			}
+			continue
+		}
+		if !errors.Is(err, util.ErrNotExist) {
+			log.Error("Unable to get object %s: %v", p.Oid, err)
+			obj.Error = &lfs_module.ObjectError{Code: http.StatusInternalServerError, Message: "unable to get object"}
+			continue
+		}
+
+		code := base.CreateTimeLimitCode(uploadCodeData(ctx.Package.Owner.ID, ctx.Doer.ID, p.Oid), uploadLinkLifetime, time.Now(), nil)
+
+		obj.Actions = map[string]*lfs_module.Link{
+			"upload": {Href: fmt.Sprintf("%s/objects/%s?user=%d&code=%s", lfsURL, p.Oid, ctx.Doer.ID, code)},
+			"verify": {Href: lfsURL + "/verify", Header: header},
+		}
+	}
+
+	lfsResponse(ctx, http.StatusOK, &lfs_module.BatchResponse{
+		Transfer: lfs_module.TransferBasic,
+		Objects:  objects,
+	})
+}
+
+// UploadObject stores the content of a large file
+// The request is authorized by the signed link created by the batch request.
+func UploadObject(ctx *context.Context) {
+	oid := ctx.PathParam("oid")
+	if !huggingface_module.IsValidOID(oid) {
+		apiError(ctx, http.StatusBadRequest, huggingface_module.ErrInvalidObjectID)
+		return
+	}
+
+	doerID := ctx.FormInt64("user")
+	if !base.VerifyTimeLimitCode(time.Now(), uploadCodeData(ctx.Package.Owner.ID, doerID, oid), uploadLinkLifetime, ctx.FormString("code")) {
+		apiError(ctx, http.StatusUnauthorized, "invalid or expired upload link")
+		return
+	}
+
+	doer, err := user_model.GetUserByID(ctx, doerID)
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			apiError(ctx, http.StatusUnauthorized, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if !doer.IsActive || doer.ProhibitLogin {
+		apiError(ctx, http.StatusUnauthorized, "user is not allowed to upload")
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if err := huggingface_service.UploadObject(ctx, doer, ctx.Package.Owner, oid, buf); err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// VerifyObject checks if a large file was uploaded completely
+func VerifyObject(ctx *context.Context) {
+	var p lfs_module.Pointer
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&p); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !p.IsValid() {
+		apiError(ctx, http.StatusBadRequest, huggingface_module.ErrInvalidObjectID)
+		return
+	}
+
+	_, pb, err := huggingface_service.GetObject(ctx, ctx.Package.Owner.ID, p.Oid)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if pb.Size != p.Size {
+		apiError(ctx, http.StatusBadRequest, huggingface_service.ErrSizeMismatch)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
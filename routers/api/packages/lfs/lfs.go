@@ -0,0 +1,434 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	lfs_model "code.gitea.io/gitea/models/packages/lfs"
+	"code.gitea.io/gitea/models/perm"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	lfs_module "code.gitea.io/gitea/modules/packages/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	lfs_service "code.gitea.io/gitea/services/packages/lfs"
+)
+
+const (
+	defaultLockLimit = 100
+	maxLockLimit     = 1000
+)
+
+func jsonResponse(ctx *context.Context, status int, obj any) {
+	ctx.Resp.Header().Set("Content-Type", lfs_module.MediaType)
+	ctx.Resp.WriteHeader(status)
+	if err := json.NewEncoder(ctx.Resp).Encode(obj); err != nil {
+		log.Error("JSON encode: %v", err)
+	}
+}
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		jsonResponse(ctx, status, &lfs_module.ErrorResponse{
+			Message: message,
+		})
+	})
+}
+
+func projectURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/lfs/%s", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name), url.PathEscape(ctx.PathParam("project")))
+}
+
+// CheckProject validates the project namespace of the request
+func CheckProject(ctx *context.Context) {
+	if !lfs_module.IsValidProjectName(ctx.PathParam("project")) {
+		apiError(ctx, http.StatusNotFound, "invalid project name")
+	}
+}
+
+// Batch returns the handler of the batch API
+// The operation is part of the request body, so uploads are checked by reqWrite after decoding it.
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md
+func Batch(reqWrite func(*context.Context)) func(*context.Context) {
+	return func(ctx *context.Context) {
+		var req lfs_module.BatchRequest
+		if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+			apiError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Operation != lfs_module.OperationDownload && req.Operation != lfs_module.OperationUpload {
+			apiError(ctx, http.StatusUnprocessableEntity, "unsupported operation")
+			return
+		}
+		if len(req.Transfers) > 0 && !slices.Contains(req.Transfers, lfs_module.TransferBasic) {
+			apiError(ctx, http.StatusUnprocessableEntity, "only the basic transfer adapter is supported")
+			return
+		}
+
+		if req.Operation == lfs_module.OperationUpload {
+			reqWrite(ctx)
+			if ctx.Written() {
+				return
+			}
+		}
+
+		project := ctx.PathParam("project")
+		base := projectURL(ctx)
+
+		// Transfers are authenticated with the credentials of the batch request
+		var header map[string]string
+		if auth := ctx.Req.Header.Get("Authorization"); auth != "" {
+			header = map[string]string{"Authorization": auth}
+		}
+
+		objects := make([]*lfs_module.ObjectResponse, 0, len(req.Objects))
+		for _, p := range req.Objects {
+			obj := &lfs_module.ObjectResponse{Pointer: p}
+			objects = append(objects, obj)
+
+			if !p.IsValid() {
+				obj.Error = &lfs_module.ObjectError{Code: http.StatusUnprocessableEntity, Message: "invalid object"}
+				continue
+			}
+
+			_, pb, err := lfs_service.GetObject(ctx, ctx.Package.Owner.ID, project, p.Oid)
+			exists := err == nil
+			if err != nil && !errors.Is(err, util.ErrNotExist) {
+				log.Error("Unable to get object %s of project %s: %v", p.Oid, project, err)
+				obj.Error = &lfs_module.ObjectError{Code: http.StatusInternalServerError, Message: "unable to get object"}
+				continue
+			}
+			if exists && pb.Size != p.Size {
+				obj.Error = &lfs_module.ObjectError{Code: http.StatusUnprocessableEntity, Message: "object size does not match"}
+				continue
+			}
+
+			href := base + "/objects/" + p.Oid
+			if req.Operation == lfs_module.OperationDownload {
+				if !exists {
+					obj.Error = &lfs_module.ObjectError{Code: http.StatusNotFound, Message: "object does not exist"}
+					continue
+				}
+				obj.Actions = map[string]*lfs_module.Link{
+					"download": {Href: href, Header: header},
+				}
+			} else if !exists {
+				obj.Actions = map[string]*lfs_module.Link{
+					"upload": {Href: href, Header: header},
+					"verify": {Href: base + "/verify", Header: header},
+				}
+			}
+		}
+
+		jsonResponse(ctx, http.StatusOK, &lfs_module.BatchResponse{
+			Transfer: lfs_module.TransferBasic,
+			Objects:  objects,
+		})
+	}
+}
+
+// DownloadObject serves the content of an object
+func DownloadObject(ctx *context.Context) {
+	oid := ctx.PathParam("oid")
+	if !lfs_module.IsValidOID(oid) {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	s, u, pf, err := lfs_service.GetObjectStream(ctx, ctx.Package.Owner.ID, ctx.PathParam("project"), oid)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
+		ContentType:  "application/octet-stream",
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// UploadObject stores the content of an object
+func UploadObject(ctx *context.Context) {
+	oid := ctx.PathParam("oid")
+	if !lfs_module.IsValidOID(oid) {
+		apiError(ctx, http.StatusUnprocessableEntity, "invalid object id")
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if err := lfs_service.UploadObject(ctx, ctx.Doer, ctx.Package.Owner, ctx.PathParam("project"), oid, buf); err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// VerifyObject checks if an uploaded object is stored with the expected size
+func VerifyObject(ctx *context.Context) {
+	var p lfs_module.Pointer
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&p); err != nil || !p.IsValid() {
+		apiError(ctx, http.StatusUnprocessableEntity, "invalid object")
+		return
+	}
+
+	_, pb, err := lfs_service.GetObject(ctx, ctx.Package.Owner.ID, ctx.PathParam("project"), p.Oid)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if pb.Size != p.Size {
+		apiError(ctx, http.StatusUnprocessableEntity, "object size does not match")
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+func toLock(ctx *context.Context, l *lfs_model.Lock) *lfs_module.Lock {
+	owner := &lfs_module.LockOwner{Name: user_model.GhostUserName}
+	if u, err := user_model.GetUserByID(ctx, l.UserID); err == nil {
+		owner.Name = u.Name
+	}
+
+	return &lfs_module.Lock{
+		ID:       strconv.FormatInt(l.ID, 10),
+		Path:     l.Path,
+		LockedAt: l.CreatedUnix.AsLocalTime(),
+		Owner:    owner,
+	}
+}
+
+// CreateLock locks a path of the project
+// https://github.com/git-lfs/git-lfs/blob/main/docs/api/locking.md
+func CreateLock(ctx *context.Context) {
+	var req lfs_module.LockCreateRequest
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil || lfs_model.CleanPath(req.Path) == "" {
+		apiError(ctx, http.StatusUnprocessableEntity, "invalid lock path")
+		return
+	}
+
+	l, err := lfs_model.CreateLock(ctx, &lfs_model.Lock{
+		OwnerID: ctx.Package.Owner.ID,
+		Project: ctx.PathParam("project"),
+		Path:    req.Path,
+		UserID:  ctx.Doer.ID,
+	})
+	if err != nil {
+		if errors.Is(err, lfs_model.ErrLockAlreadyExists) {
+			jsonResponse(ctx, http.StatusConflict, &lfs_module.ErrorResponse{
+				Message: err.Error(),
+				Lock:    toLock(ctx, l),
+			})
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	jsonResponse(ctx, http.StatusCreated, &lfs_module.LockResponse{Lock: toLock(ctx, l)})
+}
+
+func parseLockPaging(cursor string, limit int) (int64, int, error) {
+	var start int64
+	if cursor != "" {
+		var err error
+		start, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if limit <= 0 {
+		limit = defaultLockLimit
+	}
+	return start, min(limit, maxLockLimit), nil
+}
+
+// getLockPage gets a page of locks and the cursor of the next page
+func getLockPage(ctx *context.Context, cursor string, limit int) ([]*lfs_model.Lock, string, error) {
+	start, limit, err := parseLockPaging(cursor, limit)
+	if err != nil {
+		return nil, "", util.NewInvalidArgumentErrorf("invalid cursor")
+	}
+
+	locks, err := lfs_model.GetLocks(ctx, ctx.Package.Owner.ID, ctx.PathParam("project"), start, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(locks) > limit {
+		next = strconv.FormatInt(locks[limit].ID, 10)
+		locks = locks[:limit]
+	}
+	return locks, next, nil
+}
+
+// ListLocks lists the locks of the project
+func ListLocks(ctx *context.Context) {
+	project := ctx.PathParam("project")
+
+	if id := ctx.FormString("id"); id != "" {
+		lockID, _ := strconv.ParseInt(id, 10, 64)
+		l, err := lfs_model.GetLockByID(ctx, ctx.Package.Owner.ID, project, lockID)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		list := &lfs_module.LockList{Locks: []*lfs_module.Lock{}}
+		if l != nil {
+			list.Locks = append(list.Locks, toLock(ctx, l))
+		}
+		jsonResponse(ctx, http.StatusOK, list)
+		return
+	}
+
+	if p := ctx.FormString("path"); p != "" {
+		l, err := lfs_model.GetLockByPath(ctx, ctx.Package.Owner.ID, project, p)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		list := &lfs_module.LockList{Locks: []*lfs_module.Lock{}}
+		if l != nil {
+			list.Locks = append(list.Locks, toLock(ctx, l))
+		}
+		jsonResponse(ctx, http.StatusOK, list)
+		return
+	}
+
+	locks, next, err := getLockPage(ctx, ctx.FormString("cursor"), ctx.FormInt("limit"))
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	list := &lfs_module.LockList{
+		Locks:      make([]*lfs_module.Lock, 0, len(locks)),
+		NextCursor: next,
+	}
+	for _, l := range locks {
+		list.Locks = append(list.Locks, toLock(ctx, l))
+	}
+	jsonResponse(ctx, http.StatusOK, list)
+}
+
+// VerifyLocks lists the locks of the project split by the user who created them
+func VerifyLocks(ctx *context.Context) {
+	var req lfs_module.LockVerifyRequest
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	locks, next, err := getLockPage(ctx, req.Cursor, req.Limit)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	resp := &lfs_module.LockVerifyResponse{
+		Ours:       []*lfs_module.Lock{},
+		Theirs:     []*lfs_module.Lock{},
+		NextCursor: next,
+	}
+	for _, l := range locks {
+		if l.UserID == ctx.Doer.ID {
+			resp.Ours = append(resp.Ours, toLock(ctx, l))
+		} else {
+			resp.Theirs = append(resp.Theirs, toLock(ctx, l))
+		}
+	}
+	jsonResponse(ctx, http.StatusOK, resp)
+}
+
+// Unlock removes a lock
+// Locks of other users can only be removed with force by owners of the namespace.
+func Unlock(ctx *context.Context) {
+	var req lfs_module.LockDeleteRequest
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	l, err := lfs_model.GetLockByID(ctx, ctx.Package.Owner.ID, ctx.PathParam("project"), ctx.PathParamInt64("id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if l.UserID != ctx.Doer.ID {
+		if !req.Force {
+			apiError(ctx, http.StatusForbidden, "the lock is owned by another user")
+			return
+		}
+		if ctx.Package.AccessMode < perm.AccessModeAdmin && !ctx.IsUserSiteAdmin() {
+			apiError(ctx, http.StatusForbidden, "forcing an unlock requires owner permissions")
+			return
+		}
+	}
+
+	if err := lfs_model.DeleteLock(ctx, l.ID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(ctx, http.StatusOK, &lfs_module.LockResponse{Lock: toLock(ctx, l)})
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package nix
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	nix_module "code.gitea.io/gitea/modules/packages/nix"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	nix_service "code.gitea.io/gitea/services/packages/nix"
+)
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, message)
+	})
+}
+
+// CacheInfo serves the properties of the binary cache
+// https://github.com/NixOS/nix/blob/master/src/libstore/binary-cache-store.cc
+func CacheInfo(ctx *context.Context) {
+	ctx.ServeContent(strings.NewReader(fmt.Sprintf("StoreDir: %s\nWantMassQuery: 1\nPriority: 40\n", nix_module.StoreDir)), &context.ServeHeaderOptions{
+		ContentType: nix_module.ContentTypeCacheInfo,
+	})
+}
+
+// GetRepositoryKey serves the public key which must be added to the trusted-public-keys
+func GetRepositoryKey(ctx *context.Context) {
+	_, pub, err := nix_service.GetOrCreateKeyPair(ctx, ctx.Package.Owner)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(strings.NewReader(pub), &context.ServeHeaderOptions{
+		ContentType: "text/plain",
+		Filename:    "repository.key",
+	})
+}
+
+// GetNarInfo serves the signed narinfo of a store path
+func GetNarInfo(ctx *context.Context) {
+	hash, ok := strings.CutSuffix(ctx.PathParam("filename"), ".narinfo")
+	if !ok || !nix_module.IsValidHash(hash) {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	narInfo, err := nix_service.GetNarInfo(ctx, ctx.Package.Owner, hash)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.ServeContent(strings.NewReader(narInfo), &context.ServeHeaderOptions{
+		ContentType: nix_module.ContentTypeNarInfo,
+	})
+}
+
+// UploadNarInfo creates a store path from the uploaded narinfo
+// The NAR file referenced by the narinfo must be uploaded first.
+func UploadNarInfo(ctx *context.Context) {
+	hash, ok := strings.CutSuffix(ctx.PathParam("filename"), ".narinfo")
+	if !ok || !nix_module.IsValidHash(hash) {
+		apiError(ctx, http.StatusBadRequest, "invalid narinfo filename")
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	n, err := nix_module.ParseNarInfo(upload)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if n.Hash() != hash {
+		apiError(ctx, http.StatusBadRequest, "narinfo filename does not match the store path")
+		return
+	}
+
+	if _, err := nix_service.UploadNarInfo(ctx, ctx.Doer, ctx.Package.Owner, n); err != nil {
+		switch {
+		case errors.Is(err, packages_model.ErrDuplicatePackageVersion):
+			// store paths are immutable and Nix may upload a narinfo again
+			ctx.Status(http.StatusOK)
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DownloadNar serves a NAR file
+func DownloadNar(ctx *context.Context) {
+	pf, err := nix_service.GetNarFile(ctx, ctx.Package.Owner.ID, ctx.PathParam("filename"))
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, u, _, err := packages_service.GetPackageFileStream(ctx, pf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
+		ContentType:  nix_module.ContentTypeNar,
+		Filename:     pf.Name,
+		LastModified: pf.CreatedUnix.AsLocalTime(),
+	})
+}
+
+// UploadNar stores a NAR file until the narinfo referencing it gets uploaded
+func UploadNar(ctx *context.Context) {
+	filename := ctx.PathParam("filename")
+	if _, err := nix_module.ParseNarFilename(filename); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if err := nix_service.UploadNar(ctx, ctx.Doer, ctx.Package.Owner, filename, buf); err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidArgument):
+			apiError(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
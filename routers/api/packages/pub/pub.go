@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	pub_service "code.gitea.io/gitea/services/packages/pub"
 )
 
 func jsonResponse(ctx *context.Context, status int, obj any) {
@@ -54,21 +56,28 @@ func apiError(ctx *context.Context, status int, obj any) {
 }
 
 type packageVersions struct {
-	Name     string             `json:"name"`
-	Latest   *versionMetadata   `json:"latest"`
-	Versions []*versionMetadata `json:"versions"`
+	Name              string             `json:"name"`
+	IsDiscontinued    bool               `json:"isDiscontinued,omitempty"`
+	ReplacedBy        string             `json:"replacedBy,omitempty"`
+	AdvisoriesUpdated *time.Time         `json:"advisoriesUpdated,omitempty"`
+	Latest            *versionMetadata   `json:"latest"`
+	Versions          []*versionMetadata `json:"versions"`
 }
 
 type versionMetadata struct {
 	Version    string    `json:"version"`
+	Retracted  bool      `json:"retracted,omitempty"`
 	ArchiveURL string    `json:"archive_url"`
 	Published  time.Time `json:"published"`
 	Pubspec    any       `json:"pubspec,omitempty"`
 }
 
 func packageDescriptorToMetadata(baseURL string, pd *packages_model.PackageDescriptor) *versionMetadata {
+	retracted, _ := strconv.ParseBool(pd.VersionProperties.GetByName(pub_module.PropertyRetracted))
+
 	return &versionMetadata{
 		Version:    pd.Version.Version,
+		Retracted:  retracted,
 		ArchiveURL: fmt.Sprintf("%s/files/%s.tar.gz", baseURL, url.PathEscape(pd.Version.Version)),
 		Published:  pd.Version.CreatedUnix.AsLocalTime(),
 		Pubspec:    pd.Metadata.(*pub_module.Metadata).Pubspec,
@@ -105,16 +114,40 @@ func EnumeratePackageVersions(ctx *context.Context) {
 
 	baseURL := fmt.Sprintf("%s/%s", baseURL(ctx), url.PathEscape(pds[0].Package.Name))
 
+	// The latest version is the highest version which is not retracted
+	var latest *versionMetadata
 	versions := make([]*versionMetadata, 0, len(pds))
 	for _, pd := range pds {
-		versions = append(versions, packageDescriptorToMetadata(baseURL, pd))
+		vm := packageDescriptorToMetadata(baseURL, pd)
+		if !vm.Retracted || latest == nil || latest.Retracted {
+			latest = vm
+		}
+		versions = append(versions, vm)
 	}
 
-	jsonResponse(ctx, http.StatusOK, &packageVersions{
-		Name:     pds[0].Package.Name,
-		Latest:   packageDescriptorToMetadata(baseURL, pds[0]),
-		Versions: versions,
-	})
+	p := pds[0].Package
+	discontinued, _ := strconv.ParseBool(pds[0].PackageProperties.GetByName(pub_module.PropertyDiscontinued))
+
+	result := &packageVersions{
+		Name:           p.Name,
+		IsDiscontinued: discontinued,
+		Latest:         latest,
+		Versions:       versions,
+	}
+	if discontinued {
+		result.ReplacedBy = pds[0].PackageProperties.GetByName(pub_module.PropertyReplacedBy)
+	}
+
+	_, advisoriesUpdated, err := pub_service.GetAdvisories(ctx, p)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !advisoriesUpdated.IsZero() {
+		result.AdvisoriesUpdated = &advisoriesUpdated
+	}
+
+	jsonResponse(ctx, http.StatusOK, result)
 }
 
 // https://github.com/dart-lang/pub/blob/master/doc/repository-spec-v2.md#deprecated-inspect-a-specific-version-of-a-package
@@ -282,3 +315,155 @@ func DownloadPackageFile(ctx *context.Context) {
 
 	helper.ServePackageFile(ctx, s, u, pf)
 }
+
+// https://github.com/dart-lang/pub/blob/master/doc/repository-spec-v2.md#get-security-advisories-for-a-package
+func PackageAdvisories(ctx *context.Context) {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypePub, ctx.PathParam("id"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	advisories, updated, err := pub_service.GetAdvisories(ctx, p)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	type Advisories struct {
+		Advisories        []*pub_module.Advisory `json:"advisories"`
+		AdvisoriesUpdated *time.Time             `json:"advisoriesUpdated,omitempty"`
+	}
+
+	result := &Advisories{
+		Advisories: advisories,
+	}
+	if !updated.IsZero() {
+		result.AdvisoriesUpdated = &updated
+	}
+
+	jsonResponse(ctx, http.StatusOK, result)
+}
+
+// UploadAdvisory adds or replaces a security advisory of the package
+func UploadAdvisory(ctx *context.Context) {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypePub, ctx.PathParam("id"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	a, err := pub_module.ParseAdvisory(ctx.Req.Body, p.Name)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if err := pub_service.AddAdvisory(ctx, p, a); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(ctx, http.StatusOK, a)
+}
+
+// DeleteAdvisory removes a security advisory of the package
+func DeleteAdvisory(ctx *context.Context) {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypePub, ctx.PathParam("id"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := pub_service.DeleteAdvisory(ctx, p, ctx.PathParam("advisory")); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// https://pub.dev/help/publishing#discontinue
+func SetPackageOptions(ctx *context.Context) {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypePub, ctx.PathParam("id"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var options struct {
+		IsDiscontinued bool   `json:"isDiscontinued"`
+		ReplacedBy     string `json:"replacedBy"`
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&options); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := pub_service.SetPackageDiscontinued(ctx, p, options.IsDiscontinued, options.ReplacedBy); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	jsonResponse(ctx, http.StatusOK, options)
+}
+
+// https://dart.dev/tools/pub/publishing#retract
+func SetVersionOptions(ctx *context.Context) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypePub, ctx.PathParam("id"), ctx.PathParam("version"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var options struct {
+		IsRetracted bool `json:"isRetracted"`
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&options); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := pub_service.SetVersionRetracted(ctx, pv, options.IsRetracted); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	jsonResponse(ctx, http.StatusOK, options)
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"errors"
+	"net/http"
+
+	packages_module "code.gitea.io/gitea/modules/packages"
+	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	rpm_service "code.gitea.io/gitea/services/packages/rpm"
+)
+
+func errorToStatus(err error) int {
+	switch {
+	case errors.Is(err, util.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, util.ErrNotExist):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListAdvisories lists the advisories of the group
+func ListAdvisories(ctx *context.Context) {
+	advisories, err := rpm_service.GetAdvisories(ctx, ctx.Package.Owner.ID, ctx.PathParam("group"))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, advisories)
+}
+
+// GetAdvisory gets a single advisory of the group
+func GetAdvisory(ctx *context.Context) {
+	a, err := rpm_service.GetAdvisory(ctx, ctx.Package.Owner.ID, ctx.PathParam("group"), ctx.PathParam("id"))
+	if err != nil {
+		apiError(ctx, errorToStatus(err), err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, a)
+}
+
+// UploadAdvisory adds or replaces an advisory of the group
+func UploadAdvisory(ctx *context.Context) {
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	a, err := rpm_module.ParseAdvisory(upload)
+	if err != nil {
+		apiError(ctx, errorToStatus(err), err)
+		return
+	}
+
+	if err := rpm_service.AddAdvisory(ctx, ctx.Package.Owner.ID, ctx.PathParam("group"), a); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, a)
+}
+
+// DeleteAdvisory deletes an advisory of the group
+func DeleteAdvisory(ctx *context.Context) {
+	if err := rpm_service.DeleteAdvisory(ctx, ctx.Package.Owner.ID, ctx.PathParam("group"), ctx.PathParam("id")); err != nil {
+		apiError(ctx, errorToStatus(err), err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetModuleMetadata gets the uploaded module metadata of the group
+func GetModuleMetadata(ctx *context.Context) {
+	pv, err := rpm_service.GetOrCreateModuleVersion(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	s, u, pf, err := packages_service.GetFileStreamByPackageVersion(
+		ctx,
+		pv,
+		&packages_service.PackageFileInfo{
+			Filename:     rpm_module.ModuleMetadataFilename,
+			CompositeKey: ctx.PathParam("group"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// UploadModuleMetadata stores the module metadata (modules.yaml) of the group
+func UploadModuleMetadata(ctx *context.Context) {
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if err := rpm_service.SetModuleMetadata(ctx, ctx.Package.Owner.ID, ctx.PathParam("group"), buf); err != nil {
+		apiError(ctx, errorToStatus(err), err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeleteModuleMetadata deletes the module metadata of the group
+func DeleteModuleMetadata(ctx *context.Context) {
+	if err := rpm_service.DeleteModuleMetadata(ctx, ctx.Package.Owner.ID, ctx.PathParam("group")); err != nil {
+		apiError(ctx, errorToStatus(err), err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
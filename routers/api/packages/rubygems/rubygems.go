@@ -11,9 +11,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/optional"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	rubygems_module "code.gitea.io/gitea/modules/packages/rubygems"
@@ -21,6 +24,9 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	rubygems_service "code.gitea.io/gitea/services/packages/rubygems"
+
+	"gopkg.in/yaml.v3"
 )
 
 func apiError(ctx *context.Context, status int, obj any) {
@@ -228,6 +234,24 @@ func UploadPackageFile(ctx *context.Context) {
 		return
 	}
 
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeRubyGems, rp.Name)
+	if err != nil && err != packages_model.ErrPackageNotExist {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if p != nil {
+		if err := rubygems_service.CheckOwner(ctx, p, ctx.Doer); err != nil {
+			apiOwnerError(ctx, err)
+			return
+		}
+	}
+	if rp.Metadata.MFARequired {
+		if err := rubygems_service.VerifyOTP(ctx, ctx.Doer, ctx.Req.Header.Get("OTP")); err != nil {
+			apiOwnerError(ctx, err)
+			return
+		}
+	}
+
 	filename := makeGemFullFileName(rp.Name, rp.Version, rp.Metadata.Platform)
 
 	_, _, err = packages_service.CreatePackageAndAddFile(
@@ -242,6 +266,8 @@ func UploadPackageFile(ctx *context.Context) {
 			SemverCompatible: true,
 			Creator:          ctx.Doer,
 			Metadata:         rp.Metadata,
+			// The uploader becomes the first owner if the gem is created by this upload
+			PackageProperties: rubygems_service.OwnerProperties(ctx.Doer),
 		},
 		&packages_service.PackageFileCreationInfo{
 			PackageFileInfo: packages_service.PackageFileInfo{
@@ -277,7 +303,34 @@ func DeletePackage(ctx *context.Context) {
 	packageName := ctx.FormString("gem_name")
 	packageVersion := ctx.FormString("version")
 
-	err := packages_service.RemovePackageVersionByNameAndVersion(
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeRubyGems, packageName, packageVersion)
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := rubygems_service.CheckOwner(ctx, pd.Package, ctx.Doer); err != nil {
+		apiOwnerError(ctx, err)
+		return
+	}
+	if pd.Metadata.(*rubygems_module.Metadata).MFARequired {
+		if err := rubygems_service.VerifyOTP(ctx, ctx.Doer, ctx.Req.Header.Get("OTP")); err != nil {
+			apiOwnerError(ctx, err)
+			return
+		}
+	}
+
+	err = packages_service.RemovePackageVersionByNameAndVersion(
 		ctx,
 		ctx.Doer,
 		&packages_service.PackageInfo{
@@ -296,6 +349,171 @@ func DeletePackage(ctx *context.Context) {
 	}
 }
 
+type packageOwner struct {
+	ID     int64  `json:"id" yaml:"id"`
+	Handle string `json:"handle" yaml:"handle"`
+}
+
+// GetPackageOwners lists the owners of a gem
+// https://guides.rubygems.org/rubygems-org-api/#owner-methods
+func GetPackageOwners(ctx *context.Context) {
+	owners := getPackageOwners(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, owners)
+}
+
+// GetPackageOwnersYAML lists the owners of a gem as YAML which is requested by "gem owner"
+func GetPackageOwnersYAML(ctx *context.Context) {
+	owners := getPackageOwners(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/x-yaml")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if err := yaml.NewEncoder(ctx.Resp).Encode(owners); err != nil {
+		log.Error("Failed to write owners of gem: %v", err)
+	}
+}
+
+func getPackageOwners(ctx *context.Context) []*packageOwner {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeRubyGems, ctx.PathParam("packagename"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return nil
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil
+	}
+
+	owners, err := rubygems_service.GetOwners(ctx, p)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil
+	}
+
+	result := make([]*packageOwner, 0, len(owners))
+	for _, owner := range owners {
+		result = append(result, &packageOwner{
+			ID:     owner.ID,
+			Handle: owner.Name,
+		})
+	}
+	return result
+}
+
+// AddPackageOwner adds an owner to a gem
+func AddPackageOwner(ctx *context.Context) {
+	p, owner := getPackageAndOwnerCandidate(ctx, ctx.FormString("email"))
+	if ctx.Written() {
+		return
+	}
+
+	if err := rubygems_service.AddOwner(ctx, p, owner); err != nil {
+		apiOwnerError(ctx, err)
+		return
+	}
+
+	ctx.PlainText(http.StatusOK, fmt.Sprintf("Owner added successfully. %s has been added as an owner of %s.", owner.Name, p.Name))
+}
+
+// RemovePackageOwner removes an owner from a gem
+func RemovePackageOwner(ctx *context.Context) {
+	form, err := parseDeleteForm(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	p, owner := getPackageAndOwnerCandidate(ctx, form.Get("email"))
+	if ctx.Written() {
+		return
+	}
+
+	if err := rubygems_service.RemoveOwner(ctx, p, owner); err != nil {
+		apiOwnerError(ctx, err)
+		return
+	}
+
+	ctx.PlainText(http.StatusOK, fmt.Sprintf("Owner removed successfully. %s has been removed as an owner of %s.", owner.Name, p.Name))
+}
+
+func getPackageAndOwnerCandidate(ctx *context.Context, handle string) (*packages_model.Package, *user_model.User) {
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packages_model.TypeRubyGems, ctx.PathParam("packagename"))
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+			return nil, nil
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, nil
+	}
+
+	if err := rubygems_service.CheckOwner(ctx, p, ctx.Doer); err != nil {
+		apiOwnerError(ctx, err)
+		return nil, nil
+	}
+
+	owner, err := rubygems_service.FindOwnerCandidate(ctx, handle)
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) || errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, "Owner could not be found.")
+			return nil, nil
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, nil
+	}
+
+	return p, owner
+}
+
+// parseDeleteForm parses the form of a DELETE request.
+// Go populates the form only for POST, PUT and PATCH requests and the gem client sends url encoded forms.
+func parseDeleteForm(ctx *context.Context) (url.Values, error) {
+	if strings.HasPrefix(ctx.Req.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := ctx.Req.ParseMultipartForm(32 << 20); err != nil {
+			return nil, err
+		}
+		return ctx.Req.Form, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Req.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range ctx.Req.URL.Query() {
+		for _, value := range values {
+			form.Add(key, value)
+		}
+	}
+	return form, nil
+}
+
+func apiOwnerError(ctx *context.Context, err error) {
+	switch err {
+	case rubygems_service.ErrOTPMissing, rubygems_service.ErrOTPInvalid:
+		apiError(ctx, http.StatusUnauthorized, err)
+	case rubygems_service.ErrNotOwner, rubygems_service.ErrMFANotEnrolled:
+		apiError(ctx, http.StatusForbidden, err)
+	case rubygems_service.ErrOwnerAlreadyExist:
+		apiError(ctx, http.StatusConflict, err)
+	case rubygems_service.ErrOwnerNotExist:
+		apiError(ctx, http.StatusNotFound, err)
+	case rubygems_service.ErrLastOwner:
+		apiError(ctx, http.StatusBadRequest, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
 // GetPackageInfo returns a custom text based format for the single rubygem with a line for each version of the rubygem
 // ref: https://guides.rubygems.org/rubygems-org-compact-index-api/
 func GetPackageInfo(ctx *context.Context) {
@@ -4,6 +4,7 @@
 package swift
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -23,10 +24,19 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	swift_service "code.gitea.io/gitea/services/packages/swift"
 
 	"github.com/hashicorp/go-version"
 )
 
+// https://github.com/apple/swift-evolution/blob/main/proposals/0391-package-registry-publish.md
+const (
+	headerSignatureFormat = "X-Swift-Package-Signature-Format"
+	headerSignature       = "X-Swift-Package-Signature"
+
+	maxSignatureSize = 64 * 1024
+)
+
 // https://github.com/apple/swift-package-manager/blob/main/Documentation/Registry.md#35-api-versioning
 const (
 	AcceptJSON  = "application/vnd.swift.registry.v1+json"
@@ -39,14 +49,18 @@ var (
 	scopePattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9-]{0,38}\z`)
 	// https://github.com/apple/swift-package-manager/blob/main/Documentation/Registry.md#362-package-name
 	namePattern = regexp.MustCompile(`\A[a-zA-Z0-9][a-zA-Z0-9-_]{0,99}\z`)
+
+	errSignatureTooLarge = util.NewInvalidArgumentErrorf("signature exceeds the maximum size")
 )
 
 type headers struct {
-	Status      int
-	ContentType string
-	Digest      string
-	Location    string
-	Link        string
+	Status          int
+	ContentType     string
+	Digest          string
+	Location        string
+	Link            string
+	SignatureFormat string
+	Signature       string
 }
 
 // https://github.com/apple/swift-package-manager/blob/main/Documentation/Registry.md#35-api-versioning
@@ -63,6 +77,10 @@ func setResponseHeaders(resp http.ResponseWriter, h *headers) {
 	if h.Link != "" {
 		resp.Header().Set("Link", h.Link)
 	}
+	if h.SignatureFormat != "" {
+		resp.Header().Set(headerSignatureFormat, h.SignatureFormat)
+		resp.Header().Set(headerSignature, h.Signature)
+	}
 	resp.Header().Set("Content-Version", "1")
 	if h.Status != 0 {
 		resp.WriteHeader(h.Status)
@@ -158,9 +176,15 @@ func EnumeratePackageVersions(ctx *context.Context) {
 }
 
 type Resource struct {
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	Checksum string `json:"checksum"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Checksum string   `json:"checksum"`
+	Signing  *Signing `json:"signing,omitempty"`
+}
+
+type Signing struct {
+	SignatureBase64Encoded string `json:"signatureBase64Encoded"`
+	SignatureFormat        string `json:"signatureFormat"`
 }
 
 type PackageVersionMetadataResponse struct {
@@ -192,6 +216,14 @@ func PackageVersionMetadata(ctx *context.Context) {
 
 	metadata := pd.Metadata.(*swift_module.Metadata)
 
+	var signing *Signing
+	if format := pd.VersionProperties.GetByName(swift_module.PropertySignatureFormat); format != "" {
+		signing = &Signing{
+			SignatureBase64Encoded: pd.VersionProperties.GetByName(swift_module.PropertySourceArchiveSignature),
+			SignatureFormat:        format,
+		}
+	}
+
 	setResponseHeaders(ctx.Resp, &headers{})
 
 	ctx.JSON(http.StatusOK, PackageVersionMetadataResponse{
@@ -202,6 +234,7 @@ func PackageVersionMetadata(ctx *context.Context) {
 				Name:     "source-archive",
 				Type:     "application/zip",
 				Checksum: pd.Files[0].Blob.HashSHA256,
+				Signing:  signing,
 			},
 		},
 		Metadata: &swift_module.SoftwareSourceCode{
@@ -324,6 +357,52 @@ func UploadPackageFile(ctx *context.Context) {
 		return
 	}
 
+	versionProperties := map[string]string{}
+
+	sourceArchiveSignature, err := readFormPart(ctx, "source-archive-signature")
+	if err != nil {
+		if errors.Is(err, errSignatureTooLarge) {
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		} else {
+			apiError(ctx, http.StatusBadRequest, err)
+		}
+		return
+	}
+	if len(sourceArchiveSignature) > 0 {
+		if _, err := buf.Seek(0, io.SeekStart); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		metadataSignature, err := readFormPart(ctx, "metadata-signature")
+		if err != nil {
+			if errors.Is(err, errSignatureTooLarge) {
+				apiError(ctx, http.StatusRequestEntityTooLarge, err)
+			} else {
+				apiError(ctx, http.StatusBadRequest, err)
+			}
+			return
+		}
+
+		si, err := swift_service.VerifySignatures(ctx.Req.Header.Get(headerSignatureFormat), buf, sourceArchiveSignature, metadata, metadataSignature)
+		if err != nil {
+			if errors.Is(err, util.ErrInvalidArgument) {
+				apiError(ctx, http.StatusUnprocessableEntity, err)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		pck.Metadata.Signer = si.Signer
+
+		versionProperties[swift_module.PropertySignatureFormat] = si.Format
+		versionProperties[swift_module.PropertySourceArchiveSignature] = base64.StdEncoding.EncodeToString(si.SourceArchiveSignature)
+		if len(si.MetadataSignature) > 0 {
+			versionProperties[swift_module.PropertyMetadataSignature] = base64.StdEncoding.EncodeToString(si.MetadataSignature)
+		}
+	}
+
 	if _, err := buf.Seek(0, io.SeekStart); err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
@@ -345,6 +424,7 @@ func UploadPackageFile(ctx *context.Context) {
 				swift_module.PropertyScope: packageScope,
 				swift_module.PropertyName:  packageName,
 			},
+			VersionProperties: versionProperties,
 		},
 		&packages_service.PackageFileCreationInfo{
 			PackageFileInfo: packages_service.PackageFileInfo{
@@ -406,7 +486,9 @@ func DownloadPackageFile(ctx *context.Context) {
 	}
 
 	setResponseHeaders(ctx.Resp, &headers{
-		Digest: pd.Files[0].Blob.HashSHA256,
+		Digest:          pd.Files[0].Blob.HashSHA256,
+		SignatureFormat: pd.VersionProperties.GetByName(swift_module.PropertySignatureFormat),
+		Signature:       pd.VersionProperties.GetByName(swift_module.PropertySourceArchiveSignature),
 	})
 
 	helper.ServePackageFile(ctx, s, u, pf, &context.ServeHeaderOptions{
@@ -416,6 +498,32 @@ func DownloadPackageFile(ctx *context.Context) {
 	})
 }
 
+// readFormPart reads an optional part of the multipart upload which may be sent as file or as value
+func readFormPart(ctx *context.Context, name string) ([]byte, error) {
+	file, _, err := ctx.Req.FormFile(name)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			value := ctx.Req.FormValue(name)
+			if len(value) > maxSignatureSize {
+				return nil, errSignatureTooLarge
+			}
+			return []byte(value), nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	// Read one byte more than allowed to detect oversized parts instead of truncating them
+	data, err := io.ReadAll(io.LimitReader(file, maxSignatureSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSignatureSize {
+		return nil, errSignatureTooLarge
+	}
+	return data, nil
+}
+
 type LookupPackageIdentifiersResponse struct {
 	Identifiers []string `json:"identifiers"`
 }
@@ -463,3 +571,31 @@ func LookupPackageIdentifiers(ctx *context.Context) {
 		Identifiers: identifiers,
 	})
 }
+
+// PackageCollection serves the package collection of all packages in the scope
+// https://github.com/apple/swift-evolution/blob/main/proposals/0291-package-collections.md
+func PackageCollection(ctx *context.Context) {
+	scope := ctx.PathParam("scope")
+	if !scopePattern.MatchString(scope) {
+		apiError(ctx, http.StatusBadRequest, nil)
+		return
+	}
+
+	c, err := swift_service.BuildCollection(ctx, ctx.Package.Owner, scope)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(c.Packages) == 0 {
+		apiError(ctx, http.StatusNotFound, nil)
+		return
+	}
+
+	signed, err := swift_service.SignCollection(c)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signed)
+}
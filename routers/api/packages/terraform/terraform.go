@@ -0,0 +1,686 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	terraform_service "code.gitea.io/gitea/services/packages/terraform"
+
+	"github.com/hashicorp/go-version"
+)
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.JSON(status, struct {
+			Errors []string `json:"errors"`
+		}{
+			Errors: []string{
+				message,
+			},
+		})
+	})
+}
+
+func baseURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/terraform", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name))
+}
+
+// ServiceDiscovery returns the locations of the module and provider registry of the owner
+// https://developer.hashicorp.com/terraform/internals/remote-service-discovery
+func ServiceDiscovery(ctx *context.Context) {
+	ctx.JSON(http.StatusOK, terraform_module.ServiceDiscovery(baseURL(ctx)))
+}
+
+// NamespaceOwnerAssignment assigns the user or organization named by the namespace of the address as package owner
+func NamespaceOwnerAssignment(ctx *context.Context) {
+	owner, err := user_model.GetUserByName(ctx, ctx.PathParam("namespace"))
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	ctx.ContextUser = owner
+}
+
+// getSortedVersions gets all versions of the package sorted ascending
+func getSortedVersions(ctx *context.Context, packageType packages_model.Type, name string) ([]*packages_model.PackageDescriptor, error) {
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packageType, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pds, func(i, j int) bool {
+		return pds[i].SemVer.LessThan(pds[j].SemVer)
+	})
+
+	return pds, nil
+}
+
+// moduleName validates the module address and gets the package name
+func moduleName(ctx *context.Context) (string, bool) {
+	namespace := ctx.PathParam("namespace")
+	name := ctx.PathParam("name")
+	system := ctx.PathParam("system")
+
+	if !terraform_module.IsValidNamespace(namespace) {
+		apiError(ctx, http.StatusBadRequest, terraform_module.ErrInvalidNamespace)
+		return "", false
+	}
+	if !terraform_module.IsValidName(name) {
+		apiError(ctx, http.StatusBadRequest, terraform_module.ErrInvalidName)
+		return "", false
+	}
+	if !terraform_module.IsValidSystem(system) {
+		apiError(ctx, http.StatusBadRequest, terraform_module.ErrInvalidSystem)
+		return "", false
+	}
+
+	return terraform_module.ModulePackageName(namespace, name, system), true
+}
+
+// providerName validates the provider address and gets the package name
+func providerName(ctx *context.Context) (string, bool) {
+	namespace := ctx.PathParam("namespace")
+	providerType := ctx.PathParam("type")
+
+	if !terraform_module.IsValidNamespace(namespace) {
+		apiError(ctx, http.StatusBadRequest, terraform_module.ErrInvalidNamespace)
+		return "", false
+	}
+	if !terraform_module.IsValidName(providerType) {
+		apiError(ctx, http.StatusBadRequest, terraform_module.ErrInvalidName)
+		return "", false
+	}
+
+	return terraform_module.ProviderPackageName(namespace, providerType), true
+}
+
+type moduleVersions struct {
+	Modules []*moduleVersionList `json:"modules"`
+}
+
+type moduleVersionList struct {
+	Versions []*moduleVersion `json:"versions"`
+}
+
+type moduleVersion struct {
+	Version string `json:"version"`
+}
+
+// EnumerateModuleVersions lists the available versions of a module
+// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#list-available-versions-for-a-specific-module
+func EnumerateModuleVersions(ctx *context.Context) {
+	packageName, ok := moduleName(ctx)
+	if !ok {
+		return
+	}
+
+	pds, err := getSortedVersions(ctx, packages_model.TypeTerraformModule, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pds) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	versions := make([]*moduleVersion, 0, len(pds))
+	for _, pd := range pds {
+		versions = append(versions, &moduleVersion{
+			Version: pd.Version.Version,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, &moduleVersions{
+		Modules: []*moduleVersionList{
+			{
+				Versions: versions,
+			},
+		},
+	})
+}
+
+// DownloadLatestModule redirects to the download location of the latest module version
+func DownloadLatestModule(ctx *context.Context) {
+	packageName, ok := moduleName(ctx)
+	if !ok {
+		return
+	}
+
+	pds, err := getSortedVersions(ctx, packages_model.TypeTerraformModule, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pds) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	ctx.Redirect(fmt.Sprintf("%s/v1/modules/%s/%s/download", baseURL(ctx), packageName, url.PathEscape(pds[len(pds)-1].Version.Version)))
+}
+
+// GetModuleDownloadLocation returns the location of the module source archive in the X-Terraform-Get header
+// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#download-source-code-for-a-specific-module-version
+func GetModuleDownloadLocation(ctx *context.Context) {
+	packageName, ok := moduleName(ctx)
+	if !ok {
+		return
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraformModule, packageName, ctx.PathParam("version"))
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Resp.Header().Set("X-Terraform-Get", fmt.Sprintf("%s/v1/modules/%s/%s/%s", baseURL(ctx), packageName, url.PathEscape(pv.Version), terraform_module.ModuleArchiveFilename))
+	ctx.Status(http.StatusNoContent)
+}
+
+// DownloadModuleArchive serves the module source archive
+func DownloadModuleArchive(ctx *context.Context) {
+	packageName, ok := moduleName(ctx)
+	if !ok {
+		return
+	}
+
+	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeTerraformModule,
+			Name:        packageName,
+			Version:     ctx.PathParam("version"),
+		},
+		&packages_service.PackageFileInfo{
+			Filename: terraform_module.ModuleArchiveFilename,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// UploadModule creates a module version from a gzipped source archive
+func UploadModule(ctx *context.Context) {
+	packageName, ok := moduleName(ctx)
+	if !ok {
+		return
+	}
+
+	moduleVersion := ctx.PathParam("version")
+	if _, err := version.NewSemver(moduleVersion); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	upload, needsClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needsClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	metadata, err := terraform_module.ParseModuleArchive(buf)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeTerraformModule,
+				Name:        packageName,
+				Version:     moduleVersion,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata:         metadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: terraform_module.ModuleArchiveFilename,
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageVersion:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeleteModule deletes a module version
+func DeleteModule(ctx *context.Context) {
+	packageName, ok := moduleName(ctx)
+	if !ok {
+		return
+	}
+
+	deletePackageVersion(ctx, packages_model.TypeTerraformModule, packageName)
+}
+
+func deletePackageVersion(ctx *context.Context, packageType packages_model.Type, packageName string) {
+	err := packages_service.RemovePackageVersionByNameAndVersion(
+		ctx,
+		ctx.Doer,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packageType,
+			Name:        packageName,
+			Version:     ctx.PathParam("version"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type providerVersions struct {
+	Versions []*providerVersion `json:"versions"`
+}
+
+type providerVersion struct {
+	Version   string              `json:"version"`
+	Protocols []string            `json:"protocols"`
+	Platforms []*providerPlatform `json:"platforms"`
+}
+
+type providerPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// EnumerateProviderVersions lists the available versions and platforms of a provider
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#list-available-versions
+func EnumerateProviderVersions(ctx *context.Context) {
+	packageName, ok := providerName(ctx)
+	if !ok {
+		return
+	}
+
+	pds, err := getSortedVersions(ctx, packages_model.TypeTerraformProvider, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pds) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	versions := make([]*providerVersion, 0, len(pds))
+	for _, pd := range pds {
+		platforms := make([]*providerPlatform, 0, len(pd.Files))
+		for _, pfd := range pd.Files {
+			platforms = append(platforms, &providerPlatform{
+				OS:   pfd.Properties.GetByName(terraform_module.PropertyOS),
+				Arch: pfd.Properties.GetByName(terraform_module.PropertyArch),
+			})
+		}
+		sort.Slice(platforms, func(i, j int) bool {
+			if platforms[i].OS == platforms[j].OS {
+				return platforms[i].Arch < platforms[j].Arch
+			}
+			return platforms[i].OS < platforms[j].OS
+		})
+
+		versions = append(versions, &providerVersion{
+			Version:   pd.Version.Version,
+			Protocols: pd.Metadata.(*terraform_module.ProviderMetadata).Protocols,
+			Platforms: platforms,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, &providerVersions{
+		Versions: versions,
+	})
+}
+
+type providerPackage struct {
+	Protocols           []string            `json:"protocols"`
+	OS                  string              `json:"os"`
+	Arch                string              `json:"arch"`
+	Filename            string              `json:"filename"`
+	DownloadURL         string              `json:"download_url"`
+	ShasumsURL          string              `json:"shasums_url"`
+	ShasumsSignatureURL string              `json:"shasums_signature_url"`
+	Shasum              string              `json:"shasum"`
+	SigningKeys         providerSigningKeys `json:"signing_keys"`
+}
+
+type providerSigningKeys struct {
+	GPGPublicKeys []*providerGPGPublicKey `json:"gpg_public_keys"`
+}
+
+type providerGPGPublicKey struct {
+	KeyID      string `json:"key_id"`
+	ASCIIArmor string `json:"ascii_armor"`
+}
+
+func getProviderDescriptor(ctx *context.Context) (*packages_model.PackageDescriptor, bool) {
+	packageName, ok := providerName(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraformProvider, packageName, ctx.PathParam("version"))
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, false
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return pd, true
+}
+
+// GetProviderPackage returns the download information of a provider package for a platform
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#find-a-provider-package
+func GetProviderPackage(ctx *context.Context) {
+	pd, ok := getProviderDescriptor(ctx)
+	if !ok {
+		return
+	}
+
+	providerType := ctx.PathParam("type")
+	filename := terraform_module.ProviderFilename(providerType, pd.Version.Version, ctx.PathParam("os"), ctx.PathParam("arch"))
+
+	var pfd *packages_model.PackageFileDescriptor
+	for _, f := range pd.Files {
+		if f.File.Name == filename {
+			pfd = f
+			break
+		}
+	}
+	if pfd == nil {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+
+	_, pub, err := terraform_service.GetOrCreateKeyPair(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	keyID, err := terraform_service.GetPublicKeyID(pub)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	versionURL := fmt.Sprintf("%s/v1/providers/%s/%s", baseURL(ctx), pd.Package.Name, url.PathEscape(pd.Version.Version))
+
+	ctx.JSON(http.StatusOK, &providerPackage{
+		Protocols:           pd.Metadata.(*terraform_module.ProviderMetadata).Protocols,
+		OS:                  pfd.Properties.GetByName(terraform_module.PropertyOS),
+		Arch:                pfd.Properties.GetByName(terraform_module.PropertyArch),
+		Filename:            pfd.File.Name,
+		DownloadURL:         versionURL + "/files/" + url.PathEscape(pfd.File.Name),
+		ShasumsURL:          versionURL + "/SHA256SUMS",
+		ShasumsSignatureURL: versionURL + "/SHA256SUMS.sig",
+		Shasum:              pfd.Blob.HashSHA256,
+		SigningKeys: providerSigningKeys{
+			GPGPublicKeys: []*providerGPGPublicKey{
+				{
+					KeyID:      keyID,
+					ASCIIArmor: pub,
+				},
+			},
+		},
+	})
+}
+
+// DownloadProviderPackage serves a provider package
+func DownloadProviderPackage(ctx *context.Context) {
+	packageName, ok := providerName(ctx)
+	if !ok {
+		return
+	}
+
+	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeTerraformProvider,
+			Name:        packageName,
+			Version:     ctx.PathParam("version"),
+		},
+		&packages_service.PackageFileInfo{
+			Filename: ctx.PathParam("filename"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// DownloadProviderShasums serves the SHA256SUMS file of all packages of a provider version
+func DownloadProviderShasums(ctx *context.Context) {
+	pd, ok := getProviderDescriptor(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.PlainTextBytes(http.StatusOK, terraform_service.BuildShasums(pd))
+}
+
+// DownloadProviderShasumsSignature serves the detached signature of the SHA256SUMS file
+func DownloadProviderShasumsSignature(ctx *context.Context) {
+	pd, ok := getProviderDescriptor(ctx)
+	if !ok {
+		return
+	}
+
+	sig, err := terraform_service.SignShasums(ctx, ctx.Package.Owner.ID, terraform_service.BuildShasums(pd))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(sig)
+}
+
+// UploadProviderPackage adds the zipped provider package of a platform to a provider version.
+// The supported plugin protocol versions can be passed as comma separated protocols parameter.
+func UploadProviderPackage(ctx *context.Context) {
+	packageName, ok := providerName(ctx)
+	if !ok {
+		return
+	}
+
+	providerVersion := ctx.PathParam("version")
+	if _, err := version.NewSemver(providerVersion); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	os := strings.ToLower(ctx.PathParam("os"))
+	arch := strings.ToLower(ctx.PathParam("arch"))
+	if !terraform_module.IsValidPlatform(os) || !terraform_module.IsValidPlatform(arch) {
+		apiError(ctx, http.StatusBadRequest, terraform_module.ErrInvalidPlatform)
+		return
+	}
+
+	protocols, err := terraform_module.ParseProtocols(ctx.FormTrim("protocols"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	upload, needsClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if needsClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	providerType := ctx.PathParam("type")
+	if err := terraform_module.ValidateProviderArchive(buf, buf.Size(), providerType); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			apiError(ctx, http.StatusBadRequest, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeTerraformProvider,
+				Name:        packageName,
+				Version:     providerVersion,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata: &terraform_module.ProviderMetadata{
+				Protocols: protocols,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: terraform_module.ProviderFilename(providerType, providerVersion, os, arch),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+			Properties: map[string]string{
+				terraform_module.PropertyOS:   os,
+				terraform_module.PropertyArch: arch,
+			},
+		},
+	)
+	if err != nil {
+		switch err {
+		case packages_model.ErrDuplicatePackageFile:
+			apiError(ctx, http.StatusConflict, err)
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeleteProvider deletes a provider version with all packages
+func DeleteProvider(ctx *context.Context) {
+	packageName, ok := providerName(ctx)
+	if !ok {
+		return
+	}
+
+	deletePackageVersion(ctx, packages_model.TypeTerraformProvider, packageName)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
 
@@ -73,26 +74,62 @@ type versionMetadata struct {
 }
 
 type providerData struct {
-	Name         string `json:"name"`
-	URL          string `json:"url"`
-	Checksum     string `json:"checksum"`
-	ChecksumType string `json:"checksum_type"`
+	Name                string `json:"name"`
+	URL                 string `json:"url"`
+	Checksum            string `json:"checksum"`
+	ChecksumType        string `json:"checksum_type"`
+	Architecture        string `json:"architecture"`
+	DefaultArchitecture bool   `json:"default_architecture"`
 }
 
 func packageDescriptorToMetadata(baseURL string, pd *packages_model.PackageDescriptor) *versionMetadata {
 	versionURL := baseURL + "/" + url.PathEscape(pd.Version.Version)
 
-	providers := make([]*providerData, 0, len(pd.Files))
+	files := slices.Clone(pd.Files)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].File.ID < files[j].File.ID
+	})
+
+	providers := make([]*providerData, 0, len(files))
+	defaultArchitectures := make(map[string]*providerData)
 
-	for _, f := range pd.Files {
-		providers = append(providers, &providerData{
-			Name:         f.Properties.GetByName(vagrant_module.PropertyProvider),
-			URL:          versionURL + "/" + url.PathEscape(f.File.Name),
+	for _, f := range files {
+		name := f.Properties.GetByName(vagrant_module.PropertyProvider)
+
+		fileURL := versionURL + "/" + url.PathEscape(f.File.Name)
+		architecture := f.File.CompositeKey
+		if architecture == "" {
+			architecture = vagrant_module.UnknownArchitecture
+		} else {
+			fileURL = versionURL + "/" + url.PathEscape(architecture) + "/" + url.PathEscape(f.File.Name)
+		}
+
+		p := &providerData{
+			Name:         name,
+			URL:          fileURL,
 			Checksum:     f.Blob.HashSHA512,
 			ChecksumType: "sha512",
-		})
+			Architecture: architecture,
+		}
+		providers = append(providers, p)
+
+		// The last box explicitly marked as default wins, otherwise the first uploaded box is the default
+		if _, has := defaultArchitectures[name]; !has || f.Properties.GetByName(vagrant_module.PropertyDefaultArchitecture) == "true" {
+			defaultArchitectures[name] = p
+		}
+	}
+
+	for _, p := range defaultArchitectures {
+		p.DefaultArchitecture = true
 	}
 
+	sort.SliceStable(providers, func(i, j int) bool {
+		if providers[i].Name == providers[j].Name {
+			return providers[i].Architecture < providers[j].Architecture
+		}
+		return providers[i].Name < providers[j].Name
+	})
+
 	return &versionMetadata{
 		Status:    "active",
 		Version:   pd.Version.Version,
@@ -148,6 +185,20 @@ func UploadPackageFile(ctx *context.Context) {
 		apiError(ctx, http.StatusBadRequest, err)
 		return
 	}
+	boxArchitecture, ok := architectureFileKey(ctx)
+	if !ok {
+		return
+	}
+
+	properties := map[string]string{
+		vagrant_module.PropertyProvider: strings.TrimSuffix(boxProvider, ".box"),
+	}
+	if boxArchitecture != packages_model.EmptyFileKey {
+		properties[vagrant_module.PropertyArchitecture] = boxArchitecture
+		if ctx.FormBool("default_architecture") {
+			properties[vagrant_module.PropertyDefaultArchitecture] = "true"
+		}
+	}
 
 	upload, needsClose, err := ctx.UploadStream()
 	if err != nil {
@@ -191,14 +242,13 @@ func UploadPackageFile(ctx *context.Context) {
 		},
 		&packages_service.PackageFileCreationInfo{
 			PackageFileInfo: packages_service.PackageFileInfo{
-				Filename: strings.ToLower(boxProvider),
-			},
-			Creator: ctx.Doer,
-			Data:    buf,
-			IsLead:  true,
-			Properties: map[string]string{
-				vagrant_module.PropertyProvider: strings.TrimSuffix(boxProvider, ".box"),
+				Filename:     strings.ToLower(boxProvider),
+				CompositeKey: boxArchitecture,
 			},
+			Creator:    ctx.Doer,
+			Data:       buf,
+			IsLead:     true,
+			Properties: properties,
 		},
 	)
 	if err != nil {
@@ -216,7 +266,26 @@ func UploadPackageFile(ctx *context.Context) {
 	ctx.Status(http.StatusCreated)
 }
 
+// architectureFileKey gets the optional architecture of the box which is used as file key.
+// Boxes without architecture or with the unknown architecture use the empty file key.
+func architectureFileKey(ctx *context.Context) (string, bool) {
+	architecture := strings.ToLower(ctx.PathParam("architecture"))
+	if architecture == "" || architecture == vagrant_module.UnknownArchitecture {
+		return packages_model.EmptyFileKey, true
+	}
+	if !vagrant_module.IsValidArchitecture(architecture) {
+		apiError(ctx, http.StatusBadRequest, vagrant_module.ErrInvalidArchitecture)
+		return "", false
+	}
+	return architecture, true
+}
+
 func DownloadPackageFile(ctx *context.Context) {
+	boxArchitecture, ok := architectureFileKey(ctx)
+	if !ok {
+		return
+	}
+
 	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
 		ctx,
 		&packages_service.PackageInfo{
@@ -226,7 +295,8 @@ func DownloadPackageFile(ctx *context.Context) {
 			Version:     ctx.PathParam("version"),
 		},
 		&packages_service.PackageFileInfo{
-			Filename: ctx.PathParam("provider"),
+			Filename:     ctx.PathParam("provider"),
+			CompositeKey: boxArchitecture,
 		},
 	)
 	if err != nil {
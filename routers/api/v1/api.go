@@ -79,6 +79,7 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/admin"
 	"code.gitea.io/gitea/routers/api/v1/misc"
+	"code.gitea.io/gitea/routers/api/v1/packages"
 	"code.gitea.io/gitea/routers/api/v1/settings"
 	"code.gitea.io/gitea/routers/api/v1/user"
 	"code.gitea.io/gitea/routers/common"
@@ -437,6 +438,9 @@ func Routes() *web.Router {
 			})
 		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryUser), reqToken())
 
+		// NOTE: this is Gitea package management API - see packages.CommonRoutes and packages.ContainerRoutes for endpoints that implement package manager APIs
+		m.Post("/packages/{username}/container/{image}/index", tokenRequiresScopes(auth_model.AccessTokenScopeCategoryPackage), reqToken(), context.UserAssignmentAPI(), context.PackageAssignmentAPI(), reqPackageAccess(perm.AccessModeWrite), bind(api.CreateContainerImageIndexOption{}), packages.CreateContainerImageIndex)
+
 		m.Group("/admin", func() {
 			m.Group("/cron", func() {
 				m.Get("", admin.ListCronTasks)
@@ -0,0 +1,99 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"errors"
+	"net/http"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	container_service "code.gitea.io/gitea/services/packages/container"
+)
+
+// CreateContainerImageIndex creates an image index from existing manifests of a container image
+func CreateContainerImageIndex(ctx *context.APIContext) {
+	// swagger:operation POST /packages/{owner}/container/{image}/index package createContainerImageIndex
+	// ---
+	// summary: Create a multi-arch image index from existing manifests of a container image
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: image
+	//   in: path
+	//   description: name of the container image
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateContainerImageIndexOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ContainerImageIndex"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateContainerImageIndexOption)
+
+	image := ctx.PathParam("image")
+
+	manifestDigest, index, err := container_service.CreateImageIndex(ctx, &container_service.ImageIndexCreationOptions{
+		Owner:      ctx.Package.Owner,
+		Creator:    ctx.Doer,
+		Image:      image,
+		Tag:        form.Tag,
+		References: form.Manifests,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, util.ErrInvalidArgument):
+			ctx.Error(http.StatusUnprocessableEntity, "CreateImageIndex", err)
+		case errors.Is(err, util.ErrNotExist):
+			ctx.Error(http.StatusNotFound, "CreateImageIndex", err)
+		case errors.Is(err, util.ErrPermissionDenied):
+			ctx.Error(http.StatusForbidden, "CreateImageIndex", err)
+		case errors.Is(err, packages_service.ErrQuotaTotalCount), errors.Is(err, packages_service.ErrQuotaTypeSize), errors.Is(err, packages_service.ErrQuotaTotalSize):
+			ctx.Error(http.StatusForbidden, "CreateImageIndex", err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "CreateImageIndex", err)
+		}
+		return
+	}
+
+	apiIndex := &api.ContainerImageIndex{
+		Image:     image,
+		Tag:       form.Tag,
+		Digest:    manifestDigest,
+		MediaType: index.MediaType,
+		Manifests: make([]*api.ContainerImageIndexManifest, 0, len(index.Manifests)),
+	}
+	for _, manifest := range index.Manifests {
+		apiIndex.Manifests = append(apiIndex.Manifests, &api.ContainerImageIndexManifest{
+			Digest:       string(manifest.Digest),
+			MediaType:    manifest.MediaType,
+			Size:         manifest.Size,
+			OS:           manifest.Platform.OS,
+			Architecture: manifest.Platform.Architecture,
+			Variant:      manifest.Platform.Variant,
+			OSVersion:    manifest.Platform.OSVersion,
+		})
+	}
+
+	ctx.JSON(http.StatusCreated, apiIndex)
+}
@@ -40,7 +40,7 @@ func ListPackages(ctx *context.APIContext) {
 	//   in: query
 	//   description: package type filter
 	//   type: string
-	//   enum: [alpine, cargo, chef, composer, conan, conda, container, cran, debian, generic, go, helm, maven, npm, nuget, pub, pypi, rpm, rubygems, swift, vagrant]
+	//   enum: [alpine, ansible, arch, bazel, cargo, chef, cocoapods, composer, conan, conda, container, cran, debian, generic, go, gradle, helm, hex, lfs, maven, model, nix, npm, nuget, pub, pypi, rpm, rubygems, swift, terraform_module, terraform_provider, vagrant]
 	// - name: q
 	//   in: query
 	//   description: name filter
@@ -48,4 +48,7 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	UserBadgeOption api.UserBadgeOption
+
+	// in:body
+	CreateContainerImageIndexOption api.CreateContainerImageIndexOption
 }
@@ -27,3 +27,10 @@ type swaggerResponsePackageFileList struct {
 	// in:body
 	Body []api.PackageFile `json:"body"`
 }
+
+// ContainerImageIndex
+// swagger:response ContainerImageIndex
+type swaggerResponseContainerImageIndex struct {
+	// in:body
+	Body api.ContainerImageIndex `json:"body"`
+}
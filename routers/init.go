@@ -31,6 +31,7 @@ import (
 	"code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
 	"code.gitea.io/gitea/services/cron"
+	container_service "code.gitea.io/gitea/services/packages/container"
 	"code.gitea.io/gitea/services/task"
 	"code.gitea.io/gitea/services/webhook"
 )
@@ -113,6 +114,7 @@ func InitWebInstalled(ctx context.Context) {
 
 	mustInit(webhook.Init)
 	mustInit(task.Init)
+	mustInit(container_service.InitNotifications)
 	eventsource.GetManager().Init()
 
 	mustInitCtx(ctx, syncAppConfForGit)
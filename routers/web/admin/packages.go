@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
 	packages_cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
+	gradle_service "code.gitea.io/gitea/services/packages/gradle"
 )
 
 const (
@@ -65,6 +66,12 @@ func Packages(ctx *context.Context) {
 		return
 	}
 
+	gradleCacheStatistics, err := gradle_service.GetCacheStatistics(ctx)
+	if err != nil {
+		ctx.ServerError("GetCacheStatistics", err)
+		return
+	}
+
 	ctx.Data["Title"] = ctx.Tr("packages.title")
 	ctx.Data["PageIsAdminPackages"] = true
 	ctx.Data["Query"] = query
@@ -75,6 +82,7 @@ func Packages(ctx *context.Context) {
 	ctx.Data["TotalCount"] = total
 	ctx.Data["TotalBlobSize"] = totalBlobSize - totalUnreferencedBlobSize
 	ctx.Data["TotalUnreferencedBlobSize"] = totalUnreferencedBlobSize
+	ctx.Data["GradleCacheStatistics"] = gradleCacheStatistics
 
 	pager := context.NewPagination(int(total), setting.UI.PackagesPagingNum, page, 5)
 	pager.AddParamString("q", query)
@@ -4,21 +4,29 @@
 package packages
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
-	"code.gitea.io/gitea/models/db"
 	packages_model "code.gitea.io/gitea/models/packages"
+	container_model "code.gitea.io/gitea/models/packages/container"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
-	"code.gitea.io/gitea/modules/optional"
+	gradle_module "code.gitea.io/gitea/modules/packages/gradle"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 	cargo_service "code.gitea.io/gitea/services/packages/cargo"
-	container_service "code.gitea.io/gitea/services/packages/container"
+	cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
+	gradle_service "code.gitea.io/gitea/services/packages/gradle"
+
+	"github.com/dustin/go-humanize"
 )
 
 func SetPackagesContext(ctx *context.Context, owner *user_model.User) {
@@ -29,6 +37,32 @@ func SetPackagesContext(ctx *context.Context, owner *user_model.User) {
 	}
 
 	ctx.Data["CleanupRules"] = pcrs
+
+	rules, err := container_model.GetImmutableTagRulesByOwner(ctx, owner.ID)
+	if err != nil {
+		ctx.ServerError("GetImmutableTagRulesByOwner", err)
+		return
+	}
+
+	ctx.Data["ImmutableTagRules"] = rules
+
+	maxSize, err := user_model.GetUserSetting(ctx, owner.ID, gradle_module.SettingCacheMaxSize)
+	if err != nil {
+		ctx.ServerError("GetUserSetting", err)
+		return
+	}
+
+	if maxSize != "" {
+		if size, err := strconv.ParseInt(maxSize, 10, 64); err == nil && size >= 0 {
+			maxSize = humanize.IBytes(uint64(size))
+		}
+	}
+	ctx.Data["GradleCacheMaxSize"] = maxSize
+	if setting.Packages.GradleCacheMaxSize < 0 {
+		ctx.Data["GradleDefaultCacheMaxSize"] = ctx.Tr("packages.owner.settings.gradle.max_size.unlimited")
+	} else {
+		ctx.Data["GradleDefaultCacheMaxSize"] = humanize.IBytes(uint64(setting.Packages.GradleCacheMaxSize))
+	}
 }
 
 func SetRuleAddContext(ctx *context.Context) {
@@ -91,8 +125,10 @@ func performRuleEditPost(ctx *context.Context, owner *user_model.User, pcr *pack
 	pcr.Enabled = form.Enabled
 	pcr.OwnerID = owner.ID
 	pcr.KeepCount = form.KeepCount
+	pcr.KeepCountPattern = form.KeepCountPattern
 	pcr.KeepPattern = form.KeepPattern
 	pcr.RemoveDays = form.RemoveDays
+	pcr.RemoveUnpulledDays = form.RemoveUnpulledDays
 	pcr.RemovePattern = form.RemovePattern
 	pcr.MatchFullName = form.MatchFullName
 
@@ -144,8 +180,6 @@ func SetRulePreviewContext(ctx *context.Context, owner *user_model.User) {
 		return
 	}
 
-	olderThan := time.Now().AddDate(0, 0, -pcr.RemoveDays)
-
 	packages, err := packages_model.GetPackagesByType(ctx, pcr.OwnerID, pcr.Type)
 	if err != nil {
 		ctx.ServerError("GetPackagesByType", err)
@@ -155,39 +189,12 @@ func SetRulePreviewContext(ctx *context.Context, owner *user_model.User) {
 	versionsToRemove := make([]*packages_model.PackageDescriptor, 0, 10)
 
 	for _, p := range packages {
-		pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
-			PackageID:  p.ID,
-			IsInternal: optional.Some(false),
-			Sort:       packages_model.SortCreatedDesc,
-			Paginator:  db.NewAbsoluteListOptions(pcr.KeepCount, 200),
-		})
+		pvs, err := cleanup_service.GetVersionsToRemove(ctx, pcr, p)
 		if err != nil {
-			ctx.ServerError("SearchVersions", err)
+			ctx.ServerError("GetVersionsToRemove", err)
 			return
 		}
 		for _, pv := range pvs {
-			if skip, err := container_service.ShouldBeSkipped(ctx, pcr, p, pv); err != nil {
-				ctx.ServerError("ShouldBeSkipped", err)
-				return
-			} else if skip {
-				continue
-			}
-
-			toMatch := pv.LowerVersion
-			if pcr.MatchFullName {
-				toMatch = p.LowerName + "/" + pv.LowerVersion
-			}
-
-			if pcr.KeepPatternMatcher != nil && pcr.KeepPatternMatcher.MatchString(toMatch) {
-				continue
-			}
-			if pv.CreatedUnix.AsLocalTime().After(olderThan) {
-				continue
-			}
-			if pcr.RemovePatternMatcher != nil && !pcr.RemovePatternMatcher.MatchString(toMatch) {
-				continue
-			}
-
 			pd, err := packages_model.GetPackageDescriptor(ctx, pv)
 			if err != nil {
 				ctx.ServerError("GetPackageDescriptor", err)
@@ -245,3 +252,58 @@ func RebuildCargoIndex(ctx *context.Context, owner *user_model.User) {
 		ctx.Flash.Success(ctx.Tr("packages.owner.settings.cargo.rebuild.success"))
 	}
 }
+
+func AddImmutableTagRule(ctx *context.Context, owner *user_model.User) {
+	form := web.GetForm(ctx).(*forms.PackageImmutableTagRuleForm)
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		return
+	}
+
+	if err := container_model.InsertImmutableTagRule(ctx, &container_model.ImmutableTagRule{
+		OwnerID: owner.ID,
+		Image:   strings.TrimSpace(form.Image),
+		Pattern: strings.TrimSpace(form.Pattern),
+	}); err != nil {
+		ctx.ServerError("InsertImmutableTagRule", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("packages.owner.settings.container.immutable_tags.success.add"))
+}
+
+func DeleteImmutableTagRule(ctx *context.Context, owner *user_model.User) {
+	if err := container_model.DeleteImmutableTagRule(ctx, owner.ID, ctx.PathParamInt64("id")); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("", err)
+		} else {
+			ctx.ServerError("DeleteImmutableTagRule", err)
+		}
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("packages.owner.settings.container.immutable_tags.success.delete"))
+}
+
+func SetGradleCacheMaxSize(ctx *context.Context, owner *user_model.User) {
+	form := web.GetForm(ctx).(*forms.PackageGradleCacheForm)
+
+	var maxSize *int64
+	if value := strings.TrimSpace(form.MaxSize); value == "-1" {
+		maxSize = util.ToPointer[int64](-1)
+	} else if value != "" {
+		size, err := humanize.ParseBytes(value)
+		if err != nil || size > math.MaxInt64 {
+			ctx.Flash.Error(ctx.Tr("packages.owner.settings.gradle.max_size.invalid"))
+			return
+		}
+		maxSize = util.ToPointer(int64(size))
+	}
+
+	if err := gradle_service.SetCacheMaxSize(ctx, owner.ID, maxSize); err != nil {
+		ctx.ServerError("SetCacheMaxSize", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("packages.owner.settings.gradle.max_size.success"))
+}
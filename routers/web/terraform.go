@@ -0,0 +1,18 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"net/http"
+
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/context"
+)
+
+// terraformServiceDiscovery is requested by Terraform at the host root. The owner is not known here,
+// so the registry locations resolve the package owner from the namespace of the address.
+func terraformServiceDiscovery(ctx *context.Context) {
+	ctx.JSON(http.StatusOK, terraform_module.ServiceDiscovery(setting.AppURL+"api/packages/"+terraform_module.NamespaceOwner+"/terraform"))
+}
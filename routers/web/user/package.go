@@ -6,6 +6,8 @@ package user
 import (
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"code.gitea.io/gitea/models/db"
 	packages_model "code.gitea.io/gitea/models/packages"
@@ -17,7 +19,9 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/optional"
 	alpine_module "code.gitea.io/gitea/modules/packages/alpine"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
 	debian_module "code.gitea.io/gitea/modules/packages/debian"
+	pub_module "code.gitea.io/gitea/modules/packages/pub"
 	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
@@ -27,6 +31,8 @@ import (
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 	packages_service "code.gitea.io/gitea/services/packages"
+	container_service "code.gitea.io/gitea/services/packages/container"
+	pub_service "code.gitea.io/gitea/services/packages/pub"
 )
 
 const (
@@ -182,6 +188,23 @@ func ViewPackageVersion(ctx *context.Context) {
 		}
 
 		ctx.Data["Branches"] = util.Sorted(branches.Values())
+		ctx.Data["Repositories"] = util.Sorted(repositories.Values())
+		ctx.Data["Architectures"] = util.Sorted(architectures.Values())
+	case packages_model.TypeArch:
+		repositories := make(container.Set[string])
+		architectures := make(container.Set[string])
+
+		for _, f := range pd.Files {
+			for _, pp := range f.Properties {
+				switch pp.Name {
+				case arch_module.PropertyRepository:
+					repositories.Add(pp.Value)
+				case arch_module.PropertyArchitecture:
+					architectures.Add(pp.Value)
+				}
+			}
+		}
+
 		ctx.Data["Repositories"] = util.Sorted(repositories.Values())
 		ctx.Data["Architectures"] = util.Sorted(architectures.Values())
 	case packages_model.TypeDebian:
@@ -384,6 +407,16 @@ func PackageSettings(ctx *context.Context) {
 
 	ctx.Data["CanWritePackages"] = ctx.Package.AccessMode >= perm.AccessModeWrite || ctx.IsUserSiteAdmin()
 
+	if pd.Package.Type == packages_model.TypePub {
+		retracted, _ := strconv.ParseBool(pd.VersionProperties.GetByName(pub_module.PropertyRetracted))
+		discontinued, _ := strconv.ParseBool(pd.PackageProperties.GetByName(pub_module.PropertyDiscontinued))
+
+		ctx.Data["PubRetracted"] = retracted
+		ctx.Data["PubCanChangeRetraction"] = pub_service.CanChangeRetraction(pd.Version)
+		ctx.Data["PubRetractionWindowDays"] = int(pub_service.RetractionWindow.Hours() / 24)
+		ctx.Data["PubDiscontinued"] = discontinued
+	}
+
 	err := shared_user.LoadHeaderCount(ctx)
 	if err != nil {
 		ctx.ServerError("LoadHeaderCount", err)
@@ -400,6 +433,17 @@ func PackageSettingsPost(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.PackageSettingForm)
 	switch form.Action {
 	case "delete":
+		if pd.Package.Type == packages_model.TypeContainer {
+			if immutable, err := container_service.IsVersionImmutable(ctx, pd.Package, pd.Version); err != nil {
+				ctx.ServerError("IsVersionImmutable", err)
+				return
+			} else if immutable {
+				ctx.Flash.Error(ctx.Tr("packages.settings.delete.error.immutable"))
+				ctx.Redirect(pd.VersionWebLink())
+				return
+			}
+		}
+
 		err := packages_service.RemovePackageVersion(ctx, ctx.Doer, ctx.Package.Descriptor.Version)
 		if err != nil {
 			log.Error("Error deleting package: %v", err)
@@ -416,6 +460,36 @@ func PackageSettingsPost(ctx *context.Context) {
 
 		ctx.Redirect(redirectURL)
 		return
+	case "pub_retract", "pub_unretract":
+		if pd.Package.Type != packages_model.TypePub {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		if err := pub_service.SetVersionRetracted(ctx, pd.Version, form.Action == "pub_retract"); err != nil {
+			log.Error("Error updating retraction: %v", err)
+			ctx.Flash.Error(ctx.Tr("packages.settings.pub.retract.error"))
+		} else {
+			ctx.Flash.Success(ctx.Tr("packages.settings.pub.retract.success"))
+		}
+
+		ctx.Redirect(ctx.Link)
+		return
+	case "pub_discontinue", "pub_continue":
+		if pd.Package.Type != packages_model.TypePub {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		if err := pub_service.SetPackageDiscontinued(ctx, pd.Package, form.Action == "pub_discontinue", strings.TrimSpace(form.ReplacedBy)); err != nil {
+			log.Error("Error updating discontinued status: %v", err)
+			ctx.Flash.Error(ctx.Tr("packages.settings.pub.discontinue.error"))
+		} else {
+			ctx.Flash.Success(ctx.Tr("packages.settings.pub.discontinue.success"))
+		}
+
+		ctx.Redirect(ctx.Link)
+		return
 	}
 }
 
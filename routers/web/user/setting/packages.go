@@ -106,6 +106,33 @@ func RebuildCargoIndex(ctx *context.Context) {
 	ctx.Redirect(setting.AppSubURL + "/user/settings/packages")
 }
 
+func AddContainerImmutableTagRule(ctx *context.Context) {
+	shared.AddImmutableTagRule(ctx, ctx.Doer)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Redirect(setting.AppSubURL + "/user/settings/packages")
+}
+
+func DeleteContainerImmutableTagRule(ctx *context.Context) {
+	shared.DeleteImmutableTagRule(ctx, ctx.Doer)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Redirect(setting.AppSubURL + "/user/settings/packages")
+}
+
+func SetGradleCacheMaxSize(ctx *context.Context) {
+	shared.SetGradleCacheMaxSize(ctx, ctx.Doer)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Redirect(setting.AppSubURL + "/user/settings/packages")
+}
+
 func RegenerateChefKeyPair(ctx *context.Context) {
 	priv, pub, err := util.GenerateKeyPair(chef_module.KeyBits)
 	if err != nil {
@@ -354,6 +354,7 @@ func registerRoutes(m *web.Router) {
 			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
 		})
 		m.Get("/passkey-endpoints", passkeyEndpoints)
+		m.Get("/terraform.json", packagesEnabled, terraformServiceDiscovery)
 		m.Methods("GET, HEAD", "/*", public.FileHandlerFunc())
 	}, optionsCorsHandler())
 
@@ -500,6 +501,11 @@ func registerRoutes(m *web.Router) {
 				m.Post("/initialize", user_setting.InitializeCargoIndex)
 				m.Post("/rebuild", user_setting.RebuildCargoIndex)
 			})
+			m.Group("/container/immutable_tags", func() {
+				m.Post("", web.Bind(forms.PackageImmutableTagRuleForm{}), user_setting.AddContainerImmutableTagRule)
+				m.Post("/{id}/delete", user_setting.DeleteContainerImmutableTagRule)
+			})
+			m.Post("/gradle/cache_max_size", web.Bind(forms.PackageGradleCacheForm{}), user_setting.SetGradleCacheMaxSize)
 			m.Post("/chef/regenerate_keypair", user_setting.RegenerateChefKeyPair)
 		}, packagesEnabled)
 
@@ -9,7 +9,9 @@ import (
 
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/services/auth"
+	bazel_service "code.gitea.io/gitea/services/packages/bazel"
 	packages_cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
+	gradle_service "code.gitea.io/gitea/services/packages/gradle"
 )
 
 func registerSyncExternalUsers() {
@@ -40,7 +42,29 @@ func registerCleanupPackages() {
 	})
 }
 
+func registerEvictBazelCache() {
+	RegisterTaskFatal("evict_bazel_cache", &BaseConfig{
+		Enabled:    true,
+		RunAtStart: false,
+		Schedule:   "@every 1h",
+	}, func(ctx context.Context, _ *user_model.User, _ Config) error {
+		return bazel_service.EvictEntries(ctx)
+	})
+}
+
+func registerEvictGradleCache() {
+	RegisterTaskFatal("evict_gradle_cache", &BaseConfig{
+		Enabled:    true,
+		RunAtStart: false,
+		Schedule:   "@every 1h",
+	}, func(ctx context.Context, _ *user_model.User, _ Config) error {
+		return gradle_service.EvictEntries(ctx)
+	})
+}
+
 func initBasicTasks() {
 	registerSyncExternalUsers()
 	registerCleanupPackages()
+	registerEvictBazelCache()
+	registerEvictGradleCache()
 }
@@ -13,18 +13,39 @@ import (
 )
 
 type PackageCleanupRuleForm struct {
-	ID            int64
-	Enabled       bool
-	Type          string `binding:"Required;In(alpine,cargo,chef,composer,conan,conda,container,cran,debian,generic,go,helm,maven,npm,nuget,pub,pypi,rpm,rubygems,swift,vagrant)"`
-	KeepCount     int    `binding:"In(0,1,5,10,25,50,100)"`
-	KeepPattern   string `binding:"RegexPattern"`
-	RemoveDays    int    `binding:"In(0,7,14,30,60,90,180)"`
-	RemovePattern string `binding:"RegexPattern"`
-	MatchFullName bool
-	Action        string `binding:"Required;In(save,remove)"`
+	ID                 int64
+	Enabled            bool
+	Type               string `binding:"Required;In(alpine,ansible,arch,bazel,cargo,chef,cocoapods,composer,conan,conda,container,cran,debian,generic,go,gradle,helm,hex,lfs,maven,model,nix,npm,nuget,pub,pypi,rpm,rubygems,swift,terraform_module,terraform_provider,vagrant)"`
+	KeepCount          int    `binding:"In(0,1,5,10,25,50,100)"`
+	KeepCountPattern   string `binding:"RegexPattern"`
+	KeepPattern        string `binding:"RegexPattern"`
+	RemoveDays         int    `binding:"In(0,7,14,30,60,90,180)"`
+	RemoveUnpulledDays int    `binding:"In(0,7,14,30,60,90,180)"`
+	RemovePattern      string `binding:"RegexPattern"`
+	MatchFullName      bool
+	Action             string `binding:"Required;In(save,remove)"`
 }
 
 func (f *PackageCleanupRuleForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
 	ctx := context.GetValidateContext(req)
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+type PackageImmutableTagRuleForm struct {
+	Image   string
+	Pattern string `binding:"Required;GlobPattern"`
+}
+
+func (f *PackageImmutableTagRuleForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+type PackageGradleCacheForm struct {
+	MaxSize string
+}
+
+func (f *PackageGradleCacheForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
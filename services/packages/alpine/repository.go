@@ -34,6 +34,8 @@ import (
 const (
 	IndexFilename        = "APKINDEX"
 	IndexArchiveFilename = IndexFilename + ".tar.gz"
+	// ADBIndexFilename is the index used by apk-tools v3
+	ADBIndexFilename = "Packages.adb"
 )
 
 // GetOrCreateRepositoryVersion gets or creates the internal repository package
@@ -184,16 +186,23 @@ func buildPackagesIndex(ctx context.Context, ownerID int64, repoVersion *package
 		pfs = append(pfs, noarchFiles...)
 	}
 
+	key := fmt.Sprintf("%s|%s|%s", branch, repository, architecture)
+
 	// Delete the package indices if there are no packages
 	if len(pfs) == 0 {
-		pf, err := packages_model.GetFileForVersionByName(ctx, repoVersion.ID, IndexArchiveFilename, fmt.Sprintf("%s|%s|%s", branch, repository, architecture))
-		if err != nil && !errors.Is(err, util.ErrNotExist) {
-			return err
-		} else if pf == nil {
-			return nil
-		}
+		for _, filename := range []string{IndexArchiveFilename, ADBIndexFilename} {
+			pf, err := packages_model.GetFileForVersionByName(ctx, repoVersion.ID, filename, key)
+			if err != nil && !errors.Is(err, util.ErrNotExist) {
+				return err
+			} else if pf == nil {
+				continue
+			}
 
-		return packages_service.DeletePackageFile(ctx, pf)
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
 	// Cache data needed for all repository files
@@ -238,6 +247,11 @@ func buildPackagesIndex(ctx context.Context, ownerID int64, repoVersion *package
 	for _, pf := range pfs {
 		pd := cache[pf]
 
+		// apk-tools v3 packages are only listed in the ADB index
+		if pd.FileMetadata.PackageFormat == alpine_module.PackageFormatV3 {
+			continue
+		}
+
 		fmt.Fprintf(&buf, "C:%s\n", pd.FileMetadata.Checksum)
 		fmt.Fprintf(&buf, "P:%s\n", pd.Package.Name)
 		fmt.Fprintf(&buf, "V:%s\n", pd.Version.Version)
@@ -283,17 +297,7 @@ func buildPackagesIndex(ctx context.Context, ownerID int64, repoVersion *package
 		return err
 	}
 
-	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
-	if err != nil {
-		return err
-	}
-
-	privPem, _ := pem.Decode([]byte(priv))
-	if privPem == nil {
-		return fmt.Errorf("failed to decode private key pem")
-	}
-
-	privKey, err := x509.ParsePKCS1PrivateKey(privPem.Bytes)
+	privKey, err := getPrivateKey(ctx, ownerID)
 	if err != nil {
 		return err
 	}
@@ -329,16 +333,55 @@ func buildPackagesIndex(ctx context.Context, ownerID int64, repoVersion *package
 		return err
 	}
 
-	_, err = packages_service.AddFileToPackageVersionInternal(
+	if err := addIndexFile(ctx, repoVersion, IndexArchiveFilename, branch, repository, architecture, signedIndexContent); err != nil {
+		return err
+	}
+
+	return buildADBIndex(ctx, repoVersion, privKey, branch, repository, architecture, pfs, cache)
+}
+
+// https://gitlab.alpinelinux.org/alpine/apk-tools/-/blob/master/doc/apk-v3.5.scd
+func buildADBIndex(ctx context.Context, repoVersion *packages_model.PackageVersion, privKey *rsa.PrivateKey, branch, repository, architecture string, pfs []*packages_model.PackageFile, c packageCache) error {
+	entries := make([]*alpine_module.ADBIndexEntry, 0, len(pfs))
+	for _, pf := range pfs {
+		pd := c[pf]
+
+		entries = append(entries, &alpine_module.ADBIndexEntry{
+			Name:            pd.Package.Name,
+			Version:         pd.Version.Version,
+			Architecture:    architecture,
+			FileSize:        pd.Blob.Size,
+			VersionMetadata: pd.VersionMetadata,
+			FileMetadata:    pd.FileMetadata,
+		})
+	}
+
+	indexContent, _ := packages_module.NewHashedBuffer()
+	defer indexContent.Close()
+
+	if err := alpine_module.WriteADBFile(
+		indexContent,
+		alpine_module.ADBSchemaIndex,
+		alpine_module.CreateADBIndex(fmt.Sprintf("%s/%s", branch, repository), entries),
+		privKey,
+	); err != nil {
+		return err
+	}
+
+	return addIndexFile(ctx, repoVersion, ADBIndexFilename, branch, repository, architecture, indexContent)
+}
+
+func addIndexFile(ctx context.Context, repoVersion *packages_model.PackageVersion, filename, branch, repository, architecture string, content *packages_module.HashedBuffer) error {
+	_, err := packages_service.AddFileToPackageVersionInternal(
 		ctx,
 		repoVersion,
 		&packages_service.PackageFileCreationInfo{
 			PackageFileInfo: packages_service.PackageFileInfo{
-				Filename:     IndexArchiveFilename,
+				Filename:     filename,
 				CompositeKey: fmt.Sprintf("%s|%s|%s", branch, repository, architecture),
 			},
 			Creator:           user_model.NewGhostUser(),
-			Data:              signedIndexContent,
+			Data:              content,
 			IsLead:            false,
 			OverwriteExisting: true,
 			Properties: map[string]string{
@@ -351,6 +394,20 @@ func buildPackagesIndex(ctx context.Context, ownerID int64, repoVersion *package
 	return err
 }
 
+func getPrivateKey(ctx context.Context, ownerID int64) (*rsa.PrivateKey, error) {
+	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	privPem, _ := pem.Decode([]byte(priv))
+	if privPem == nil {
+		return nil, fmt.Errorf("failed to decode private key pem")
+	}
+
+	return x509.ParsePKCS1PrivateKey(privPem.Bytes)
+}
+
 func writeGzipStream(w io.Writer, filename string, content []byte, addTarEnd bool) error {
 	zw := gzip.NewWriter(w)
 	defer zw.Close()
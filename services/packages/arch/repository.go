@@ -0,0 +1,536 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	arch_model "code.gitea.io/gitea/models/packages/arch"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+
+	"github.com/keybase/go-crypto/openpgp"
+	"github.com/keybase/go-crypto/openpgp/armor"
+	"github.com/keybase/go-crypto/openpgp/packet"
+)
+
+var ErrInvalidSignature = util.NewInvalidArgumentErrorf("signature is invalid")
+
+// IndexArchiveFilename gets the name of the package database of the repository
+func IndexArchiveFilename(repository string) string {
+	return repository + ".db.tar.gz"
+}
+
+// FilesArchiveFilename gets the name of the files database of the repository
+func FilesArchiveFilename(repository string) string {
+	return repository + ".files.tar.gz"
+}
+
+// GetOrCreateRepositoryVersion gets or creates the internal repository package
+// The Arch registry needs multiple database files which are stored in this package.
+func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeArch, arch_module.RepositoryPackage, arch_module.RepositoryVersion)
+}
+
+// GetOrCreateKeyPair gets or creates the PGP keys used to sign packages and repository files
+func GetOrCreateKeyPair(ctx context.Context, ownerID int64) (string, string, error) {
+	priv, err := user_model.GetSetting(ctx, ownerID, arch_module.SettingKeyPrivate)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	pub, err := user_model.GetSetting(ctx, ownerID, arch_module.SettingKeyPublic)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	if priv == "" || pub == "" {
+		priv, pub, err = generateKeypair()
+		if err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, ownerID, arch_module.SettingKeyPrivate, priv); err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, ownerID, arch_module.SettingKeyPublic, pub); err != nil {
+			return "", "", err
+		}
+	}
+
+	return priv, pub, nil
+}
+
+func generateKeypair() (string, string, error) {
+	e, err := openpgp.NewEntity("", "Arch Registry", "", nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	var priv strings.Builder
+	var pub strings.Builder
+
+	w, err := armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if err := e.SerializePrivate(w, nil); err != nil {
+		return "", "", err
+	}
+	w.Close()
+
+	w, err = armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if err := e.Serialize(w); err != nil {
+		return "", "", err
+	}
+	w.Close()
+
+	return priv.String(), pub.String(), nil
+}
+
+func getEntity(ctx context.Context, ownerID int64) (*openpgp.Entity, error) {
+	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := armor.Decode(strings.NewReader(priv))
+	if err != nil {
+		return nil, err
+	}
+
+	return openpgp.ReadEntity(packet.NewReader(block.Body))
+}
+
+// CreateSignature creates a binary detached signature of the content with the owner key
+func CreateSignature(ctx context.Context, ownerID int64, r io.Reader) ([]byte, error) {
+	e, err := getEntity(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := openpgp.DetachSign(&buf, e, r, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ValidateSignature checks if the content is a binary detached PGP signature
+// The signature can't be verified because the key of the packager is unknown.
+func ValidateSignature(sig []byte) error {
+	p, err := packet.Read(bytes.NewReader(sig))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	switch p.(type) {
+	case *packet.Signature, *packet.SignatureV3:
+		return nil
+	}
+	return ErrInvalidSignature
+}
+
+// BuildAllRepositoryFiles (re)builds all repository files for every available repositories and architectures
+func BuildAllRepositoryFiles(ctx context.Context, ownerID int64) error {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	// 1. Delete all existing repository files
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, pf := range pfs {
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	// 2. (Re)Build repository files for existing packages
+	repositories, err := arch_model.GetRepositories(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	for _, repository := range repositories {
+		architectures, err := arch_model.GetArchitectures(ctx, ownerID, repository)
+		if err != nil {
+			return err
+		}
+		for _, architecture := range architectures {
+			if err := buildPackagesIndex(ctx, ownerID, pv, repository, architecture); err != nil {
+				return fmt.Errorf("failed to build repository files [%s/%s]: %w", repository, architecture, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// BuildSpecificRepositoryFiles builds database files for the repository
+func BuildSpecificRepositoryFiles(ctx context.Context, ownerID int64, repository, architecture string) error {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	architectures := container.SetOf(architecture)
+	if architecture == arch_module.AnyArch {
+		// Update all other architectures too when updating the any index
+		additionalArchitectures, err := arch_model.GetArchitectures(ctx, ownerID, repository)
+		if err != nil {
+			return err
+		}
+		architectures.AddMultiple(additionalArchitectures...)
+	}
+
+	for architecture := range architectures {
+		if err := buildPackagesIndex(ctx, ownerID, pv, repository, architecture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type packageData struct {
+	Package         *packages_model.Package
+	Version         *packages_model.PackageVersion
+	File            *packages_model.PackageFile
+	Blob            *packages_model.PackageBlob
+	VersionMetadata *arch_module.VersionMetadata
+	FileMetadata    *arch_module.FileMetadata
+	Files           []string
+	Signature       string
+}
+
+func searchPackageFiles(ctx context.Context, ownerID int64, repository, architecture string) ([]*packages_model.PackageFile, error) {
+	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		OwnerID:     ownerID,
+		PackageType: packages_model.TypeArch,
+		Query:       "%" + arch_module.PackageExtension,
+		Properties: map[string]string{
+			arch_module.PropertyRepository:   repository,
+			arch_module.PropertyArchitecture: architecture,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pfs, nil
+}
+
+// https://gitlab.archlinux.org/pacman/pacman/-/blob/master/scripts/repo-add.sh.in
+func buildPackagesIndex(ctx context.Context, ownerID int64, repoVersion *packages_model.PackageVersion, repository, architecture string) error {
+	pfs, err := searchPackageFiles(ctx, ownerID, repository, architecture)
+	if err != nil {
+		return err
+	}
+	if architecture != arch_module.AnyArch {
+		// Add all any packages too
+		anyFiles, err := searchPackageFiles(ctx, ownerID, repository, arch_module.AnyArch)
+		if err != nil {
+			return err
+		}
+		pfs = append(pfs, anyFiles...)
+	}
+
+	key := fmt.Sprintf("%s|%s", repository, architecture)
+
+	// Delete the package databases if there are no packages
+	if len(pfs) == 0 {
+		for _, filename := range []string{IndexArchiveFilename(repository), FilesArchiveFilename(repository)} {
+			for _, name := range []string{filename, filename + arch_module.SignatureExtension} {
+				pf, err := packages_model.GetFileForVersionByName(ctx, repoVersion.ID, name, key)
+				if err != nil && !errors.Is(err, util.ErrNotExist) {
+					return err
+				} else if pf == nil {
+					continue
+				}
+
+				if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	pds := make([]*packageData, 0, len(pfs))
+	for _, pf := range pfs {
+		pd, err := loadPackageData(ctx, pf)
+		if err != nil {
+			return err
+		}
+		pds = append(pds, pd)
+	}
+	pds = latestPackages(pds)
+
+	e, err := getEntity(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	dbContent, err := writeDatabase(pds, false)
+	if err != nil {
+		return err
+	}
+	if err := addIndexFile(ctx, e, repoVersion, IndexArchiveFilename(repository), repository, architecture, dbContent); err != nil {
+		return err
+	}
+
+	filesContent, err := writeDatabase(pds, true)
+	if err != nil {
+		return err
+	}
+	return addIndexFile(ctx, e, repoVersion, FilesArchiveFilename(repository), repository, architecture, filesContent)
+}
+
+// latestPackages keeps only the highest version of every package because pacman expects a single entry per package name.
+// The architecture specific and the any packages are filtered together, so a newer any package replaces an older architecture specific one and vice versa.
+func latestPackages(pds []*packageData) []*packageData {
+	latest := make(map[string]*packageData, len(pds))
+	for _, pd := range pds {
+		if cur, ok := latest[pd.Package.LowerName]; !ok || arch_module.CompareVersions(pd.Version.Version, cur.Version.Version) > 0 {
+			latest[pd.Package.LowerName] = pd
+		}
+	}
+
+	result := make([]*packageData, 0, len(latest))
+	for _, pd := range pds {
+		if latest[pd.Package.LowerName] == pd {
+			result = append(result, pd)
+		}
+	}
+	return result
+}
+
+func loadPackageData(ctx context.Context, pf *packages_model.PackageFile) (*packageData, error) {
+	pv, err := packages_model.GetVersionByID(ctx, pf.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := packages_model.GetPackageByID(ctx, pv.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, err
+	}
+	pps, err := packages_model.GetProperties(ctx, packages_model.PropertyTypeFile, pf.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	pd := &packageData{
+		Package: p,
+		Version: pv,
+		File:    pf,
+		Blob:    pb,
+	}
+
+	if err := json.Unmarshal([]byte(pv.MetadataJSON), &pd.VersionMetadata); err != nil {
+		return nil, err
+	}
+
+	for _, pp := range pps {
+		switch pp.Name {
+		case arch_module.PropertyMetadata:
+			if err := json.Unmarshal([]byte(pp.Value), &pd.FileMetadata); err != nil {
+				return nil, err
+			}
+		case arch_module.PropertyFiles:
+			if err := json.Unmarshal([]byte(pp.Value), &pd.Files); err != nil {
+				return nil, err
+			}
+		case arch_module.PropertySignature:
+			pd.Signature = pp.Value
+		}
+	}
+
+	if pd.FileMetadata == nil {
+		pd.FileMetadata = &arch_module.FileMetadata{}
+	}
+
+	return pd, nil
+}
+
+// writeDatabase creates a repository database with a desc entry for every package
+// The files database contains the list of package files in addition.
+func writeDatabase(pds []*packageData, withFiles bool) (*packages_module.HashedBuffer, error) {
+	content, _ := packages_module.NewHashedBuffer()
+
+	zw := gzip.NewWriter(content)
+	tw := tar.NewWriter(zw)
+
+	for _, pd := range pds {
+		directory := fmt.Sprintf("%s-%s", pd.Package.Name, pd.Version.Version)
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     directory + "/",
+			Typeflag: tar.TypeDir,
+			Mode:     0o755,
+		}); err != nil {
+			content.Close()
+			return nil, err
+		}
+
+		entries := map[string][]byte{
+			"desc": buildDescEntry(pd),
+		}
+		if withFiles {
+			entries["files"] = buildFilesEntry(pd)
+		}
+
+		for _, name := range []string{"desc", "files"} {
+			data, ok := entries[name]
+			if !ok {
+				continue
+			}
+
+			if err := tw.WriteHeader(&tar.Header{
+				Name: directory + "/" + name,
+				Mode: 0o644,
+				Size: int64(len(data)),
+			}); err != nil {
+				content.Close()
+				return nil, err
+			}
+			if _, err := tw.Write(data); err != nil {
+				content.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		content.Close()
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		content.Close()
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func buildDescEntry(pd *packageData) []byte {
+	var buf bytes.Buffer
+
+	writeField := func(name string, values ...string) {
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			return
+		}
+		fmt.Fprintf(&buf, "%%%s%%\n%s\n\n", name, strings.Join(values, "\n"))
+	}
+
+	writeField("FILENAME", pd.File.Name)
+	writeField("NAME", pd.Package.Name)
+	writeField("BASE", pd.VersionMetadata.Base)
+	writeField("VERSION", pd.Version.Version)
+	writeField("DESC", pd.VersionMetadata.Description)
+	writeField("GROUPS", pd.VersionMetadata.Groups...)
+	writeField("CSIZE", fmt.Sprint(pd.Blob.Size))
+	writeField("ISIZE", fmt.Sprint(pd.FileMetadata.InstalledSize))
+	writeField("MD5SUM", pd.Blob.HashMD5)
+	writeField("SHA256SUM", pd.Blob.HashSHA256)
+	writeField("PGPSIG", pd.Signature)
+	writeField("URL", pd.VersionMetadata.ProjectURL)
+	writeField("LICENSE", pd.VersionMetadata.Licenses...)
+	writeField("ARCH", pd.FileMetadata.Architecture)
+	writeField("BUILDDATE", fmt.Sprint(pd.FileMetadata.BuildDate))
+	writeField("PACKAGER", pd.FileMetadata.Packager)
+	writeField("REPLACES", pd.FileMetadata.Replaces...)
+	writeField("CONFLICTS", pd.FileMetadata.Conflicts...)
+	writeField("PROVIDES", pd.FileMetadata.Provides...)
+	writeField("DEPENDS", pd.FileMetadata.Dependencies...)
+	writeField("OPTDEPENDS", pd.FileMetadata.OptDependencies...)
+	writeField("MAKEDEPENDS", pd.FileMetadata.MakeDependencies...)
+	writeField("CHECKDEPENDS", pd.FileMetadata.CheckDependencies...)
+
+	return buf.Bytes()
+}
+
+func buildFilesEntry(pd *packageData) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("%FILES%\n")
+	for _, file := range pd.Files {
+		fmt.Fprintf(&buf, "%s\n", file)
+	}
+	if len(pd.FileMetadata.Backup) > 0 {
+		buf.WriteString("\n%BACKUP%\n")
+		for _, file := range pd.FileMetadata.Backup {
+			fmt.Fprintf(&buf, "%s\n", file)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// addIndexFile stores the database together with its detached signature
+func addIndexFile(ctx context.Context, e *openpgp.Entity, repoVersion *packages_model.PackageVersion, filename, repository, architecture string, content *packages_module.HashedBuffer) error {
+	defer content.Close()
+
+	signatureContent, _ := packages_module.NewHashedBuffer()
+	defer signatureContent.Close()
+
+	if err := openpgp.DetachSign(signatureContent, e, content, nil); err != nil {
+		return err
+	}
+
+	for _, file := range []struct {
+		Name    string
+		Content *packages_module.HashedBuffer
+	}{
+		{filename, content},
+		{filename + arch_module.SignatureExtension, signatureContent},
+	} {
+		if _, err := file.Content.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		if _, err := packages_service.AddFileToPackageVersionInternal(
+			ctx,
+			repoVersion,
+			&packages_service.PackageFileCreationInfo{
+				PackageFileInfo: packages_service.PackageFileInfo{
+					Filename:     file.Name,
+					CompositeKey: fmt.Sprintf("%s|%s", repository, architecture),
+				},
+				Creator:           user_model.NewGhostUser(),
+				Data:              file.Content,
+				IsLead:            false,
+				OverwriteExisting: true,
+				Properties: map[string]string{
+					arch_module.PropertyRepository:   repository,
+					arch_module.PropertyArchitecture: architecture,
+				},
+			},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
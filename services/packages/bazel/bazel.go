@@ -0,0 +1,162 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package bazel
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	bazel_module "code.gitea.io/gitea/modules/packages/bazel"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var ErrDigestMismatch = util.NewInvalidArgumentErrorf("content does not match the digest")
+
+// UploadEntry stores a cache entry
+// Entries of the content addressable store are immutable and must match their digest.
+// Action results may be overwritten because actions are not guaranteed to be reproducible.
+func UploadEntry(ctx context.Context, doer, owner *user_model.User, kind bazel_module.Kind, digest string, buf *packages_module.HashedBuffer) error {
+	if kind == bazel_module.KindCAS {
+		_, _, sum, _ := buf.Sums()
+		if hex.EncodeToString(sum) != digest {
+			return ErrDigestMismatch
+		}
+
+		if _, err := packages_model.GetVersionByNameAndVersion(ctx, owner.ID, packages_model.TypeBazel, string(kind), digest); err == nil {
+			return nil
+		}
+	}
+
+	_, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       owner,
+				PackageType: packages_model.TypeBazel,
+				Name:        string(kind),
+				Version:     digest,
+			},
+			Creator: doer,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: digest,
+			},
+			Creator:           doer,
+			Data:              buf,
+			IsLead:            true,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+// isExpired checks if the entry is an action result older than the configured TTL
+func isExpired(kind bazel_module.Kind, created timeutil.TimeStamp) bool {
+	return kind == bazel_module.KindAC && setting.Packages.BazelActionCacheTTL > 0 && created.AsTime().Before(time.Now().Add(-setting.Packages.BazelActionCacheTTL))
+}
+
+// GetEntryStream gets the content of a cache entry
+// Expired action results are treated as missing.
+func GetEntryStream(ctx context.Context, owner *user_model.User, kind bazel_module.Kind, digest string) (io.ReadSeekCloser, *url.URL, *packages_model.PackageFile, error) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, owner.ID, packages_model.TypeBazel, string(kind), digest)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, digest, packages_model.EmptyFileKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if isExpired(kind, pf.CreatedUnix) {
+		return nil, nil, nil, packages_model.ErrPackageNotExist
+	}
+
+	return packages_service.GetPackageFileStream(ctx, pf)
+}
+
+// EvictEntries removes expired action results of all owners and the least recently used entries of owners exceeding the size cap
+func EvictEntries(ctx context.Context) error {
+	ownerIDs, err := packages_model.GetOwnerIDsByType(ctx, packages_model.TypeBazel)
+	if err != nil {
+		return err
+	}
+
+	for _, ownerID := range ownerIDs {
+		select {
+		case <-ctx.Done():
+			return db.ErrCancelledf("while evicting Bazel cache entries of owner %d", ownerID)
+		default:
+		}
+
+		if err := evictOwnerEntries(ctx, ownerID); err != nil {
+			return fmt.Errorf("evicting Bazel cache entries of owner %d failed: %w", ownerID, err)
+		}
+	}
+	return nil
+}
+
+func evictOwnerEntries(ctx context.Context, ownerID int64) error {
+	entries, err := packages_model.GetCacheEntries(ctx, ownerID, packages_model.TypeBazel)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastActivityUnix() < entries[j].LastActivityUnix()
+	})
+
+	var totalSize int64
+	keep := make([]*packages_model.CacheEntry, 0, len(entries))
+	for _, e := range entries {
+		if isExpired(bazel_module.Kind(e.Name), e.CreatedUnix) {
+			if err := deleteEntry(ctx, e); err != nil {
+				return err
+			}
+			continue
+		}
+		totalSize += e.Size
+		keep = append(keep, e)
+	}
+
+	if setting.Packages.BazelCacheMaxSize < 0 {
+		return nil
+	}
+
+	for _, e := range keep {
+		if totalSize <= setting.Packages.BazelCacheMaxSize {
+			break
+		}
+		if err := deleteEntry(ctx, e); err != nil {
+			return err
+		}
+		totalSize -= e.Size
+	}
+	return nil
+}
+
+func deleteEntry(ctx context.Context, e *packages_model.CacheEntry) error {
+	pv, err := packages_model.GetVersionByID(ctx, e.VersionID)
+	if err != nil {
+		return err
+	}
+
+	log.Trace("Evicting Bazel cache entry %s/%s", e.Name, pv.Version)
+
+	return packages_service.DeletePackageVersionAndReferences(ctx, pv)
+}
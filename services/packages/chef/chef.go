@@ -0,0 +1,116 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package chef
+
+import (
+	"context"
+	"strconv"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	chef_module "code.gitea.io/gitea/modules/packages/chef"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+var (
+	ErrInvalidReplacement = util.NewInvalidArgumentErrorf("the replacement cookbook is invalid")
+	ErrInvalidURL         = util.NewInvalidArgumentErrorf("the url is invalid")
+)
+
+// CookbookOptions are the cookbook settings which can be changed without uploading a new version.
+// Nil fields are not changed.
+type CookbookOptions struct {
+	Deprecated  *bool
+	Replacement *string
+	SourceURL   *string
+	IssuesURL   *string
+}
+
+// IsVersionYanked checks if the version should not be used for dependency resolution anymore
+func IsVersionYanked(pd *packages_model.PackageDescriptor) bool {
+	yanked, _ := strconv.ParseBool(pd.VersionProperties.GetByName(chef_module.PropertyYanked))
+	return yanked
+}
+
+// IsCookbookDeprecated checks if the cookbook is deprecated
+func IsCookbookDeprecated(pd *packages_model.PackageDescriptor) bool {
+	deprecated, _ := strconv.ParseBool(pd.PackageProperties.GetByName(chef_module.PropertyDeprecated))
+	return deprecated
+}
+
+// UpdateCookbook changes the deprecation status and the urls of the cookbook
+func UpdateCookbook(ctx context.Context, p *packages_model.Package, opts *CookbookOptions) error {
+	if opts.Replacement != nil && *opts.Replacement != "" {
+		if !chef_module.IsValidName(*opts.Replacement) || *opts.Replacement == p.Name {
+			return ErrInvalidReplacement
+		}
+		if _, err := packages_model.GetPackageByName(ctx, p.OwnerID, packages_model.TypeChef, *opts.Replacement); err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				return ErrInvalidReplacement
+			}
+			return err
+		}
+	}
+	for _, u := range []*string{opts.SourceURL, opts.IssuesURL} {
+		if u != nil && *u != "" && !validation.IsValidURL(*u) {
+			return ErrInvalidURL
+		}
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if opts.Deprecated != nil {
+			if err := setPackageProperty(ctx, p, chef_module.PropertyDeprecated, strconv.FormatBool(*opts.Deprecated), !*opts.Deprecated); err != nil {
+				return err
+			}
+			// The replacement is only meaningful for deprecated cookbooks
+			if !*opts.Deprecated {
+				if err := setPackageProperty(ctx, p, chef_module.PropertyReplacement, "", true); err != nil {
+					return err
+				}
+			}
+		}
+		if opts.Replacement != nil && (opts.Deprecated == nil || *opts.Deprecated) {
+			if err := setPackageProperty(ctx, p, chef_module.PropertyReplacement, *opts.Replacement, *opts.Replacement == ""); err != nil {
+				return err
+			}
+		}
+		if opts.SourceURL != nil {
+			if err := setPackageProperty(ctx, p, chef_module.PropertySourceURL, *opts.SourceURL, *opts.SourceURL == ""); err != nil {
+				return err
+			}
+		}
+		if opts.IssuesURL != nil {
+			if err := setPackageProperty(ctx, p, chef_module.PropertyIssuesURL, *opts.IssuesURL, *opts.IssuesURL == ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func setPackageProperty(ctx context.Context, p *packages_model.Package, name, value string, remove bool) error {
+	if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypePackage, p.ID, name); err != nil {
+		return err
+	}
+	if remove {
+		return nil
+	}
+	_, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypePackage, p.ID, name, value)
+	return err
+}
+
+// SetVersionYanked marks the version as yanked or restores it
+func SetVersionYanked(ctx context.Context, pv *packages_model.PackageVersion, yanked bool) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypeVersion, pv.ID, chef_module.PropertyYanked); err != nil {
+			return err
+		}
+		if !yanked {
+			return nil
+		}
+		_, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, pv.ID, chef_module.PropertyYanked, strconv.FormatBool(true))
+		return err
+	})
+}
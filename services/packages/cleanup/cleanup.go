@@ -16,9 +16,12 @@ import (
 	packages_module "code.gitea.io/gitea/modules/packages"
 	packages_service "code.gitea.io/gitea/services/packages"
 	alpine_service "code.gitea.io/gitea/services/packages/alpine"
+	arch_service "code.gitea.io/gitea/services/packages/arch"
 	cargo_service "code.gitea.io/gitea/services/packages/cargo"
 	container_service "code.gitea.io/gitea/services/packages/container"
 	debian_service "code.gitea.io/gitea/services/packages/debian"
+	huggingface_service "code.gitea.io/gitea/services/packages/huggingface"
+	nix_service "code.gitea.io/gitea/services/packages/nix"
 	rpm_service "code.gitea.io/gitea/services/packages/rpm"
 )
 
@@ -49,8 +52,6 @@ func ExecuteCleanupRules(outerCtx context.Context) error {
 			return fmt.Errorf("CleanupRule [%d]: CompilePattern failed: %w", pcr.ID, err)
 		}
 
-		olderThan := time.Now().AddDate(0, 0, -pcr.RemoveDays)
-
 		packages, err := packages_model.GetPackagesByType(ctx, pcr.OwnerID, pcr.Type)
 		if err != nil {
 			return fmt.Errorf("CleanupRule [%d]: GetPackagesByType failed: %w", pcr.ID, err)
@@ -58,44 +59,12 @@ func ExecuteCleanupRules(outerCtx context.Context) error {
 
 		anyVersionDeleted := false
 		for _, p := range packages {
-			pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
-				PackageID:  p.ID,
-				IsInternal: optional.Some(false),
-				Sort:       packages_model.SortCreatedDesc,
-				Paginator:  db.NewAbsoluteListOptions(pcr.KeepCount, 200),
-			})
+			pvs, err := GetVersionsToRemove(ctx, pcr, p)
 			if err != nil {
-				return fmt.Errorf("CleanupRule [%d]: SearchVersions failed: %w", pcr.ID, err)
+				return fmt.Errorf("CleanupRule [%d]: GetVersionsToRemove failed: %w", pcr.ID, err)
 			}
 			versionDeleted := false
 			for _, pv := range pvs {
-				if pcr.Type == packages_model.TypeContainer {
-					if skip, err := container_service.ShouldBeSkipped(ctx, pcr, p, pv); err != nil {
-						return fmt.Errorf("CleanupRule [%d]: container.ShouldBeSkipped failed: %w", pcr.ID, err)
-					} else if skip {
-						log.Debug("Rule[%d]: keep '%s/%s' (container)", pcr.ID, p.Name, pv.Version)
-						continue
-					}
-				}
-
-				toMatch := pv.LowerVersion
-				if pcr.MatchFullName {
-					toMatch = p.LowerName + "/" + pv.LowerVersion
-				}
-
-				if pcr.KeepPatternMatcher != nil && pcr.KeepPatternMatcher.MatchString(toMatch) {
-					log.Debug("Rule[%d]: keep '%s/%s' (keep pattern)", pcr.ID, p.Name, pv.Version)
-					continue
-				}
-				if pv.CreatedUnix.AsLocalTime().After(olderThan) {
-					log.Debug("Rule[%d]: keep '%s/%s' (remove days)", pcr.ID, p.Name, pv.Version)
-					continue
-				}
-				if pcr.RemovePatternMatcher != nil && !pcr.RemovePatternMatcher.MatchString(toMatch) {
-					log.Debug("Rule[%d]: keep '%s/%s' (remove pattern)", pcr.ID, p.Name, pv.Version)
-					continue
-				}
-
 				log.Debug("Rule[%d]: remove '%s/%s'", pcr.ID, p.Name, pv.Version)
 
 				if err := packages_service.DeletePackageVersionAndReferences(ctx, pv); err != nil {
@@ -128,6 +97,10 @@ func ExecuteCleanupRules(outerCtx context.Context) error {
 				if err := alpine_service.BuildAllRepositoryFiles(ctx, pcr.OwnerID); err != nil {
 					return fmt.Errorf("CleanupRule [%d]: alpine.BuildAllRepositoryFiles failed: %w", pcr.ID, err)
 				}
+			} else if pcr.Type == packages_model.TypeArch {
+				if err := arch_service.BuildAllRepositoryFiles(ctx, pcr.OwnerID); err != nil {
+					return fmt.Errorf("CleanupRule [%d]: arch.BuildAllRepositoryFiles failed: %w", pcr.ID, err)
+				}
 			} else if pcr.Type == packages_model.TypeRpm {
 				if err := rpm_service.BuildAllRepositoryFiles(ctx, pcr.OwnerID); err != nil {
 					return fmt.Errorf("CleanupRule [%d]: rpm.BuildAllRepositoryFiles failed: %w", pcr.ID, err)
@@ -143,6 +116,75 @@ func ExecuteCleanupRules(outerCtx context.Context) error {
 	return committer.Commit()
 }
 
+// GetVersionsToRemove returns the versions of the package which get removed by the cleanup rule.
+// The patterns of the rule must be compiled already.
+func GetVersionsToRemove(ctx context.Context, pcr *packages_model.PackageCleanupRule, p *packages_model.Package) ([]*packages_model.PackageVersion, error) {
+	now := time.Now()
+	olderThan := now.AddDate(0, 0, -pcr.RemoveDays)
+	unpulledSince := now.AddDate(0, 0, -pcr.RemoveUnpulledDays)
+
+	// Without a keep count pattern the newest versions can be skipped directly.
+	// Otherwise only the versions matching the pattern count and all versions must be inspected.
+	paginator := db.NewAbsoluteListOptions(pcr.KeepCount, 200)
+	if pcr.KeepCountPatternMatcher != nil {
+		paginator = db.NewAbsoluteListOptions(0, pcr.KeepCount+200)
+	}
+
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		PackageID:  p.ID,
+		IsInternal: optional.Some(false),
+		Sort:       packages_model.SortCreatedDesc,
+		Paginator:  paginator,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SearchVersions failed: %w", err)
+	}
+
+	versionsToRemove := make([]*packages_model.PackageVersion, 0, len(pvs))
+	keptCount := 0
+	for _, pv := range pvs {
+		toMatch := pv.LowerVersion
+		if pcr.MatchFullName {
+			toMatch = p.LowerName + "/" + pv.LowerVersion
+		}
+
+		if pcr.KeepCountPatternMatcher != nil && pcr.KeepCountPatternMatcher.MatchString(toMatch) && keptCount < pcr.KeepCount {
+			keptCount++
+			log.Debug("Rule[%d]: keep '%s/%s' (keep count)", pcr.ID, p.Name, pv.Version)
+			continue
+		}
+
+		if pcr.Type == packages_model.TypeContainer {
+			if skip, err := container_service.ShouldBeSkipped(ctx, pcr, p, pv); err != nil {
+				return nil, fmt.Errorf("container.ShouldBeSkipped failed: %w", err)
+			} else if skip {
+				log.Debug("Rule[%d]: keep '%s/%s' (container)", pcr.ID, p.Name, pv.Version)
+				continue
+			}
+		}
+
+		if pcr.KeepPatternMatcher != nil && pcr.KeepPatternMatcher.MatchString(toMatch) {
+			log.Debug("Rule[%d]: keep '%s/%s' (keep pattern)", pcr.ID, p.Name, pv.Version)
+			continue
+		}
+		if pv.CreatedUnix.AsLocalTime().After(olderThan) {
+			log.Debug("Rule[%d]: keep '%s/%s' (remove days)", pcr.ID, p.Name, pv.Version)
+			continue
+		}
+		if pcr.RemoveUnpulledDays > 0 && pv.LastActivityUnix().AsLocalTime().After(unpulledSince) {
+			log.Debug("Rule[%d]: keep '%s/%s' (remove unpulled days)", pcr.ID, p.Name, pv.Version)
+			continue
+		}
+		if pcr.RemovePatternMatcher != nil && !pcr.RemovePatternMatcher.MatchString(toMatch) {
+			log.Debug("Rule[%d]: keep '%s/%s' (remove pattern)", pcr.ID, p.Name, pv.Version)
+			continue
+		}
+
+		versionsToRemove = append(versionsToRemove, pv)
+	}
+	return versionsToRemove, nil
+}
+
 func CleanupExpiredData(outerCtx context.Context, olderThan time.Duration) error {
 	ctx, committer, err := db.TxContext(outerCtx)
 	if err != nil {
@@ -154,6 +196,18 @@ func CleanupExpiredData(outerCtx context.Context, olderThan time.Duration) error
 		return err
 	}
 
+	if err := debian_service.Cleanup(ctx, olderThan); err != nil {
+		return err
+	}
+
+	if err := huggingface_service.Cleanup(ctx, olderThan); err != nil {
+		return err
+	}
+
+	if err := nix_service.Cleanup(ctx, olderThan); err != nil {
+		return err
+	}
+
 	ps, err := packages_model.FindUnreferencedPackages(ctx)
 	if err != nil {
 		return err
@@ -0,0 +1,325 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package conda
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	conda_model "code.gitea.io/gitea/models/packages/conda"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	conda_module "code.gitea.io/gitea/modules/packages/conda"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var ErrNoPackages = util.NewNotExistErrorf("no packages found")
+
+// GetOrCreateRepositoryVersion gets or creates the internal repository package holding the patch instructions
+func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeConda, conda_module.RepositoryPackage, conda_module.RepositoryVersion)
+}
+
+func patchInstructionsKey(channel, subdir string) string {
+	return fmt.Sprintf("%s|%s", channel, subdir)
+}
+
+// GetPatchInstructions gets the patch instructions of the subdir
+func GetPatchInstructions(ctx context.Context, ownerID int64, channel, subdir string) (*conda_module.PatchInstructions, error) {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, conda_module.PatchInstructionsFilename, patchInstructionsKey(channel, subdir))
+	if err != nil {
+		return nil, err
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	var pi *conda_module.PatchInstructions
+	if err := json.NewDecoder(s).Decode(&pi); err != nil {
+		return nil, err
+	}
+	return pi, nil
+}
+
+// SetPatchInstructions stores the patch instructions of the subdir
+func SetPatchInstructions(ctx context.Context, ownerID int64, channel, subdir string, pi *conda_module.PatchInstructions) error {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(pi)
+	if err != nil {
+		return err
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	_, err = packages_service.AddFileToPackageVersionInternal(
+		ctx,
+		pv,
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     conda_module.PatchInstructionsFilename,
+				CompositeKey: patchInstructionsKey(channel, subdir),
+			},
+			Creator:           user_model.NewGhostUser(),
+			Data:              buf,
+			IsLead:            false,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+// DeletePatchInstructions removes the patch instructions of the subdir
+func DeletePatchInstructions(ctx context.Context, ownerID int64, channel, subdir string) error {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, conda_module.PatchInstructionsFilename, patchInstructionsKey(channel, subdir))
+	if err != nil {
+		return err
+	}
+	return packages_service.DeletePackageFile(ctx, pf)
+}
+
+type packageFile struct {
+	Descriptor     *packages_model.PackageDescriptor
+	FileDescriptor *packages_model.PackageFileDescriptor
+	Metadata       *conda_module.FileMetadata
+	Subdir         string
+}
+
+func searchPackageFiles(ctx context.Context, opts *conda_model.FileSearchOptions) ([]*packageFile, error) {
+	pfs, err := conda_model.SearchFiles(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(pfs) == 0 {
+		return nil, ErrNoPackages
+	}
+
+	pds := make(map[int64]*packages_model.PackageDescriptor)
+
+	files := make([]*packageFile, 0, len(pfs))
+	for _, pf := range pfs {
+		pd, exists := pds[pf.VersionID]
+		if !exists {
+			pv, err := packages_model.GetVersionByID(ctx, pf.VersionID)
+			if err != nil {
+				return nil, err
+			}
+
+			pd, err = packages_model.GetPackageDescriptor(ctx, pv)
+			if err != nil {
+				return nil, err
+			}
+
+			pds[pf.VersionID] = pd
+		}
+
+		var pfd *packages_model.PackageFileDescriptor
+		for _, d := range pd.Files {
+			if d.File.ID == pf.ID {
+				pfd = d
+				break
+			}
+		}
+
+		var fileMetadata *conda_module.FileMetadata
+		if err := json.Unmarshal([]byte(pfd.Properties.GetByName(conda_module.PropertyMetadata)), &fileMetadata); err != nil {
+			return nil, err
+		}
+
+		files = append(files, &packageFile{
+			Descriptor:     pd,
+			FileDescriptor: pfd,
+			Metadata:       fileMetadata,
+			Subdir:         pfd.Properties.GetByName(conda_module.PropertySubdir),
+		})
+	}
+	return files, nil
+}
+
+// BuildRepoData builds the repodata of the subdir with the patch instructions applied
+func BuildRepoData(ctx context.Context, ownerID int64, channel, subdir string) (*conda_module.RepoData, error) {
+	files, err := searchPackageFiles(ctx, &conda_model.FileSearchOptions{
+		OwnerID: ownerID,
+		Channel: channel,
+		Subdir:  subdir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	repoData := &conda_module.RepoData{
+		Info: conda_module.RepoDataInfo{
+			Subdir: subdir,
+		},
+		Packages:      make(map[string]*conda_module.PackageInfo),
+		PackagesConda: make(map[string]*conda_module.PackageInfo),
+		Removed:       make(map[string]*conda_module.PackageInfo),
+	}
+
+	for _, f := range files {
+		versionMetadata := f.Descriptor.Metadata.(*conda_module.VersionMetadata)
+
+		pi := &conda_module.PackageInfo{
+			Name:          f.Descriptor.PackageProperties.GetByName(conda_module.PropertyName),
+			Version:       f.Descriptor.Version.Version,
+			NoArch:        f.Metadata.NoArch,
+			Subdir:        subdir,
+			Timestamp:     f.Metadata.Timestamp,
+			Build:         f.Metadata.Build,
+			BuildNumber:   f.Metadata.BuildNumber,
+			Dependencies:  f.Metadata.Dependencies,
+			Constrains:    f.Metadata.Constrains,
+			License:       versionMetadata.License,
+			LicenseFamily: versionMetadata.LicenseFamily,
+			TrackFeatures: f.Metadata.TrackFeatures,
+			HashMD5:       f.FileDescriptor.Blob.HashMD5,
+			HashSHA256:    f.FileDescriptor.Blob.HashSHA256,
+			Size:          f.FileDescriptor.Blob.Size,
+		}
+
+		if f.Metadata.IsCondaPackage {
+			repoData.PackagesConda[f.FileDescriptor.File.Name] = pi
+		} else {
+			repoData.Packages[f.FileDescriptor.File.Name] = pi
+		}
+	}
+
+	pi, err := GetPatchInstructions(ctx, ownerID, channel, subdir)
+	if err != nil && err != packages_model.ErrPackageFileNotExist {
+		return nil, err
+	}
+	if pi != nil {
+		repoData.ApplyPatchInstructions(pi)
+	}
+
+	return repoData, nil
+}
+
+// BuildRunExports builds the run_exports.json of the subdir
+func BuildRunExports(ctx context.Context, ownerID int64, channel, subdir string) (*conda_module.RunExportsData, error) {
+	files, err := searchPackageFiles(ctx, &conda_model.FileSearchOptions{
+		OwnerID: ownerID,
+		Channel: channel,
+		Subdir:  subdir,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := &conda_module.RunExportsData{
+		Info: conda_module.RepoDataInfo{
+			Subdir: subdir,
+		},
+		Packages:      make(map[string]*conda_module.RunExportsDataPackage),
+		PackagesConda: make(map[string]*conda_module.RunExportsDataPackage),
+	}
+
+	for _, f := range files {
+		re := f.Metadata.RunExports
+		if re == nil {
+			re = &conda_module.RunExports{}
+		}
+
+		entry := &conda_module.RunExportsDataPackage{RunExports: re}
+		if f.Metadata.IsCondaPackage {
+			data.PackagesConda[f.FileDescriptor.File.Name] = entry
+		} else {
+			data.Packages[f.FileDescriptor.File.Name] = entry
+		}
+	}
+
+	return data, nil
+}
+
+// BuildChannelData builds the channeldata.json of the channel with the latest information of every package
+func BuildChannelData(ctx context.Context, ownerID int64, channel string) (*conda_module.ChannelData, error) {
+	files, err := searchPackageFiles(ctx, &conda_model.FileSearchOptions{
+		OwnerID: ownerID,
+		Channel: channel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Process the files from old to new so that the newest file provides the package information
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Metadata.Timestamp < files[j].Metadata.Timestamp
+	})
+
+	channelData := &conda_module.ChannelData{
+		ChannelDataVersion: 1,
+		Packages:           make(map[string]*conda_module.ChannelDataPackage),
+	}
+
+	subdirs := make(container.Set[string])
+	packageSubdirs := make(map[string]container.Set[string])
+
+	for _, f := range files {
+		name := f.Descriptor.PackageProperties.GetByName(conda_module.PropertyName)
+		versionMetadata := f.Descriptor.Metadata.(*conda_module.VersionMetadata)
+
+		cdp, ok := channelData.Packages[name]
+		if !ok {
+			cdp = &conda_module.ChannelDataPackage{
+				RunExports: make(map[string]*conda_module.RunExports),
+			}
+			channelData.Packages[name] = cdp
+			packageSubdirs[name] = make(container.Set[string])
+		}
+
+		cdp.Description = versionMetadata.Description
+		cdp.Summary = versionMetadata.Summary
+		cdp.Home = versionMetadata.ProjectURL
+		cdp.DevURL = versionMetadata.RepositoryURL
+		cdp.DocURL = versionMetadata.DocumentationURL
+		cdp.License = versionMetadata.License
+		cdp.Timestamp = f.Metadata.Timestamp
+		cdp.Version = f.Descriptor.Version.Version
+
+		if !f.Metadata.RunExports.IsEmpty() {
+			cdp.RunExports[f.Descriptor.Version.Version] = f.Metadata.RunExports
+		}
+
+		subdirs.Add(f.Subdir)
+		packageSubdirs[name].Add(f.Subdir)
+	}
+
+	for name, cdp := range channelData.Packages {
+		cdp.Subdirs = util.Sorted(packageSubdirs[name].Values())
+	}
+	channelData.Subdirs = util.Sorted(subdirs.Values())
+
+	return channelData, nil
+}
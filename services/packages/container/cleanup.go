@@ -104,5 +104,6 @@ func ShouldBeSkipped(ctx context.Context, pcr *packages_model.PackageCleanupRule
 		}
 	}
 
-	return false, nil
+	// Skip immutable tags
+	return IsVersionImmutable(ctx, p, pv)
 }
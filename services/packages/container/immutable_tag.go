@@ -0,0 +1,24 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"context"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	container_model "code.gitea.io/gitea/models/packages/container"
+	"code.gitea.io/gitea/modules/util"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+var ErrTagImmutable = util.NewPermissionDeniedErrorf("tag is immutable")
+
+// IsVersionImmutable checks if the version is a tag which is protected by an immutable tag rule of the owner
+func IsVersionImmutable(ctx context.Context, p *packages_model.Package, pv *packages_model.PackageVersion) (bool, error) {
+	if digest.Digest(pv.LowerVersion).Validate() == nil {
+		return false, nil
+	}
+	return container_model.IsTagImmutable(ctx, p.OwnerID, p.LowerName, pv.LowerVersion)
+}
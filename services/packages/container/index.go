@@ -0,0 +1,177 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	container_model "code.gitea.io/gitea/models/packages/container"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/util"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ImageIndexCreationOptions describes an image index to assemble from existing manifests
+type ImageIndexCreationOptions struct {
+	Owner      *user_model.User
+	Creator    *user_model.User
+	Image      string
+	Tag        string
+	References []string
+}
+
+// CreateImageIndex creates an OCI image index from existing image manifests of the image.
+// The manifests are referenced by tag or digest and the platform of each entry is read from the manifest config.
+func CreateImageIndex(ctx context.Context, opts *ImageIndexCreationOptions) (string, *oci.Index, error) {
+	if !ImageNamePattern.MatchString(opts.Image) {
+		return "", nil, util.NewInvalidArgumentErrorf("image name is invalid")
+	}
+	if !ReferencePattern.MatchString(opts.Tag) {
+		return "", nil, util.NewInvalidArgumentErrorf("tag is invalid")
+	}
+	if len(opts.References) == 0 {
+		return "", nil, util.NewInvalidArgumentErrorf("no manifests specified")
+	}
+
+	index := &oci.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2,
+		},
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: make([]oci.Descriptor, 0, len(opts.References)),
+	}
+
+	seen := make(map[string]bool, len(opts.References))
+	for _, reference := range opts.References {
+		descriptor, err := getImageIndexDescriptor(ctx, opts.Owner.ID, opts.Image, reference)
+		if err != nil {
+			return "", nil, err
+		}
+		if seen[string(descriptor.Digest)] {
+			return "", nil, util.NewInvalidArgumentErrorf("manifest %s is referenced multiple times", descriptor.Digest)
+		}
+		seen[string(descriptor.Digest)] = true
+
+		index.Manifests = append(index.Manifests, *descriptor)
+	}
+
+	content, err := json.Marshal(index)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(content) > MaxManifestSize {
+		return "", nil, util.NewInvalidArgumentErrorf("image index exceeds maximum size")
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(content))
+	if err != nil {
+		return "", nil, err
+	}
+	defer buf.Close()
+
+	if err := CheckImmutableTagUpdate(ctx, opts.Owner.ID, opts.Image, opts.Tag, DigestFromHashSummer(buf)); err != nil {
+		return "", nil, err
+	}
+
+	mci := &ManifestCreationInfo{
+		MediaType: oci.MediaTypeImageIndex,
+		Owner:     opts.Owner,
+		Creator:   opts.Creator,
+		Image:     opts.Image,
+		Reference: opts.Tag,
+		IsTagged:  true,
+	}
+
+	manifestDigest, err := processImageManifestIndex(ctx, mci, buf)
+	if err != nil {
+		return "", nil, err
+	}
+	return manifestDigest, index, nil
+}
+
+// getImageIndexDescriptor creates the index entry of the image manifest referenced by tag or digest
+func getImageIndexDescriptor(ctx context.Context, ownerID int64, image, reference string) (*oci.Descriptor, error) {
+	opts := &container_model.BlobSearchOptions{
+		OwnerID:    ownerID,
+		Image:      image,
+		IsManifest: true,
+	}
+	if digest.Digest(reference).Validate() == nil {
+		opts.Digest = reference
+	} else if ReferencePattern.MatchString(reference) {
+		opts.Tag = reference
+	} else {
+		return nil, util.NewInvalidArgumentErrorf("reference %s is invalid", reference)
+	}
+
+	pfd, err := container_model.GetContainerBlob(ctx, opts)
+	if err != nil {
+		if errors.Is(err, container_model.ErrContainerBlobNotExist) {
+			return nil, util.NewNotExistErrorf("manifest %s does not exist", reference)
+		}
+		return nil, err
+	}
+
+	mediaType := pfd.Properties.GetByName(container_module.PropertyMediaType)
+	if !IsImageManifestMediaType(mediaType) {
+		return nil, util.NewInvalidArgumentErrorf("manifest %s is not an image manifest", reference)
+	}
+
+	var manifest oci.Manifest
+	if err := decodeBlob(pfd, &manifest); err != nil {
+		return nil, err
+	}
+
+	configDescriptor, err := container_model.GetContainerBlob(ctx, &container_model.BlobSearchOptions{
+		OwnerID: ownerID,
+		Image:   image,
+		Digest:  string(manifest.Config.Digest),
+	})
+	if err != nil {
+		if errors.Is(err, container_model.ErrContainerBlobNotExist) {
+			return nil, util.NewNotExistErrorf("config of manifest %s does not exist", reference)
+		}
+		return nil, err
+	}
+
+	var config oci.Image
+	if err := decodeBlob(configDescriptor, &config); err != nil {
+		return nil, util.NewInvalidArgumentErrorf("config of manifest %s is invalid", reference)
+	}
+	if config.OS == "" || config.Architecture == "" {
+		return nil, util.NewInvalidArgumentErrorf("config of manifest %s has no platform", reference)
+	}
+
+	return &oci.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.Digest(pfd.Properties.GetByName(container_module.PropertyDigest)),
+		Size:      pfd.Blob.Size,
+		Platform: &oci.Platform{
+			Architecture: config.Architecture,
+			OS:           config.OS,
+			OSVersion:    config.OSVersion,
+			OSFeatures:   config.OSFeatures,
+			Variant:      config.Variant,
+		},
+	}, nil
+}
+
+func decodeBlob(pfd *packages_model.PackageFileDescriptor, v any) error {
+	r, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pfd.Blob.HashSHA256))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return json.NewDecoder(r).Decode(v)
+}
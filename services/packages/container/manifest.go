@@ -5,10 +5,12 @@ package container
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 
 	"code.gitea.io/gitea/models/db"
@@ -27,20 +29,38 @@ import (
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+// MaxManifestSize is the maximum size of a container manifest
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-manifests
+const MaxManifestSize = 10 * 1024 * 1024
+
+var (
+	ImageNamePattern = regexp.MustCompile(`\A[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*\z`)
+	ReferencePattern = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
+)
+
+var (
+	ErrManifestInvalid     = util.NewInvalidArgumentErrorf("manifest invalid")
+	ErrManifestBlobUnknown = util.NewNotExistErrorf("manifest blob unknown")
+	ErrSizeInvalid         = util.NewInvalidArgumentErrorf("size invalid")
+	ErrUnsupported         = util.NewInvalidArgumentErrorf("unsupported")
+)
+
 func isValidMediaType(mt string) bool {
 	return strings.HasPrefix(mt, "application/vnd.docker.") || strings.HasPrefix(mt, "application/vnd.oci.")
 }
 
-func isImageManifestMediaType(mt string) bool {
+// IsImageManifestMediaType checks if the media type is an OCI or Docker image manifest
+func IsImageManifestMediaType(mt string) bool {
 	return strings.EqualFold(mt, oci.MediaTypeImageManifest) || strings.EqualFold(mt, "application/vnd.docker.distribution.manifest.v2+json")
 }
 
-func isImageIndexMediaType(mt string) bool {
+// IsImageIndexMediaType checks if the media type is an OCI image index or a Docker manifest list
+func IsImageIndexMediaType(mt string) bool {
 	return strings.EqualFold(mt, oci.MediaTypeImageIndex) || strings.EqualFold(mt, "application/vnd.docker.distribution.manifest.list.v2+json")
 }
 
-// manifestCreationInfo describes a manifest to create
-type manifestCreationInfo struct {
+// ManifestCreationInfo describes a manifest to create
+type ManifestCreationInfo struct {
 	MediaType  string
 	Owner      *user_model.User
 	Creator    *user_model.User
@@ -50,14 +70,15 @@ type manifestCreationInfo struct {
 	Properties map[string]string
 }
 
-func processManifest(ctx context.Context, mci *manifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
+// ProcessManifest creates the package version of the image manifest or image index
+func ProcessManifest(ctx context.Context, mci *ManifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
 	var index oci.Index
 	if err := json.NewDecoder(buf).Decode(&index); err != nil {
 		return "", err
 	}
 
 	if index.SchemaVersion != 2 {
-		return "", errUnsupported.WithMessage("Schema version is not supported")
+		return "", util.NewSilentWrapErrorf(ErrUnsupported, "Schema version is not supported")
 	}
 
 	if _, err := buf.Seek(0, io.SeekStart); err != nil {
@@ -67,19 +88,19 @@ func processManifest(ctx context.Context, mci *manifestCreationInfo, buf *packag
 	if !isValidMediaType(mci.MediaType) {
 		mci.MediaType = index.MediaType
 		if !isValidMediaType(mci.MediaType) {
-			return "", errManifestInvalid.WithMessage("MediaType not recognized")
+			return "", util.NewSilentWrapErrorf(ErrManifestInvalid, "MediaType not recognized")
 		}
 	}
 
-	if isImageManifestMediaType(mci.MediaType) {
+	if IsImageManifestMediaType(mci.MediaType) {
 		return processImageManifest(ctx, mci, buf)
-	} else if isImageIndexMediaType(mci.MediaType) {
+	} else if IsImageIndexMediaType(mci.MediaType) {
 		return processImageManifestIndex(ctx, mci, buf)
 	}
-	return "", errManifestInvalid
+	return "", ErrManifestInvalid
 }
 
-func processImageManifest(ctx context.Context, mci *manifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
+func processImageManifest(ctx context.Context, mci *ManifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
 	manifestDigest := ""
 
 	err := func() error {
@@ -196,7 +217,7 @@ func processImageManifest(ctx context.Context, mci *manifestCreationInfo, buf *p
 	return manifestDigest, nil
 }
 
-func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
+func processImageManifestIndex(ctx context.Context, mci *ManifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
 	manifestDigest := ""
 
 	err := func() error {
@@ -221,8 +242,8 @@ func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, b
 		}
 
 		for _, manifest := range index.Manifests {
-			if !isImageManifestMediaType(manifest.MediaType) {
-				return errManifestInvalid
+			if !IsImageManifestMediaType(manifest.MediaType) {
+				return ErrManifestInvalid
 			}
 
 			platform := container_module.DefaultPlatform
@@ -241,7 +262,7 @@ func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, b
 			})
 			if err != nil {
 				if err == container_model.ErrContainerBlobNotExist {
-					return errManifestBlobUnknown
+					return ErrManifestBlobUnknown
 				}
 				return err
 			}
@@ -300,6 +321,34 @@ func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, b
 	return manifestDigest, nil
 }
 
+// CheckImmutableTagUpdate checks if the tag may point to the manifest.
+// Tags protected by an immutable tag rule can be created but not changed afterwards.
+// Pushing the same manifest again is allowed to keep retries of clients working.
+func CheckImmutableTagUpdate(ctx context.Context, ownerID int64, image, tag, manifestDigest string) error {
+	immutable, err := container_model.IsTagImmutable(ctx, ownerID, image, tag)
+	if err != nil || !immutable {
+		return err
+	}
+
+	pfd, err := container_model.GetContainerBlob(ctx, &container_model.BlobSearchOptions{
+		OwnerID:    ownerID,
+		Image:      image,
+		Tag:        tag,
+		IsManifest: true,
+	})
+	if err != nil {
+		if errors.Is(err, container_model.ErrContainerBlobNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if pfd.Properties.GetByName(container_module.PropertyDigest) == manifestDigest {
+		return nil
+	}
+	return ErrTagImmutable
+}
+
 func notifyPackageCreate(ctx context.Context, doer *user_model.User, pv *packages_model.PackageVersion) error {
 	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
 	if err != nil {
@@ -311,7 +360,7 @@ func notifyPackageCreate(ctx context.Context, doer *user_model.User, pv *package
 	return nil
 }
 
-func createPackageAndVersion(ctx context.Context, mci *manifestCreationInfo, metadata *container_module.Metadata) (*packages_model.PackageVersion, error) {
+func createPackageAndVersion(ctx context.Context, mci *ManifestCreationInfo, metadata *container_module.Metadata) (*packages_model.PackageVersion, error) {
 	created := true
 	p := &packages_model.Package{
 		OwnerID:   mci.Owner.ID,
@@ -357,8 +406,9 @@ func createPackageAndVersion(ctx context.Context, mci *manifestCreationInfo, met
 				return nil, err
 			}
 
-			// keep download count on overwrite
+			// keep download statistics on overwrite
 			_pv.DownloadCount = pv.DownloadCount
+			_pv.LastDownloadUnix = pv.LastDownloadUnix
 
 			if pv, err = packages_model.GetOrInsertVersion(ctx, _pv); err != nil {
 				log.Error("Error inserting package: %v", err)
@@ -401,7 +451,7 @@ type blobReference struct {
 
 func createFileFromBlobReference(ctx context.Context, pv, uploadVersion *packages_model.PackageVersion, ref *blobReference) error {
 	if ref.File.Blob.Size != ref.ExpectedSize {
-		return errSizeInvalid
+		return ErrSizeInvalid
 	}
 
 	if ref.Name == "" {
@@ -446,7 +496,7 @@ func createFileFromBlobReference(ctx context.Context, pv, uploadVersion *package
 	return nil
 }
 
-func createManifestBlob(ctx context.Context, mci *manifestCreationInfo, pv *packages_model.PackageVersion, buf *packages_module.HashedBuffer) (*packages_model.PackageBlob, bool, string, error) {
+func createManifestBlob(ctx context.Context, mci *ManifestCreationInfo, pv *packages_model.PackageVersion, buf *packages_module.HashedBuffer) (*packages_model.PackageBlob, bool, string, error) {
 	pb, exists, err := packages_model.GetOrInsertBlob(ctx, packages_service.NewPackageBlob(buf))
 	if err != nil {
 		log.Error("Error inserting package blob: %v", err)
@@ -469,7 +519,7 @@ func createManifestBlob(ctx context.Context, mci *manifestCreationInfo, pv *pack
 		}
 	}
 
-	manifestDigest := digestFromHashSummer(buf)
+	manifestDigest := DigestFromHashSummer(buf)
 	err = createFileFromBlobReference(ctx, pv, nil, &blobReference{
 		Digest:       digest.Digest(manifestDigest),
 		MediaType:    mci.MediaType,
@@ -481,3 +531,9 @@ func createManifestBlob(ctx context.Context, mci *manifestCreationInfo, pv *pack
 
 	return pb, !exists, manifestDigest, err
 }
+
+// DigestFromHashSummer gets the sha256 digest of the content
+func DigestFromHashSummer(h packages_module.HashSummer) string {
+	_, _, hashSHA256, _ := h.Sums()
+	return "sha256:" + hex.EncodeToString(hashSHA256)
+}
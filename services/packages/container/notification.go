@@ -0,0 +1,247 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/proxy"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// notificationRetryDelay is the delay before the first retry of a failed delivery, it doubles with every further attempt
+	notificationRetryDelay    = 10 * time.Second
+	maxNotificationRetryDelay = 10 * time.Minute
+)
+
+// notificationTask is the delivery of a single event to an endpoint
+type notificationTask struct {
+	Endpoint    string                  `json:"endpoint"`
+	Event       *container_module.Event `json:"event"`
+	Attempts    int                     `json:"attempts"`
+	NextAttempt time.Time               `json:"next_attempt"`
+}
+
+var (
+	notificationQueue      *queue.WorkerPoolQueue[*notificationTask]
+	notificationCtx        context.Context
+	notificationCancel     context.CancelFunc
+	notificationHTTPClient *http.Client
+	notificationSource     container_module.EventSource
+)
+
+// InitNotifications starts the delivery of registry notifications if endpoints are configured
+func InitNotifications() error {
+	if !setting.Packages.Enabled || len(setting.Packages.ContainerNotificationEndpoints) == 0 {
+		return nil
+	}
+
+	notificationHTTPClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy.Proxy(),
+		},
+	}
+
+	hostname, _ := os.Hostname()
+	notificationSource = container_module.EventSource{
+		Addr:       hostname,
+		InstanceID: uuid.NewString(),
+	}
+
+	notificationCtx, notificationCancel = context.WithCancel(graceful.GetManager().ShutdownContext())
+
+	notificationQueue = queue.CreateSimpleQueue(notificationCtx, "container_notification", handleNotificationTasks)
+	if notificationQueue == nil {
+		notificationCancel()
+		return fmt.Errorf("unable to create container_notification queue")
+	}
+	go graceful.GetManager().RunWithCancel(notificationQueue)
+
+	return nil
+}
+
+// ShutdownNotifications stops the delivery of registry notifications and resets the state.
+// It is used by tests which enable notifications temporarily.
+func ShutdownNotifications() {
+	if notificationQueue == nil {
+		return
+	}
+
+	notificationCancel()
+	notificationQueue.ShutdownWait(5 * time.Second)
+	queue.GetManager().RemoveManagedQueue(notificationQueue)
+
+	notificationQueue = nil
+	notificationCtx = nil
+	notificationCancel = nil
+	notificationHTTPClient = nil
+	notificationSource = container_module.EventSource{}
+}
+
+// IsNotificationEnabled checks if registry notifications get delivered
+func IsNotificationEnabled() bool {
+	return notificationQueue != nil
+}
+
+// Notify queues the event for delivery to all endpoints which do not ignore it
+func Notify(event *container_module.Event) {
+	if !IsNotificationEnabled() {
+		return
+	}
+
+	event.ID = uuid.NewString()
+	event.Timestamp = time.Now().UTC()
+	event.Source = notificationSource
+
+	for _, endpoint := range setting.Packages.ContainerNotificationEndpoints {
+		if slices.Contains(endpoint.IgnoredActions, event.Action) || slices.Contains(endpoint.IgnoredMediaTypes, event.Target.MediaType) {
+			continue
+		}
+
+		if err := notificationQueue.Push(&notificationTask{Endpoint: endpoint.Name, Event: event}); err != nil {
+			log.Error("Unable to queue container notification for endpoint %q: %v", endpoint.Name, err)
+		}
+	}
+}
+
+func getNotificationEndpoint(name string) *setting.ContainerNotificationEndpoint {
+	for _, endpoint := range setting.Packages.ContainerNotificationEndpoints {
+		if endpoint.Name == name {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+// retryDelay gets the exponential backoff before the next attempt of a task which failed the given number of times
+func retryDelay(attempts int) time.Duration {
+	delay := notificationRetryDelay
+	for i := 1; i < attempts && delay < maxNotificationRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxNotificationRetryDelay)
+}
+
+// scheduleNotificationTask queues the task again when its next attempt is due.
+// On shutdown the task is queued immediately, so a persistent queue keeps it together with its next attempt time.
+func scheduleNotificationTask(task *notificationTask) {
+	q, ctx := notificationQueue, notificationCtx
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Until(task.NextAttempt)):
+		}
+		if err := q.Push(task); err != nil {
+			log.Error("Unable to queue container notification %s for endpoint %q: %v", task.Event.ID, task.Endpoint, err)
+		}
+	}()
+}
+
+// handleNotificationTasks sends the due events of a batch bundled per endpoint.
+// The endpoints are served concurrently, so a slow endpoint does not delay the others.
+// Failed deliveries are retried with exponential backoff until the endpoint's attempt limit is reached.
+func handleNotificationTasks(items ...*notificationTask) []*notificationTask {
+	now := time.Now()
+
+	tasksByEndpoint := make(map[string][]*notificationTask)
+	for _, task := range items {
+		if task.NextAttempt.After(now) {
+			scheduleNotificationTask(task)
+			continue
+		}
+		tasksByEndpoint[task.Endpoint] = append(tasksByEndpoint[task.Endpoint], task)
+	}
+
+	var wg sync.WaitGroup
+	for name, tasks := range tasksByEndpoint {
+		endpoint := getNotificationEndpoint(name)
+		if endpoint == nil {
+			log.Warn("Dropping container notifications for removed endpoint %q", name)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deliverNotificationTasks(endpoint, tasks)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func deliverNotificationTasks(endpoint *setting.ContainerNotificationEndpoint, tasks []*notificationTask) {
+	envelope := &container_module.EventEnvelope{
+		Events: make([]*container_module.Event, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		envelope.Events = append(envelope.Events, task.Event)
+	}
+
+	err := deliverNotification(notificationCtx, endpoint, envelope)
+	if err == nil {
+		return
+	}
+
+	log.Warn("Delivering container notifications to endpoint %q failed: %v", endpoint.Name, err)
+
+	for _, task := range tasks {
+		task.Attempts++
+		if task.Attempts >= endpoint.MaxAttempts {
+			log.Error("Dropping container notification %s for endpoint %q after %d attempts: %v", task.Event.ID, endpoint.Name, task.Attempts, err)
+			continue
+		}
+		task.NextAttempt = time.Now().Add(retryDelay(task.Attempts))
+		scheduleNotificationTask(task)
+	}
+}
+
+func deliverNotification(ctx context.Context, endpoint *setting.ContainerNotificationEndpoint, envelope *container_module.EventEnvelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, endpoint.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", container_module.ContentTypeEventEnvelope)
+	for name, value := range endpoint.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := notificationHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	// The Docker registry treats every 2xx and 3xx status as success
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
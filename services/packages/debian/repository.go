@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -153,7 +154,11 @@ func BuildSpecificRepositoryFiles(ctx context.Context, ownerID int64, distributi
 }
 
 func buildRepositoryFiles(ctx context.Context, ownerID int64, repoVersion *packages_model.PackageVersion, distribution, component, architecture string) error {
-	if err := buildPackagesIndices(ctx, ownerID, repoVersion, distribution, component, architecture); err != nil {
+	if architecture == debian_module.ArchitectureSource {
+		if err := buildSourcesIndices(ctx, ownerID, repoVersion, distribution, component); err != nil {
+			return err
+		}
+	} else if err := buildPackagesIndices(ctx, ownerID, repoVersion, distribution, component, architecture); err != nil {
 		return err
 	}
 
@@ -169,90 +174,210 @@ func buildPackagesIndices(ctx context.Context, ownerID int64, repoVersion *packa
 		Architecture: architecture,
 	}
 
+	key := fmt.Sprintf("%s|%s|%s", distribution, component, architecture)
+
 	// Delete the package indices if there are no packages
 	if has, err := debian_model.ExistPackages(ctx, opts); err != nil {
 		return err
 	} else if !has {
-		key := fmt.Sprintf("%s|%s|%s", distribution, component, architecture)
-		for _, filename := range []string{"Packages", "Packages.gz", "Packages.xz"} {
-			pf, err := packages_model.GetFileForVersionByName(ctx, repoVersion.ID, filename, key)
-			if err != nil && !errors.Is(err, util.ErrNotExist) {
-				return err
-			} else if pf == nil {
-				continue
-			}
+		return deleteRepositoryFiles(ctx, repoVersion, key, "Packages", "Packages.gz", "Packages.xz")
+	}
 
-			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
-				return err
-			}
+	iw := newIndexWriter()
+	defer iw.Close()
+
+	w := iw.Writer()
+
+	addSeparator := false
+	if err := debian_model.SearchPackages(ctx, opts, func(pfd *packages_model.PackageFileDescriptor) {
+		if addSeparator {
+			fmt.Fprintln(w)
 		}
+		addSeparator = true
 
-		return nil
+		writePackagesEntry(w, pfd, fmt.Sprintf("pool/%s/%s", distribution, component))
+	}); err != nil {
+		return err
 	}
 
-	packagesContent, _ := packages_module.NewHashedBuffer()
-	defer packagesContent.Close()
-
-	packagesGzipContent, _ := packages_module.NewHashedBuffer()
-	defer packagesGzipContent.Close()
+	return iw.Save(ctx, repoVersion, "Packages", key, map[string]string{
+		debian_module.PropertyRepositoryIncludeInRelease: "",
+		debian_module.PropertyDistribution:               distribution,
+		debian_module.PropertyComponent:                  component,
+		debian_module.PropertyArchitecture:               architecture,
+	})
+}
 
-	gzw := gzip.NewWriter(packagesGzipContent)
+func writePackagesEntry(w io.Writer, pfd *packages_model.PackageFileDescriptor, poolPath string) {
+	fmt.Fprintf(w, "%s\n", strings.TrimSpace(pfd.Properties.GetByName(debian_module.PropertyControl)))
 
-	packagesXzContent, _ := packages_module.NewHashedBuffer()
-	defer packagesXzContent.Close()
+	fmt.Fprintf(w, "Filename: %s/%s\n", poolPath, pfd.File.Name)
+	fmt.Fprintf(w, "Size: %d\n", pfd.Blob.Size)
+	fmt.Fprintf(w, "MD5sum: %s\n", pfd.Blob.HashMD5)
+	fmt.Fprintf(w, "SHA1: %s\n", pfd.Blob.HashSHA1)
+	fmt.Fprintf(w, "SHA256: %s\n", pfd.Blob.HashSHA256)
+	fmt.Fprintf(w, "SHA512: %s\n", pfd.Blob.HashSHA512)
+}
 
-	xzw, _ := xz.NewWriter(packagesXzContent)
+// https://wiki.debian.org/DebianRepository/Format#A.22Sources.22_Indices
+func buildSourcesIndices(ctx context.Context, ownerID int64, repoVersion *packages_model.PackageVersion, distribution, component string) error {
+	opts := &debian_model.PackageSearchOptions{
+		OwnerID:      ownerID,
+		Distribution: distribution,
+		Component:    component,
+		Architecture: debian_module.ArchitectureSource,
+	}
 
-	w := io.MultiWriter(packagesContent, gzw, xzw)
+	key := fmt.Sprintf("%s|%s|%s", distribution, component, debian_module.ArchitectureSource)
 
-	addSeparator := false
+	entries := make([]*sourceEntry, 0, 10)
 	if err := debian_model.SearchPackages(ctx, opts, func(pfd *packages_model.PackageFileDescriptor) {
-		if addSeparator {
+		if entry := collectSourceEntry(ctx, pfd); entry != nil {
+			entries = append(entries, entry)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return deleteRepositoryFiles(ctx, repoVersion, key, "Sources", "Sources.gz", "Sources.xz")
+	}
+
+	iw := newIndexWriter()
+	defer iw.Close()
+
+	w := iw.Writer()
+
+	for i, entry := range entries {
+		if i > 0 {
 			fmt.Fprintln(w)
 		}
-		addSeparator = true
 
-		fmt.Fprintf(w, "%s\n", strings.TrimSpace(pfd.Properties.GetByName(debian_module.PropertyControl)))
+		writeSourcesEntry(w, entry, fmt.Sprintf("pool/%s/%s/%s", distribution, component, debian_module.ArchitectureSource))
+	}
 
-		fmt.Fprintf(w, "Filename: pool/%s/%s/%s\n", distribution, component, pfd.File.Name)
-		fmt.Fprintf(w, "Size: %d\n", pfd.Blob.Size)
-		fmt.Fprintf(w, "MD5sum: %s\n", pfd.Blob.HashMD5)
-		fmt.Fprintf(w, "SHA1: %s\n", pfd.Blob.HashSHA1)
-		fmt.Fprintf(w, "SHA256: %s\n", pfd.Blob.HashSHA256)
-		fmt.Fprintf(w, "SHA512: %s\n", pfd.Blob.HashSHA512)
-	}); err != nil {
-		return err
+	return iw.Save(ctx, repoVersion, "Sources", key, map[string]string{
+		debian_module.PropertyRepositoryIncludeInRelease: "",
+		debian_module.PropertyDistribution:               distribution,
+		debian_module.PropertyComponent:                  component,
+		debian_module.PropertyArchitecture:               debian_module.ArchitectureSource,
+	})
+}
+
+// sourceEntry contains the source control file and the referenced files of a source package
+type sourceEntry struct {
+	Control string
+	Files   []*packages_model.PackageFile
+	Blobs   []*packages_model.PackageBlob
+}
+
+// collectSourceEntry collects the files of a source package.
+// Only source packages whose referenced files are all available are returned.
+func collectSourceEntry(ctx context.Context, pfd *packages_model.PackageFileDescriptor) *sourceEntry {
+	entry := &sourceEntry{
+		Control: strings.TrimSpace(pfd.Properties.GetByName(debian_module.PropertyControl)),
+		Files:   []*packages_model.PackageFile{pfd.File},
+		Blobs:   []*packages_model.PackageBlob{pfd.Blob},
+	}
+
+	for _, f := range debian_module.ParseSourceFilesProperty(pfd.Properties.GetByName(debian_module.PropertySourceFiles)) {
+		pf, err := packages_model.GetFileForVersionByName(ctx, pfd.File.VersionID, f.Name, pfd.File.CompositeKey)
+		if err != nil {
+			return nil
+		}
+		pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+		if err != nil {
+			return nil
+		}
+
+		entry.Files = append(entry.Files, pf)
+		entry.Blobs = append(entry.Blobs, pb)
 	}
 
-	gzw.Close()
-	xzw.Close()
+	return entry
+}
+
+func writeSourcesEntry(w io.Writer, entry *sourceEntry, directory string) {
+	fmt.Fprintf(w, "%s\n", entry.Control)
+	fmt.Fprintf(w, "Directory: %s\n", directory)
 
-	for _, file := range []struct {
+	for _, field := range []struct {
 		Name string
-		Data packages_module.HashedSizeReader
+		Hash func(*packages_model.PackageBlob) string
 	}{
-		{"Packages", packagesContent},
-		{"Packages.gz", packagesGzipContent},
-		{"Packages.xz", packagesXzContent},
+		{"Files", func(pb *packages_model.PackageBlob) string { return pb.HashMD5 }},
+		{"Checksums-Sha1", func(pb *packages_model.PackageBlob) string { return pb.HashSHA1 }},
+		{"Checksums-Sha256", func(pb *packages_model.PackageBlob) string { return pb.HashSHA256 }},
+		{"Checksums-Sha512", func(pb *packages_model.PackageBlob) string { return pb.HashSHA512 }},
 	} {
+		fmt.Fprintf(w, "%s:\n", field.Name)
+		for i, pb := range entry.Blobs {
+			fmt.Fprintf(w, " %s %d %s\n", field.Hash(pb), pb.Size, entry.Files[i].Name)
+		}
+	}
+}
+
+// indexWriter writes an index file in plain, gzip and xz format
+type indexWriter struct {
+	content     *packages_module.HashedBuffer
+	gzipContent *packages_module.HashedBuffer
+	xzContent   *packages_module.HashedBuffer
+	gzw         *gzip.Writer
+	xzw         *xz.Writer
+}
+
+func newIndexWriter() *indexWriter {
+	iw := &indexWriter{}
+	iw.content, _ = packages_module.NewHashedBuffer()
+	iw.gzipContent, _ = packages_module.NewHashedBuffer()
+	iw.gzw = gzip.NewWriter(iw.gzipContent)
+	iw.xzContent, _ = packages_module.NewHashedBuffer()
+	iw.xzw, _ = xz.NewWriter(iw.xzContent)
+	return iw
+}
+
+func (iw *indexWriter) Writer() io.Writer {
+	return io.MultiWriter(iw.content, iw.gzw, iw.xzw)
+}
+
+func (iw *indexWriter) Close() {
+	iw.content.Close()
+	iw.gzipContent.Close()
+	iw.xzContent.Close()
+}
+
+// Save stores the index files in the package version
+func (iw *indexWriter) Save(ctx context.Context, pv *packages_model.PackageVersion, name, key string, properties map[string]string) error {
+	iw.gzw.Close()
+	iw.xzw.Close()
+
+	return addRepositoryFiles(ctx, pv, key, properties, []*repositoryFile{
+		{name, iw.content},
+		{name + ".gz", iw.gzipContent},
+		{name + ".xz", iw.xzContent},
+	})
+}
+
+type repositoryFile struct {
+	Name string
+	Data packages_module.HashedSizeReader
+}
+
+func addRepositoryFiles(ctx context.Context, pv *packages_model.PackageVersion, key string, properties map[string]string, files []*repositoryFile) error {
+	for _, file := range files {
 		_, err := packages_service.AddFileToPackageVersionInternal(
 			ctx,
-			repoVersion,
+			pv,
 			&packages_service.PackageFileCreationInfo{
 				PackageFileInfo: packages_service.PackageFileInfo{
 					Filename:     file.Name,
-					CompositeKey: fmt.Sprintf("%s|%s|%s", distribution, component, architecture),
+					CompositeKey: key,
 				},
 				Creator:           user_model.NewGhostUser(),
 				Data:              file.Data,
 				IsLead:            false,
 				OverwriteExisting: true,
-				Properties: map[string]string{
-					debian_module.PropertyRepositoryIncludeInRelease: "",
-					debian_module.PropertyDistribution:               distribution,
-					debian_module.PropertyComponent:                  component,
-					debian_module.PropertyArchitecture:               architecture,
-				},
+				Properties:        properties,
 			},
 		)
 		if err != nil {
@@ -263,6 +388,23 @@ func buildPackagesIndices(ctx context.Context, ownerID int64, repoVersion *packa
 	return nil
 }
 
+func deleteRepositoryFiles(ctx context.Context, pv *packages_model.PackageVersion, key string, filenames ...string) error {
+	for _, filename := range filenames {
+		pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, key)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return err
+		} else if pf == nil {
+			continue
+		}
+
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // https://wiki.debian.org/DebianRepository/Format#A.22Release.22_files
 func buildReleaseFiles(ctx context.Context, ownerID int64, repoVersion *packages_model.PackageVersion, distribution string) error {
 	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
@@ -278,20 +420,7 @@ func buildReleaseFiles(ctx context.Context, ownerID int64, repoVersion *packages
 
 	// Delete the release files if there are no packages
 	if len(pfs) == 0 {
-		for _, filename := range []string{"Release", "Release.gpg", "InRelease"} {
-			pf, err := packages_model.GetFileForVersionByName(ctx, repoVersion.ID, filename, distribution)
-			if err != nil && !errors.Is(err, util.ErrNotExist) {
-				return err
-			} else if pf == nil {
-				continue
-			}
-
-			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
-				return err
-			}
-		}
-
-		return nil
+		return deleteRepositoryFiles(ctx, repoVersion, distribution, "Release", "Release.gpg", "InRelease")
 	}
 
 	components, err := debian_model.GetComponents(ctx, ownerID, distribution)
@@ -299,13 +428,28 @@ func buildReleaseFiles(ctx context.Context, ownerID int64, repoVersion *packages
 		return err
 	}
 
-	sort.Strings(components)
-
 	architectures, err := debian_model.GetArchitectures(ctx, ownerID, distribution)
 	if err != nil {
 		return err
 	}
 
+	pfds, err := packages_model.GetPackageFileDescriptors(ctx, pfs)
+	if err != nil {
+		return err
+	}
+
+	return createReleaseFiles(ctx, ownerID, repoVersion, distribution, components, architectures, pfds)
+}
+
+// createReleaseFiles creates and signs the release files of the distribution which list the index files
+func createReleaseFiles(ctx context.Context, ownerID int64, pv *packages_model.PackageVersion, distribution string, components, architectures []string, pfds []*packages_model.PackageFileDescriptor) error {
+	sort.Strings(components)
+
+	// "source" is no architecture and must not be listed
+	architectures = slices.DeleteFunc(slices.Clone(architectures), func(architecture string) bool {
+		return architecture == debian_module.ArchitectureSource
+	})
+
 	sort.Strings(architectures)
 
 	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
@@ -344,14 +488,14 @@ func buildReleaseFiles(ctx context.Context, ownerID int64, repoVersion *packages
 	fmt.Fprintf(w, "Date: %s\n", time.Now().UTC().Format(time.RFC1123))
 	fmt.Fprint(w, "Acquire-By-Hash: yes\n")
 
-	pfds, err := packages_model.GetPackageFileDescriptors(ctx, pfs)
-	if err != nil {
-		return err
-	}
-
 	var md5, sha1, sha256, sha512 strings.Builder
 	for _, pfd := range pfds {
-		path := fmt.Sprintf("%s/binary-%s/%s", pfd.Properties.GetByName(debian_module.PropertyComponent), pfd.Properties.GetByName(debian_module.PropertyArchitecture), pfd.File.Name)
+		var path string
+		if architecture := pfd.Properties.GetByName(debian_module.PropertyArchitecture); architecture == debian_module.ArchitectureSource {
+			path = fmt.Sprintf("%s/source/%s", pfd.Properties.GetByName(debian_module.PropertyComponent), pfd.File.Name)
+		} else {
+			path = fmt.Sprintf("%s/binary-%s/%s", pfd.Properties.GetByName(debian_module.PropertyComponent), architecture, pfd.File.Name)
+		}
 		fmt.Fprintf(&md5, " %s %d %s\n", pfd.Blob.HashMD5, pfd.Blob.Size, path)
 		fmt.Fprintf(&sha1, " %s %d %s\n", pfd.Blob.HashSHA1, pfd.Blob.Size, path)
 		fmt.Fprintf(&sha256, " %s %d %s\n", pfd.Blob.HashSHA256, pfd.Blob.Size, path)
@@ -379,35 +523,11 @@ func buildReleaseFiles(ctx context.Context, ownerID int64, repoVersion *packages
 	releaseContent, _ := packages_module.CreateHashedBufferFromReader(&buf)
 	defer releaseContent.Close()
 
-	for _, file := range []struct {
-		Name string
-		Data packages_module.HashedSizeReader
-	}{
+	return addRepositoryFiles(ctx, pv, distribution, map[string]string{
+		debian_module.PropertyDistribution: distribution,
+	}, []*repositoryFile{
 		{"Release", releaseContent},
 		{"Release.gpg", releaseGpgContent},
 		{"InRelease", inReleaseContent},
-	} {
-		_, err = packages_service.AddFileToPackageVersionInternal(
-			ctx,
-			repoVersion,
-			&packages_service.PackageFileCreationInfo{
-				PackageFileInfo: packages_service.PackageFileInfo{
-					Filename:     file.Name,
-					CompositeKey: distribution,
-				},
-				Creator:           user_model.NewGhostUser(),
-				Data:              file.Data,
-				IsLead:            false,
-				OverwriteExisting: true,
-				Properties: map[string]string{
-					debian_module.PropertyDistribution: distribution,
-				},
-			},
-		)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	})
 }
@@ -0,0 +1,424 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	debian_model "code.gitea.io/gitea/models/packages/debian"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/optional"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	ErrInvalidSnapshotName = util.NewInvalidArgumentErrorf("snapshot name is invalid")
+	ErrSnapshotExist       = util.NewAlreadyExistErrorf("snapshot already exists")
+	ErrSnapshotNotExist    = util.NewNotExistErrorf("snapshot does not exist")
+	ErrSnapshotPublished   = errors.New("snapshot is published")
+	ErrSnapshotEmpty       = util.NewInvalidArgumentErrorf("distribution contains no packages")
+
+	snapshotNamePattern = regexp.MustCompile(`\A[a-z0-9][a-z0-9.+~_-]*\z`)
+)
+
+// Snapshot is a frozen state of a distribution
+type Snapshot struct {
+	Name         string
+	Distribution string
+	Published    []string
+	Created      timeutil.TimeStamp
+}
+
+// snapshotPoolPath gets the path of the pool of a snapshot relative to the repository root
+func snapshotPoolPath(name, component string) string {
+	return fmt.Sprintf("snapshots/%s/pool/%s", name, component)
+}
+
+func getSnapshotVersion(ctx context.Context, ownerID int64, name string) (*packages_model.PackageVersion, error) {
+	pv, err := packages_model.GetInternalVersionByNameAndVersion(ctx, ownerID, packages_model.TypeDebian, debian_module.RepositoryPackage, debian_module.SnapshotVersionPrefix+strings.ToLower(name))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			return nil, ErrSnapshotNotExist
+		}
+		return nil, err
+	}
+	return pv, nil
+}
+
+// getPublishedDistributions gets the distributions the snapshot is published as
+func getPublishedDistributions(ctx context.Context, pv *packages_model.PackageVersion) ([]string, error) {
+	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		VersionID: pv.ID,
+		Query:     "InRelease",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	distributions := make([]string, 0, len(pfs))
+	for _, pf := range pfs {
+		distributions = append(distributions, pf.CompositeKey)
+	}
+	sort.Strings(distributions)
+	return distributions, nil
+}
+
+// GetSnapshots gets all snapshots of the owner
+func GetSnapshots(ctx context.Context, ownerID int64) ([]*Snapshot, error) {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		OwnerID: ownerID,
+		Type:    packages_model.TypeDebian,
+		Name: packages_model.SearchValue{
+			ExactMatch: true,
+			Value:      debian_module.RepositoryPackage,
+		},
+		Version: packages_model.SearchValue{
+			Value: debian_module.SnapshotVersionPrefix,
+		},
+		IsInternal: optional.Some(true),
+		Sort:       packages_model.SortCreatedDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*Snapshot, 0, len(pvs))
+	for _, pv := range pvs {
+		name, ok := strings.CutPrefix(pv.Version, debian_module.SnapshotVersionPrefix)
+		if !ok {
+			continue
+		}
+
+		snapshot, err := toSnapshot(ctx, pv, name)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// GetSnapshot gets the snapshot with the name
+func GetSnapshot(ctx context.Context, ownerID int64, name string) (*Snapshot, error) {
+	pv, err := getSnapshotVersion(ctx, ownerID, name)
+	if err != nil {
+		return nil, err
+	}
+	return toSnapshot(ctx, pv, strings.ToLower(name))
+}
+
+func toSnapshot(ctx context.Context, pv *packages_model.PackageVersion, name string) (*Snapshot, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeVersion, pv.ID, debian_module.PropertySnapshotDistribution)
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := getPublishedDistributions(ctx, pv)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Name:      name,
+		Published: published,
+		Created:   pv.CreatedUnix,
+	}
+	if len(pps) > 0 {
+		snapshot.Distribution = pps[0].Value
+	}
+	return snapshot, nil
+}
+
+// CreateSnapshot freezes the current state of the distribution under the name.
+// The snapshot references the blobs of the package files and has its own index files.
+func CreateSnapshot(ctx context.Context, ownerID int64, name, distribution string) (*Snapshot, error) {
+	name = strings.ToLower(name)
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, ErrInvalidSnapshotName
+	}
+
+	// The packages are collected before the transaction is started because they are iterated with an open cursor
+	pfds := make([]*packages_model.PackageFileDescriptor, 0, 20)
+	if err := debian_model.SearchPackages(ctx, &debian_model.PackageSearchOptions{
+		OwnerID:      ownerID,
+		Distribution: distribution,
+	}, func(pfd *packages_model.PackageFileDescriptor) {
+		pfds = append(pfds, pfd)
+	}); err != nil {
+		return nil, err
+	}
+	if len(pfds) == 0 {
+		return nil, ErrSnapshotEmpty
+	}
+
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := getSnapshotVersion(ctx, ownerID, name); err == nil {
+			return ErrSnapshotExist
+		} else if !errors.Is(err, util.ErrNotExist) {
+			return err
+		}
+
+		pv, err := packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeDebian, debian_module.RepositoryPackage, debian_module.SnapshotVersionPrefix+name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, pv.ID, debian_module.PropertySnapshotDistribution, distribution); err != nil {
+			return err
+		}
+
+		return buildSnapshot(ctx, pv, name, pfds)
+	}); err != nil {
+		return nil, err
+	}
+
+	return GetSnapshot(ctx, ownerID, name)
+}
+
+// buildSnapshot copies the package files into the snapshot and creates the index files
+func buildSnapshot(ctx context.Context, pv *packages_model.PackageVersion, name string, pfds []*packages_model.PackageFileDescriptor) error {
+	packages := make(map[string][]*packages_model.PackageFileDescriptor)
+	sources := make(map[string][]*sourceEntry)
+
+	for _, pfd := range pfds {
+		component := pfd.Properties.GetByName(debian_module.PropertyComponent)
+		architecture := pfd.Properties.GetByName(debian_module.PropertyArchitecture)
+		key := component + "|" + architecture
+
+		if architecture != debian_module.ArchitectureSource {
+			if err := copySnapshotFile(ctx, pv, component, pfd.File, pfd.Blob, map[string]string{
+				debian_module.PropertyComponent:    component,
+				debian_module.PropertyArchitecture: architecture,
+				debian_module.PropertyControl:      pfd.Properties.GetByName(debian_module.PropertyControl),
+			}); err != nil {
+				return err
+			}
+			packages[key] = append(packages[key], pfd)
+			continue
+		}
+
+		entry := collectSourceEntry(ctx, pfd)
+		if entry == nil {
+			continue
+		}
+		for i, pf := range entry.Files {
+			properties := map[string]string{
+				debian_module.PropertyComponent: component,
+			}
+			if i == 0 {
+				properties[debian_module.PropertyArchitecture] = architecture
+				properties[debian_module.PropertyControl] = entry.Control
+				properties[debian_module.PropertySourceFiles] = pfd.Properties.GetByName(debian_module.PropertySourceFiles)
+			}
+			if err := copySnapshotFile(ctx, pv, component, pf, entry.Blobs[i], properties); err != nil {
+				return err
+			}
+		}
+		sources[key] = append(sources[key], entry)
+	}
+
+	if len(packages) == 0 && len(sources) == 0 {
+		return ErrSnapshotEmpty
+	}
+
+	for key, pfds := range packages {
+		component, architecture, _ := strings.Cut(key, "|")
+
+		iw := newIndexWriter()
+		w := iw.Writer()
+		for i, pfd := range pfds {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			writePackagesEntry(w, pfd, snapshotPoolPath(name, component))
+		}
+		err := iw.Save(ctx, pv, "Packages", key, snapshotIndexProperties(component, architecture))
+		iw.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	for key, entries := range sources {
+		component, architecture, _ := strings.Cut(key, "|")
+
+		iw := newIndexWriter()
+		w := iw.Writer()
+		for i, entry := range entries {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			writeSourcesEntry(w, entry, snapshotPoolPath(name, component))
+		}
+		err := iw.Save(ctx, pv, "Sources", key, snapshotIndexProperties(component, architecture))
+		iw.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func snapshotIndexProperties(component, architecture string) map[string]string {
+	return map[string]string{
+		debian_module.PropertyRepositoryIncludeInRelease: "",
+		debian_module.PropertyComponent:                  component,
+		debian_module.PropertyArchitecture:               architecture,
+	}
+}
+
+// copySnapshotFile adds a file referencing the blob of the source file to the snapshot
+func copySnapshotFile(ctx context.Context, pv *packages_model.PackageVersion, component string, source *packages_model.PackageFile, pb *packages_model.PackageBlob, properties map[string]string) error {
+	pf, err := packages_model.TryInsertFile(ctx, &packages_model.PackageFile{
+		VersionID:    pv.ID,
+		BlobID:       pb.ID,
+		Name:         source.Name,
+		LowerName:    source.LowerName,
+		CompositeKey: component,
+	})
+	if err != nil {
+		// Files of a source package may be referenced by multiple source packages
+		if errors.Is(err, packages_model.ErrDuplicatePackageFile) {
+			return nil
+		}
+		return err
+	}
+
+	for name, value := range properties {
+		if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeFile, pf.ID, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishSnapshot publishes the snapshot as the distribution.
+// If the distribution is published already, it gets switched to the snapshot atomically.
+func PublishSnapshot(ctx context.Context, ownerID int64, name, distribution string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		pv, err := getSnapshotVersion(ctx, ownerID, name)
+		if err != nil {
+			return err
+		}
+
+		current, err := debian_model.GetPublishedSnapshotVersion(ctx, ownerID, distribution)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return err
+		}
+		if current != nil {
+			if err := deleteRepositoryFiles(ctx, current, distribution, "Release", "Release.gpg", "InRelease"); err != nil {
+				return err
+			}
+		}
+
+		pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+			VersionID: pv.ID,
+			Properties: map[string]string{
+				debian_module.PropertyRepositoryIncludeInRelease: "",
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		pfds, err := packages_model.GetPackageFileDescriptors(ctx, pfs)
+		if err != nil {
+			return err
+		}
+
+		components := make(container.Set[string])
+		architectures := make(container.Set[string])
+		for _, pfd := range pfds {
+			components.Add(pfd.Properties.GetByName(debian_module.PropertyComponent))
+			architectures.Add(pfd.Properties.GetByName(debian_module.PropertyArchitecture))
+		}
+
+		return createReleaseFiles(ctx, ownerID, pv, distribution, components.Values(), architectures.Values(), pfds)
+	})
+}
+
+// UnpublishSnapshot removes the distribution published from the snapshot
+func UnpublishSnapshot(ctx context.Context, ownerID int64, name, distribution string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		pv, err := getSnapshotVersion(ctx, ownerID, name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := packages_model.GetFileForVersionByName(ctx, pv.ID, "InRelease", distribution); err != nil {
+			return err
+		}
+
+		return deleteRepositoryFiles(ctx, pv, distribution, "Release", "Release.gpg", "InRelease")
+	})
+}
+
+// DeleteSnapshot deletes the snapshot. Published snapshots can't be deleted.
+func DeleteSnapshot(ctx context.Context, ownerID int64, name string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		pv, err := getSnapshotVersion(ctx, ownerID, name)
+		if err != nil {
+			return err
+		}
+
+		published, err := getPublishedDistributions(ctx, pv)
+		if err != nil {
+			return err
+		}
+		if len(published) > 0 {
+			return ErrSnapshotPublished
+		}
+
+		return packages_service.DeletePackageVersionAndReferences(ctx, pv)
+	})
+}
+
+// GetRepositoryVersionForDistribution gets the version holding the repository files of the distribution.
+// This is the published snapshot if there is one or the live repository otherwise.
+func GetRepositoryVersionForDistribution(ctx context.Context, ownerID int64, distribution string) (*packages_model.PackageVersion, bool, error) {
+	pv, err := debian_model.GetPublishedSnapshotVersion(ctx, ownerID, distribution)
+	if err == nil {
+		return pv, true, nil
+	}
+	if !errors.Is(err, util.ErrNotExist) {
+		return nil, false, err
+	}
+
+	pv, err = GetOrCreateRepositoryVersion(ctx, ownerID)
+	return pv, false, err
+}
+
+// GetSnapshotPoolFile gets a file from the pool of the snapshot
+func GetSnapshotPoolFile(ctx context.Context, ownerID int64, name, component, filename string) (*packages_model.PackageFile, error) {
+	pv, err := getSnapshotVersion(ctx, ownerID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, component)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only pool files are served, the index files are available under dists/
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeFile, pf.ID, debian_module.PropertyRepositoryIncludeInRelease)
+	if err != nil {
+		return nil, err
+	}
+	if len(pps) > 0 {
+		return nil, packages_model.ErrPackageFileNotExist
+	}
+	return pf, nil
+}
@@ -0,0 +1,381 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package debian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/optional"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	debian_module "code.gitea.io/gitea/modules/packages/debian"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// GetOrCreateIncomingVersion gets or creates the internal incoming package
+// Files of a source package may be uploaded before the source control file which references them.
+// These files are kept in this package until they get claimed by a source control file.
+func GetOrCreateIncomingVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeDebian, debian_module.RepositoryPackage, debian_module.IncomingVersion)
+}
+
+// SourceFileCompositeKey gets the composite key of a source package file
+func SourceFileCompositeKey(distribution, component string) string {
+	return fmt.Sprintf("%s|%s", distribution, component)
+}
+
+// SourceNameFromFilename extracts the source package name from a source package filename
+func SourceNameFromFilename(filename string) (string, error) {
+	name, _, ok := strings.Cut(filename, "_")
+	if !ok || name == "" {
+		return "", debian_module.ErrInvalidFilename
+	}
+	return name, nil
+}
+
+// GetSourceControlFiles gets the source control files of a package version in the distribution and component
+func GetSourceControlFiles(ctx context.Context, pv *packages_model.PackageVersion, distribution, component string) ([]*packages_model.PackageFile, error) {
+	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		VersionID:    pv.ID,
+		CompositeKey: SourceFileCompositeKey(distribution, component),
+		Properties: map[string]string{
+			debian_module.PropertyArchitecture: debian_module.ArchitectureSource,
+		},
+	})
+	return pfs, err
+}
+
+// GetSourceFileReferences gets the files referenced by the source control file
+func GetSourceFileReferences(ctx context.Context, pf *packages_model.PackageFile) ([]*debian_module.SourceFile, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeFile, pf.ID, debian_module.PropertySourceFiles)
+	if err != nil {
+		return nil, err
+	}
+	if len(pps) == 0 {
+		return nil, nil
+	}
+	return debian_module.ParseSourceFilesProperty(pps[0].Value), nil
+}
+
+// ClaimIncomingSourceFiles moves incoming files which are referenced by a source package into its package version
+func ClaimIncomingSourceFiles(ctx context.Context, doer, owner *user_model.User, pv *packages_model.PackageVersion, name, distribution, component string, files []*debian_module.SourceFile) error {
+	incoming, err := GetOrCreateIncomingVersion(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+
+	key := SourceFileCompositeKey(distribution, component)
+
+	for _, f := range files {
+		if _, err := packages_model.GetFileForVersionByName(ctx, pv.ID, f.Name, key); err == nil {
+			continue
+		} else if !errors.Is(err, util.ErrNotExist) {
+			return err
+		}
+
+		pf, err := packages_model.GetFileForVersionByName(ctx, incoming.ID, f.Name, key)
+		if err != nil {
+			if errors.Is(err, util.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+
+		pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+		if err != nil {
+			return err
+		}
+		if !f.Matches(pb.Size, pb.HashMD5, pb.HashSHA256) {
+			continue
+		}
+
+		if err := addSourceFileFromBlob(ctx, doer, owner, pv, name, key, f.Name, pb); err != nil {
+			return err
+		}
+
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addSourceFileFromBlob(ctx context.Context, doer, owner *user_model.User, pv *packages_model.PackageVersion, name, key, filename string, pb *packages_model.PackageBlob) error {
+	s, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	buf, err := packages_module.CreateHashedBufferFromReader(s)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	return addSourceFile(ctx, doer, owner, pv, name, key, filename, buf)
+}
+
+func addSourceFile(ctx context.Context, doer, owner *user_model.User, pv *packages_model.PackageVersion, name, key, filename string, buf *packages_module.HashedBuffer) error {
+	distribution, component, _ := strings.Cut(key, "|")
+
+	_, err := packages_service.AddFileToExistingPackage(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       owner,
+			PackageType: packages_model.TypeDebian,
+			Name:        name,
+			Version:     pv.Version,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     filename,
+				CompositeKey: key,
+			},
+			Creator: doer,
+			Data:    buf,
+			IsLead:  false,
+			Properties: map[string]string{
+				debian_module.PropertyDistribution: distribution,
+				debian_module.PropertyComponent:    component,
+			},
+		},
+	)
+	return err
+}
+
+// AddSourceFile adds an uploaded source package file to every source package referencing it.
+// If no source package references the file yet, it is stored as incoming file.
+func AddSourceFile(ctx context.Context, doer, owner *user_model.User, distribution, component, filename string, buf *packages_module.HashedBuffer) error {
+	name, err := SourceNameFromFilename(filename)
+	if err != nil {
+		return err
+	}
+
+	key := SourceFileCompositeKey(distribution, component)
+	pb := packages_service.NewPackageBlob(buf)
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, owner.ID, packages_model.TypeDebian, name)
+	if err != nil {
+		return err
+	}
+
+	claimed := false
+	for _, pv := range pvs {
+		pfs, err := GetSourceControlFiles(ctx, pv, distribution, component)
+		if err != nil {
+			return err
+		}
+
+		for _, pf := range pfs {
+			files, err := GetSourceFileReferences(ctx, pf)
+			if err != nil {
+				return err
+			}
+
+			for _, f := range files {
+				if f.Name != filename {
+					continue
+				}
+				if !f.Matches(pb.Size, pb.HashMD5, pb.HashSHA256) {
+					return util.NewInvalidArgumentErrorf("checksum of %s does not match the source control file", filename)
+				}
+
+				claimed = true
+
+				if _, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, key); err == nil {
+					continue
+				} else if !errors.Is(err, util.ErrNotExist) {
+					return err
+				}
+
+				if _, err := buf.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				if err := addSourceFile(ctx, doer, owner, pv, name, key, filename, buf); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if claimed {
+		return nil
+	}
+
+	if err := packages_service.CheckSizeQuotaExceeded(ctx, doer, owner, packages_model.TypeDebian, buf.Size()); err != nil {
+		return err
+	}
+
+	incoming, err := GetOrCreateIncomingVersion(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = packages_service.AddFileToPackageVersionInternal(
+		ctx,
+		incoming,
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     filename,
+				CompositeKey: key,
+			},
+			Creator:           doer,
+			Data:              buf,
+			IsLead:            false,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+// Cleanup removes incoming source files which were never claimed by a source control file
+func Cleanup(ctx context.Context, olderThan time.Duration) error {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		Type: packages_model.TypeDebian,
+		Version: packages_model.SearchValue{
+			ExactMatch: true,
+			Value:      debian_module.IncomingVersion,
+		},
+		IsInternal: optional.Some(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pv := range pvs {
+		pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+			VersionID: pv.ID,
+			OlderThan: olderThan,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, pf := range pfs {
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetSourceFile gets a file of a source package by its name
+func GetSourceFile(ctx context.Context, ownerID int64, distribution, component, filename string) (*packages_model.PackageFile, error) {
+	name, err := SourceNameFromFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ownerID, packages_model.TypeDebian, name)
+	if err != nil {
+		return nil, err
+	}
+
+	key := SourceFileCompositeKey(distribution, component)
+	for _, pv := range pvs {
+		pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, key)
+		if err == nil {
+			return pf, nil
+		}
+		if !errors.Is(err, util.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, packages_model.ErrPackageFileNotExist
+}
+
+// ExistsUploadedFile tests if a file matching the checksums was uploaded to the distribution and component
+func ExistsUploadedFile(ctx context.Context, ownerID int64, distribution, component string, f *debian_module.SourceFile) (bool, error) {
+	algorithm, hash := "sha256", f.SHA256
+	if hash == "" {
+		algorithm, hash = "md5", f.MD5
+	}
+
+	opts := &packages_model.PackageFileSearchOptions{
+		OwnerID:       ownerID,
+		PackageType:   packages_model.TypeDebian,
+		CompositeKey:  SourceFileCompositeKey(distribution, component),
+		HashAlgorithm: algorithm,
+		Hash:          hash,
+	}
+
+	pfs, _, err := packages_model.SearchFiles(ctx, opts)
+	if err != nil {
+		return false, err
+	}
+	if len(pfs) > 0 {
+		return true, nil
+	}
+
+	// The file may still wait in the incoming package
+	incoming, err := GetOrCreateIncomingVersion(ctx, ownerID)
+	if err != nil {
+		return false, err
+	}
+
+	opts.OwnerID = 0
+	opts.PackageType = ""
+	opts.VersionID = incoming.ID
+
+	pfs, _, err = packages_model.SearchFiles(ctx, opts)
+	if err != nil {
+		return false, err
+	}
+	return len(pfs) > 0, nil
+}
+
+// DeleteSourcePackageFiles deletes the source control file and the files it references from the package version
+func DeleteSourcePackageFiles(ctx context.Context, pv *packages_model.PackageVersion, distribution, component string) error {
+	pfs, err := GetSourceControlFiles(ctx, pv, distribution, component)
+	if err != nil {
+		return err
+	}
+	if len(pfs) == 0 {
+		return packages_model.ErrPackageFileNotExist
+	}
+
+	key := SourceFileCompositeKey(distribution, component)
+
+	for _, pf := range pfs {
+		files, err := GetSourceFileReferences(ctx, pf)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			ref, err := packages_model.GetFileForVersionByName(ctx, pv.ID, f.Name, key)
+			if err != nil {
+				if errors.Is(err, util.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+
+			if err := packages_service.DeletePackageFile(ctx, ref); err != nil {
+				return err
+			}
+		}
+
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
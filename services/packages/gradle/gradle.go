@@ -0,0 +1,236 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package gradle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	gradle_model "code.gitea.io/gitea/models/packages/gradle"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	gradle_module "code.gitea.io/gitea/modules/packages/gradle"
+	"code.gitea.io/gitea/modules/setting"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// UploadEntry stores a build cache entry
+// Gradle may store an entry again if the outputs of a task are not reproducible.
+func UploadEntry(ctx context.Context, doer, owner *user_model.User, key string, buf *packages_module.HashedBuffer) error {
+	_, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       owner,
+				PackageType: packages_model.TypeGradle,
+				Name:        gradle_module.CachePackageName,
+				Version:     key,
+			},
+			Creator: doer,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: key,
+			},
+			Creator:           doer,
+			Data:              buf,
+			IsLead:            true,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+// GetEntryStream gets the content of a build cache entry and records the hit or miss
+func GetEntryStream(ctx context.Context, owner *user_model.User, key string) (io.ReadSeekCloser, *url.URL, *packages_model.PackageFile, error) {
+	s, u, pf, err := getEntryStream(ctx, owner, key)
+	if err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) || errors.Is(err, packages_model.ErrPackageFileNotExist) {
+			if err := gradle_model.IncrementMisses(ctx, owner.ID); err != nil {
+				log.Error("Error recording Gradle build cache miss: %v", err)
+			}
+		}
+		return nil, nil, nil, err
+	}
+
+	if err := gradle_model.IncrementHits(ctx, owner.ID); err != nil {
+		log.Error("Error recording Gradle build cache hit: %v", err)
+	}
+	return s, u, pf, nil
+}
+
+func getEntryStream(ctx context.Context, owner *user_model.User, key string) (io.ReadSeekCloser, *url.URL, *packages_model.PackageFile, error) {
+	pf, pb, err := GetEntry(ctx, owner, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return packages_service.GetPackageBlobStream(ctx, pf, pb)
+}
+
+// GetEntry gets the file and blob of a build cache entry.
+// In contrast to GetEntryStream neither a hit nor a download is recorded, so checking the existence of an entry does not keep it in the cache.
+func GetEntry(ctx context.Context, owner *user_model.User, key string) (*packages_model.PackageFile, *packages_model.PackageBlob, error) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, owner.ID, packages_model.TypeGradle, gradle_module.CachePackageName, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, key, packages_model.EmptyFileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pf, pb, nil
+}
+
+// GetCacheMaxSize gets the size cap of the build cache of the owner.
+// Owners without an own cap use the instance wide GRADLE_CACHE_MAX_SIZE, a negative value disables the cap.
+func GetCacheMaxSize(ctx context.Context, ownerID int64) (int64, error) {
+	value, err := user_model.GetUserSetting(ctx, ownerID, gradle_module.SettingCacheMaxSize)
+	if err != nil {
+		return 0, err
+	}
+	if value == "" {
+		return setting.Packages.GradleCacheMaxSize, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// SetCacheMaxSize sets the size cap of the build cache of the owner, nil resets it to the instance wide default
+func SetCacheMaxSize(ctx context.Context, ownerID int64, maxSize *int64) error {
+	if maxSize == nil {
+		return user_model.DeleteUserSetting(ctx, ownerID, gradle_module.SettingCacheMaxSize)
+	}
+	return user_model.SetUserSetting(ctx, ownerID, gradle_module.SettingCacheMaxSize, strconv.FormatInt(*maxSize, 10))
+}
+
+// EvictEntries removes the least recently used entries of all owners whose build cache exceeds its size cap
+func EvictEntries(ctx context.Context) error {
+	ownerIDs, err := packages_model.GetOwnerIDsByType(ctx, packages_model.TypeGradle)
+	if err != nil {
+		return err
+	}
+
+	for _, ownerID := range ownerIDs {
+		select {
+		case <-ctx.Done():
+			return db.ErrCancelledf("while evicting Gradle build cache entries of owner %d", ownerID)
+		default:
+		}
+
+		if err := evictOwnerEntries(ctx, ownerID); err != nil {
+			return fmt.Errorf("evicting Gradle build cache entries of owner %d failed: %w", ownerID, err)
+		}
+	}
+	return nil
+}
+
+func evictOwnerEntries(ctx context.Context, ownerID int64) error {
+	maxSize, err := GetCacheMaxSize(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if maxSize < 0 {
+		return nil
+	}
+
+	entries, err := packages_model.GetCacheEntries(ctx, ownerID, packages_model.TypeGradle)
+	if err != nil {
+		return err
+	}
+
+	var totalSize int64
+	for _, e := range entries {
+		totalSize += e.Size
+	}
+	if totalSize <= maxSize {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastActivityUnix() < entries[j].LastActivityUnix()
+	})
+
+	for _, e := range entries {
+		if totalSize <= maxSize {
+			break
+		}
+
+		pv, err := packages_model.GetVersionByID(ctx, e.VersionID)
+		if err != nil {
+			return err
+		}
+
+		log.Trace("Evicting Gradle build cache entry %s of owner %d", pv.Version, ownerID)
+
+		if err := packages_service.DeletePackageVersionAndReferences(ctx, pv); err != nil {
+			return err
+		}
+		totalSize -= e.Size
+	}
+	return nil
+}
+
+// CacheStatistics are the statistics of the build cache of an owner
+type CacheStatistics struct {
+	*gradle_model.CacheStatistics
+	Owner      *user_model.User
+	EntryCount int
+	TotalSize  int64
+	MaxSize    int64
+}
+
+// GetCacheStatistics gets the statistics of the build caches of all owners
+func GetCacheStatistics(ctx context.Context) ([]*CacheStatistics, error) {
+	stats, err := gradle_model.GetAllCacheStatistics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*CacheStatistics, 0, len(stats))
+	for _, s := range stats {
+		owner, err := user_model.GetUserByID(ctx, s.OwnerID)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		entries, err := packages_model.GetCacheEntries(ctx, s.OwnerID, packages_model.TypeGradle)
+		if err != nil {
+			return nil, err
+		}
+
+		maxSize, err := GetCacheMaxSize(ctx, s.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+
+		cs := &CacheStatistics{
+			CacheStatistics: s,
+			Owner:           owner,
+			EntryCount:      len(entries),
+			MaxSize:         maxSize,
+		}
+		for _, e := range entries {
+			cs.TotalSize += e.Size
+		}
+		result = append(result, cs)
+	}
+	return result, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package hex
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	hex_module "code.gitea.io/gitea/modules/packages/hex"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// GetOrCreateKeyPair gets or creates the RSA keys used to sign the registry resources
+func GetOrCreateKeyPair(ctx context.Context, ownerID int64) (string, string, error) {
+	priv, err := user_model.GetSetting(ctx, ownerID, hex_module.SettingKeyPrivate)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	pub, err := user_model.GetSetting(ctx, ownerID, hex_module.SettingKeyPublic)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	if priv == "" || pub == "" {
+		priv, pub, err = util.GenerateKeyPair(hex_module.KeyBits)
+		if err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, ownerID, hex_module.SettingKeyPrivate, priv); err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, ownerID, hex_module.SettingKeyPublic, pub); err != nil {
+			return "", "", err
+		}
+	}
+
+	return priv, pub, nil
+}
+
+// getPackageDescriptors gets the descriptors of all package versions grouped by package name
+func getPackageDescriptors(ctx context.Context, ownerID int64) (map[string][]*packages_model.PackageDescriptor, error) {
+	pvs, err := packages_model.GetVersionsByPackageType(ctx, ownerID, packages_model.TypeHex)
+	if err != nil {
+		return nil, err
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupPackageDescriptors(pds), nil
+}
+
+func groupPackageDescriptors(pds []*packages_model.PackageDescriptor) map[string][]*packages_model.PackageDescriptor {
+	grouped := make(map[string][]*packages_model.PackageDescriptor)
+	for _, pd := range pds {
+		grouped[pd.Package.Name] = append(grouped[pd.Package.Name], pd)
+	}
+	for _, versions := range grouped {
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].SemVer.LessThan(versions[j].SemVer)
+		})
+	}
+	return grouped
+}
+
+func sortedNames(grouped map[string][]*packages_model.PackageDescriptor) []string {
+	names := make([]string, 0, len(grouped))
+	for name := range grouped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildNames creates the signed names resource of the repository
+func BuildNames(ctx context.Context, owner *user_model.User) ([]byte, error) {
+	grouped, err := getPackageDescriptors(ctx, owner.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := sortedNames(grouped)
+	entries := make([]*hex_module.NamesEntry, 0, len(names))
+	for _, name := range names {
+		entry := &hex_module.NamesEntry{
+			Name: name,
+		}
+		for _, pd := range grouped[name] {
+			if t := pd.Version.CreatedUnix.AsTime(); t.After(entry.UpdatedAt) {
+				entry.UpdatedAt = t
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return signResource(ctx, owner.ID, hex_module.EncodeNames(owner.Name, entries))
+}
+
+// BuildVersions creates the signed versions resource of the repository
+func BuildVersions(ctx context.Context, owner *user_model.User) ([]byte, error) {
+	grouped, err := getPackageDescriptors(ctx, owner.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := sortedNames(grouped)
+	entries := make([]*hex_module.VersionsEntry, 0, len(names))
+	for _, name := range names {
+		entry := &hex_module.VersionsEntry{
+			Name: name,
+		}
+		for _, pd := range grouped[name] {
+			entry.Versions = append(entry.Versions, pd.Version.Version)
+		}
+		entries = append(entries, entry)
+	}
+
+	return signResource(ctx, owner.ID, hex_module.EncodeVersions(owner.Name, entries))
+}
+
+// BuildPackage creates the signed package resource with all releases of the package
+func BuildPackage(ctx context.Context, owner *user_model.User, name string) ([]byte, error) {
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, owner.ID, packages_model.TypeHex, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(pvs) == 0 {
+		return nil, packages_model.ErrPackageNotExist
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := groupPackageDescriptors(pds)[name]
+	releases := make([]*hex_module.Release, 0, len(versions))
+	for _, pd := range versions {
+		if len(pd.Files) == 0 {
+			continue
+		}
+
+		innerChecksum, err := hex.DecodeString(pd.VersionProperties.GetByName(hex_module.PropertyInnerChecksum))
+		if err != nil {
+			return nil, err
+		}
+		outerChecksum, err := hex.DecodeString(pd.Files[0].Blob.HashSHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		releases = append(releases, &hex_module.Release{
+			Version:       pd.Version.Version,
+			InnerChecksum: innerChecksum,
+			OuterChecksum: outerChecksum,
+			Dependencies:  pd.Metadata.(*hex_module.Metadata).Requirements,
+		})
+	}
+
+	return signResource(ctx, owner.ID, hex_module.EncodePackage(owner.Name, name, releases))
+}
+
+// signResource signs the payload with the key of the owner and returns the gzipped Signed message
+func signResource(ctx context.Context, ownerID int64, payload []byte) ([]byte, error) {
+	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(priv))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key pem")
+	}
+
+	privKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha512.Sum512(payload)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privKey, crypto.SHA512, h[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(hex_module.EncodeSigned(payload, signature)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,421 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package huggingface
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	huggingface_model "code.gitea.io/gitea/models/packages/huggingface"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/optional"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	huggingface_module "code.gitea.io/gitea/modules/packages/huggingface"
+	"code.gitea.io/gitea/modules/util"
+	notify_service "code.gitea.io/gitea/services/notify"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	ErrRepositoryNotExist = util.NewNotExistErrorf("repository does not exist")
+	ErrOIDMismatch        = util.NewInvalidArgumentErrorf("content does not match the object id")
+	ErrObjectNotUploaded  = util.NewInvalidArgumentErrorf("the large file referenced by the commit was not uploaded")
+	ErrSizeMismatch       = util.NewInvalidArgumentErrorf("the size of the large file does not match")
+)
+
+// GetOrCreateUploadVersion gets or creates the internal upload package
+// Large files are uploaded before the commit which references them.
+// The files are kept in this package until they get claimed by a commit.
+func GetOrCreateUploadVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeModel, huggingface_module.UploadPackage, huggingface_module.UploadVersion)
+}
+
+// GetObject gets a file and the blob with the content of the object
+func GetObject(ctx context.Context, ownerID int64, oid string) (*packages_model.PackageFile, *packages_model.PackageBlob, error) {
+	pf, err := huggingface_model.GetFileByBlobHash(ctx, ownerID, oid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pf, pb, nil
+}
+
+// UploadObject stores a large file in the upload package
+// The content is deduplicated with all other files of the owner.
+func UploadObject(ctx context.Context, doer, owner *user_model.User, oid string, buf *packages_module.HashedBuffer) error {
+	_, _, sum, _ := buf.Sums()
+	if hex.EncodeToString(sum) != oid {
+		return ErrOIDMismatch
+	}
+
+	if _, _, err := GetObject(ctx, owner.ID, oid); err == nil {
+		return nil
+	} else if !errors.Is(err, util.ErrNotExist) {
+		return err
+	}
+
+	if err := packages_service.CheckSizeQuotaExceeded(ctx, doer, owner, packages_model.TypeModel, buf.Size()); err != nil {
+		return err
+	}
+
+	uploadVersion, err := GetOrCreateUploadVersion(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = packages_service.AddFileToPackageVersionInternal(
+		ctx,
+		uploadVersion,
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: oid,
+			},
+			Creator:           doer,
+			Data:              buf,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+// getRepoType gets the type of the repository
+// Repositories created before datasets were supported have no type and are models.
+func getRepoType(ctx context.Context, packageID int64) (string, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypePackage, packageID, huggingface_module.PropertyRepoType)
+	if err != nil {
+		return "", err
+	}
+	if len(pps) == 0 {
+		return huggingface_module.RepoTypeModel, nil
+	}
+	return pps[0].Value, nil
+}
+
+// GetRepository gets the package of the repository with the specific type
+// A repository of the other type with the same id does not exist for the caller.
+func GetRepository(ctx context.Context, ownerID int64, repoType, repoID string) (*packages_model.Package, error) {
+	p, err := packages_model.GetPackageByName(ctx, ownerID, packages_model.TypeModel, repoID)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			return nil, ErrRepositoryNotExist
+		}
+		return nil, err
+	}
+
+	t, err := getRepoType(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if t != repoType {
+		return nil, ErrRepositoryNotExist
+	}
+	return p, nil
+}
+
+// GetRevision gets the version of the repository by the name of the revision or by its commit id
+func GetRevision(ctx context.Context, ownerID int64, repoType, repoID, revision string) (*packages_model.PackageVersion, error) {
+	p, err := GetRepository(ctx, ownerID, repoType, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ownerID, packages_model.TypeModel, repoID, revision)
+	if err == nil || !errors.Is(err, util.ErrNotExist) || !huggingface_module.IsCommitOID(revision) {
+		return pv, err
+	}
+
+	return huggingface_model.GetVersionByCommit(ctx, p.ID, revision)
+}
+
+// GetCommit gets the commit id of the revision
+func GetCommit(ctx context.Context, pv *packages_model.PackageVersion) (string, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeVersion, pv.ID, huggingface_module.PropertyCommit)
+	if err != nil {
+		return "", err
+	}
+	if len(pps) == 0 {
+		return "", packages_model.ErrPackageNotExist
+	}
+	return pps[0].Value, nil
+}
+
+// CreateCommit applies the operations of the commit to the revision and returns the new commit id
+// The repository and the revision are created if they do not exist.
+func CreateCommit(ctx context.Context, doer, owner *user_model.User, repoType, repoID, revision string, c *huggingface_module.Commit) (*packages_model.PackageVersion, string, error) {
+	var pv *packages_model.PackageVersion
+	var commit string
+	var created bool
+
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		// Models and datasets share the repository ids of the owner
+		if p, err := packages_model.GetPackageByName(ctx, owner.ID, packages_model.TypeModel, repoID); err == nil {
+			if t, err := getRepoType(ctx, p.ID); err != nil {
+				return err
+			} else if t != repoType {
+				return ErrRepositoryNotExist
+			}
+		} else if !errors.Is(err, util.ErrNotExist) {
+			return err
+		}
+
+		// Check the large files before anything gets changed
+		lfsFiles := make([]*packages_model.PackageFile, 0, len(c.LFSFiles))
+		lfsBlobs := make([]*packages_model.PackageBlob, 0, len(c.LFSFiles))
+		for _, f := range c.LFSFiles {
+			pf, pb, err := GetObject(ctx, owner.ID, f.OID)
+			if err != nil {
+				if errors.Is(err, util.ErrNotExist) {
+					return ErrObjectNotUploaded
+				}
+				return err
+			}
+			if f.Size != 0 && f.Size != pb.Size {
+				return ErrSizeMismatch
+			}
+			lfsFiles = append(lfsFiles, pf)
+			lfsBlobs = append(lfsBlobs, pb)
+		}
+
+		pi := packages_service.PackageInfo{
+			Owner:       owner,
+			PackageType: packages_model.TypeModel,
+			Name:        repoID,
+			Version:     revision,
+		}
+
+		var err error
+		pv, created, err = packages_service.CreatePackageOrGetVersion(ctx, &packages_service.PackageCreationInfo{
+			PackageInfo: pi,
+			Creator:     doer,
+			Metadata:    &huggingface_module.Metadata{},
+			PackageProperties: map[string]string{
+				huggingface_module.PropertyRepoType: repoType,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := deleteFiles(ctx, pv, c); err != nil {
+			return err
+		}
+
+		for _, f := range c.Files {
+			buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(f.Content))
+			if err != nil {
+				return err
+			}
+			_, err = packages_service.AddFileToExistingPackage(
+				ctx,
+				&pi,
+				&packages_service.PackageFileCreationInfo{
+					PackageFileInfo: packages_service.PackageFileInfo{
+						Filename: f.Path,
+					},
+					Creator:           doer,
+					Data:              buf,
+					OverwriteExisting: true,
+				},
+			)
+			buf.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		uploadVersion, err := GetOrCreateUploadVersion(ctx, owner.ID)
+		if err != nil {
+			return err
+		}
+
+		for i, f := range c.LFSFiles {
+			if err := packages_service.CheckSizeQuotaExceeded(ctx, doer, owner, packages_model.TypeModel, lfsBlobs[i].Size); err != nil {
+				return err
+			}
+			if err := linkBlob(ctx, pv, f.Path, lfsBlobs[i]); err != nil {
+				return err
+			}
+			if lfsFiles[i].VersionID == uploadVersion.ID {
+				if err := packages_service.DeletePackageFile(ctx, lfsFiles[i]); err != nil && !errors.Is(err, util.ErrNotExist) {
+					return err
+				}
+			}
+		}
+
+		commit, err = updateRevision(ctx, pv, c)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if created {
+		pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+		if err != nil {
+			return nil, "", err
+		}
+
+		notify_service.PackageCreate(ctx, doer, pd)
+	}
+
+	return pv, commit, nil
+}
+
+func deleteFiles(ctx context.Context, pv *packages_model.PackageVersion, c *huggingface_module.Commit) error {
+	for _, path := range c.DeletedFiles {
+		pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, path, packages_model.EmptyFileKey)
+		if err != nil {
+			return err
+		}
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	if len(c.DeletedFolders) == 0 {
+		return nil
+	}
+
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		return err
+	}
+	for _, pf := range pfs {
+		for _, folder := range c.DeletedFolders {
+			if strings.HasPrefix(pf.Name, folder+"/") {
+				if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// linkBlob adds the already stored blob as file to the revision
+func linkBlob(ctx context.Context, pv *packages_model.PackageVersion, path string, pb *packages_model.PackageBlob) error {
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, path, packages_model.EmptyFileKey)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return err
+	}
+	if pf != nil {
+		if pf.BlobID == pb.ID {
+			return nil
+		}
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	_, err = packages_model.TryInsertFile(ctx, &packages_model.PackageFile{
+		VersionID:    pv.ID,
+		BlobID:       pb.ID,
+		Name:         path,
+		LowerName:    strings.ToLower(path),
+		CompositeKey: packages_model.EmptyFileKey,
+	})
+	return err
+}
+
+// updateRevision stores the commit id and the metadata of the model card of the revision
+func updateRevision(ctx context.Context, pv *packages_model.PackageVersion, c *huggingface_module.Commit) (string, error) {
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		return "", err
+	}
+
+	metadata := &huggingface_module.Metadata{
+		Summary:     c.Summary,
+		Description: c.Description,
+	}
+
+	files := make(map[string]string, len(pfs))
+	for _, pf := range pfs {
+		pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+		if err != nil {
+			return "", err
+		}
+		files[pf.Name] = pb.HashSHA256
+
+		if pf.Name == huggingface_module.ReadmeFilename {
+			// A model card which is too large is not shown but does not fail the commit
+			if err := readModelCard(pb, metadata); err != nil && !errors.Is(err, huggingface_module.ErrReadmeIsTooLarge) {
+				return "", err
+			}
+		}
+	}
+
+	commit := huggingface_module.CommitOID(files)
+
+	if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypeVersion, pv.ID, huggingface_module.PropertyCommit); err != nil {
+		return "", err
+	}
+	if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, pv.ID, huggingface_module.PropertyCommit, commit); err != nil {
+		return "", err
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	pv.MetadataJSON = string(metadataJSON)
+
+	return commit, packages_model.UpdateVersion(ctx, pv)
+}
+
+func readModelCard(pb *packages_model.PackageBlob, metadata *huggingface_module.Metadata) error {
+	s, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return huggingface_module.ReadModelCard(s, metadata)
+}
+
+// Cleanup removes uploaded large files which were never claimed by a commit
+func Cleanup(ctx context.Context, olderThan time.Duration) error {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		Type: packages_model.TypeModel,
+		Version: packages_model.SearchValue{
+			ExactMatch: true,
+			Value:      huggingface_module.UploadVersion,
+		},
+		IsInternal: optional.Some(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pv := range pvs {
+		pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+			VersionID: pv.ID,
+			OlderThan: olderThan,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, pf := range pfs {
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package lfs
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/url"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var ErrOIDMismatch = util.NewInvalidArgumentErrorf("content does not match the object id")
+
+// GetObject gets the file of an object of the project
+// Every object is stored as a version of the project package named by its object id.
+func GetObject(ctx context.Context, ownerID int64, project, oid string) (*packages_model.PackageFile, *packages_model.PackageBlob, error) {
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ownerID, packages_model.TypeLFS, project, oid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, oid, packages_model.EmptyFileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pf, pb, nil
+}
+
+// GetObjectStream gets the content of an object of the project
+func GetObjectStream(ctx context.Context, ownerID int64, project, oid string) (io.ReadSeekCloser, *url.URL, *packages_model.PackageFile, error) {
+	pf, pb, err := GetObject(ctx, ownerID, project, oid)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return packages_service.GetPackageBlobStream(ctx, pf, pb)
+}
+
+// UploadObject stores an object of the project
+// The content is deduplicated with all other packages by the blob store.
+func UploadObject(ctx context.Context, doer, owner *user_model.User, project, oid string, buf *packages_module.HashedBuffer) error {
+	_, _, sum, _ := buf.Sums()
+	if hex.EncodeToString(sum) != oid {
+		return ErrOIDMismatch
+	}
+
+	if _, _, err := GetObject(ctx, owner.ID, project, oid); err == nil {
+		return nil
+	}
+
+	_, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       owner,
+				PackageType: packages_model.TypeLFS,
+				Name:        project,
+				Version:     oid,
+			},
+			Creator: doer,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: oid,
+			},
+			Creator:           doer,
+			Data:              buf,
+			IsLead:            true,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
@@ -0,0 +1,316 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package nix
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/optional"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	nix_module "code.gitea.io/gitea/modules/packages/nix"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	ErrNarNotUploaded = util.NewInvalidArgumentErrorf("the NAR file referenced by the narinfo was not uploaded")
+	ErrNarMismatch    = util.NewInvalidArgumentErrorf("the NAR file does not match the narinfo")
+)
+
+// GetOrCreateKeyPair gets or creates the ed25519 keys used to sign the narinfo files.
+// The keys are stored in the name:base64 format used by Nix.
+func GetOrCreateKeyPair(ctx context.Context, owner *user_model.User) (string, string, error) {
+	priv, err := user_model.GetSetting(ctx, owner.ID, nix_module.SettingKeyPrivate)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	pub, err := user_model.GetSetting(ctx, owner.ID, nix_module.SettingKeyPublic)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	if priv == "" || pub == "" {
+		priv, pub, err = generateKeypair(fmt.Sprintf("%s-%s", setting.Domain, owner.LowerName))
+		if err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, owner.ID, nix_module.SettingKeyPrivate, priv); err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, owner.ID, nix_module.SettingKeyPublic, pub); err != nil {
+			return "", "", err
+		}
+	}
+
+	return priv, pub, nil
+}
+
+func generateKeypair(name string) (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return nix_module.FormatKey(name, priv), nix_module.FormatKey(name, pub), nil
+}
+
+// GetOrCreateIncomingVersion gets or creates the internal incoming package
+// Nix uploads the NAR file before the narinfo which references it.
+// The NAR files are kept in this package until they get claimed by a narinfo.
+func GetOrCreateIncomingVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeNix, nix_module.RepositoryPackage, nix_module.IncomingVersion)
+}
+
+// GetNarFile gets a NAR file which is referenced by a store path
+func GetNarFile(ctx context.Context, ownerID int64, filename string) (*packages_model.PackageFile, error) {
+	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		OwnerID:     ownerID,
+		PackageType: packages_model.TypeNix,
+		Query:       filename,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, pf := range pfs {
+		if pf.Name == filename {
+			return pf, nil
+		}
+	}
+	return nil, packages_model.ErrPackageFileNotExist
+}
+
+// UploadNar stores an uploaded NAR file in the incoming package.
+// The name of the file must contain the hash of its content.
+func UploadNar(ctx context.Context, doer, owner *user_model.User, filename string, buf *packages_module.HashedBuffer) error {
+	hash, err := nix_module.ParseNarFilename(filename)
+	if err != nil {
+		return err
+	}
+
+	_, _, sum, _ := buf.Sums()
+	if nix_module.EncodeBase32(sum) != hash {
+		return util.NewInvalidArgumentErrorf("checksum of %s does not match its name", filename)
+	}
+
+	// The same NAR may be referenced by multiple store paths
+	if _, err := GetNarFile(ctx, owner.ID, filename); err == nil {
+		return nil
+	} else if !errors.Is(err, packages_model.ErrPackageFileNotExist) {
+		return err
+	}
+
+	if err := packages_service.CheckSizeQuotaExceeded(ctx, doer, owner, packages_model.TypeNix, buf.Size()); err != nil {
+		return err
+	}
+
+	incoming, err := GetOrCreateIncomingVersion(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = packages_service.AddFileToPackageVersionInternal(
+		ctx,
+		incoming,
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: filename,
+			},
+			Creator:           doer,
+			Data:              buf,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+// UploadNarInfo creates the package version of a store path and claims the NAR file referenced by the narinfo
+func UploadNarInfo(ctx context.Context, doer, owner *user_model.User, n *nix_module.NarInfo) (*packages_model.PackageVersion, error) {
+	hash, name, err := nix_module.SplitStorePath(n.StorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, ok := strings.CutPrefix(n.URL, "nar/")
+	if !ok {
+		return nil, nix_module.ErrInvalidNarInfo
+	}
+	fileHash, err := nix_module.ParseNarFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+	if "sha256:"+fileHash != n.FileHash {
+		return nil, ErrNarMismatch
+	}
+
+	// The NAR is either waiting in the incoming package or already referenced by another store path
+	isIncoming := false
+	pf, err := GetNarFile(ctx, owner.ID, filename)
+	if errors.Is(err, packages_model.ErrPackageFileNotExist) {
+		incoming, err := GetOrCreateIncomingVersion(ctx, owner.ID)
+		if err != nil {
+			return nil, err
+		}
+		pf, err = packages_model.GetFileForVersionByName(ctx, incoming.ID, filename, packages_model.EmptyFileKey)
+		if err != nil {
+			if errors.Is(err, util.ErrNotExist) {
+				return nil, ErrNarNotUploaded
+			}
+			return nil, err
+		}
+		isIncoming = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, err
+	}
+	if pb.Size != n.FileSize {
+		return nil, ErrNarMismatch
+	}
+
+	s, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	buf, err := packages_module.CreateHashedBufferFromReader(s)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Close()
+
+	pv, _, err := packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       owner,
+				PackageType: packages_model.TypeNix,
+				Name:        name,
+				Version:     hash,
+			},
+			Creator:  doer,
+			Metadata: &n.Metadata,
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: filename,
+			},
+			Creator: doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if isIncoming {
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return nil, err
+		}
+	}
+
+	return pv, nil
+}
+
+// GetStorePath gets the package version of the store path with the hash
+func GetStorePath(ctx context.Context, ownerID int64, hash string) (*packages_model.PackageVersion, error) {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		OwnerID: ownerID,
+		Type:    packages_model.TypeNix,
+		Version: packages_model.SearchValue{
+			ExactMatch: true,
+			Value:      hash,
+		},
+		IsInternal: optional.Some(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pvs) == 0 {
+		return nil, packages_model.ErrPackageNotExist
+	}
+	return pvs[0], nil
+}
+
+// GetNarInfo renders the narinfo of the store path with the hash and signs it with the key of the owner
+func GetNarInfo(ctx context.Context, owner *user_model.User, hash string) (string, error) {
+	pv, err := GetStorePath(ctx, owner.ID, hash)
+	if err != nil {
+		return "", err
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		return "", err
+	}
+	if len(pd.Files) == 0 {
+		return "", packages_model.ErrPackageFileNotExist
+	}
+
+	n := &nix_module.NarInfo{
+		Metadata: *pd.Metadata.(*nix_module.Metadata),
+		URL:      "nar/" + pd.Files[0].File.Name,
+	}
+
+	priv, _, err := GetOrCreateKeyPair(ctx, owner)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := n.Sign(priv)
+	if err != nil {
+		return "", err
+	}
+	n.Signatures = append(n.Signatures, sig)
+
+	return n.String(), nil
+}
+
+// Cleanup removes incoming NAR files which were never claimed by a narinfo
+func Cleanup(ctx context.Context, olderThan time.Duration) error {
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		Type: packages_model.TypeNix,
+		Version: packages_model.SearchValue{
+			ExactMatch: true,
+			Value:      nix_module.IncomingVersion,
+		},
+		IsInternal: optional.Some(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pv := range pvs {
+		pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+			VersionID: pv.ID,
+			OlderThan: olderThan,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, pf := range pfs {
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
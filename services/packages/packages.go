@@ -73,6 +73,12 @@ func CreatePackageOrAddFileToExisting(ctx context.Context, pvci *PackageCreation
 	return createPackageAndAddFile(ctx, pvci, pfci, true)
 }
 
+// CreatePackageOrGetVersion creates a package version without files or gets the existing one
+// The caller is responsible to add the files and to send the notification if the version was created.
+func CreatePackageOrGetVersion(ctx context.Context, pvci *PackageCreationInfo) (*packages_model.PackageVersion, bool, error) {
+	return createPackageAndVersion(ctx, pvci, true)
+}
+
 func createPackageAndAddFile(ctx context.Context, pvci *PackageCreationInfo, pfci *PackageFileCreationInfo, allowDuplicate bool) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
 	dbCtx, committer, err := db.TxContext(ctx)
 	if err != nil {
@@ -353,10 +359,18 @@ func CheckSizeQuotaExceeded(ctx context.Context, doer, owner *user_model.User, p
 	switch packageType {
 	case packages_model.TypeAlpine:
 		typeSpecificSize = setting.Packages.LimitSizeAlpine
+	case packages_model.TypeAnsible:
+		typeSpecificSize = setting.Packages.LimitSizeAnsible
+	case packages_model.TypeArch:
+		typeSpecificSize = setting.Packages.LimitSizeArch
+	case packages_model.TypeBazel:
+		typeSpecificSize = setting.Packages.LimitSizeBazel
 	case packages_model.TypeCargo:
 		typeSpecificSize = setting.Packages.LimitSizeCargo
 	case packages_model.TypeChef:
 		typeSpecificSize = setting.Packages.LimitSizeChef
+	case packages_model.TypeCocoaPods:
+		typeSpecificSize = setting.Packages.LimitSizeCocoaPods
 	case packages_model.TypeComposer:
 		typeSpecificSize = setting.Packages.LimitSizeComposer
 	case packages_model.TypeConan:
@@ -373,10 +387,20 @@ func CheckSizeQuotaExceeded(ctx context.Context, doer, owner *user_model.User, p
 		typeSpecificSize = setting.Packages.LimitSizeGeneric
 	case packages_model.TypeGo:
 		typeSpecificSize = setting.Packages.LimitSizeGo
+	case packages_model.TypeGradle:
+		typeSpecificSize = setting.Packages.LimitSizeGradle
 	case packages_model.TypeHelm:
 		typeSpecificSize = setting.Packages.LimitSizeHelm
+	case packages_model.TypeHex:
+		typeSpecificSize = setting.Packages.LimitSizeHex
+	case packages_model.TypeLFS:
+		typeSpecificSize = setting.Packages.LimitSizeLFS
 	case packages_model.TypeMaven:
 		typeSpecificSize = setting.Packages.LimitSizeMaven
+	case packages_model.TypeModel:
+		typeSpecificSize = setting.Packages.LimitSizeModel
+	case packages_model.TypeNix:
+		typeSpecificSize = setting.Packages.LimitSizeNix
 	case packages_model.TypeNpm:
 		typeSpecificSize = setting.Packages.LimitSizeNpm
 	case packages_model.TypeNuGet:
@@ -391,6 +415,10 @@ func CheckSizeQuotaExceeded(ctx context.Context, doer, owner *user_model.User, p
 		typeSpecificSize = setting.Packages.LimitSizeRubyGems
 	case packages_model.TypeSwift:
 		typeSpecificSize = setting.Packages.LimitSizeSwift
+	case packages_model.TypeTerraformModule:
+		typeSpecificSize = setting.Packages.LimitSizeTerraformModule
+	case packages_model.TypeTerraformProvider:
+		typeSpecificSize = setting.Packages.LimitSizeTerraformProvider
 	case packages_model.TypeVagrant:
 		typeSpecificSize = setting.Packages.LimitSizeVagrant
 	}
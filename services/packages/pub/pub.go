@@ -0,0 +1,177 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pub
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	pub_module "code.gitea.io/gitea/modules/packages/pub"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// RetractionWindow is the time after publishing in which a version can be retracted or restored
+// https://dart.dev/tools/pub/publishing#retract
+const RetractionWindow = 7 * 24 * time.Hour
+
+var (
+	ErrRetractionWindowExpired = util.NewInvalidArgumentErrorf("the retraction window of the version has expired")
+	ErrInvalidReplacement      = util.NewInvalidArgumentErrorf("the replacement package is invalid")
+	ErrAdvisoryNotExist        = util.NewNotExistErrorf("advisory does not exist")
+)
+
+// CanChangeRetraction checks if the version is still in the retraction window
+func CanChangeRetraction(pv *packages_model.PackageVersion) bool {
+	return time.Since(pv.CreatedUnix.AsTime()) < RetractionWindow
+}
+
+// SetVersionRetracted marks the version as retracted or restores it
+func SetVersionRetracted(ctx context.Context, pv *packages_model.PackageVersion, retracted bool) error {
+	if !CanChangeRetraction(pv) {
+		return ErrRetractionWindowExpired
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypeVersion, pv.ID, pub_module.PropertyRetracted); err != nil {
+			return err
+		}
+		if !retracted {
+			return nil
+		}
+		_, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, pv.ID, pub_module.PropertyRetracted, strconv.FormatBool(true))
+		return err
+	})
+}
+
+// SetPackageDiscontinued marks the package as discontinued with an optional replacement or continues it
+func SetPackageDiscontinued(ctx context.Context, p *packages_model.Package, discontinued bool, replacedBy string) error {
+	if discontinued && replacedBy != "" {
+		if !pub_module.IsValidPackageName(replacedBy) || replacedBy == p.Name {
+			return ErrInvalidReplacement
+		}
+		if _, err := packages_model.GetPackageByName(ctx, p.OwnerID, packages_model.TypePub, replacedBy); err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				return ErrInvalidReplacement
+			}
+			return err
+		}
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		for _, name := range []string{pub_module.PropertyDiscontinued, pub_module.PropertyReplacedBy} {
+			if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypePackage, p.ID, name); err != nil {
+				return err
+			}
+		}
+		if !discontinued {
+			return nil
+		}
+		if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypePackage, p.ID, pub_module.PropertyDiscontinued, strconv.FormatBool(true)); err != nil {
+			return err
+		}
+		if replacedBy != "" {
+			if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypePackage, p.ID, pub_module.PropertyReplacedBy, replacedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAdvisories gets all advisories of the package and the time of the last change
+func GetAdvisories(ctx context.Context, p *packages_model.Package) ([]*pub_module.Advisory, time.Time, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypePackage, p.ID, pub_module.PropertyAdvisory)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	advisories := make([]*pub_module.Advisory, 0, len(pps))
+	var updated time.Time
+	for _, pp := range pps {
+		var a *pub_module.Advisory
+		if err := json.Unmarshal([]byte(pp.Value), &a); err != nil {
+			return nil, time.Time{}, err
+		}
+		if a.Modified.After(updated) {
+			updated = a.Modified
+		}
+		advisories = append(advisories, a)
+	}
+
+	sort.Slice(advisories, func(i, j int) bool {
+		return advisories[i].ID < advisories[j].ID
+	})
+
+	return advisories, updated, nil
+}
+
+// AddAdvisory adds the advisory to the package or replaces an existing advisory with the same id.
+// The affected ranges are resolved against the existing versions of the package.
+func AddAdvisory(ctx context.Context, p *packages_model.Package, a *pub_module.Advisory) error {
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, p.OwnerID, packages_model.TypePub, p.Name)
+	if err != nil {
+		return err
+	}
+
+	versions := make([]string, 0, len(pvs))
+	for _, pv := range pvs {
+		versions = append(versions, pv.Version)
+	}
+	a.ResolveVersions(versions)
+
+	value, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := findAdvisoryProperty(ctx, p, a.ID)
+		if err != nil && err != ErrAdvisoryNotExist {
+			return err
+		}
+		if existing != nil {
+			existing.Value = string(value)
+			return packages_model.UpdateProperty(ctx, existing)
+		}
+
+		_, err = packages_model.InsertProperty(ctx, packages_model.PropertyTypePackage, p.ID, pub_module.PropertyAdvisory, string(value))
+		return err
+	})
+}
+
+// DeleteAdvisory removes the advisory from the package
+func DeleteAdvisory(ctx context.Context, p *packages_model.Package, advisoryID string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		pp, err := findAdvisoryProperty(ctx, p, advisoryID)
+		if err != nil {
+			return err
+		}
+		return packages_model.DeletePropertyByID(ctx, pp.ID)
+	})
+}
+
+func findAdvisoryProperty(ctx context.Context, p *packages_model.Package, advisoryID string) (*packages_model.PackageProperty, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypePackage, p.ID, pub_module.PropertyAdvisory)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pp := range pps {
+		var a struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(pp.Value), &a); err != nil {
+			return nil, err
+		}
+		if a.ID == advisoryID {
+			return pp, nil
+		}
+	}
+	return nil, ErrAdvisoryNotExist
+}
@@ -242,15 +242,33 @@ func BuildSpecificRepositoryFiles(ctx context.Context, ownerID int64, group stri
 		return err
 	}
 
+	data := []*repoData{
+		primary,
+		filelists,
+		other,
+	}
+
+	updateinfo, err := buildUpdateinfo(ctx, pv, ownerID, pfs, cache, group)
+	if err != nil {
+		return err
+	}
+	if updateinfo != nil {
+		data = append(data, updateinfo)
+	}
+
+	modules, err := buildModules(ctx, pv, ownerID, group)
+	if err != nil {
+		return err
+	}
+	if modules != nil {
+		data = append(data, modules)
+	}
+
 	return buildRepomd(
 		ctx,
 		pv,
 		ownerID,
-		[]*repoData{
-			primary,
-			filelists,
-			other,
-		},
+		data,
 		group,
 	)
 }
@@ -583,6 +601,14 @@ func (wc *writtenCounter) Written() int64 {
 }
 
 func addDataAsFileToRepo(ctx context.Context, pv *packages_model.PackageVersion, filetype string, obj any, group string) (*repoData, error) {
+	return addContentAsFileToRepo(ctx, pv, filetype, filetype+".xml.gz", func(w io.Writer) error {
+		_, _ = w.Write([]byte(xml.Header))
+
+		return xml.NewEncoder(w).Encode(obj)
+	}, group)
+}
+
+func addContentAsFileToRepo(ctx context.Context, pv *packages_model.PackageVersion, filetype, filename string, write func(io.Writer) error, group string) (*repoData, error) {
 	content, _ := packages_module.NewHashedBuffer()
 	defer content.Close()
 
@@ -590,10 +616,7 @@ func addDataAsFileToRepo(ctx context.Context, pv *packages_model.PackageVersion,
 	wc := &writtenCounter{}
 	h := sha256.New()
 
-	w := io.MultiWriter(gzw, wc, h)
-	_, _ = w.Write([]byte(xml.Header))
-
-	if err := xml.NewEncoder(w).Encode(obj); err != nil {
+	if err := write(io.MultiWriter(gzw, wc, h)); err != nil {
 		return nil, err
 	}
 
@@ -601,8 +624,6 @@ func addDataAsFileToRepo(ctx context.Context, pv *packages_model.PackageVersion,
 		return nil, err
 	}
 
-	filename := filetype + ".xml.gz"
-
 	_, err := packages_service.AddFileToPackageVersionInternal(
 		ctx,
 		pv,
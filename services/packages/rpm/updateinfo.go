@@ -0,0 +1,416 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rpm
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// GetOrCreateAdvisoryVersion gets or creates the internal package holding the advisories
+func GetOrCreateAdvisoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeRpm, rpm_module.RepositoryPackage, rpm_module.AdvisoryVersion)
+}
+
+// GetOrCreateModuleVersion gets or creates the internal package holding the module metadata
+func GetOrCreateModuleVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeRpm, rpm_module.RepositoryPackage, rpm_module.ModuleVersion)
+}
+
+func addInternalFile(ctx context.Context, pv *packages_model.PackageVersion, filename, group string, data packages_module.HashedSizeReader) error {
+	_, err := packages_service.AddFileToPackageVersionInternal(
+		ctx,
+		pv,
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     filename,
+				CompositeKey: group,
+			},
+			Creator:           user_model.NewGhostUser(),
+			Data:              data,
+			IsLead:            false,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+func deleteInternalFile(ctx context.Context, pv *packages_model.PackageVersion, filename, group string) error {
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, group)
+	if err != nil {
+		return err
+	}
+	return packages_service.DeletePackageFile(ctx, pf)
+}
+
+func openInternalFile(ctx context.Context, pf *packages_model.PackageFile) (io.ReadCloser, error) {
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, err
+	}
+	return packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pb.HashSHA256))
+}
+
+// AddAdvisory adds the advisory to the group or replaces an existing advisory with the same id
+func AddAdvisory(ctx context.Context, ownerID int64, group string, a *rpm_module.Advisory) error {
+	pv, err := GetOrCreateAdvisoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	if err := addInternalFile(ctx, pv, a.ID+".json", group, buf); err != nil {
+		return err
+	}
+
+	return BuildSpecificRepositoryFiles(ctx, ownerID, group)
+}
+
+// GetAdvisories gets all advisories of the group sorted by their id
+func GetAdvisories(ctx context.Context, ownerID int64, group string) ([]*rpm_module.Advisory, error) {
+	pv, err := GetOrCreateAdvisoryVersion(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	advisories := make([]*rpm_module.Advisory, 0, len(pfs))
+	for _, pf := range pfs {
+		if pf.CompositeKey != group {
+			continue
+		}
+
+		a, err := readAdvisory(ctx, pf)
+		if err != nil {
+			return nil, err
+		}
+		advisories = append(advisories, a)
+	}
+
+	sort.Slice(advisories, func(i, j int) bool {
+		return advisories[i].ID < advisories[j].ID
+	})
+
+	return advisories, nil
+}
+
+// GetAdvisory gets the advisory with the id
+func GetAdvisory(ctx context.Context, ownerID int64, group, id string) (*rpm_module.Advisory, error) {
+	pv, err := GetOrCreateAdvisoryVersion(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, id+".json", group)
+	if err != nil {
+		return nil, err
+	}
+
+	return readAdvisory(ctx, pf)
+}
+
+func readAdvisory(ctx context.Context, pf *packages_model.PackageFile) (*rpm_module.Advisory, error) {
+	r, err := openInternalFile(ctx, pf)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var a *rpm_module.Advisory
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// DeleteAdvisory deletes the advisory with the id
+func DeleteAdvisory(ctx context.Context, ownerID int64, group, id string) error {
+	pv, err := GetOrCreateAdvisoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteInternalFile(ctx, pv, id+".json", group); err != nil {
+		return err
+	}
+
+	return BuildSpecificRepositoryFiles(ctx, ownerID, group)
+}
+
+// SetModuleMetadata stores the module metadata (modules.yaml) of the group
+func SetModuleMetadata(ctx context.Context, ownerID int64, group string, buf *packages_module.HashedBuffer) error {
+	if _, err := rpm_module.ParseModuleMetadata(buf); err != nil {
+		return err
+	}
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	pv, err := GetOrCreateModuleVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := addInternalFile(ctx, pv, rpm_module.ModuleMetadataFilename, group, buf); err != nil {
+		return err
+	}
+
+	return BuildSpecificRepositoryFiles(ctx, ownerID, group)
+}
+
+// DeleteModuleMetadata deletes the module metadata of the group
+func DeleteModuleMetadata(ctx context.Context, ownerID int64, group string) error {
+	pv, err := GetOrCreateModuleVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteInternalFile(ctx, pv, rpm_module.ModuleMetadataFilename, group); err != nil {
+		return err
+	}
+
+	return BuildSpecificRepositoryFiles(ctx, ownerID, group)
+}
+
+// https://github.com/rpm-software-management/createrepo_c/blob/master/src/xml_dump_updateinfo.c
+func buildUpdateinfo(ctx context.Context, pv *packages_model.PackageVersion, ownerID int64, pfs []*packages_model.PackageFile, c packageCache, group string) (*repoData, error) {
+	type Date struct {
+		Date string `xml:"date,attr"`
+	}
+
+	type Reference struct {
+		Href  string `xml:"href,attr"`
+		ID    string `xml:"id,attr,omitempty"`
+		Type  string `xml:"type,attr,omitempty"`
+		Title string `xml:"title,attr,omitempty"`
+	}
+
+	type Sum struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+
+	type Package struct {
+		Name            string `xml:"name,attr"`
+		Version         string `xml:"version,attr"`
+		Release         string `xml:"release,attr"`
+		Epoch           string `xml:"epoch,attr"`
+		Architecture    string `xml:"arch,attr"`
+		Src             string `xml:"src,attr,omitempty"`
+		Filename        string `xml:"filename"`
+		Sum             *Sum   `xml:"sum,omitempty"`
+		RebootSuggested string `xml:"reboot_suggested,omitempty"`
+	}
+
+	type Module struct {
+		Name         string `xml:"name,attr"`
+		Stream       string `xml:"stream,attr"`
+		Version      uint64 `xml:"version,attr"`
+		Context      string `xml:"context,attr"`
+		Architecture string `xml:"arch,attr"`
+	}
+
+	type Collection struct {
+		Short    string     `xml:"short,attr"`
+		Name     string     `xml:"name"`
+		Module   *Module    `xml:"module,omitempty"`
+		Packages []*Package `xml:"package"`
+	}
+
+	type Update struct {
+		From        string        `xml:"from,attr"`
+		Status      string        `xml:"status,attr"`
+		Type        string        `xml:"type,attr"`
+		Version     string        `xml:"version,attr"`
+		ID          string        `xml:"id"`
+		Title       string        `xml:"title"`
+		Issued      Date          `xml:"issued"`
+		Updated     Date          `xml:"updated"`
+		Rights      string        `xml:"rights,omitempty"`
+		Release     string        `xml:"release,omitempty"`
+		Severity    string        `xml:"severity,omitempty"`
+		Summary     string        `xml:"summary,omitempty"`
+		Description string        `xml:"description"`
+		Solution    string        `xml:"solution,omitempty"`
+		References  []*Reference  `xml:"references>reference"`
+		Collections []*Collection `xml:"pkglist>collection"`
+	}
+
+	type Updates struct {
+		XMLName xml.Name  `xml:"updates"`
+		Updates []*Update `xml:"update"`
+	}
+
+	advisories, err := GetAdvisories(ctx, ownerID, group)
+	if err != nil {
+		return nil, err
+	}
+	if len(advisories) == 0 {
+		if err := deleteInternalFile(ctx, pv, "updateinfo.xml.gz", group); err != nil && !errors.Is(err, util.ErrNotExist) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Map the NEVRA of the available packages to their files to add the checksums
+	available := make(map[string]*packages_model.PackageFile, len(pfs))
+	for _, pf := range pfs {
+		pd := c[pf]
+
+		epoch := pd.FileMetadata.Epoch
+		if epoch == "" {
+			epoch = "0"
+		}
+
+		nevra := &rpm_module.NEVRA{
+			Name:         pd.Package.Name,
+			Epoch:        epoch,
+			Version:      pd.FileMetadata.Version,
+			Release:      pd.FileMetadata.Release,
+			Architecture: pd.FileMetadata.Architecture,
+		}
+		available[nevra.String()] = pf
+	}
+
+	updates := make([]*Update, 0, len(advisories))
+	for _, a := range advisories {
+		collection := &Collection{
+			Short: group,
+			Name:  group,
+		}
+		if collection.Short == "" {
+			collection.Short = "default"
+			collection.Name = "default"
+		}
+		if a.Module != nil {
+			collection.Module = &Module{
+				Name:         a.Module.Name,
+				Stream:       a.Module.Stream,
+				Version:      a.Module.Version,
+				Context:      a.Module.Context,
+				Architecture: a.Module.Architecture,
+			}
+		}
+
+		for _, p := range a.Packages {
+			nevra, err := rpm_module.ParseNEVRA(p)
+			if err != nil {
+				continue
+			}
+
+			pkg := &Package{
+				Name:         nevra.Name,
+				Version:      nevra.Version,
+				Release:      nevra.Release,
+				Epoch:        nevra.Epoch,
+				Architecture: nevra.Architecture,
+				Filename:     nevra.Filename(),
+			}
+			if pf, ok := available[nevra.String()]; ok {
+				pd := c[pf]
+
+				pkg.Src = pd.FileMetadata.SourceRpm
+				pkg.Filename = pf.Name
+				pkg.Sum = &Sum{
+					Type:  "sha256",
+					Value: pd.Blob.HashSHA256,
+				}
+			}
+			if a.RebootSuggested {
+				pkg.RebootSuggested = "True"
+			}
+			collection.Packages = append(collection.Packages, pkg)
+		}
+
+		references := make([]*Reference, 0, len(a.References))
+		for _, ref := range a.References {
+			references = append(references, &Reference{
+				Href:  ref.Href,
+				ID:    ref.ID,
+				Type:  ref.Type,
+				Title: ref.Title,
+			})
+		}
+
+		updates = append(updates, &Update{
+			From:        setting.AppName,
+			Status:      a.Status,
+			Type:        a.Type,
+			Version:     "1",
+			ID:          a.ID,
+			Title:       a.Title,
+			Issued:      Date{a.Issued.Format(rpm_module.AdvisoryDateFormat)},
+			Updated:     Date{a.Updated.Format(rpm_module.AdvisoryDateFormat)},
+			Rights:      a.Rights,
+			Release:     a.Release,
+			Severity:    a.Severity,
+			Summary:     a.Summary,
+			Description: a.Description,
+			Solution:    a.Solution,
+			References:  references,
+			Collections: []*Collection{collection},
+		})
+	}
+
+	return addDataAsFileToRepo(ctx, pv, "updateinfo", &Updates{
+		Updates: updates,
+	}, group)
+}
+
+// https://github.com/rpm-software-management/createrepo_c/blob/master/src/modifyrepo_c.c
+func buildModules(ctx context.Context, pv *packages_model.PackageVersion, ownerID int64, group string) (*repoData, error) {
+	mv, err := GetOrCreateModuleVersion(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := packages_model.GetFileForVersionByName(ctx, mv.ID, rpm_module.ModuleMetadataFilename, group)
+	if err != nil {
+		if !errors.Is(err, util.ErrNotExist) {
+			return nil, err
+		}
+		if err := deleteInternalFile(ctx, pv, rpm_module.ModuleMetadataFilename+".gz", group); err != nil && !errors.Is(err, util.ErrNotExist) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	r, err := openInternalFile(ctx, pf)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return addContentAsFileToRepo(ctx, pv, "modules", rpm_module.ModuleMetadataFilename+".gz", func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	}, group)
+}
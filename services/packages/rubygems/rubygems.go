@@ -0,0 +1,209 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package rubygems
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/optional"
+	rubygems_module "code.gitea.io/gitea/modules/packages/rubygems"
+	"code.gitea.io/gitea/modules/util"
+)
+
+var (
+	ErrNotOwner          = util.NewPermissionDeniedErrorf("you are not an owner of this gem")
+	ErrOwnerAlreadyExist = util.NewAlreadyExistErrorf("user is already an owner of this gem")
+	ErrOwnerNotExist     = util.NewNotExistErrorf("user is not an owner of this gem")
+	ErrLastOwner         = util.NewInvalidArgumentErrorf("the last owner of a gem can not be removed")
+	ErrOTPMissing        = util.NewPermissionDeniedErrorf("You have enabled multifactor authentication but no OTP code provided. Please fill it and retry.")
+	ErrOTPInvalid        = util.NewPermissionDeniedErrorf("Your OTP code is incorrect. Please check it and retry.")
+	ErrMFANotEnrolled    = util.NewPermissionDeniedErrorf("This gem requires owners to enable MFA. You must enable MFA before pushing new versions.")
+)
+
+func getStoredOwnerIDs(ctx context.Context, p *packages_model.Package) ([]int64, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypePackage, p.ID, rubygems_module.PropertyOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(pps))
+	for _, pp := range pps {
+		id, err := strconv.ParseInt(pp.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// getOwnerIDs gets the ids of the owners of the gem.
+// Gems uploaded before owners were tracked are owned by the creator of their first version.
+func getOwnerIDs(ctx context.Context, p *packages_model.Package) ([]int64, error) {
+	ids, err := getStoredOwnerIDs(ctx, p)
+	if err != nil || len(ids) > 0 {
+		return ids, err
+	}
+
+	pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+		PackageID:  p.ID,
+		IsInternal: optional.Some(false),
+		Sort:       packages_model.SortCreatedAsc,
+		Paginator:  db.NewAbsoluteListOptions(0, 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pvs) == 0 || pvs[0].CreatorID <= 0 {
+		return ids, nil
+	}
+	return []int64{pvs[0].CreatorID}, nil
+}
+
+// storeImplicitOwner stores the implicit owner of a gem uploaded before owners were tracked,
+// so changing the owners does not drop it.
+func storeImplicitOwner(ctx context.Context, p *packages_model.Package) error {
+	stored, err := getStoredOwnerIDs(ctx, p)
+	if err != nil || len(stored) > 0 {
+		return err
+	}
+
+	ids, err := getOwnerIDs(ctx, p)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypePackage, p.ID, rubygems_module.PropertyOwner, strconv.FormatInt(id, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OwnerProperties gets the package properties which make the user the first owner of a new gem
+func OwnerProperties(u *user_model.User) map[string]string {
+	return map[string]string{
+		rubygems_module.PropertyOwner: strconv.FormatInt(u.ID, 10),
+	}
+}
+
+// GetOwners gets the owners of the gem
+func GetOwners(ctx context.Context, p *packages_model.Package) ([]*user_model.User, error) {
+	ids, err := getOwnerIDs(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []*user_model.User{}, nil
+	}
+	return user_model.GetUsersByIDs(ctx, ids)
+}
+
+// CheckOwner checks if the user may push, yank or manage the owners of the gem.
+// Gems without any owner can only be managed by admins.
+func CheckOwner(ctx context.Context, p *packages_model.Package, doer *user_model.User) error {
+	if doer.IsAdmin {
+		return nil
+	}
+
+	ids, err := getOwnerIDs(ctx, p)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == doer.ID {
+			return nil
+		}
+	}
+	return ErrNotOwner
+}
+
+// AddOwner adds the user to the owners of the gem
+func AddOwner(ctx context.Context, p *packages_model.Package, u *user_model.User) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := storeImplicitOwner(ctx, p); err != nil {
+			return err
+		}
+
+		ids, err := getStoredOwnerIDs(ctx, p)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if id == u.ID {
+				return ErrOwnerAlreadyExist
+			}
+		}
+
+		_, err = packages_model.InsertProperty(ctx, packages_model.PropertyTypePackage, p.ID, rubygems_module.PropertyOwner, strconv.FormatInt(u.ID, 10))
+		return err
+	})
+}
+
+// RemoveOwner removes the user from the owners of the gem
+func RemoveOwner(ctx context.Context, p *packages_model.Package, u *user_model.User) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := storeImplicitOwner(ctx, p); err != nil {
+			return err
+		}
+
+		pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypePackage, p.ID, rubygems_module.PropertyOwner)
+		if err != nil {
+			return err
+		}
+
+		value := strconv.FormatInt(u.ID, 10)
+		for _, pp := range pps {
+			if pp.Value != value {
+				continue
+			}
+			if len(pps) == 1 {
+				return ErrLastOwner
+			}
+			return packages_model.DeletePropertyByID(ctx, pp.ID)
+		}
+		return ErrOwnerNotExist
+	})
+}
+
+// FindOwnerCandidate searches a user by name or email
+func FindOwnerCandidate(ctx context.Context, handle string) (*user_model.User, error) {
+	if strings.Contains(handle, "@") {
+		return user_model.GetUserByEmail(ctx, handle)
+	}
+	return user_model.GetUserByName(ctx, handle)
+}
+
+// VerifyOTP validates the one-time password against the TOTP enrollment of the user.
+// A passcode can only be used once.
+func VerifyOTP(ctx context.Context, doer *user_model.User, otp string) error {
+	twofa, err := auth_model.GetTwoFactorByUID(ctx, doer.ID)
+	if err != nil {
+		if auth_model.IsErrTwoFactorNotEnrolled(err) {
+			return ErrMFANotEnrolled
+		}
+		return err
+	}
+
+	if otp == "" {
+		return ErrOTPMissing
+	}
+
+	ok, err := twofa.ValidateTOTP(otp)
+	if err != nil {
+		return err
+	}
+	if !ok || twofa.LastUsedPasscode == otp {
+		return ErrOTPInvalid
+	}
+
+	twofa.LastUsedPasscode = otp
+	return auth_model.UpdateTwoFactor(ctx, twofa)
+}
@@ -0,0 +1,306 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package swift
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	swift_module "code.gitea.io/gitea/modules/packages/swift"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/validation"
+)
+
+// BuildCollection builds the package collection of all packages in the scope
+func BuildCollection(ctx context.Context, owner *user_model.User, scope string) (*swift_module.Collection, error) {
+	ps, err := packages_model.GetPackagesByType(ctx, owner.ID, packages_model.TypeSwift)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &swift_module.Collection{
+		Name:          fmt.Sprintf("%s/%s", owner.Name, scope),
+		Overview:      fmt.Sprintf("Swift packages of the scope %s", scope),
+		Packages:      []*swift_module.CollectionPackage{},
+		FormatVersion: swift_module.CollectionFormatVersion,
+		GeneratedBy: &swift_module.CollectionAuthor{
+			Name: setting.AppName,
+		},
+	}
+
+	prefix := strings.ToLower(scope) + "."
+	for _, p := range ps {
+		if !strings.HasPrefix(p.LowerName, prefix) {
+			continue
+		}
+
+		pvs, err := packages_model.GetVersionsByPackageName(ctx, owner.ID, packages_model.TypeSwift, p.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(pvs) == 0 {
+			continue
+		}
+
+		pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(pds, func(i, j int) bool {
+			return pds[i].SemVer.GreaterThan(pds[j].SemVer)
+		})
+
+		// The revision is the time of the latest change to keep the collection stable between requests
+		if created := int64(pds[0].Version.CreatedUnix); created > c.Revision {
+			c.Revision = created
+		}
+
+		c.Packages = append(c.Packages, buildCollectionPackage(owner, pds))
+	}
+
+	sort.Slice(c.Packages, func(i, j int) bool {
+		return c.Packages[i].Identity < c.Packages[j].Identity
+	})
+
+	if c.Revision > 0 {
+		c.GeneratedAt = time.Unix(c.Revision, 0).UTC()
+	} else {
+		c.GeneratedAt = time.Now().UTC()
+	}
+
+	return c, nil
+}
+
+func buildCollectionPackage(owner *user_model.User, pds []*packages_model.PackageDescriptor) *swift_module.CollectionPackage {
+	latest := pds[0]
+	metadata := latest.Metadata.(*swift_module.Metadata)
+
+	scope := latest.PackageProperties.GetByName(swift_module.PropertyScope)
+	name := latest.PackageProperties.GetByName(swift_module.PropertyName)
+
+	cp := &swift_module.CollectionPackage{
+		URL:      metadata.RepositoryURL,
+		Identity: latest.Package.Name,
+		Summary:  metadata.Description,
+		Keywords: metadata.Keywords,
+		Versions: make([]*swift_module.CollectionVersion, 0, len(pds)),
+		License:  buildCollectionLicense(metadata.License),
+	}
+	if cp.URL == "" {
+		cp.URL = fmt.Sprintf("%sapi/packages/%s/swift/%s/%s", setting.AppURL, owner.LowerName, scope, name)
+	}
+
+	for _, pd := range pds {
+		metadata := pd.Metadata.(*swift_module.Metadata)
+
+		defaultManifest, ok := metadata.Manifests[""]
+		if !ok || defaultManifest.ToolsVersion == "" {
+			continue
+		}
+
+		cv := &swift_module.CollectionVersion{
+			Version:             pd.Version.Version,
+			Summary:             metadata.Description,
+			Manifests:           make(map[string]*swift_module.CollectionManifest),
+			DefaultToolsVersion: defaultManifest.ToolsVersion,
+			License:             buildCollectionLicense(metadata.License),
+			Signer:              metadata.Signer,
+			CreatedAt:           pd.Version.CreatedUnix.AsTime().UTC(),
+		}
+		if author := metadata.Author.String(); author != "" {
+			cv.Author = &swift_module.CollectionAuthor{Name: author}
+		}
+		for _, m := range metadata.Manifests {
+			if m.ToolsVersion == "" {
+				continue
+			}
+			cv.Manifests[m.ToolsVersion] = swift_module.NewCollectionManifest(m, name)
+		}
+		// The default manifest takes precedence over version specific manifests with the same tools version
+		cv.Manifests[defaultManifest.ToolsVersion] = swift_module.NewCollectionManifest(defaultManifest, name)
+
+		cp.Versions = append(cp.Versions, cv)
+	}
+
+	return cp
+}
+
+func buildCollectionLicense(license string) *swift_module.CollectionLicense {
+	if !validation.IsValidURL(license) {
+		return nil
+	}
+	return &swift_module.CollectionLicense{
+		URL: license,
+	}
+}
+
+// SignCollection signs the collection with the configured key.
+// The signature is a JWS of the collection with the certificate chain in the header.
+// If no key is configured, the unsigned collection is returned.
+func SignCollection(c *swift_module.Collection) (any, error) {
+	if setting.Packages.SwiftCollectionSigningKeyPath == "" || setting.Packages.SwiftCollectionSigningCertificatePath == "" {
+		return c, nil
+	}
+
+	key, err := loadSigningKey(setting.Packages.SwiftCollectionSigningKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := loadCertificates(setting.Packages.SwiftCollectionSigningCertificatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var algorithm string
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		algorithm = "RS256"
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 keys are supported")
+		}
+		algorithm = "ES256"
+	default:
+		return nil, errors.New("unsupported signing key type")
+	}
+
+	x5c := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		x5c = append(x5c, base64.StdEncoding.EncodeToString(cert.Raw))
+	}
+
+	header, err := json.Marshal(map[string]any{
+		"alg": algorithm,
+		"x5c": x5c,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		// JWS uses the concatenation of r and s instead of the ASN.1 encoding
+		r, s, signErr := ecdsa.Sign(rand.Reader, k, digest[:])
+		if signErr == nil {
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+		err = signErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &swift_module.SignedCollection{
+		Collection: c,
+		Signature: &swift_module.CollectionSignature{
+			Signature: signingInput + "." + base64.RawURLEncoding.EncodeToString(signature),
+			Certificate: &swift_module.CollectionSignatureCertificate{
+				Subject: collectionSignatureName(certs[0].Subject),
+				Issuer:  collectionSignatureName(certs[0].Issuer),
+			},
+		},
+	}, nil
+}
+
+func collectionSignatureName(name pkix.Name) *swift_module.CollectionSignatureName {
+	n := &swift_module.CollectionSignatureName{
+		CommonName: name.CommonName,
+	}
+	if len(name.OrganizationalUnit) > 0 {
+		n.OrganizationalUnit = name.OrganizationalUnit[0]
+	}
+	if len(name.Organization) > 0 {
+		n.Organization = name.Organization[0]
+	}
+	return n
+}
+
+func loadSigningKey(path string) (crypto.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("failed to decode signing key pem")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported signing key type")
+	}
+	return signer, nil
+}
+
+func loadCertificates(path string) ([]*x509.Certificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing certificate: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no signing certificate found")
+	}
+	return certs, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package swift
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+
+	swift_module "code.gitea.io/gitea/modules/packages/swift"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+func loadTrustedRoots() (*x509.CertPool, error) {
+	if setting.Packages.SwiftSigningTrustedRootsPath == "" {
+		// Use the system roots
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(setting.Packages.SwiftSigningTrustedRootsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted roots: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no trusted roots found in %s", setting.Packages.SwiftSigningTrustedRootsPath)
+	}
+	return roots, nil
+}
+
+// SignatureInfo contains the validated signatures of a package release
+type SignatureInfo struct {
+	Format                 string
+	SourceArchiveSignature []byte
+	MetadataSignature      []byte
+	Signer                 *swift_module.Signer
+}
+
+// VerifySignatures validates the signatures of the source archive and the optional metadata.
+// Both must be signed by the same certificate which chains up to a trusted root.
+func VerifySignatures(format string, sourceArchive io.Reader, sourceArchiveSignature []byte, metadata string, metadataSignature []byte) (*SignatureInfo, error) {
+	if format != swift_module.SignatureFormatCMS {
+		return nil, swift_module.ErrUnsupportedSignatureFormat
+	}
+
+	roots, err := loadTrustedRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := swift_module.VerifySignature(sourceArchiveSignature, sourceArchive, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadataSignature) > 0 {
+		metadataCert, err := swift_module.VerifySignature(metadataSignature, bytes.NewReader([]byte(metadata)), roots)
+		if err != nil {
+			return nil, err
+		}
+		if !metadataCert.Equal(cert) {
+			return nil, swift_module.ErrInvalidSignature
+		}
+	}
+
+	return &SignatureInfo{
+		Format:                 format,
+		SourceArchiveSignature: sourceArchiveSignature,
+		MetadataSignature:      metadataSignature,
+		Signer:                 swift_module.SignerFromCertificate(cert),
+	}, nil
+}
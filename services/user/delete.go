@@ -11,6 +11,8 @@ import (
 
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
+	gradle_model "code.gitea.io/gitea/models/packages/gradle"
+	lfs_model "code.gitea.io/gitea/models/packages/lfs"
 	user_model "code.gitea.io/gitea/models/user"
 )
 
@@ -33,6 +35,9 @@ func deleteUser(ctx context.Context, u *user_model.User, purge bool) (err error)
 		&user_model.EmailAddress{UID: u.ID},
 		&user_model.UserOpenID{UID: u.ID},
 		&user_model.Setting{UserID: u.ID},
+		&gradle_model.CacheStatistics{OwnerID: u.ID},
+		&lfs_model.Lock{OwnerID: u.ID},
+		&lfs_model.Lock{UserID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %w", err)
 	}
@@ -8,7 +8,9 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
@@ -92,6 +94,20 @@ AACAX/AKARNTyAAoAAA=`
 
 	rootURL := fmt.Sprintf("/api/packages/%s/alpine", user.Name)
 
+	v3PackageName := "gitea-v3"
+	v3PackageVersion := "1.0-r0"
+
+	var v3Content bytes.Buffer
+	assert.NoError(t, alpine_module.WriteADBFile(&v3Content, alpine_module.ADBSchemaPackage, alpine_module.CreateADBPackage(&alpine_module.ADBIndexEntry{
+		Name:            v3PackageName,
+		Version:         v3PackageVersion,
+		Architecture:    "x86_64",
+		VersionMetadata: &alpine_module.VersionMetadata{Description: "Gitea Test Package v3", License: "MIT"},
+		FileMetadata:    &alpine_module.FileMetadata{Size: 4096, Dependencies: []string{"so:libc.musl-x86_64.so.1"}},
+	}), nil))
+
+	var keyID []byte
+
 	t.Run("RepositoryKey", func(t *testing.T) {
 		defer tests.PrintCurrentTest(t)()
 
@@ -100,6 +116,13 @@ AACAX/AKARNTyAAoAAA=`
 
 		assert.Equal(t, "application/x-pem-file", resp.Header().Get("Content-Type"))
 		assert.Contains(t, resp.Body.String(), "-----BEGIN PUBLIC KEY-----")
+
+		block, _ := pem.Decode(resp.Body.Bytes())
+		assert.NotNil(t, block)
+		pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+		keyID, err = alpine_module.ADBKeyID(pubKey)
+		assert.NoError(t, err)
 	})
 
 	for _, branch := range branches {
@@ -234,6 +257,24 @@ AACAX/AKARNTyAAoAAA=`
 					assert.Contains(t, content, "t:1679498030\n")
 				})
 
+				t.Run("ADBIndex", func(t *testing.T) {
+					defer tests.PrintCurrentTest(t)()
+
+					req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/x86_64/Packages.adb", rootURL, branch, repository))
+					resp := MakeRequest(t, req, http.StatusOK)
+
+					index, err := alpine_module.ReadADBIndex(resp.Body)
+					assert.NoError(t, err)
+					assert.Equal(t, [][]byte{keyID}, index.SignatureKeyIDs)
+					assert.Len(t, index.Entries, 1)
+					assert.Equal(t, packageName, index.Entries[0].Name)
+					assert.Equal(t, packageVersion, index.Entries[0].Version)
+					assert.Equal(t, "x86_64", index.Entries[0].Architecture)
+					assert.Equal(t, "Gitea Test Package", index.Entries[0].VersionMetadata.Description)
+					assert.Equal(t, "Q1/se1PjO94hYXbfpNR1/61hVORIc=", index.Entries[0].FileMetadata.Checksum)
+					assert.EqualValues(t, 1353, index.Entries[0].FileSize)
+				})
+
 				t.Run("Download", func(t *testing.T) {
 					defer tests.PrintCurrentTest(t)()
 
@@ -269,6 +310,56 @@ AACAX/AKARNTyAAoAAA=`
 						AddBasicAuth(user.Name)
 					MakeRequest(t, req, http.StatusNoContent)
 				})
+
+				t.Run("PackageV3", func(t *testing.T) {
+					defer tests.PrintCurrentTest(t)()
+
+					req := NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/%s/%s", rootURL, branch, repository), bytes.NewReader(v3Content.Bytes())).
+						AddBasicAuth(user.Name)
+					MakeRequest(t, req, http.StatusCreated)
+
+					// apk-tools v3 packages are only listed in the ADB index
+					req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/x86_64/APKINDEX.tar.gz", rootURL, branch, repository))
+					resp := MakeRequest(t, req, http.StatusOK)
+
+					content, err := readIndexContent(resp.Body)
+					assert.NoError(t, err)
+					assert.Contains(t, content, "P:"+packageName+"\n")
+					assert.NotContains(t, content, "P:"+v3PackageName+"\n")
+
+					req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/x86_64/Packages.adb", rootURL, branch, repository))
+					resp = MakeRequest(t, req, http.StatusOK)
+
+					index, err := alpine_module.ReadADBIndex(resp.Body)
+					assert.NoError(t, err)
+					assert.Equal(t, [][]byte{keyID}, index.SignatureKeyIDs)
+					assert.Len(t, index.Entries, 2)
+
+					var entry *alpine_module.ADBIndexEntry
+					for _, e := range index.Entries {
+						if e.Name == v3PackageName {
+							entry = e
+						}
+					}
+					if assert.NotNil(t, entry) {
+						assert.Equal(t, v3PackageVersion, entry.Version)
+						assert.Equal(t, "x86_64", entry.Architecture)
+						assert.Equal(t, "Gitea Test Package v3", entry.VersionMetadata.Description)
+						assert.Equal(t, "MIT", entry.VersionMetadata.License)
+						assert.EqualValues(t, 4096, entry.FileMetadata.Size)
+						assert.EqualValues(t, v3Content.Len(), entry.FileSize)
+						assert.Equal(t, []string{"so:libc.musl-x86_64.so.1"}, entry.FileMetadata.Dependencies)
+						assert.Regexp(t, `\AQ2`, entry.FileMetadata.Checksum)
+					}
+
+					req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/x86_64/%s-%s.apk", rootURL, branch, repository, v3PackageName, v3PackageVersion))
+					resp = MakeRequest(t, req, http.StatusOK)
+					assert.Equal(t, v3Content.Bytes(), resp.Body.Bytes())
+
+					req = NewRequest(t, "DELETE", fmt.Sprintf("%s/%s/%s/x86_64/%s-%s.apk", rootURL, branch, repository, v3PackageName, v3PackageVersion)).
+						AddBasicAuth(user.Name)
+					MakeRequest(t, req, http.StatusNoContent)
+				})
 			})
 		}
 	}
@@ -288,6 +379,9 @@ AACAX/AKARNTyAAoAAA=`
 				// Deleting the last file of an architecture should remove that index
 				req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/x86_64/APKINDEX.tar.gz", rootURL, branch, repository))
 				MakeRequest(t, req, http.StatusNotFound)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/x86_64/Packages.adb", rootURL, branch, repository))
+				MakeRequest(t, req, http.StatusNotFound)
 			}
 		}
 	})
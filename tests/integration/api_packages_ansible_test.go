@@ -0,0 +1,253 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	ansible_module "code.gitea.io/gitea/modules/packages/ansible"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageAnsible(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	namespace := "gitea"
+	name := "test_collection"
+	packageName := namespace + "." + name
+	packageDescription := "Test Description"
+	packageReadme := "# Test Collection"
+
+	createCollection := func(version string) []byte {
+		manifest := fmt.Sprintf(`{"collection_info":{"namespace":"%s","name":"%s","version":"%s","authors":["Gitea"],"readme":"README.md","description":"%s","license":["MIT"],"dependencies":{"community.general":">=1.0.0"}}}`, namespace, name, version, packageDescription)
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		archive := tar.NewWriter(zw)
+		for filename, content := range map[string]string{
+			"MANIFEST.json": manifest,
+			"README.md":     packageReadme,
+		} {
+			archive.WriteHeader(&tar.Header{
+				Name: filename,
+				Mode: 0o600,
+				Size: int64(len(content)),
+			})
+			archive.Write([]byte(content))
+		}
+		archive.Close()
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	root := fmt.Sprintf("/api/packages/%s/ansible", user.Name)
+	apiURL := root + "/api/v3"
+	collectionURL := fmt.Sprintf("%s/collections/%s/%s/", apiURL, namespace, name)
+
+	uploadFile := func(t *testing.T, filename string, content []byte, checksum string, expectedStatus int) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", filename)
+		part.Write(content)
+		if checksum != "" {
+			writer.WriteField("sha256", checksum)
+		}
+		writer.Close()
+
+		req := NewRequestWithBody(t, "POST", apiURL+"/artifacts/collections/", body).
+			SetHeader("Content-Type", writer.FormDataContentType()).
+			AddBasicAuth(user.Name)
+		return MakeRequest(t, req, expectedStatus)
+	}
+
+	t.Run("APIRoot", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/api/")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			AvailableVersions map[string]string `json:"available_versions"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, "v3/", result.AvailableVersions["v3"])
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		content := createCollection("1.0.0")
+		filename := ansible_module.Filename(namespace, name, "1.0.0")
+
+		req := NewRequestWithBody(t, "POST", apiURL+"/artifacts/collections/", bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		uploadFile(t, filename, content, "0000", http.StatusBadRequest)
+		uploadFile(t, "invalid.tar.gz", content, "", http.StatusBadRequest)
+		uploadFile(t, filename, []byte("invalid"), "", http.StatusBadRequest)
+
+		hash := sha256.Sum256(content)
+		resp := uploadFile(t, filename, content, hex.EncodeToString(hash[:]), http.StatusAccepted)
+
+		var result struct {
+			Task string `json:"task"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeAnsible)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.NotNil(t, pd.SemVer)
+		assert.Equal(t, packageName, pd.Package.Name)
+		assert.Equal(t, "1.0.0", pd.Version.Version)
+		assert.IsType(t, &ansible_module.Metadata{}, pd.Metadata)
+		metadata := pd.Metadata.(*ansible_module.Metadata)
+		assert.Equal(t, packageDescription, metadata.Description)
+		assert.Equal(t, packageReadme, metadata.Readme)
+		assert.Equal(t, map[string]string{"community.general": ">=1.0.0"}, metadata.Dependencies)
+
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, filename, pfs[0].Name)
+		assert.True(t, pfs[0].IsLead)
+
+		assert.True(t, strings.HasSuffix(result.Task, fmt.Sprintf("%s/imports/collections/%d/", apiURL, pvs[0].ID)))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/imports/collections/%d/", apiURL, pvs[0].ID))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var task struct {
+			State string `json:"state"`
+		}
+		DecodeJSON(t, resp, &task)
+		assert.Equal(t, "completed", task.State)
+
+		uploadFile(t, filename, content, "", http.StatusConflict)
+
+		uploadFile(t, ansible_module.Filename(namespace, name, "1.1.0"), createCollection("1.1.0"), "", http.StatusAccepted)
+	})
+
+	t.Run("Collection", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/collections/%s/missing/", apiURL, namespace))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", collectionURL)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			Namespace      string `json:"namespace"`
+			Name           string `json:"name"`
+			HighestVersion struct {
+				Version string `json:"version"`
+			} `json:"highest_version"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, namespace, result.Namespace)
+		assert.Equal(t, name, result.Name)
+		assert.Equal(t, "1.1.0", result.HighestVersion.Version)
+	})
+
+	t.Run("EnumerateVersions", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", collectionURL+"versions/?limit=1")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			Meta struct {
+				Count int `json:"count"`
+			} `json:"meta"`
+			Links struct {
+				Next *string `json:"next"`
+			} `json:"links"`
+			Data []struct {
+				Version string `json:"version"`
+			} `json:"data"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, 2, result.Meta.Count)
+		assert.Len(t, result.Data, 1)
+		assert.Equal(t, "1.1.0", result.Data[0].Version)
+		assert.NotNil(t, result.Links.Next)
+
+		req = NewRequest(t, "GET", collectionURL+"versions/?limit=1&offset=1")
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &result)
+
+		assert.Len(t, result.Data, 1)
+		assert.Equal(t, "1.0.0", result.Data[0].Version)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", collectionURL+"versions/1.0.0/")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			DownloadURL string `json:"download_url"`
+			Artifact    struct {
+				Filename string `json:"filename"`
+				Sha256   string `json:"sha256"`
+			} `json:"artifact"`
+			Metadata struct {
+				Dependencies map[string]string `json:"dependencies"`
+			} `json:"metadata"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		filename := ansible_module.Filename(namespace, name, "1.0.0")
+		assert.Equal(t, filename, result.Artifact.Filename)
+		assert.Equal(t, map[string]string{"community.general": ">=1.0.0"}, result.Metadata.Dependencies)
+		assert.True(t, strings.HasSuffix(result.DownloadURL, root+"/download/"+filename))
+
+		req = NewRequest(t, "GET", root+"/download/"+filename)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, result.Artifact.Sha256, fmt.Sprintf("%x", sha256.Sum256(resp.Body.Bytes())))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", collectionURL+"versions/1.0.0/")
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		for _, version := range []string{"1.0.0", "1.1.0"} {
+			req = NewRequest(t, "DELETE", collectionURL+"versions/"+version+"/").
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNoContent)
+		}
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeAnsible)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+	})
+}
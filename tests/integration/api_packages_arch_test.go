@@ -0,0 +1,308 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/keybase/go-crypto/openpgp"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageArch(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "gitea-test"
+	packageVersion := "1.0.1-1"
+	packageArchitecture := "x86_64"
+	repository := "core"
+
+	createPackage := func(name, version, architecture string) []byte {
+		pkginfo := []byte(`pkgname = ` + name + `
+pkgver = ` + version + `
+pkgdesc = Gitea Test Package
+url = https://gitea.io/
+size = 1024
+arch = ` + architecture + `
+license = MIT
+depend = glibc`)
+
+		var buf bytes.Buffer
+		zw, _ := zstd.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+
+		for _, file := range []struct {
+			Name    string
+			Content []byte
+		}{
+			{".PKGINFO", pkginfo},
+			{"usr/bin/" + name, []byte("binary")},
+		} {
+			tw.WriteHeader(&tar.Header{
+				Name: file.Name,
+				Mode: 0o755,
+				Size: int64(len(file.Content)),
+			})
+			tw.Write(file.Content)
+		}
+
+		tw.Close()
+		zw.Close()
+
+		return buf.Bytes()
+	}
+
+	readDatabase := func(t *testing.T, content []byte) map[string]string {
+		gzr, err := gzip.NewReader(bytes.NewReader(content))
+		assert.NoError(t, err)
+
+		entries := make(map[string]string)
+
+		tr := tar.NewReader(gzr)
+		for {
+			hd, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+
+			data, err := io.ReadAll(tr)
+			assert.NoError(t, err)
+
+			entries[hd.Name] = string(data)
+		}
+		return entries
+	}
+
+	content := createPackage(packageName, packageVersion, packageArchitecture)
+	filename := arch_module.FormatFilename(packageName, packageVersion, packageArchitecture)
+
+	rootURL := fmt.Sprintf("/api/packages/%s/arch", user.Name)
+
+	var keyring openpgp.EntityList
+
+	t.Run("RepositoryKey", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+"/repository.key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, "application/pgp-keys", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Body.String(), "-----BEGIN PGP PUBLIC KEY BLOCK-----")
+
+		var err error
+		keyring, err = openpgp.ReadArmoredKeyRing(resp.Body)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		uploadURL := rootURL + "/" + repository
+
+		req := NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader([]byte{})).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeArch)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.IsType(t, &arch_module.VersionMetadata{}, pd.Metadata)
+		assert.Equal(t, packageName, pd.Package.Name)
+		assert.Equal(t, packageVersion, pd.Version.Version)
+
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, filename, pfs[0].Name)
+		assert.True(t, pfs[0].IsLead)
+
+		req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		for _, name := range []string{repository + ".db", repository + ".db.tar.gz", repository + ".files", repository + ".files.tar.gz"} {
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, packageArchitecture, name))
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			data := resp.Body.Bytes()
+
+			entries := readDatabase(t, data)
+			directory := packageName + "-" + packageVersion + "/"
+			assert.Contains(t, entries, directory)
+			assert.Contains(t, entries[directory+"desc"], "%FILENAME%\n"+filename+"\n")
+			assert.Contains(t, entries[directory+"desc"], "%NAME%\n"+packageName+"\n")
+			assert.Contains(t, entries[directory+"desc"], "%DEPENDS%\nglibc\n")
+			assert.Contains(t, entries[directory+"desc"], "%PGPSIG%\n")
+			if strings.Contains(name, ".files") {
+				assert.Equal(t, "%FILES%\nusr/bin/"+packageName+"\n", entries[directory+"files"])
+			} else {
+				assert.NotContains(t, entries, directory+"files")
+			}
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s.sig", rootURL, repository, packageArchitecture, name))
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			_, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), resp.Body)
+			assert.NoError(t, err)
+		}
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s.db", rootURL, repository, "aarch64", repository))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, packageArchitecture, filename))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s.sig", rootURL, repository, packageArchitecture, filename))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		_, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(content), resp.Body)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Signature", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		signatureURL := fmt.Sprintf("%s/%s/%s/%s.sig", rootURL, repository, packageArchitecture, filename)
+
+		req := NewRequestWithBody(t, "PUT", signatureURL, strings.NewReader("invalid")).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		e, err := openpgp.NewEntity("", "Packager", "", nil)
+		assert.NoError(t, err)
+
+		var sig bytes.Buffer
+		assert.NoError(t, openpgp.DetachSign(&sig, e, bytes.NewReader(content), nil))
+
+		req = NewRequestWithBody(t, "PUT", signatureURL, bytes.NewReader(sig.Bytes())).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "GET", signatureURL)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, sig.Bytes(), resp.Body.Bytes())
+	})
+
+	t.Run("AnyArchitecture", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		anyContent := createPackage("gitea-any", packageVersion, arch_module.AnyArch)
+
+		req := NewRequestWithBody(t, "PUT", rootURL+"/"+repository, bytes.NewReader(anyContent)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s.db", rootURL, repository, packageArchitecture, repository))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		entries := readDatabase(t, resp.Body.Bytes())
+		assert.Contains(t, entries, "gitea-any-"+packageVersion+"/")
+		assert.Contains(t, entries, packageName+"-"+packageVersion+"/")
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, packageArchitecture, arch_module.FormatFilename("gitea-any", packageVersion, arch_module.AnyArch)))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, anyContent, resp.Body.Bytes())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, packageArchitecture, filename))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, packageArchitecture, filename)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, arch_module.AnyArch, arch_module.FormatFilename("gitea-any", packageVersion, arch_module.AnyArch))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeArch)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s.db", rootURL, repository, packageArchitecture, repository))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+	t.Run("LatestVersion", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		readIndex := func(t *testing.T) map[string]string {
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s/%s.db", rootURL, repository, packageArchitecture, repository))
+			resp := MakeRequest(t, req, http.StatusOK)
+			return readDatabase(t, resp.Body.Bytes())
+		}
+
+		// upload the newer version first, the index must not depend on the upload order
+		for _, version := range []string{"1.0.10-1", "1.0.9-1"} {
+			req := NewRequestWithBody(t, "PUT", rootURL+"/"+repository, bytes.NewReader(createPackage(packageName, version, packageArchitecture))).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+		}
+
+		entries := readIndex(t)
+		assert.Contains(t, entries, packageName+"-1.0.10-1/")
+		assert.NotContains(t, entries, packageName+"-1.0.9-1/")
+
+		req := NewRequestWithBody(t, "PUT", rootURL+"/"+repository, bytes.NewReader(createPackage(packageName, "1:0.1-1", arch_module.AnyArch))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		entries = readIndex(t)
+		assert.Contains(t, entries, packageName+"-1:0.1-1/")
+		assert.NotContains(t, entries, packageName+"-1.0.10-1/")
+		assert.NotContains(t, entries, packageName+"-1.0.9-1/")
+
+		for _, file := range []struct {
+			Version      string
+			Architecture string
+		}{
+			{"1.0.10-1", packageArchitecture},
+			{"1.0.9-1", packageArchitecture},
+			{"1:0.1-1", arch_module.AnyArch},
+		} {
+			req := NewRequest(t, "DELETE", fmt.Sprintf("%s/%s/%s/%s", rootURL, repository, file.Architecture, arch_module.FormatFilename(packageName, file.Version, file.Architecture))).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNoContent)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	bazel_service "code.gitea.io/gitea/services/packages/bazel"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageBazel(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	content := []byte("bazel output file")
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+
+	actionResult := []byte("action result")
+	actionDigest := strings.Repeat("a", 64)
+
+	root := fmt.Sprintf("/api/packages/%s/bazel", user.Name)
+
+	t.Run("CAS", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "HEAD", root+"/cas/"+digest)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithBody(t, "PUT", root+"/cas/"+digest, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", root+"/cas/invalid", bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/cas/"+strings.Repeat("0", 64), bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/cas/"+digest, bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithBody(t, "PUT", root+"/cas/"+digest, bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeBazel)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.Nil(t, pd.Metadata)
+		assert.Equal(t, "cas", pd.Package.Name)
+		assert.Equal(t, digest, pd.Version.Version)
+		assert.Len(t, pd.Files, 1)
+		assert.Equal(t, digest, pd.Files[0].File.Name)
+		assert.Equal(t, int64(len(content)), pd.Files[0].Blob.Size)
+
+		req = NewRequest(t, "HEAD", root+"/cas/"+digest)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", root+"/cas/"+digest)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", root+"/ac/"+digest)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("AC", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", root+"/ac/"+actionDigest, bytes.NewReader(actionResult)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", root+"/ac/"+actionDigest)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, actionResult, resp.Body.Bytes())
+
+		overwritten := []byte("other action result")
+
+		req = NewRequestWithBody(t, "PUT", root+"/ac/"+actionDigest, bytes.NewReader(overwritten)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", root+"/ac/"+actionDigest)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, overwritten, resp.Body.Bytes())
+
+		t.Run("Expired", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+			defer test.MockVariableValue(&setting.Packages.BazelActionCacheTTL, time.Nanosecond)()
+
+			time.Sleep(time.Second)
+
+			req := NewRequest(t, "GET", root+"/ac/"+actionDigest)
+			MakeRequest(t, req, http.StatusNotFound)
+
+			req = NewRequest(t, "GET", root+"/cas/"+digest)
+			MakeRequest(t, req, http.StatusOK)
+		})
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		assert.NoError(t, bazel_service.EvictEntries(db.DefaultContext))
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeBazel)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 2)
+
+		t.Run("ActionCacheTTL", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+			defer test.MockVariableValue(&setting.Packages.BazelActionCacheTTL, time.Nanosecond)()
+
+			assert.NoError(t, bazel_service.EvictEntries(db.DefaultContext))
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeBazel)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 1)
+			assert.Equal(t, digest, pvs[0].Version)
+		})
+
+		t.Run("MaxSize", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			other := []byte("another bazel output file")
+			otherSum := sha256.Sum256(other)
+			otherDigest := hex.EncodeToString(otherSum[:])
+
+			defer test.MockVariableValue(&setting.Packages.BazelCacheMaxSize, int64(len(other)))()
+
+			time.Sleep(time.Second)
+
+			req := NewRequestWithBody(t, "PUT", root+"/cas/"+otherDigest, bytes.NewReader(other)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusOK)
+
+			assert.NoError(t, bazel_service.EvictEntries(db.DefaultContext))
+
+			req = NewRequest(t, "GET", root+"/cas/"+digest)
+			MakeRequest(t, req, http.StatusNotFound)
+
+			req = NewRequest(t, "GET", root+"/cas/"+otherDigest)
+			MakeRequest(t, req, http.StatusOK)
+		})
+	})
+}
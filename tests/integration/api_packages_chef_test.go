@@ -524,6 +524,81 @@ nwIDAQAB
 		assert.Equal(t, fmt.Sprintf("%s/versions/%s/download", packageURL, packageVersion), result.File)
 	})
 
+	t.Run("Deprecation", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		getUniverse := func(t *testing.T) map[string]map[string]any {
+			req := NewRequest(t, "GET", root+"/universe")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result map[string]map[string]any
+			DecodeJSON(t, resp, &result)
+			return result
+		}
+
+		type Result struct {
+			Deprecated  bool   `json:"deprecated"`
+			Replacement string `json:"replacement"`
+			SourceURL   string `json:"source_url"`
+			Yanked      bool   `json:"yanked"`
+		}
+
+		versionURL := fmt.Sprintf("%s/cookbooks/%s/versions/%s", root, packageName, packageVersion)
+
+		req := NewRequestWithBody(t, "PUT", versionURL, strings.NewReader(`{"yanked":true}`))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", versionURL, strings.NewReader(`{"yanked":true}`)).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result Result
+		DecodeJSON(t, resp, &result)
+		assert.True(t, result.Yanked)
+
+		assert.Empty(t, getUniverse(t))
+
+		req = NewRequestWithBody(t, "PUT", versionURL, strings.NewReader(`{"yanked":false}`)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		assert.Contains(t, getUniverse(t), packageName)
+
+		cookbookURL := fmt.Sprintf("%s/cookbooks/%s", root, packageName)
+
+		for _, body := range []string{
+			`{"deprecated":true,"replacement":"not-existing"}`,
+			`{"deprecated":true,"replacement":"` + packageName + `"}`,
+			`{"source_url":"invalid"}`,
+		} {
+			req = NewRequestWithBody(t, "PUT", cookbookURL, strings.NewReader(body)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+		}
+
+		req = NewRequestWithBody(t, "PUT", cookbookURL, strings.NewReader(`{"deprecated":true,"source_url":"https://gitea.io"}`)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		result = Result{}
+		DecodeJSON(t, resp, &result)
+		assert.True(t, result.Deprecated)
+		assert.Empty(t, result.Replacement)
+		assert.Equal(t, "https://gitea.io", result.SourceURL)
+
+		assert.Empty(t, getUniverse(t))
+
+		req = NewRequestWithBody(t, "PUT", cookbookURL, strings.NewReader(`{"deprecated":false}`)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		result = Result{}
+		DecodeJSON(t, resp, &result)
+		assert.False(t, result.Deprecated)
+
+		assert.Contains(t, getUniverse(t), packageName)
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 		uploadPackage(t, "1.0.2", http.StatusCreated)
 		uploadPackage(t, "1.0.3", http.StatusCreated)
@@ -0,0 +1,172 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	cocoapods_module "code.gitea.io/gitea/modules/packages/cocoapods"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageCocoaPods(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "GiteaKit"
+	packageVersion := "1.0.1"
+	packageSummary := "Gitea client library"
+
+	podspec := `{
+  "name": "` + packageName + `",
+  "version": "` + packageVersion + `",
+  "summary": "` + packageSummary + `",
+  "homepage": "https://gitea.io",
+  "license": { "type": "MIT" },
+  "authors": { "KN4CK3R": "dummy@gitea.io" },
+  "platforms": { "ios": "13.0" },
+  "source": { "git": "https://gitea.io/gitea/gitea-kit.git", "tag": "` + packageVersion + `" },
+  "source_files": "Sources/**/*.swift"
+}`
+	content := []byte("dummy source archive")
+	filename := packageName + "-" + packageVersion + ".zip"
+
+	rootURL := fmt.Sprintf("/api/packages/%s/cocoapods", user.Name)
+
+	uploadPackage := func(t *testing.T, podspec, sourceFilename string, content []byte, expectedStatus int) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		writer.WriteField("podspec", podspec)
+		part, _ := writer.CreateFormFile("source", sourceFilename)
+		part.Write(content)
+		writer.Close()
+
+		req := NewRequestWithBody(t, "PUT", rootURL, body).
+			SetHeader("Content-Type", writer.FormDataContentType()).
+			AddBasicAuth(user.Name)
+		return MakeRequest(t, req, expectedStatus)
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", rootURL, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		uploadPackage(t, "{", "source.zip", content, http.StatusBadRequest)
+		uploadPackage(t, podspec, "source.rar", content, http.StatusBadRequest)
+		uploadPackage(t, podspec, "source.zip", content, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeCocoaPods)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.IsType(t, &cocoapods_module.Metadata{}, pd.Metadata)
+		assert.Equal(t, packageName, pd.Package.Name)
+		assert.Equal(t, packageVersion, pd.Version.Version)
+		assert.Equal(t, packageSummary, pd.Metadata.(*cocoapods_module.Metadata).Summary)
+
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, filename, pfs[0].Name)
+		assert.True(t, pfs[0].IsLead)
+
+		uploadPackage(t, podspec, "source.zip", content, http.StatusConflict)
+	})
+
+	t.Run("Trunk", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+"/CocoaPods-version.yml")
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "prefix_lengths:")
+
+		req = NewRequest(t, "GET", rootURL+"/all_pods.txt")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, packageName+"\n", resp.Body.String())
+
+		shard := cocoapods_module.ShardPrefix(packageName)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/all_pods_versions_%s.txt", rootURL, strings.Join(shard, "_")))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, packageName+"/"+packageVersion+"\n", resp.Body.String())
+
+		req = NewRequest(t, "GET", rootURL+"/deprecated_podspecs.txt")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Empty(t, resp.Body.String())
+	})
+
+	t.Run("Podspec", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+"/"+cocoapods_module.PodspecPath(packageName, packageVersion))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			Name        string            `json:"name"`
+			Version     string            `json:"version"`
+			Source      map[string]string `json:"source"`
+			SourceFiles string            `json:"source_files"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		hash := sha256.Sum256(content)
+
+		assert.Equal(t, packageName, result.Name)
+		assert.Equal(t, packageVersion, result.Version)
+		assert.Equal(t, "Sources/**/*.swift", result.SourceFiles)
+		assert.Equal(t, fmt.Sprintf("%sapi/packages/%s/cocoapods/Pods/%s/%s/%s", setting.AppURL, user.Name, packageName, packageVersion, filename), result.Source["http"])
+		assert.Equal(t, hex.EncodeToString(hash[:]), result.Source["sha256"])
+		assert.NotContains(t, result.Source, "git")
+
+		req = NewRequest(t, "GET", rootURL+"/"+cocoapods_module.PodspecPath(strings.ToLower(packageName), packageVersion))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/Pods/%s/%s/%s", rootURL, packageName, packageVersion, filename))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/Pods/%s/%s", rootURL, packageName, packageVersion))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("%s/Pods/%s/%s", rootURL, packageName, packageVersion)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeCocoaPods)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+
+		req = NewRequest(t, "GET", rootURL+"/all_pods.txt")
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Empty(t, resp.Body.String())
+	})
+}
@@ -478,6 +478,38 @@ func TestPackageConan(t *testing.T) {
 				info := result[conanPackageReference]
 				assert.NotEmpty(t, info.Settings)
 			})
+
+			t.Run("PackageQuery", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				cases := []struct {
+					Query    string
+					Expected bool
+				}{
+					{"arch=x84_64", true},
+					{"arch=x84_64 AND shared=False", true},
+					{"arch=x84_64 AND shared=True", false},
+					{"options.shared=True OR arch=x84*", true},
+					{"os=Linux", false},
+				}
+
+				for _, c := range cases {
+					req := NewRequest(t, "GET", fmt.Sprintf("%s/v1/conans/%s/%s/%s/%s/search?q=%s", url, name, version1, user1, channel2, stdurl.QueryEscape(c.Query)))
+					resp := MakeRequest(t, req, http.StatusOK)
+
+					var result map[string]*conan_module.Conaninfo
+					DecodeJSON(t, resp, &result)
+
+					if c.Expected {
+						assert.Contains(t, result, conanPackageReference, "query: %s", c.Query)
+					} else {
+						assert.Empty(t, result, "query: %s", c.Query)
+					}
+				}
+
+				req := NewRequest(t, "GET", fmt.Sprintf("%s/v1/conans/%s/%s/%s/%s/search?q=%s", url, name, version1, user1, channel2, stdurl.QueryEscape("arch=x84_64 AND")))
+				MakeRequest(t, req, http.StatusBadRequest)
+			})
 		})
 
 		t.Run("Delete", func(t *testing.T) {
@@ -784,6 +816,67 @@ func TestPackageConan(t *testing.T) {
 				info = result[conanPackageReference]
 				assert.NotEmpty(t, info.Settings)
 			})
+
+			t.Run("List", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				type listRevision struct {
+					Timestamp int64 `json:"timestamp"`
+				}
+				type listPackage struct {
+					Info      *conan_module.Conaninfo  `json:"info"`
+					Revisions map[string]*listRevision `json:"revisions"`
+				}
+				type listRecipeRevision struct {
+					Timestamp int64                   `json:"timestamp"`
+					Packages  map[string]*listPackage `json:"packages"`
+				}
+				type listRecipe struct {
+					Revisions map[string]*listRecipeRevision `json:"revisions"`
+				}
+
+				recipeKey := fmt.Sprintf("%s/%s@%s/%s", name, version1, user1, channel1)
+
+				req := NewRequest(t, "GET", fmt.Sprintf("%s/v2/conans/%s/%s/%s/%s/list?q=%s", url, name, version1, user1, channel1, stdurl.QueryEscape("arch=x84_64 AND shared=False")))
+				resp := MakeRequest(t, req, http.StatusOK)
+
+				var result map[string]*listRecipe
+				DecodeJSON(t, resp, &result)
+
+				assert.Contains(t, result, recipeKey)
+				recipe := result[recipeKey]
+				assert.Len(t, recipe.Revisions, 2)
+				for _, recipeRevision := range []string{revision1, revision2} {
+					assert.Contains(t, recipe.Revisions, recipeRevision)
+					rr := recipe.Revisions[recipeRevision]
+					assert.NotZero(t, rr.Timestamp)
+					assert.Contains(t, rr.Packages, conanPackageReference)
+					pkg := rr.Packages[conanPackageReference]
+					assert.Equal(t, "x84_64", pkg.Info.Settings["arch"])
+					assert.Equal(t, "False", pkg.Info.Options["shared"])
+					assert.Len(t, pkg.Revisions, 2)
+					assert.Contains(t, pkg.Revisions, revision1)
+					assert.Contains(t, pkg.Revisions, revision2)
+				}
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/v2/conans/%s/%s/%s/%s/revisions/%s/list?q=%s", url, name, version1, user1, channel1, revision1, stdurl.QueryEscape("shared=True")))
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				result = make(map[string]*listRecipe)
+				DecodeJSON(t, resp, &result)
+
+				assert.Contains(t, result, recipeKey)
+				recipe = result[recipeKey]
+				assert.Len(t, recipe.Revisions, 1)
+				assert.Contains(t, recipe.Revisions, revision1)
+				assert.Empty(t, recipe.Revisions[revision1].Packages)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/v2/conans/%s/%s/%s/%s/revisions/%s/list", url, name, version1, user1, channel1, "unknown"))
+				MakeRequest(t, req, http.StatusNotFound)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/v2/conans/%s/%s/%s/%s/list?q=%s", url, name, version1, user1, channel1, stdurl.QueryEscape("(arch=x84_64")))
+				MakeRequest(t, req, http.StatusBadRequest)
+			})
 		})
 
 		t.Run("Delete", func(t *testing.T) {
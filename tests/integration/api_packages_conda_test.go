@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"code.gitea.io/gitea/models/db"
@@ -271,4 +272,96 @@ func TestPackageConda(t *testing.T) {
 			assert.Equal(t, pd.Files[0].Blob.Size, packageInfo.Size)
 		})
 	})
+
+	t.Run("ChannelData", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/channeldata.json", root, channel))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result conda_module.ChannelData
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, 1, result.ChannelDataVersion)
+		assert.Equal(t, []string{"noarch"}, result.Subdirs)
+		assert.Contains(t, result.Packages, packageName)
+		assert.Equal(t, packageVersion, result.Packages[packageName].Version)
+		assert.Equal(t, []string{"noarch"}, result.Packages[packageName].Subdirs)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/not-existing/channeldata.json", root))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("RunExports", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/noarch/run_exports.json", root, channel))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+		var result conda_module.RunExportsData
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, "noarch", result.Info.Subdir)
+		assert.Empty(t, result.Packages)
+		assert.Contains(t, result.PackagesConda, fmt.Sprintf("%s-%s-xxx.conda", packageName, packageVersion))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/noarch/run_exports.json.bz2", root, channel))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "application/x-bzip2", resp.Header().Get("Content-Type"))
+	})
+
+	t.Run("PatchInstructions", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		filename := fmt.Sprintf("%s-%s-xxx.tar.bz2", packageName, packageVersion)
+		url := fmt.Sprintf("%s/noarch/patch_instructions.json", root)
+
+		req := NewRequest(t, "GET", url)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		body := `{"packages":{"` + filename + `":{"depends":["python >=3.8"]}}}`
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(body))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"packages":{"`+filename+`":{"name":"other"}}}`)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(body)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "GET", url)
+		MakeRequest(t, req, http.StatusOK)
+
+		getRepoData := func(t *testing.T) *conda_module.RepoData {
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/noarch/repodata.json", root))
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result *conda_module.RepoData
+			DecodeJSON(t, resp, &result)
+			return result
+		}
+
+		result := getRepoData(t)
+		assert.Contains(t, result.Packages, filename)
+		assert.Equal(t, []string{"python >=3.8"}, result.Packages[filename].Dependencies)
+
+		req = NewRequest(t, "DELETE", url).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", url).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		result = getRepoData(t)
+		assert.Empty(t, result.Packages[filename].Dependencies)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeConda)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 2)
+	})
 }
@@ -9,9 +9,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
@@ -19,11 +22,13 @@ import (
 	container_model "code.gitea.io/gitea/models/packages/container"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
 	container_module "code.gitea.io/gitea/modules/packages/container"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/test"
 	package_service "code.gitea.io/gitea/services/packages"
+	container_service "code.gitea.io/gitea/services/packages/container"
 	"code.gitea.io/gitea/tests"
 
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
@@ -684,6 +689,117 @@ func TestPackageContainer(t *testing.T) {
 				assert.Len(t, apiPackages, 4) // "latest", "main", "multi", "sha256:..."
 			})
 
+			t.Run("CreateImageIndex", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				writeToken := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWritePackage)
+				indexURL := fmt.Sprintf("/api/v1/packages/%s/container/%s/index", user.Name, strings.ReplaceAll(image, "/", "%2F"))
+				assembledTag := "assembled"
+
+				req := NewRequestWithJSON(t, "POST", indexURL, &api.CreateContainerImageIndexOption{
+					Tag:       assembledTag,
+					Manifests: []string{tags[0], untaggedManifestDigest},
+				}).AddTokenAuth(token)
+				MakeRequest(t, req, http.StatusForbidden)
+
+				req = NewRequestWithJSON(t, "POST", indexURL, &api.CreateContainerImageIndexOption{
+					Tag:       assembledTag,
+					Manifests: []string{unknownDigest},
+				}).AddTokenAuth(writeToken)
+				MakeRequest(t, req, http.StatusNotFound)
+
+				req = NewRequestWithJSON(t, "POST", indexURL, &api.CreateContainerImageIndexOption{
+					Tag:       assembledTag,
+					Manifests: []string{multiTag},
+				}).AddTokenAuth(writeToken)
+				MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+				req = NewRequestWithJSON(t, "POST", indexURL, &api.CreateContainerImageIndexOption{
+					Tag:       assembledTag,
+					Manifests: []string{tags[0], manifestDigest},
+				}).AddTokenAuth(writeToken)
+				MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+				req = NewRequestWithJSON(t, "POST", indexURL, &api.CreateContainerImageIndexOption{
+					Tag:       assembledTag,
+					Manifests: []string{tags[0], untaggedManifestDigest},
+				}).AddTokenAuth(writeToken)
+				resp := MakeRequest(t, req, http.StatusCreated)
+
+				var apiIndex *api.ContainerImageIndex
+				DecodeJSON(t, resp, &apiIndex)
+				assert.Equal(t, image, apiIndex.Image)
+				assert.Equal(t, assembledTag, apiIndex.Tag)
+				assert.Equal(t, oci.MediaTypeImageIndex, apiIndex.MediaType)
+				assert.Len(t, apiIndex.Manifests, 2)
+				assert.Equal(t, manifestDigest, apiIndex.Manifests[0].Digest)
+				assert.Equal(t, "application/vnd.docker.distribution.manifest.v2+json", apiIndex.Manifests[0].MediaType)
+				assert.Equal(t, untaggedManifestDigest, apiIndex.Manifests[1].Digest)
+				assert.Equal(t, oci.MediaTypeImageManifest, apiIndex.Manifests[1].MediaType)
+				for _, m := range apiIndex.Manifests {
+					assert.Equal(t, "linux", m.OS)
+					assert.Equal(t, "amd64", m.Architecture)
+				}
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/manifests/%s", url, assembledTag)).
+					AddTokenAuth(userToken)
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				assert.Equal(t, oci.MediaTypeImageIndex, resp.Header().Get("Content-Type"))
+				assert.Equal(t, apiIndex.Digest, resp.Header().Get("Docker-Content-Digest"))
+
+				var index oci.Index
+				DecodeJSON(t, resp, &index)
+				assert.Len(t, index.Manifests, 2)
+				assert.Equal(t, "amd64", index.Manifests[0].Platform.Architecture)
+
+				pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeContainer, image, assembledTag)
+				assert.NoError(t, err)
+				pd, err := packages_model.GetPackageDescriptor(db.DefaultContext, pv)
+				assert.NoError(t, err)
+				metadata := pd.Metadata.(*container_module.Metadata)
+				assert.Len(t, metadata.Manifests, 2)
+				for _, m := range metadata.Manifests {
+					assert.Equal(t, "linux/amd64", m.Platform)
+				}
+
+				req = NewRequest(t, "DELETE", fmt.Sprintf("%s/manifests/%s", url, assembledTag)).
+					AddTokenAuth(userToken)
+				MakeRequest(t, req, http.StatusAccepted)
+			})
+
+			t.Run("ImmutableTags", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				rule := &container_model.ImmutableTagRule{
+					OwnerID: user.ID,
+					Image:   image,
+					Pattern: "mul*",
+				}
+				assert.NoError(t, container_model.InsertImmutableTagRule(db.DefaultContext, rule))
+
+				req := NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/manifests/%s", url, multiTag), strings.NewReader(indexManifestContent)).
+					AddTokenAuth(userToken).
+					SetHeader("Content-Type", oci.MediaTypeImageIndex)
+				MakeRequest(t, req, http.StatusCreated)
+
+				req = NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/manifests/%s", url, multiTag), strings.NewReader(manifestContent)).
+					AddTokenAuth(userToken).
+					SetHeader("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+				MakeRequest(t, req, http.StatusForbidden)
+
+				req = NewRequest(t, "DELETE", fmt.Sprintf("%s/manifests/%s", url, multiTag)).
+					AddTokenAuth(userToken)
+				MakeRequest(t, req, http.StatusForbidden)
+
+				req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, multiTag)).
+					AddTokenAuth(userToken)
+				resp := MakeRequest(t, req, http.StatusOK)
+				assert.Equal(t, indexManifestDigest, resp.Header().Get("Docker-Content-Digest"))
+
+				assert.NoError(t, container_model.DeleteImmutableTagRule(db.DefaultContext, user.ID, rule.ID))
+			})
+
 			t.Run("Delete", func(t *testing.T) {
 				t.Run("Blob", func(t *testing.T) {
 					defer tests.PrintCurrentTest(t)()
@@ -802,3 +918,114 @@ func TestPackageContainer(t *testing.T) {
 		session.MakeRequest(t, req, http.StatusSeeOther)
 	})
 }
+
+func TestPackageContainerNotifications(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	var mu sync.Mutex
+	var events []*container_module.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, container_module.ContentTypeEventEnvelope, r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		var envelope container_module.EventEnvelope
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&envelope))
+
+		mu.Lock()
+		events = append(events, envelope.Events...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	defer test.MockVariableValue(&setting.Packages.ContainerNotificationEndpoints, []*setting.ContainerNotificationEndpoint{
+		{
+			Name:           "test",
+			URL:            server.URL,
+			Headers:        map[string]string{"Authorization": "secret"},
+			Timeout:        5 * time.Second,
+			MaxAttempts:    1,
+			IgnoredActions: []string{container_module.EventActionMount},
+		},
+	})()
+	assert.NoError(t, container_service.InitNotifications())
+	defer container_service.ShutdownNotifications()
+
+	image := "notify"
+	url := fmt.Sprintf("%sv2/%s/%s", setting.AppURL, user.Name, image)
+	repository := user.LowerName + "/" + image
+
+	blobDigest := "sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"
+	blobContent, _ := base64.StdEncoding.DecodeString(`H4sIAAAJbogA/2IYBaNgFIxYAAgAAP//Lq+17wAEAAA=`)
+	configDigest := "sha256:4607e093bec406eaadb6f3a340f63400c9d3a7038680744c406903766b938f0d"
+	configContent := `{"architecture":"amd64","config":{"Env":["PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"],"Cmd":["/true"],"ArgsEscaped":true,"Image":"sha256:9bd8b88dc68b80cffe126cc820e4b52c6e558eb3b37680bfee8e5f3ed7b8c257"},"container":"b89fe92a887d55c0961f02bdfbfd8ac3ddf66167db374770d2d9e9fab3311510","container_config":{"Hostname":"b89fe92a887d","Env":["PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"],"Cmd":["/bin/sh","-c","#(nop) ","CMD [\"/true\"]"],"ArgsEscaped":true,"Image":"sha256:9bd8b88dc68b80cffe126cc820e4b52c6e558eb3b37680bfee8e5f3ed7b8c257"},"created":"2022-01-01T00:00:00.000000000Z","docker_version":"20.10.12","history":[{"created":"2022-01-01T00:00:00.000000000Z","created_by":"/bin/sh -c #(nop) COPY file:0e7589b0c800daaf6fa460d2677101e4676dd9491980210cb345480e513f3602 in /true "},{"created":"2022-01-01T00:00:00.000000001Z","created_by":"/bin/sh -c #(nop)  CMD [\"/true\"]","empty_layer":true}],"os":"linux","rootfs":{"type":"layers","diff_ids":["sha256:0ff3b91bdf21ecdf2f2f3d4372c2098a14dbe06cd678e8f0a85fd4902d00e2e2"]}}`
+	manifestDigest := "sha256:4f10484d1c1bb13e3956b4de1cd42db8e0f14a75be1617b60f2de3cd59c803c6"
+	manifestContent := `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"sha256:4607e093bec406eaadb6f3a340f63400c9d3a7038680744c406903766b938f0d","size":1069},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4","size":32}]}`
+
+	req := NewRequest(t, "GET", fmt.Sprintf("%sv2/token", setting.AppURL)).
+		AddBasicAuth(user.Name)
+	resp := MakeRequest(t, req, http.StatusOK)
+	tokenResponse := &struct {
+		Token string `json:"token"`
+	}{}
+	DecodeJSON(t, resp, &tokenResponse)
+	userToken := fmt.Sprintf("Bearer %s", tokenResponse.Token)
+
+	req = NewRequestWithBody(t, "POST", fmt.Sprintf("%s/blobs/uploads?digest=%s", url, blobDigest), bytes.NewReader(blobContent)).
+		AddTokenAuth(userToken)
+	MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequestWithBody(t, "POST", fmt.Sprintf("%s/blobs/uploads?digest=%s", url, configDigest), strings.NewReader(configContent)).
+		AddTokenAuth(userToken)
+	MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/manifests/latest", url), strings.NewReader(manifestContent)).
+		AddTokenAuth(userToken).
+		SetHeader("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+	MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/latest", url)).
+		AddTokenAuth(userToken)
+	MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequest(t, "GET", fmt.Sprintf("%s/manifests/latest", url)).
+		AddTokenAuth(userToken)
+	MakeRequest(t, req, http.StatusOK)
+
+	getEvents := func() []*container_module.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(events)
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(getEvents()) == 4
+	}, 10*time.Second, 100*time.Millisecond)
+
+	received := getEvents()
+	assert.Len(t, received, 4)
+	for _, event := range received {
+		assert.NotEmpty(t, event.ID)
+		assert.Equal(t, repository, event.Target.Repository)
+		assert.Equal(t, user.Name, event.Actor.Name)
+	}
+
+	var manifestEvents []*container_module.Event
+	for _, event := range received {
+		if event.Target.MediaType != container_module.BlobMediaType {
+			manifestEvents = append(manifestEvents, event)
+		}
+	}
+	assert.Len(t, manifestEvents, 2)
+	actions := make([]string, 0, len(manifestEvents))
+	for _, event := range manifestEvents {
+		actions = append(actions, event.Action)
+		assert.Equal(t, manifestDigest, event.Target.Digest)
+		assert.Equal(t, "latest", event.Target.Tag)
+		assert.Equal(t, "application/vnd.docker.distribution.manifest.v2+json", event.Target.MediaType)
+		assert.EqualValues(t, len(manifestContent), event.Target.Size)
+		assert.Equal(t, fmt.Sprintf("%s/manifests/%s", url, manifestDigest), event.Target.URL)
+	}
+	assert.ElementsMatch(t, []string{container_module.EventActionPush, container_module.EventActionPull}, actions)
+}
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io"
 	"net/http"
 	"testing"
 
@@ -187,6 +188,19 @@ func TestPackageCran(t *testing.T) {
 				createDescription(packageName, packageVersion),
 			)).AddBasicAuth(user.Name)
 			MakeRequest(t, req, http.StatusConflict)
+
+			// R patch versions share the binaries of the major.minor version
+			req = NewRequestWithBody(t, "PUT", url+"/bin?platform=windows&rversion=4.2.3", createArchive(
+				"package/DESCRIPTION",
+				createDescription(packageName, packageVersion),
+			)).AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusConflict)
+
+			req = NewRequestWithBody(t, "PUT", url+"/bin?platform=macosx/big-sur-arm64&rversion=4.3.1", createArchive(
+				"package/DESCRIPTION",
+				createDescription(packageName, packageVersion),
+			)).AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusCreated)
 		})
 
 		t.Run("Download", func(t *testing.T) {
@@ -200,6 +214,8 @@ func TestPackageCran(t *testing.T) {
 				{"osx", "4.2", http.StatusNotFound},
 				{"windows", "4.1", http.StatusNotFound},
 				{"windows", "4.2", http.StatusOK},
+				{"macosx/big-sur-arm64", "4.2", http.StatusNotFound},
+				{"macosx/big-sur-arm64", "4.3", http.StatusOK},
 			}
 
 			for _, c := range cases {
@@ -231,5 +247,95 @@ func TestPackageCran(t *testing.T) {
 
 			assert.Contains(t, resp.Header().Get("Content-Type"), "application/x-gzip")
 		})
+
+		t.Run("FullRVersion", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			// Binaries uploaded before the R version got normalized store the full version
+			pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeCran, packageName, packageVersion)
+			assert.NoError(t, err)
+			pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+			assert.NoError(t, err)
+
+			updated := false
+			for _, pf := range pfs {
+				pps, err := packages.GetPropertiesByName(db.DefaultContext, packages.PropertyTypeFile, pf.ID, cran_module.PropertyRVersion)
+				assert.NoError(t, err)
+				for _, pp := range pps {
+					if pp.Value == "4.3" {
+						pp.Value = "4.3.1"
+						assert.NoError(t, packages.UpdateProperty(db.DefaultContext, pp))
+						updated = true
+					}
+				}
+			}
+			assert.True(t, updated)
+
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/bin/macosx/big-sur-arm64/contrib/4.3/%s_%s.zip", url, packageName, packageVersion)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusOK)
+
+			req = NewRequest(t, "GET", url+"/bin/macosx/big-sur-arm64/contrib/4.3/PACKAGES").
+				AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+			assert.Contains(t, resp.Body.String(), fmt.Sprintf("Package: %s", packageName))
+
+			req = NewRequest(t, "GET", url+"/bin/macosx/big-sur-arm64/contrib/4.30/PACKAGES").
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		newPackageVersion := "1.0.4"
+
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		content := createDescription(packageName, newPackageVersion)
+		tw.WriteHeader(&tar.Header{
+			Name: "package/DESCRIPTION",
+			Mode: 0o600,
+			Size: int64(len(content)),
+		})
+		tw.Write(content)
+		tw.Close()
+		gw.Close()
+
+		req := NewRequestWithBody(t, "PUT", url+"/src", &buf).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "GET", url+"/src/contrib/PACKAGES").
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("Version: %s", newPackageVersion))
+
+		// The new version has no binary, so the binary listing still contains the older version
+		req = NewRequest(t, "GET", url+"/bin/windows/contrib/4.2/PACKAGES").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("Version: %s", packageVersion))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/src/contrib/Archive/%s/%s_%s.tar.gz", url, packageName, packageName, packageVersion)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/src/contrib/Archive/%s/%s_%s.tar.gz", url, packageName, packageName, newPackageVersion)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", url+"/src/contrib/Meta/archive.rds").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		gzr, err := gzip.NewReader(resp.Body)
+		assert.NoError(t, err)
+		index, err := io.ReadAll(gzr)
+		assert.NoError(t, err)
+		assert.Contains(t, string(index), fmt.Sprintf("%s/%s_%s.tar.gz", packageName, packageName, packageVersion))
+		assert.NotContains(t, string(index), fmt.Sprintf("%s/%s_%s.tar.gz", packageName, packageName, newPackageVersion))
 	})
 }
@@ -7,6 +7,9 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -233,6 +236,65 @@ func TestPackageDebian(t *testing.T) {
 		})
 	}
 
+	t.Run("Snapshot", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		distribution := distributions[1]
+		snapshotURL := fmt.Sprintf("%s/snapshots/release-1", rootURL)
+
+		req := NewRequest(t, "PUT", snapshotURL+"?distribution="+distribution)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "PUT", snapshotURL+"?distribution="+distribution).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "PUT", snapshotURL+"?distribution="+distribution).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequest(t, "PUT", snapshotURL+"/publish/production").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/production/Release", rootURL))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		body := resp.Body.String()
+		assert.Contains(t, body, "Suite: production\n")
+		assert.Contains(t, body, "Components: "+strings.Join(components, " ")+"\n")
+		assert.Contains(t, body, "Architectures: "+strings.Join(architectures, " ")+"\n")
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/production/%s/binary-%s/Packages", rootURL, components[0], architectures[0]))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		filename := fmt.Sprintf("%s_%s_%s.deb", packageName, packageVersion, architectures[0])
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("Filename: snapshots/release-1/pool/%s/%s\n", components[0], filename))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/pool/%s/%s", snapshotURL, components[0], filename))
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "DELETE", snapshotURL).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/snapshots", rootURL))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		assert.Contains(t, resp.Body.String(), `"published":["production"]`)
+
+		req = NewRequest(t, "DELETE", snapshotURL+"/publish/production").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/production/Release", rootURL))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "DELETE", snapshotURL).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 		defer tests.PrintCurrentTest(t)()
 
@@ -263,4 +325,119 @@ func TestPackageDebian(t *testing.T) {
 		assert.Contains(t, body, "Components: "+strings.Join(components, " ")+"\n")
 		assert.Contains(t, body, "Architectures: "+architectures[1]+"\n")
 	})
+
+	t.Run("SourcePackage", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		distribution := "sid"
+		component := components[0]
+		sourceVersion := "1.0.1-1"
+
+		origContent := []byte("orig source archive")
+		debianContent := []byte("debian directory archive")
+
+		origFilename := packageName + "_1.0.1.orig.tar.gz"
+		debianFilename := fmt.Sprintf("%s_%s.debian.tar.xz", packageName, sourceVersion)
+		dscFilename := fmt.Sprintf("%s_%s.dsc", packageName, sourceVersion)
+
+		md5Hex := func(data []byte) string {
+			h := md5.Sum(data)
+			return hex.EncodeToString(h[:])
+		}
+		sha256Hex := func(data []byte) string {
+			h := sha256.Sum256(data)
+			return hex.EncodeToString(h[:])
+		}
+
+		dsc := []byte(fmt.Sprintf(`Format: 3.0 (quilt)
+Source: %[1]s
+Binary: %[1]s
+Architecture: any
+Version: %[2]s
+Maintainer: KN4CK3R <kn4ck3r@gitea.io>
+Checksums-Sha256:
+ %[3]s %[4]d %[5]s
+ %[6]s %[7]d %[8]s
+Files:
+ %[9]s %[4]d %[5]s
+ %[10]s %[7]d %[8]s
+`, packageName, sourceVersion,
+			sha256Hex(origContent), len(origContent), origFilename,
+			sha256Hex(debianContent), len(debianContent), debianFilename,
+			md5Hex(origContent), md5Hex(debianContent)))
+
+		createChanges := func(dscHash string) []byte {
+			return []byte(fmt.Sprintf(`Format: 1.8
+Source: %[1]s
+Architecture: source
+Version: %[2]s
+Distribution: %[3]s
+Files:
+ %[4]s %[5]d web optional %[6]s
+Checksums-Sha256:
+ %[7]s %[5]d %[6]s
+ %[8]s %[9]d %[10]s
+ %[11]s %[12]d %[13]s
+`, packageName, sourceVersion, distribution,
+				md5Hex(dsc), len(dsc), dscFilename,
+				dscHash,
+				sha256Hex(origContent), len(origContent), origFilename,
+				sha256Hex(debianContent), len(debianContent), debianFilename))
+		}
+
+		uploadURL := fmt.Sprintf("%s/pool/%s/%s/upload", rootURL, distribution, component)
+		sourcesURL := fmt.Sprintf("%s/dists/%s/%s/source/Sources", rootURL, distribution, component)
+
+		upload := func(t *testing.T, filename string, content []byte, expectedStatus int) {
+			req := NewRequestWithBody(t, "PUT", uploadURL+"/"+filename, bytes.NewReader(content)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, expectedStatus)
+		}
+
+		// The files referenced by the source control file may be uploaded first
+		upload(t, origFilename, origContent, http.StatusCreated)
+		upload(t, dscFilename, dsc, http.StatusCreated)
+
+		// The source package is not listed until all referenced files are available
+		req := NewRequest(t, "GET", sourcesURL)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		upload(t, debianFilename, []byte("mismatching content"), http.StatusBadRequest)
+		upload(t, debianFilename, debianContent, http.StatusCreated)
+
+		req = NewRequest(t, "GET", sourcesURL)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		body := resp.Body.String()
+		assert.Contains(t, body, "Package: "+packageName+"\n")
+		assert.Contains(t, body, "Version: "+sourceVersion+"\n")
+		assert.Contains(t, body, fmt.Sprintf("Directory: pool/%s/%s/source\n", distribution, component))
+		assert.Contains(t, body, fmt.Sprintf(" %s %d %s\n", sha256Hex(dsc), len(dsc), dscFilename))
+		assert.Contains(t, body, fmt.Sprintf(" %s %d %s\n", sha256Hex(origContent), len(origContent), origFilename))
+		assert.Contains(t, body, fmt.Sprintf(" %s %d %s\n", sha256Hex(debianContent), len(debianContent), debianFilename))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/dists/%s/Release", rootURL, distribution))
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), component+"/source/Sources\n")
+
+		for filename, content := range map[string][]byte{
+			dscFilename:    dsc,
+			origFilename:   origContent,
+			debianFilename: debianContent,
+		} {
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/pool/%s/%s/source/%s", rootURL, distribution, component, filename))
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, content, resp.Body.Bytes())
+		}
+
+		upload(t, fmt.Sprintf("%s_%s_source.changes", packageName, sourceVersion), createChanges(strings.Repeat("0", 64)), http.StatusBadRequest)
+		upload(t, fmt.Sprintf("%s_%s_source.changes", packageName, sourceVersion), createChanges(sha256Hex(dsc)), http.StatusCreated)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("%s/pool/%s/%s/%s/%s/source", rootURL, distribution, component, packageName, sourceVersion)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "GET", sourcesURL)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
 }
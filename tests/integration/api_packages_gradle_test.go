@@ -0,0 +1,216 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	gradle_model "code.gitea.io/gitea/models/packages/gradle"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	gradle_module "code.gitea.io/gitea/modules/packages/gradle"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	gradle_service "code.gitea.io/gitea/services/packages/gradle"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageGradle(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	key := strings.Repeat("a", 32)
+	content := []byte("gradle build cache entry")
+
+	root := fmt.Sprintf("/api/packages/%s/gradle/cache", user.Name)
+
+	readToken := getUserToken(t, user.Name, auth_model.AccessTokenScopeReadPackage)
+	writeToken := getUserToken(t, user.Name, auth_model.AccessTokenScopeWritePackage)
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", root+"/"+key, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", root+"/"+key, bytes.NewReader(content)).
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", root+"/invalid", bytes.NewReader(content)).
+			AddTokenAuth(writeToken)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/"+key, bytes.NewReader(content)).
+			AddTokenAuth(writeToken)
+		MakeRequest(t, req, http.StatusOK)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeGradle)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.Nil(t, pd.Metadata)
+		assert.Equal(t, gradle_module.CachePackageName, pd.Package.Name)
+		assert.Equal(t, key, pd.Version.Version)
+		assert.Len(t, pd.Files, 1)
+		assert.Equal(t, int64(len(content)), pd.Files[0].Blob.Size)
+
+		t.Run("TooLarge", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+			defer test.MockVariableValue(&setting.Packages.LimitSizeGradle, int64(len(content)-1))()
+
+			req := NewRequestWithBody(t, "PUT", root+"/"+strings.Repeat("b", 32), bytes.NewReader(content)).
+				AddTokenAuth(writeToken)
+			MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+		})
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/"+key).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", root+"/"+strings.Repeat("c", 32)).
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		stats := unittest.AssertExistsAndLoadBean(t, &gradle_model.CacheStatistics{OwnerID: user.ID})
+		assert.EqualValues(t, 1, stats.Hits)
+		assert.EqualValues(t, 1, stats.Misses)
+		assert.EqualValues(t, 50, stats.HitRate())
+	})
+
+	t.Run("Head", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		pv, err := packages.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeGradle, gradle_module.CachePackageName, key)
+		assert.NoError(t, err)
+
+		req := NewRequest(t, "HEAD", root+"/"+key).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, strconv.Itoa(len(content)), resp.Header().Get("Content-Length"))
+		assert.Empty(t, resp.Body.Bytes())
+
+		req = NewRequest(t, "HEAD", root+"/"+strings.Repeat("c", 32)).
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		stats := unittest.AssertExistsAndLoadBean(t, &gradle_model.CacheStatistics{OwnerID: user.ID})
+		assert.EqualValues(t, 1, stats.Hits)
+		assert.EqualValues(t, 1, stats.Misses)
+
+		unchanged, err := packages.GetVersionByID(db.DefaultContext, pv.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, pv.DownloadCount, unchanged.DownloadCount)
+		assert.Equal(t, pv.LastDownloadUnix, unchanged.LastDownloadUnix)
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		other := []byte("other gradle build cache entry")
+		otherKey := strings.Repeat("d", 32)
+
+		time.Sleep(time.Second)
+
+		req := NewRequestWithBody(t, "PUT", root+"/"+otherKey, bytes.NewReader(other)).
+			AddTokenAuth(writeToken)
+		MakeRequest(t, req, http.StatusOK)
+
+		time.Sleep(time.Second)
+
+		// checking the existence must not keep the older entry in the cache
+		req = NewRequest(t, "HEAD", root+"/"+key).
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusOK)
+
+		assert.NoError(t, gradle_service.EvictEntries(db.DefaultContext))
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeGradle)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 2)
+
+		session := loginUser(t, user.Name)
+
+		setMaxSize := func(t *testing.T, maxSize string) {
+			req := NewRequestWithValues(t, "POST", "/user/settings/packages/gradle/cache_max_size", map[string]string{
+				"_csrf":    GetCSRF(t, session, "/user/settings/packages"),
+				"max_size": maxSize,
+			})
+			session.MakeRequest(t, req, http.StatusSeeOther)
+		}
+
+		t.Run("OwnerUnlimited", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+			defer test.MockVariableValue(&setting.Packages.GradleCacheMaxSize, int64(len(other)))()
+
+			setMaxSize(t, "-1")
+
+			maxSize, err := gradle_service.GetCacheMaxSize(db.DefaultContext, user.ID)
+			assert.NoError(t, err)
+			assert.EqualValues(t, -1, maxSize)
+
+			assert.NoError(t, gradle_service.EvictEntries(db.DefaultContext))
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeGradle)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 2)
+		})
+
+		t.Run("OwnerCap", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			setMaxSize(t, strconv.Itoa(len(other)))
+
+			maxSize, err := gradle_service.GetCacheMaxSize(db.DefaultContext, user.ID)
+			assert.NoError(t, err)
+			assert.EqualValues(t, len(other), maxSize)
+
+			assert.NoError(t, gradle_service.EvictEntries(db.DefaultContext))
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeGradle)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 1)
+			assert.Equal(t, otherKey, pvs[0].Version)
+		})
+
+		t.Run("Default", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			setMaxSize(t, "")
+
+			maxSize, err := gradle_service.GetCacheMaxSize(db.DefaultContext, user.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, setting.Packages.GradleCacheMaxSize, maxSize)
+		})
+	})
+
+	t.Run("AdminStatistics", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		session := loginUser(t, "user1")
+
+		req := NewRequest(t, "GET", "/admin/packages")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "Gradle Build Cache")
+	})
+}
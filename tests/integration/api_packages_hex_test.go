@@ -0,0 +1,259 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	hex_module "code.gitea.io/gitea/modules/packages/hex"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestPackageHex(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	token := getUserToken(t, user.Name, auth_model.AccessTokenScopeWritePackage)
+
+	packageName := "gitea_test"
+	packageDescription := "Test Description"
+
+	createPackage := func(version string) []byte {
+		metadata := fmt.Sprintf(`{<<"name">>,<<"%s">>}.
+{<<"version">>,<<"%s">>}.
+{<<"app">>,<<"%s">>}.
+{<<"description">>,<<"%s">>}.
+{<<"licenses">>,[<<"MIT">>]}.
+{<<"requirements">>,[{<<"jason">>,[{<<"app">>,<<"jason">>},{<<"optional">>,false},{<<"requirement">>,<<"~> 1.4">>},{<<"repository">>,<<"hexpm">>}]}]}.
+`, packageName, version, packageName, packageDescription)
+
+		var contents bytes.Buffer
+		zw := gzip.NewWriter(&contents)
+		inner := tar.NewWriter(zw)
+		inner.WriteHeader(&tar.Header{Name: "mix.exs", Mode: 0o600, Size: 4})
+		inner.Write([]byte("test"))
+		inner.Close()
+		zw.Close()
+
+		h := sha256.New()
+		h.Write([]byte("3"))
+		h.Write([]byte(metadata))
+		h.Write(contents.Bytes())
+
+		var buf bytes.Buffer
+		archive := tar.NewWriter(&buf)
+		for _, file := range []struct {
+			Name    string
+			Content []byte
+		}{
+			{"VERSION", []byte("3")},
+			{"CHECKSUM", []byte(hex_module.EncodeChecksum(h.Sum(nil)))},
+			{"metadata.config", []byte(metadata)},
+			{"contents.tar.gz", contents.Bytes()},
+		} {
+			archive.WriteHeader(&tar.Header{
+				Name: file.Name,
+				Mode: 0o600,
+				Size: int64(len(file.Content)),
+			})
+			archive.Write(file.Content)
+		}
+		archive.Close()
+		return buf.Bytes()
+	}
+
+	root := fmt.Sprintf("/api/packages/%s/hex", user.Name)
+
+	// readResource verifies the signature of a registry resource and returns its payload
+	readResource := func(t *testing.T, url string) []byte {
+		req := NewRequest(t, "GET", root+"/public_key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		block, _ := pem.Decode(resp.Body.Bytes())
+		assert.NotNil(t, block)
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+
+		req = NewRequest(t, "GET", url)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		zr, err := gzip.NewReader(resp.Body)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		assert.NoError(t, err)
+
+		payload, signature, err := hex_module.DecodeSigned(data)
+		assert.NoError(t, err)
+
+		h := sha512.Sum512(payload)
+		assert.NoError(t, rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA512, h[:], signature))
+
+		return payload
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		content := createPackage("1.0.0")
+
+		req := NewRequestWithBody(t, "POST", root+"/api/publish", bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "POST", root+"/api/publish", bytes.NewReader([]byte("invalid"))).
+			SetHeader("Authorization", token)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithBody(t, "POST", root+"/api/publish", bytes.NewReader(content)).
+			SetHeader("Authorization", token)
+		resp := MakeRequest(t, req, http.StatusCreated)
+
+		var result struct {
+			Version  string `json:"version"`
+			Checksum string `json:"checksum"`
+		}
+		DecodeJSON(t, resp, &result)
+		assert.Equal(t, "1.0.0", result.Version)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content)), result.Checksum)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeHex)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.NotNil(t, pd.SemVer)
+		assert.Equal(t, packageName, pd.Package.Name)
+		assert.Equal(t, "1.0.0", pd.Version.Version)
+		assert.IsType(t, &hex_module.Metadata{}, pd.Metadata)
+		metadata := pd.Metadata.(*hex_module.Metadata)
+		assert.Equal(t, packageDescription, metadata.Description)
+		assert.Equal(t, []string{"MIT"}, metadata.Licenses)
+		assert.Len(t, metadata.Requirements, 1)
+		assert.NotEmpty(t, pd.VersionProperties.GetByName(hex_module.PropertyInnerChecksum))
+
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, hex_module.Filename(packageName, "1.0.0"), pfs[0].Name)
+		assert.True(t, pfs[0].IsLead)
+
+		req = NewRequestWithBody(t, "POST", root+"/api/publish", bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequestWithBody(t, "POST", root+"/api/publish", bytes.NewReader(createPackage("1.1.0"))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		payload := readResource(t, root+"/names")
+		assert.Contains(t, string(payload), packageName)
+
+		payload = readResource(t, root+"/versions")
+		assert.Contains(t, string(payload), "1.0.0")
+		assert.Contains(t, string(payload), "1.1.0")
+
+		payload = readResource(t, root+"/packages/"+packageName)
+
+		var versions []string
+		for len(payload) > 0 {
+			num, typ, n := protowire.ConsumeTag(payload)
+			assert.Greater(t, n, 0)
+			payload = payload[n:]
+			n = protowire.ConsumeFieldValue(num, typ, payload)
+			if num == 1 {
+				release, _ := protowire.ConsumeBytes(payload)
+				_, _, m := protowire.ConsumeTag(release)
+				version, _ := protowire.ConsumeString(release[m:])
+				versions = append(versions, version)
+			}
+			payload = payload[n:]
+		}
+		assert.Equal(t, []string{"1.0.0", "1.1.0"}, versions)
+
+		req := NewRequest(t, "GET", root+"/packages/missing")
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("PackageInfo", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/api/packages/"+packageName)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			Name     string `json:"name"`
+			Releases []struct {
+				Version string `json:"version"`
+			} `json:"releases"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, packageName, result.Name)
+		assert.Len(t, result.Releases, 2)
+		assert.Equal(t, "1.1.0", result.Releases[0].Version)
+
+		req = NewRequest(t, "GET", root+"/api/packages/"+packageName).
+			SetHeader("Accept", hex_module.ContentTypeErlang)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, hex_module.ContentTypeErlang, resp.Header().Get("Content-Type"))
+		assert.Equal(t, byte(131), resp.Body.Bytes()[0])
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		filename := hex_module.Filename(packageName, "1.0.0")
+
+		req := NewRequest(t, "GET", root+"/tarballs/"+filename)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, createPackage("1.0.0"), resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", root+"/tarballs/"+strings.TrimSuffix(filename, ".tar"))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := fmt.Sprintf("%s/api/packages/%s/releases/", root, packageName)
+
+		req := NewRequest(t, "DELETE", url+"1.0.0")
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		for _, version := range []string{"1.0.0", "1.1.0"} {
+			req = NewRequest(t, "DELETE", url+version).
+				SetHeader("Authorization", token)
+			MakeRequest(t, req, http.StatusNoContent)
+		}
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeHex)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+	})
+}
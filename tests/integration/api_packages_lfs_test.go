@@ -0,0 +1,184 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	lfs_module "code.gitea.io/gitea/modules/packages/lfs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageLFS(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	project := "test-project"
+	content := []byte("large binary content")
+	sum := sha256.Sum256(content)
+	oid := hex.EncodeToString(sum[:])
+	pointer := lfs_module.Pointer{Oid: oid, Size: int64(len(content))}
+
+	root := fmt.Sprintf("/api/packages/%s/lfs/%s", user.Name, project)
+
+	batch := func(t *testing.T, operation string, status int) *lfs_module.BatchResponse {
+		req := NewRequestWithJSON(t, "POST", root+"/objects/batch", &lfs_module.BatchRequest{
+			Operation: operation,
+			Transfers: []string{lfs_module.TransferBasic},
+			Objects:   []lfs_module.Pointer{pointer},
+		}).AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, status)
+		if status != http.StatusOK {
+			return nil
+		}
+
+		assert.Equal(t, lfs_module.MediaType, resp.Header().Get("Content-Type"))
+
+		var result *lfs_module.BatchResponse
+		DecodeJSON(t, resp, &result)
+		assert.Equal(t, lfs_module.TransferBasic, result.Transfer)
+		assert.Len(t, result.Objects, 1)
+		return result
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithJSON(t, "POST", root+"/objects/batch", &lfs_module.BatchRequest{
+			Operation: lfs_module.OperationUpload,
+			Objects:   []lfs_module.Pointer{pointer},
+		})
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithJSON(t, "POST", root+"/objects/batch", &lfs_module.BatchRequest{
+			Operation: lfs_module.OperationUpload,
+			Transfers: []string{"tus"},
+			Objects:   []lfs_module.Pointer{pointer},
+		}).AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		result := batch(t, lfs_module.OperationDownload, http.StatusOK)
+		assert.NotNil(t, result.Objects[0].Error)
+		assert.Equal(t, http.StatusNotFound, result.Objects[0].Error.Code)
+
+		result = batch(t, lfs_module.OperationUpload, http.StatusOK)
+		assert.Nil(t, result.Objects[0].Error)
+		assert.Contains(t, result.Objects[0].Actions, "upload")
+		assert.Contains(t, result.Objects[0].Actions, "verify")
+		assert.True(t, strings.HasPrefix(result.Objects[0].Actions["upload"].Header["Authorization"], "Basic "))
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", root+"/objects/"+oid, bytes.NewReader([]byte("other content"))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithBody(t, "PUT", root+"/objects/"+oid, bytes.NewReader(content)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithJSON(t, "POST", root+"/verify", &lfs_module.Pointer{Oid: oid, Size: 1}).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", root+"/verify", &pointer).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeLFS)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.Equal(t, project, pd.Package.Name)
+		assert.Equal(t, oid, pd.Version.Version)
+		assert.Len(t, pd.Files, 1)
+		assert.Equal(t, oid, pd.Files[0].Blob.HashSHA256)
+
+		result := batch(t, lfs_module.OperationUpload, http.StatusOK)
+		assert.Empty(t, result.Objects[0].Actions)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		result := batch(t, lfs_module.OperationDownload, http.StatusOK)
+		assert.Nil(t, result.Objects[0].Error)
+		assert.Contains(t, result.Objects[0].Actions, "download")
+
+		req := NewRequest(t, "GET", root+"/objects/"+oid).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/lfs/other-project/objects/%s", user.Name, oid)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Locks", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithJSON(t, "POST", root+"/locks", &lfs_module.LockCreateRequest{Path: "assets/image.psd"}).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusCreated)
+
+		var created *lfs_module.LockResponse
+		DecodeJSON(t, resp, &created)
+		assert.Equal(t, "assets/image.psd", created.Lock.Path)
+		assert.Equal(t, user.Name, created.Lock.Owner.Name)
+
+		req = NewRequestWithJSON(t, "POST", root+"/locks", &lfs_module.LockCreateRequest{Path: "/assets/./image.psd"}).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusConflict)
+
+		var conflict *lfs_module.ErrorResponse
+		DecodeJSON(t, resp, &conflict)
+		assert.Equal(t, created.Lock.ID, conflict.Lock.ID)
+
+		req = NewRequest(t, "GET", root+"/locks?path=assets/image.psd").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var list *lfs_module.LockList
+		DecodeJSON(t, resp, &list)
+		assert.Len(t, list.Locks, 1)
+
+		req = NewRequestWithJSON(t, "POST", root+"/locks/verify", &lfs_module.LockVerifyRequest{}).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var verify *lfs_module.LockVerifyResponse
+		DecodeJSON(t, resp, &verify)
+		assert.Len(t, verify.Ours, 1)
+		assert.Empty(t, verify.Theirs)
+
+		req = NewRequestWithJSON(t, "POST", root+"/locks/"+created.Lock.ID+"/unlock", &lfs_module.LockDeleteRequest{}).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", root+"/locks").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		DecodeJSON(t, resp, &list)
+		assert.Empty(t, list.Locks)
+	})
+}
@@ -0,0 +1,400 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	huggingface_module "code.gitea.io/gitea/modules/packages/huggingface"
+	lfs_module "code.gitea.io/gitea/modules/packages/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageModel(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	repoName := "test-model"
+	repoID := user.Name + "/" + repoName
+
+	config := []byte(`{"model_type": "gpt2"}`)
+	weights := []byte("large binary weights\x00")
+	sum := sha256.Sum256(weights)
+	weightsOID := hex.EncodeToString(sum[:])
+
+	readme := `---
+license: mit
+library_name: transformers
+pipeline_tag: text-generation
+tags:
+- gitea
+---
+# Test Model`
+
+	root := fmt.Sprintf("/api/packages/%s/model", user.Name)
+
+	commit := func(t *testing.T, revision, body string, expectedStatus int) string {
+		req := NewRequestWithBody(t, "POST", fmt.Sprintf("%s/api/models/%s/commit/%s", root, repoID, revision), strings.NewReader(body)).
+			SetHeader("Content-Type", "application/x-ndjson").
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, expectedStatus)
+		if expectedStatus != http.StatusOK {
+			return ""
+		}
+
+		var result struct {
+			CommitOID string `json:"commitOid"`
+		}
+		DecodeJSON(t, resp, &result)
+		assert.True(t, huggingface_module.IsCommitOID(result.CommitOID))
+		return result.CommitOID
+	}
+
+	t.Run("CreateRepository", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		body := map[string]string{"name": repoName, "type": "model"}
+
+		req := NewRequestWithJSON(t, "POST", root+"/api/repos/create", body)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithJSON(t, "POST", root+"/api/repos/create", map[string]string{"name": repoName, "type": "space"}).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithJSON(t, "POST", root+"/api/repos/create", body).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			URL string `json:"url"`
+		}
+		DecodeJSON(t, resp, &result)
+		assert.Equal(t, fmt.Sprintf("%s%s/%s", setting.AppURL, root[1:], repoID), result.URL)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeModel)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+		assert.Equal(t, huggingface_module.DefaultRevision, pvs[0].Version)
+
+		req = NewRequestWithJSON(t, "POST", root+"/api/repos/create", body).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		// models and datasets share the repository ids
+		req = NewRequestWithJSON(t, "POST", root+"/api/repos/create", map[string]string{"name": repoName, "type": "dataset"}).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+	})
+
+	t.Run("Preupload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/api/models/%s/preupload/main", root, repoID), map[string]any{
+			"files": []map[string]any{
+				{"path": "config.json", "sample": "eyJtb2RlbF90eXBlIjogImdwdDIifQ==", "size": len(config)},
+				{"path": "model.safetensors", "sample": "", "size": 100 * 1024 * 1024},
+			},
+		}).AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			Files []struct {
+				Path       string `json:"path"`
+				UploadMode string `json:"uploadMode"`
+			} `json:"files"`
+		}
+		DecodeJSON(t, resp, &result)
+		assert.Len(t, result.Files, 2)
+		assert.Equal(t, huggingface_module.UploadModeRegular, result.Files[0].UploadMode)
+		assert.Equal(t, huggingface_module.UploadModeLFS, result.Files[1].UploadMode)
+	})
+
+	t.Run("UploadLargeFile", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		pointer := lfs_module.Pointer{Oid: weightsOID, Size: int64(len(weights))}
+
+		batch := func(t *testing.T) *lfs_module.ObjectResponse {
+			req := NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/%s.git/info/lfs/objects/batch", root, repoID), &lfs_module.BatchRequest{
+				Operation: lfs_module.OperationUpload,
+				Transfers: []string{lfs_module.TransferBasic},
+				Objects:   []lfs_module.Pointer{pointer},
+			}).AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result *lfs_module.BatchResponse
+			DecodeJSON(t, resp, &result)
+			assert.Len(t, result.Objects, 1)
+			return result.Objects[0]
+		}
+
+		obj := batch(t)
+		assert.Nil(t, obj.Error)
+		assert.Contains(t, obj.Actions, "upload")
+		assert.Contains(t, obj.Actions, "verify")
+
+		upload := obj.Actions["upload"].Href
+
+		req := NewRequestWithBody(t, "PUT", strings.Replace(upload, "code=", "code=0", 1), strings.NewReader(string(weights)))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", upload, strings.NewReader("corrupted"))
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", upload, strings.NewReader(string(weights)))
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithJSON(t, "POST", obj.Actions["verify"].Href, &pointer).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		// An uploaded object is not uploaded again
+		obj = batch(t)
+		assert.Nil(t, obj.Error)
+		assert.Empty(t, obj.Actions)
+	})
+
+	var commitOID string
+
+	t.Run("Commit", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		commit(t, "main", `{"key":"header","value":{"summary":"Add model"}}
+{"key":"lfsFile","value":{"path":"model.safetensors","algo":"sha256","oid":"`+strings.Repeat("0", 64)+`","size":1}}`, http.StatusBadRequest)
+
+		commit(t, "main", `{"key":"file","value":{"path":"../config.json","content":"","encoding":"base64"}}`, http.StatusBadRequest)
+
+		commitOID = commit(t, "main", fmt.Sprintf(`{"key":"header","value":{"summary":"Add model","description":"first version"}}
+{"key":"file","value":{"path":"config.json","content":%q,"encoding":"utf-8"}}
+{"key":"file","value":{"path":"README.md","content":%q,"encoding":"utf-8"}}
+{"key":"lfsFile","value":{"path":"model.safetensors","algo":"sha256","oid":%q,"size":%d}}
+`, config, readme, weightsOID, len(weights)), http.StatusOK)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeModel)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.Equal(t, repoID, pd.Package.Name)
+		assert.Len(t, pd.Files, 3)
+		assert.Equal(t, commitOID, pd.VersionProperties.GetByName(huggingface_module.PropertyCommit))
+
+		metadata := pd.Metadata.(*huggingface_module.Metadata)
+		assert.Equal(t, "Add model", metadata.Summary)
+		assert.Equal(t, "mit", metadata.License)
+		assert.Equal(t, "transformers", metadata.LibraryName)
+		assert.Equal(t, "text-generation", metadata.PipelineTag)
+		assert.Equal(t, []string{"gitea"}, metadata.Tags)
+		assert.Equal(t, "# Test Model", strings.TrimSpace(metadata.Readme))
+
+		// The large file is linked to the staged blob and no longer staged
+		for _, pfd := range pd.Files {
+			if pfd.File.Name == "model.safetensors" {
+				assert.Equal(t, weightsOID, pfd.Blob.HashSHA256)
+			}
+		}
+		pv, err := packages.GetInternalVersionByNameAndVersion(db.DefaultContext, user.ID, packages.TypeModel, huggingface_module.UploadPackage, huggingface_module.UploadVersion)
+		assert.NoError(t, err)
+		pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, pfs)
+	})
+
+	t.Run("ModelInfo", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/api/models/%s/revision/main", root, repoID)).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var info struct {
+			ID          string   `json:"id"`
+			SHA         string   `json:"sha"`
+			LibraryName string   `json:"library_name"`
+			Tags        []string `json:"tags"`
+			Siblings    []struct {
+				Filename string `json:"rfilename"`
+				Size     int64  `json:"size"`
+			} `json:"siblings"`
+		}
+		DecodeJSON(t, resp, &info)
+		assert.Equal(t, repoID, info.ID)
+		assert.Equal(t, commitOID, info.SHA)
+		assert.Equal(t, "transformers", info.LibraryName)
+		assert.Equal(t, []string{"gitea"}, info.Tags)
+		assert.Len(t, info.Siblings, 3)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/models/%s/revision/%s", root, repoID, commitOID)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/models/%s/revision/dev", root, repoID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "RevisionNotFound", resp.Header().Get("X-Error-Code"))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/models/%s/unknown", root, user.Name)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "RepoNotFound", resp.Header().Get("X-Error-Code"))
+	})
+
+	t.Run("ResolveFile", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := fmt.Sprintf("%s/%s/resolve/main/model.safetensors", root, repoID)
+
+		req := NewRequest(t, "HEAD", url).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, commitOID, resp.Header().Get("X-Repo-Commit"))
+		assert.Equal(t, `"`+weightsOID+`"`, resp.Header().Get("X-Linked-Etag"))
+		assert.Equal(t, fmt.Sprint(len(weights)), resp.Header().Get("X-Linked-Size"))
+
+		req = NewRequest(t, "GET", url).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, weights, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/resolve/%s/config.json", root, repoID, commitOID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, config, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/resolve/main/missing.bin", root, repoID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "EntryNotFound", resp.Header().Get("X-Error-Code"))
+	})
+
+	t.Run("DeleteFile", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		newCommitOID := commit(t, "main", `{"key":"header","value":{"summary":"Remove weights"}}
+{"key":"deletedFile","value":{"path":"model.safetensors"}}`, http.StatusOK)
+		assert.NotEqual(t, commitOID, newCommitOID)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/resolve/main/model.safetensors", root, repoID)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Dataset", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		datasetName := "test-dataset"
+		datasetID := user.Name + "/" + datasetName
+		data := []byte("text,label\nhello,1\n")
+
+		req := NewRequestWithJSON(t, "POST", root+"/api/repos/create", map[string]string{"name": datasetName, "type": "dataset"}).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var created struct {
+			URL string `json:"url"`
+		}
+		DecodeJSON(t, resp, &created)
+		assert.Equal(t, fmt.Sprintf("%s%s/datasets/%s", setting.AppURL, root[1:], datasetID), created.URL)
+
+		p, err := packages.GetPackageByName(db.DefaultContext, user.ID, packages.TypeModel, datasetID)
+		assert.NoError(t, err)
+		pps, err := packages.GetPropertiesByName(db.DefaultContext, packages.PropertyTypePackage, p.ID, huggingface_module.PropertyRepoType)
+		assert.NoError(t, err)
+		assert.Len(t, pps, 1)
+		assert.Equal(t, huggingface_module.RepoTypeDataset, pps[0].Value)
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/api/datasets/%s/preupload/main", root, datasetID), map[string]any{
+			"files": []map[string]any{
+				{"path": "train.csv", "sample": "", "size": len(data)},
+			},
+		}).AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithBody(t, "POST", fmt.Sprintf("%s/api/datasets/%s/commit/main", root, datasetID), strings.NewReader(fmt.Sprintf(`{"key":"header","value":{"summary":"Add data"}}
+{"key":"file","value":{"path":"train.csv","content":%q,"encoding":"utf-8"}}
+`, data))).
+			SetHeader("Content-Type", "application/x-ndjson").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			CommitURL string `json:"commitUrl"`
+			CommitOID string `json:"commitOid"`
+		}
+		DecodeJSON(t, resp, &result)
+		assert.Equal(t, fmt.Sprintf("%s%s/datasets/%s/commit/%s", setting.AppURL, root[1:], datasetID, result.CommitOID), result.CommitURL)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/datasets/%s", root, datasetID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var info struct {
+			ID       string `json:"id"`
+			SHA      string `json:"sha"`
+			Siblings []struct {
+				Filename string `json:"rfilename"`
+			} `json:"siblings"`
+		}
+		assert.NotContains(t, resp.Body.String(), "modelId")
+		DecodeJSON(t, resp, &info)
+		assert.Equal(t, datasetID, info.ID)
+		assert.Equal(t, result.CommitOID, info.SHA)
+		assert.Len(t, info.Siblings, 1)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/datasets/%s/resolve/main/train.csv", root, datasetID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, data, resp.Body.Bytes())
+		assert.Equal(t, result.CommitOID, resp.Header().Get("X-Repo-Commit"))
+
+		// the large file links point to the dataset
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/datasets/%s.git/info/lfs/objects/batch", root, datasetID), &lfs_module.BatchRequest{
+			Operation: lfs_module.OperationUpload,
+			Objects:   []lfs_module.Pointer{{Oid: strings.Repeat("1", 64), Size: 1}},
+		}).AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var batch *lfs_module.BatchResponse
+		DecodeJSON(t, resp, &batch)
+		assert.Len(t, batch.Objects, 1)
+		assert.True(t, strings.HasPrefix(batch.Objects[0].Actions["upload"].Href, fmt.Sprintf("%s%s/datasets/%s.git/info/lfs/objects/", setting.AppURL, root[1:], datasetID)))
+
+		// a dataset is not a model and vice versa
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/models/%s", root, datasetID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "RepoNotFound", resp.Header().Get("X-Error-Code"))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/resolve/main/train.csv", root, datasetID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "RepoNotFound", resp.Header().Get("X-Error-Code"))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/datasets/%s", root, repoID)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "RepoNotFound", resp.Header().Get("X-Error-Code"))
+
+		req = NewRequestWithBody(t, "POST", fmt.Sprintf("%s/api/models/%s/commit/main", root, datasetID), strings.NewReader(`{"key":"header","value":{"summary":"Overwrite"}}`)).
+			SetHeader("Content-Type", "application/x-ndjson").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusNotFound)
+		assert.Equal(t, "RepoNotFound", resp.Header().Get("X-Error-Code"))
+	})
+}
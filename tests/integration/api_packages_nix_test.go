@@ -0,0 +1,181 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	nix_module "code.gitea.io/gitea/modules/packages/nix"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageNix(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	storeHash := "sjnnpl3an2lakq7kvp0wm2n5ylhv0gjc"
+	storeName := "hello-2.12.1"
+	storePath := nix_module.StoreDir + "/" + storeHash + "-" + storeName
+
+	narContent := []byte("nix-archive-1 compressed")
+	sum := sha256.Sum256(narContent)
+	fileHash := nix_module.EncodeBase32(sum[:])
+	narFilename := fileHash + ".nar.xz"
+
+	narInfo := fmt.Sprintf(`StorePath: %s
+URL: nar/%s
+Compression: xz
+FileHash: sha256:%s
+FileSize: %d
+NarHash: sha256:1v6l0q5cz81i2rp48bhrhzazbg4m5ixvdhxhyh0gbdknv1zbnqgg
+NarSize: 226560
+References: %s-%s
+System: x86_64-linux
+`, storePath, narFilename, fileHash, len(narContent), storeHash, storeName)
+
+	root := fmt.Sprintf("/api/packages/%s/nix", user.Name)
+
+	t.Run("CacheInfo", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/nix-cache-info")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Contains(t, resp.Body.String(), "StoreDir: /nix/store\n")
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "HEAD", root+"/"+storeHash+".narinfo")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithBody(t, "PUT", root+"/nar/"+narFilename, bytes.NewReader(narContent))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", root+"/nar/"+narFilename, bytes.NewReader([]byte("invalid"))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/"+storeHash+".narinfo", strings.NewReader(narInfo)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/nar/"+narFilename, bytes.NewReader(narContent)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeNix)
+		assert.NoError(t, err)
+		assert.Empty(t, pvs)
+
+		req = NewRequestWithBody(t, "PUT", root+"/00000000000000000000000000000000.narinfo", strings.NewReader(narInfo)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/"+storeHash+".narinfo", strings.NewReader(narInfo)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		pvs, err = packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeNix)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+		assert.NoError(t, err)
+		assert.Nil(t, pd.SemVer)
+		assert.Equal(t, storeName, pd.Package.Name)
+		assert.Equal(t, storeHash, pd.Version.Version)
+		assert.IsType(t, &nix_module.Metadata{}, pd.Metadata)
+		metadata := pd.Metadata.(*nix_module.Metadata)
+		assert.Equal(t, storePath, metadata.StorePath)
+		assert.Equal(t, "x86_64-linux", metadata.System)
+		assert.Len(t, pd.Files, 1)
+		assert.Equal(t, narFilename, pd.Files[0].File.Name)
+		assert.True(t, pd.Files[0].File.IsLead)
+
+		req = NewRequestWithBody(t, "PUT", root+"/"+storeHash+".narinfo", strings.NewReader(narInfo)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/repository.key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		name, key, ok := strings.Cut(resp.Body.String(), ":")
+		assert.True(t, ok)
+		pub, err := base64.StdEncoding.DecodeString(key)
+		assert.NoError(t, err)
+
+		req = NewRequest(t, "HEAD", root+"/"+storeHash+".narinfo")
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", root+"/"+storeHash+".narinfo")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, nix_module.ContentTypeNarInfo, resp.Header().Get("Content-Type"))
+
+		n, err := nix_module.ParseNarInfo(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, storePath, n.StorePath)
+		assert.Equal(t, "nar/"+narFilename, n.URL)
+		assert.Len(t, n.Signatures, 1)
+
+		sigName, sig, _ := strings.Cut(n.Signatures[0], ":")
+		assert.Equal(t, name, sigName)
+		signature, err := base64.StdEncoding.DecodeString(sig)
+		assert.NoError(t, err)
+		assert.True(t, ed25519.Verify(pub, []byte(n.Fingerprint()), signature))
+
+		req = NewRequest(t, "GET", root+"/"+n.URL)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, narContent, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", root+"/nar/"+strings.Repeat("0", 52)+".nar.xz")
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Deduplicate", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		otherHash := strings.Repeat("1", 32)
+
+		req := NewRequestWithBody(t, "PUT", root+"/nar/"+narFilename, bytes.NewReader(narContent)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequestWithBody(t, "PUT", root+"/"+otherHash+".narinfo", strings.NewReader(strings.ReplaceAll(narInfo, storeHash, otherHash))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeNix)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 2)
+
+		pfs := make([]*packages.PackageFile, 0, 2)
+		for _, pv := range pvs {
+			files, err := packages.GetFilesByVersionID(db.DefaultContext, pv.ID)
+			assert.NoError(t, err)
+			pfs = append(pfs, files...)
+		}
+		assert.Len(t, pfs, 2)
+		assert.Equal(t, pfs[0].BlobID, pfs[1].BlobID)
+	})
+}
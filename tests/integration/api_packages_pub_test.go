@@ -12,6 +12,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -178,4 +179,128 @@ description: ` + packageDescription
 		assert.Equal(t, packageVersion, result.Latest.Version)
 		assert.NotNil(t, result.Latest.Pubspec)
 	})
+
+	t.Run("RetractVersion", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := fmt.Sprintf("%s/api/packages/%s/versions/%s/options", root, packageName, packageVersion)
+
+		req := NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isRetracted":true}`))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isRetracted":true}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		type VersionMetadata struct {
+			Version   string `json:"version"`
+			Retracted bool   `json:"retracted"`
+		}
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/packages/%s/%s", root, packageName, packageVersion))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result VersionMetadata
+		DecodeJSON(t, resp, &result)
+		assert.True(t, result.Retracted)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isRetracted":false}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/packages/%s/%s", root, packageName, packageVersion))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		DecodeJSON(t, resp, &result)
+		assert.False(t, result.Retracted)
+	})
+
+	t.Run("DiscontinuePackage", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := fmt.Sprintf("%s/api/packages/%s/options", root, packageName)
+
+		req := NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isDiscontinued":true,"replacedBy":"`+packageName+`"}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isDiscontinued":true,"replacedBy":"not_existing"}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isDiscontinued":true}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		type PackageVersions struct {
+			Name           string `json:"name"`
+			IsDiscontinued bool   `json:"isDiscontinued"`
+		}
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/packages/%s", root, packageName))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result PackageVersions
+		DecodeJSON(t, resp, &result)
+		assert.True(t, result.IsDiscontinued)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"isDiscontinued":false}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/api/packages/%s", root, packageName))
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		DecodeJSON(t, resp, &result)
+		assert.False(t, result.IsDiscontinued)
+	})
+
+	t.Run("Advisories", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		url := fmt.Sprintf("%s/api/packages/%s/advisories", root, packageName)
+
+		type Advisories struct {
+			Advisories        []*pub_module.Advisory `json:"advisories"`
+			AdvisoriesUpdated *time.Time             `json:"advisoriesUpdated"`
+		}
+
+		req := NewRequest(t, "GET", url)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result Advisories
+		DecodeJSON(t, resp, &result)
+		assert.Empty(t, result.Advisories)
+		assert.Nil(t, result.AdvisoriesUpdated)
+
+		advisory := `{"id":"GHSA-test","summary":"Test","affected":[{"package":{"name":"` + packageName + `"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"}]}]}]}`
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(advisory))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"id":"GHSA-test"}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", url, strings.NewReader(advisory)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", url)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		DecodeJSON(t, resp, &result)
+		assert.Len(t, result.Advisories, 1)
+		assert.NotNil(t, result.AdvisoriesUpdated)
+		assert.Equal(t, "GHSA-test", result.Advisories[0].ID)
+		assert.Equal(t, []string{packageVersion}, result.Advisories[0].Affected[0].Versions)
+
+		req = NewRequest(t, "DELETE", url+"/GHSA-test").
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", url+"/GHSA-test").
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
 }
@@ -417,6 +417,107 @@ gpgkey=%sapi/packages/%s/rpm/repository.key`,
 				})
 			})
 
+			t.Run("Advisory", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				url := groupURL + "/advisories"
+				advisory := fmt.Sprintf(`{"id":"GITEA-2024-0001","type":"security","title":"gitea-test security update","severity":"Important","packages":["%s-%s.%s"]}`, packageName, packageVersion, packageArchitecture)
+
+				req := NewRequestWithBody(t, "PUT", url, strings.NewReader(advisory))
+				MakeRequest(t, req, http.StatusUnauthorized)
+
+				req = NewRequestWithBody(t, "PUT", url, strings.NewReader(`{"id":"GITEA-2024-0001"}`)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusBadRequest)
+
+				req = NewRequestWithBody(t, "PUT", url, strings.NewReader(advisory)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusCreated)
+
+				req = NewRequest(t, "GET", url)
+				resp := MakeRequest(t, req, http.StatusOK)
+
+				var advisories []*rpm_module.Advisory
+				DecodeJSON(t, resp, &advisories)
+				assert.Len(t, advisories, 1)
+				assert.Equal(t, "GITEA-2024-0001", advisories[0].ID)
+
+				req = NewRequest(t, "GET", groupURL+"/repodata/updateinfo.xml.gz")
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				type Updateinfo struct {
+					XMLName xml.Name `xml:"updates"`
+					Updates []struct {
+						Type     string `xml:"type,attr"`
+						ID       string `xml:"id"`
+						Severity string `xml:"severity"`
+						Packages []struct {
+							Name     string `xml:"name,attr"`
+							Filename string `xml:"filename"`
+							Sum      string `xml:"sum"`
+						} `xml:"pkglist>collection>package"`
+					} `xml:"update"`
+				}
+
+				zr, err := gzip.NewReader(resp.Body)
+				assert.NoError(t, err)
+
+				var result Updateinfo
+				assert.NoError(t, xml.NewDecoder(zr).Decode(&result))
+
+				assert.Len(t, result.Updates, 1)
+				u := result.Updates[0]
+				assert.Equal(t, "security", u.Type)
+				assert.Equal(t, "GITEA-2024-0001", u.ID)
+				assert.Equal(t, "Important", u.Severity)
+				assert.Len(t, u.Packages, 1)
+				assert.Equal(t, packageName, u.Packages[0].Name)
+				assert.Equal(t, fmt.Sprintf("%s-%s.%s.rpm", packageName, packageVersion, packageArchitecture), u.Packages[0].Filename)
+				assert.NotEmpty(t, u.Packages[0].Sum)
+
+				req = NewRequest(t, "DELETE", url+"/GITEA-2024-0001").
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusNoContent)
+
+				req = NewRequest(t, "GET", groupURL+"/repodata/updateinfo.xml.gz")
+				MakeRequest(t, req, http.StatusNotFound)
+			})
+
+			t.Run("Modules", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				url := groupURL + "/modules"
+				modules := "---\ndocument: modulemd\nversion: 2\ndata:\n  name: gitea\n  stream: stable\n...\n"
+
+				req := NewRequestWithBody(t, "PUT", url, strings.NewReader("invalid")).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusBadRequest)
+
+				req = NewRequestWithBody(t, "PUT", url, strings.NewReader(modules)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusCreated)
+
+				req = NewRequest(t, "GET", groupURL+"/repodata/modules.yaml.gz")
+				resp := MakeRequest(t, req, http.StatusOK)
+
+				zr, err := gzip.NewReader(resp.Body)
+				assert.NoError(t, err)
+				content, err := io.ReadAll(zr)
+				assert.NoError(t, err)
+				assert.Equal(t, modules, string(content))
+
+				req = NewRequest(t, "GET", groupURL+"/repodata/repomd.xml")
+				resp = MakeRequest(t, req, http.StatusOK)
+				assert.Contains(t, resp.Body.String(), `<data type="modules">`)
+
+				req = NewRequest(t, "DELETE", url).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusNoContent)
+
+				req = NewRequest(t, "GET", groupURL+"/repodata/modules.yaml.gz")
+				MakeRequest(t, req, http.StatusNotFound)
+			})
+
 			t.Run("Delete", func(t *testing.T) {
 				defer tests.PrintCurrentTest(t)()
 
@@ -13,16 +13,23 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/packages/rubygems"
+	rubygems_service "code.gitea.io/gitea/services/packages/rubygems"
 	"code.gitea.io/gitea/tests"
 
+	"github.com/pquerna/otp/totp"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 type tarFile struct {
@@ -55,6 +62,10 @@ func makeArchiveFileGz(data []byte) []byte {
 }
 
 func makeRubyGem(name, version string) []byte {
+	return makeRubyGemWithMetadata(name, version, "{}")
+}
+
+func makeRubyGemWithMetadata(name, version, metadata string) []byte {
 	metadataContent := fmt.Sprintf(`--- !ruby/object:Gem::Specification
 name: %s
 version: !ruby/object:Gem::Version
@@ -111,7 +122,7 @@ files:
 homepage: https://gitea.io/
 licenses:
 - MIT
-metadata: {}
+metadata: %s
 post_install_message:
 rdoc_options: []
 require_paths:
@@ -133,7 +144,7 @@ signing_key:
 specification_version: 4
 summary: Gitea package
 test_files: []
-`, name, version)
+`, name, version, metadata)
 
 	metadataGz := makeArchiveFileGz([]byte(metadataContent))
 	dataTarGz := makeArchiveFileGz(makeArchiveFileTar([]*tarFile{
@@ -301,18 +312,214 @@ gitea-another 0.99 8b639e4048d282941485368ec42609be
 `, resp.Body.String())
 	})
 
-	deleteGemPackage := func(t *testing.T, packageName, packageVersion string) {
+	newYankRequest := func(t *testing.T, packageName, packageVersion string) *RequestWrapper {
 		body := bytes.Buffer{}
 		writer := multipart.NewWriter(&body)
 		_ = writer.WriteField("gem_name", packageName)
 		_ = writer.WriteField("version", packageVersion)
 		_ = writer.Close()
-		req := NewRequestWithBody(t, "DELETE", fmt.Sprintf("%s/api/v1/gems/yank", root), &body).
-			SetHeader("Content-Type", writer.FormDataContentType()).
+		return NewRequestWithBody(t, "DELETE", fmt.Sprintf("%s/api/v1/gems/yank", root), &body).
+			SetHeader("Content-Type", writer.FormDataContentType())
+	}
+
+	deleteGemPackage := func(t *testing.T, packageName, packageVersion string) {
+		req := newYankRequest(t, packageName, packageVersion).
 			AddBasicAuth(user.Name)
 		MakeRequest(t, req, http.StatusOK)
 	}
 
+	t.Run("Owners", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		type Owner struct {
+			ID     int64  `json:"id" yaml:"id"`
+			Handle string `json:"handle" yaml:"handle"`
+		}
+
+		ownersURL := fmt.Sprintf("%s/api/v1/gems/%s/owners", root, testGemName)
+
+		getOwners := func(t *testing.T) []*Owner {
+			req := NewRequest(t, "GET", ownersURL).AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var owners []*Owner
+			DecodeJSON(t, resp, &owners)
+			return owners
+		}
+
+		owners := getOwners(t)
+		assert.Len(t, owners, 1)
+		assert.Equal(t, user.Name, owners[0].Handle)
+
+		t.Run("YAML", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			// "gem owner" requests the owners as YAML
+			req := NewRequest(t, "GET", ownersURL+".yaml").AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var owners []*Owner
+			assert.NoError(t, yaml.Unmarshal(resp.Body.Bytes(), &owners))
+			assert.Len(t, owners, 1)
+			assert.Equal(t, user.ID, owners[0].ID)
+			assert.Equal(t, user.Name, owners[0].Handle)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/api/v1/gems/%s/owners.yaml", root, "not-existing")).AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+
+		other := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+
+		req := NewRequestWithValues(t, "POST", ownersURL, map[string]string{"email": "not-existing"}).AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithValues(t, "POST", ownersURL, map[string]string{"email": other.Name}).AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithValues(t, "POST", ownersURL, map[string]string{"email": other.Email}).AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		assert.Len(t, getOwners(t), 2)
+
+		removeOwner := func(t *testing.T, handle string, expectedStatus int) {
+			req := NewRequestWithBody(t, "DELETE", ownersURL, strings.NewReader("email="+handle)).
+				SetHeader("Content-Type", "application/x-www-form-urlencoded").
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, expectedStatus)
+		}
+
+		removeOwner(t, other.Name, http.StatusOK)
+		removeOwner(t, other.Name, http.StatusNotFound)
+		removeOwner(t, user.Name, http.StatusBadRequest)
+
+		assert.Len(t, getOwners(t), 1)
+
+		t.Run("ImplicitOwner", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			// Gems uploaded before owners were tracked are owned by the creator of their first version
+			p, err := packages.GetPackageByName(db.DefaultContext, user.ID, packages.TypeRubyGems, testGemName)
+			assert.NoError(t, err)
+			assert.NoError(t, packages.DeletePropertyByName(db.DefaultContext, packages.PropertyTypePackage, p.ID, rubygems.PropertyOwner))
+
+			owners := getOwners(t)
+			assert.Len(t, owners, 1)
+			assert.Equal(t, user.Name, owners[0].Handle)
+
+			req := NewRequestWithValues(t, "POST", ownersURL, map[string]string{"email": other.Name}).AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusOK)
+
+			assert.Len(t, getOwners(t), 2)
+
+			removeOwner(t, other.Name, http.StatusOK)
+
+			owners = getOwners(t)
+			assert.Len(t, owners, 1)
+			assert.Equal(t, user.Name, owners[0].Handle)
+		})
+
+		t.Run("NotOwner", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			// The package owner keeps write access to the registry but is no longer an owner of the gem
+			req := NewRequestWithValues(t, "POST", ownersURL, map[string]string{"email": other.Name}).AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusOK)
+			removeOwner(t, user.Name, http.StatusOK)
+
+			owners := getOwners(t)
+			assert.Len(t, owners, 1)
+			assert.Equal(t, other.Name, owners[0].Handle)
+
+			uploadFile(t, makeRubyGem(testGemName, "1.0.6"), http.StatusForbidden)
+
+			req = newYankRequest(t, testGemName, testGemVersion).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusForbidden)
+
+			removeOwner(t, other.Name, http.StatusForbidden)
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeRubyGems)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 2)
+
+			p, err := packages.GetPackageByName(db.DefaultContext, user.ID, packages.TypeRubyGems, testGemName)
+			assert.NoError(t, err)
+			assert.NoError(t, rubygems_service.AddOwner(db.DefaultContext, p, user))
+			assert.NoError(t, rubygems_service.RemoveOwner(db.DefaultContext, p, other))
+		})
+	})
+
+	t.Run("MFARequired", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// Basic auth with a password requires the OTP of the login once MFA is enabled, so a token is used
+		token := getUserToken(t, user.Name, auth_model.AccessTokenScopeWritePackage)
+
+		otpKey, err := totp.Generate(totp.GenerateOpts{
+			SecretSize:  40,
+			Issuer:      "gitea-test",
+			AccountName: user.Name,
+		})
+		assert.NoError(t, err)
+
+		tfa := &auth_model.TwoFactor{
+			UID: user.ID,
+		}
+		assert.NoError(t, tfa.SetSecret(otpKey.Secret()))
+		assert.NoError(t, auth_model.NewTwoFactor(db.DefaultContext, tfa))
+		defer func() {
+			assert.NoError(t, auth_model.DeleteTwoFactorByID(db.DefaultContext, tfa.ID, user.ID))
+		}()
+
+		mfaGemName := "gitea-mfa"
+		mfaGemVersion := "1.0.0"
+		mfaGemContent := makeRubyGemWithMetadata(mfaGemName, mfaGemVersion, "{rubygems_mfa_required: 'true'}")
+
+		uploadMFAGem := func(t *testing.T, otp string, expectedStatus int) *httptest.ResponseRecorder {
+			req := NewRequestWithBody(t, "POST", fmt.Sprintf("%s/api/v1/gems", root), bytes.NewReader(mfaGemContent)).
+				AddTokenAuth(token)
+			if otp != "" {
+				req.SetHeader("OTP", otp)
+			}
+			return MakeRequest(t, req, expectedStatus)
+		}
+
+		resp := uploadMFAGem(t, "", http.StatusUnauthorized)
+		assert.Contains(t, resp.Body.String(), rubygems_service.ErrOTPMissing.Error())
+
+		passcode, err := totp.GenerateCode(otpKey.Secret(), time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+
+		resp = uploadMFAGem(t, passcode, http.StatusUnauthorized)
+		assert.Contains(t, resp.Body.String(), rubygems_service.ErrOTPInvalid.Error())
+
+		_, err = packages.GetPackageByName(db.DefaultContext, user.ID, packages.TypeRubyGems, mfaGemName)
+		assert.ErrorIs(t, err, packages.ErrPackageNotExist)
+
+		passcode, err = totp.GenerateCode(otpKey.Secret(), time.Now())
+		assert.NoError(t, err)
+
+		uploadMFAGem(t, passcode, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeRubyGems)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 3)
+
+		// A passcode can only be used once
+		req := newYankRequest(t, mfaGemName, mfaGemVersion).
+			AddTokenAuth(token).
+			SetHeader("OTP", passcode)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		passcode, err = totp.GenerateCode(otpKey.Secret(), time.Now().Add(30*time.Second))
+		assert.NoError(t, err)
+
+		req = newYankRequest(t, mfaGemName, mfaGemVersion).
+			AddTokenAuth(token).
+			SetHeader("OTP", passcode)
+		MakeRequest(t, req, http.StatusOK)
+	})
+
 	t.Run("DeleteAll", func(t *testing.T) {
 		defer tests.PrintCurrentTest(t)()
 		deleteGemPackage(t, testGemName, testGemVersion)
@@ -163,6 +163,53 @@ func TestPackageSwift(t *testing.T) {
 			}),
 			"",
 		)
+
+		t.Run("InvalidSignature", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			var body bytes.Buffer
+			mpw := multipart.NewWriter(&body)
+
+			part, _ := mpw.CreateFormFile("source-archive", "source-archive.zip")
+			io.Copy(part, createArchive(map[string]string{
+				"Package.swift": contentManifest1,
+			}))
+			mpw.WriteField("source-archive-signature", "invalid")
+
+			mpw.Close()
+
+			for _, format := range []string{"", "unknown", swift_module.SignatureFormatCMS} {
+				req := NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/%s/%s/%s", url, packageScope, packageName, "2.0.0"), bytes.NewReader(body.Bytes())).
+					SetHeader("Content-Type", mpw.FormDataContentType()).
+					SetHeader("Accept", swift_router.AcceptJSON).
+					SetHeader("X-Swift-Package-Signature-Format", format).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusUnprocessableEntity)
+			}
+		})
+
+		t.Run("SignatureTooLarge", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			var body bytes.Buffer
+			mpw := multipart.NewWriter(&body)
+
+			part, _ := mpw.CreateFormFile("source-archive", "source-archive.zip")
+			io.Copy(part, createArchive(map[string]string{
+				"Package.swift": contentManifest1,
+			}))
+			part, _ = mpw.CreateFormFile("source-archive-signature", "source-archive.sig")
+			part.Write(bytes.Repeat([]byte{0}, 64*1024+1))
+
+			mpw.Close()
+
+			req := NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/%s/%s/%s", url, packageScope, packageName, "2.0.0"), &body).
+				SetHeader("Content-Type", mpw.FormDataContentType()).
+				SetHeader("Accept", swift_router.AcceptJSON).
+				SetHeader("X-Swift-Package-Signature-Format", swift_module.SignatureFormatCMS).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+		})
 	})
 
 	t.Run("Download", func(t *testing.T) {
@@ -323,4 +370,32 @@ func TestPackageSwift(t *testing.T) {
 		assert.Len(t, result.Identifiers, 1)
 		assert.Equal(t, packageID, result.Identifiers[0])
 	})
+
+	t.Run("PackageCollection", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", url+"/collections/unknown-scope")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", url+"/collections/"+packageScope)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result *swift_module.Collection
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, swift_module.CollectionFormatVersion, result.FormatVersion)
+		assert.Len(t, result.Packages, 1)
+		p := result.Packages[0]
+		assert.Equal(t, packageID, p.Identity)
+		assert.Equal(t, packageRepositoryURL, p.URL)
+		assert.Equal(t, packageDescription, p.Summary)
+		assert.Len(t, p.Versions, 1)
+		v := p.Versions[0]
+		assert.Equal(t, packageVersion, v.Version)
+		assert.Equal(t, "5.7", v.DefaultToolsVersion)
+		assert.Contains(t, v.Manifests, "5.7")
+		assert.Contains(t, v.Manifests, "5.6")
+		assert.Equal(t, packageAuthor, v.Author.Name)
+		assert.Nil(t, v.Signer)
+	})
 }
@@ -0,0 +1,370 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageTerraform(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	token := "Bearer " + getUserToken(t, user.Name, auth_model.AccessTokenScopeWritePackage)
+
+	root := fmt.Sprintf("/api/packages/%s/terraform", user.Name)
+
+	t.Run("ServiceDiscovery", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", "/.well-known/terraform.json")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result map[string]string
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, setting.AppURL+"api/packages/-/terraform/v1/modules/", result["modules.v1"])
+		assert.Equal(t, setting.AppURL+"api/packages/-/terraform/v1/providers/", result["providers.v1"])
+
+		req = NewRequest(t, "GET", root+"/.well-known/terraform.json")
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		DecodeJSON(t, resp, &result)
+
+		assert.True(t, strings.HasSuffix(result["modules.v1"], root+"/v1/modules/"))
+		assert.True(t, strings.HasSuffix(result["providers.v1"], root+"/v1/providers/"))
+	})
+
+	t.Run("NamespaceOwner", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		archive := tar.NewWriter(zw)
+		archive.WriteHeader(&tar.Header{
+			Name: "main.tf",
+			Mode: 0o600,
+		})
+		archive.Close()
+		zw.Close()
+		content := buf.Bytes()
+
+		moduleURL := fmt.Sprintf("/api/packages/-/terraform/v1/modules/%s/consul/aws", user.Name)
+
+		req := NewRequest(t, "GET", "/api/packages/-/terraform/v1/modules/unknown-owner/consul/aws/versions")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithBody(t, "PUT", moduleURL+"/1.0.0", bytes.NewReader(content)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusCreated)
+
+		pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeTerraformModule)
+		assert.NoError(t, err)
+		assert.Len(t, pvs, 1)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/v1/modules/%s/consul/aws/versions", root, user.Name))
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", moduleURL+"/versions")
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), `"version":"1.0.0"`)
+
+		req = NewRequest(t, "DELETE", moduleURL+"/1.0.0").
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNoContent)
+	})
+
+	t.Run("Module", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		packageName := "example/consul/aws"
+		packageVersion := "1.0.0"
+		readme := "# Consul"
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		archive := tar.NewWriter(zw)
+		for name, content := range map[string]string{
+			"main.tf":                "",
+			"README.md":              readme,
+			"modules/server/main.tf": "",
+		} {
+			archive.WriteHeader(&tar.Header{
+				Name: name,
+				Mode: 0o600,
+				Size: int64(len(content)),
+			})
+			archive.Write([]byte(content))
+		}
+		archive.Close()
+		zw.Close()
+		content := buf.Bytes()
+
+		moduleURL := fmt.Sprintf("%s/v1/modules/%s", root, packageName)
+
+		t.Run("Upload", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", moduleURL+"/versions")
+			MakeRequest(t, req, http.StatusNotFound)
+
+			uploadURL := fmt.Sprintf("%s/%s", moduleURL, packageVersion)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/v1/modules/example/consul/AWS/%s", root, packageVersion), bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader([]byte("invalid"))).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusCreated)
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeTerraformModule)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 1)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+			assert.NoError(t, err)
+			assert.Equal(t, packageName, pd.Package.Name)
+			assert.Equal(t, packageVersion, pd.Version.Version)
+			assert.IsType(t, &terraform_module.ModuleMetadata{}, pd.Metadata)
+			metadata := pd.Metadata.(*terraform_module.ModuleMetadata)
+			assert.Equal(t, readme, metadata.Readme)
+			assert.Equal(t, []string{"server"}, metadata.Submodules)
+
+			pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+			assert.NoError(t, err)
+			assert.Len(t, pfs, 1)
+			assert.Equal(t, terraform_module.ModuleArchiveFilename, pfs[0].Name)
+			assert.True(t, pfs[0].IsLead)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL, bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusConflict)
+		})
+
+		t.Run("EnumerateVersions", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", moduleURL+"/versions")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result struct {
+				Modules []struct {
+					Versions []struct {
+						Version string `json:"version"`
+					} `json:"versions"`
+				} `json:"modules"`
+			}
+			DecodeJSON(t, resp, &result)
+
+			assert.Len(t, result.Modules, 1)
+			assert.Len(t, result.Modules[0].Versions, 1)
+			assert.Equal(t, packageVersion, result.Modules[0].Versions[0].Version)
+		})
+
+		t.Run("Download", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", moduleURL+"/download")
+			resp := MakeRequest(t, req, http.StatusSeeOther)
+			assert.True(t, strings.HasSuffix(resp.Header().Get("Location"), fmt.Sprintf("%s/%s/download", moduleURL, packageVersion)))
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/download", moduleURL, packageVersion))
+			resp = MakeRequest(t, req, http.StatusNoContent)
+			location := resp.Header().Get("X-Terraform-Get")
+			assert.True(t, strings.HasSuffix(location, fmt.Sprintf("%s/%s/%s", moduleURL, packageVersion, terraform_module.ModuleArchiveFilename)))
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s", moduleURL, packageVersion, terraform_module.ModuleArchiveFilename))
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, content, resp.Body.Bytes())
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "DELETE", fmt.Sprintf("%s/%s", moduleURL, packageVersion))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequest(t, "DELETE", fmt.Sprintf("%s/%s", moduleURL, packageVersion)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeTerraformModule)
+			assert.NoError(t, err)
+			assert.Empty(t, pvs)
+		})
+	})
+
+	t.Run("Provider", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		packageName := "example/test"
+		packageVersion := "1.2.0"
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("terraform-provider-test_v1.2.0")
+		w.Write([]byte("provider"))
+		zw.Close()
+		content := buf.Bytes()
+
+		providerURL := fmt.Sprintf("%s/v1/providers/%s", root, packageName)
+		versionURL := fmt.Sprintf("%s/%s", providerURL, packageVersion)
+		filename := terraform_module.ProviderFilename("test", packageVersion, "linux", "amd64")
+
+		t.Run("Upload", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequestWithBody(t, "PUT", versionURL+"/linux/amd64", bytes.NewReader(content))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithBody(t, "PUT", versionURL+"/linux/amd64?protocols=6", bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/v1/providers/example/other/%s/linux/amd64", root, packageVersion), bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", versionURL+"/linux/amd64?protocols=5.0,6.0", bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusCreated)
+
+			req = NewRequestWithBody(t, "PUT", versionURL+"/darwin/arm64?protocols=5.0,6.0", bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusCreated)
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeTerraformProvider)
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 1)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+			assert.NoError(t, err)
+			assert.Equal(t, packageName, pd.Package.Name)
+			assert.IsType(t, &terraform_module.ProviderMetadata{}, pd.Metadata)
+			assert.Equal(t, []string{"5.0", "6.0"}, pd.Metadata.(*terraform_module.ProviderMetadata).Protocols)
+			assert.Len(t, pd.Files, 2)
+
+			req = NewRequestWithBody(t, "PUT", versionURL+"/linux/amd64", bytes.NewReader(content)).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusConflict)
+		})
+
+		t.Run("EnumerateVersions", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", providerURL+"/versions")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result struct {
+				Versions []struct {
+					Version   string   `json:"version"`
+					Protocols []string `json:"protocols"`
+					Platforms []struct {
+						OS   string `json:"os"`
+						Arch string `json:"arch"`
+					} `json:"platforms"`
+				} `json:"versions"`
+			}
+			DecodeJSON(t, resp, &result)
+
+			assert.Len(t, result.Versions, 1)
+			assert.Equal(t, packageVersion, result.Versions[0].Version)
+			assert.Equal(t, []string{"5.0", "6.0"}, result.Versions[0].Protocols)
+			assert.Len(t, result.Versions[0].Platforms, 2)
+			assert.Equal(t, "darwin", result.Versions[0].Platforms[0].OS)
+			assert.Equal(t, "arm64", result.Versions[0].Platforms[0].Arch)
+		})
+
+		t.Run("Download", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", versionURL+"/download/windows/amd64")
+			MakeRequest(t, req, http.StatusNotFound)
+
+			req = NewRequest(t, "GET", versionURL+"/download/linux/amd64")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			var result struct {
+				Filename            string `json:"filename"`
+				DownloadURL         string `json:"download_url"`
+				ShasumsURL          string `json:"shasums_url"`
+				ShasumsSignatureURL string `json:"shasums_signature_url"`
+				Shasum              string `json:"shasum"`
+				SigningKeys         struct {
+					GPGPublicKeys []struct {
+						KeyID      string `json:"key_id"`
+						ASCIIArmor string `json:"ascii_armor"`
+					} `json:"gpg_public_keys"`
+				} `json:"signing_keys"`
+			}
+			DecodeJSON(t, resp, &result)
+
+			hash := sha256.Sum256(content)
+			assert.Equal(t, filename, result.Filename)
+			assert.Equal(t, hex.EncodeToString(hash[:]), result.Shasum)
+			assert.Len(t, result.SigningKeys.GPGPublicKeys, 1)
+
+			req = NewRequest(t, "GET", versionURL+"/files/"+filename)
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, content, resp.Body.Bytes())
+
+			req = NewRequest(t, "GET", versionURL+"/SHA256SUMS")
+			resp = MakeRequest(t, req, http.StatusOK)
+			shasums := resp.Body.Bytes()
+			assert.Contains(t, string(shasums), fmt.Sprintf("%s  %s\n", result.Shasum, filename))
+
+			req = NewRequest(t, "GET", versionURL+"/SHA256SUMS.sig")
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(result.SigningKeys.GPGPublicKeys[0].ASCIIArmor))
+			assert.NoError(t, err)
+			_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(shasums), resp.Body, nil)
+			assert.NoError(t, err)
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "DELETE", versionURL)
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequest(t, "DELETE", versionURL).
+				AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeTerraformProvider)
+			assert.NoError(t, err)
+			assert.Empty(t, pvs)
+		})
+	})
+}
@@ -460,9 +460,10 @@ func TestPackageCleanup(t *testing.T) {
 		defer tests.PrintCurrentTest(t)()
 
 		type version struct {
-			Version     string
-			ShouldExist bool
-			Created     int64
+			Version      string
+			ShouldExist  bool
+			Created      int64
+			LastDownload int64
 		}
 
 		cases := []struct {
@@ -492,6 +493,19 @@ func TestPackageCleanup(t *testing.T) {
 					KeepCount: 2,
 				},
 			},
+			{
+				Name: "KeepCountPattern",
+				Versions: []version{
+					{Version: "v2.0", ShouldExist: true},
+					{Version: "test", ShouldExist: false},
+					{Version: "v1.0", ShouldExist: false, Created: 1},
+				},
+				Rule: &packages_model.PackageCleanupRule{
+					Enabled:          true,
+					KeepCount:        1,
+					KeepCountPattern: `v.+`,
+				},
+			},
 			{
 				Name: "KeepPattern",
 				Versions: []version{
@@ -514,6 +528,19 @@ func TestPackageCleanup(t *testing.T) {
 					RemoveDays: 60,
 				},
 			},
+			{
+				Name: "RemoveUnpulledDays",
+				Versions: []version{
+					{Version: "new", ShouldExist: true},
+					{Version: "pulled", ShouldExist: true, Created: 1, LastDownload: time.Now().Unix()},
+					{Version: "unpulled", ShouldExist: false, Created: 1},
+					{Version: "stale", ShouldExist: false, Created: 1, LastDownload: 2},
+				},
+				Rule: &packages_model.PackageCleanupRule{
+					Enabled:            true,
+					RemoveUnpulledDays: 30,
+				},
+			},
 			{
 				Name: "RemovePattern",
 				Versions: []version{
@@ -572,6 +599,12 @@ func TestPackageCleanup(t *testing.T) {
 						_, err = db.GetEngine(db.DefaultContext).Exec("UPDATE package_version SET created_unix = ? WHERE id = ?", v.Created, pv.ID)
 						assert.NoError(t, err)
 					}
+					if v.LastDownload != 0 {
+						pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeGeneric, "package", v.Version)
+						assert.NoError(t, err)
+						_, err = db.GetEngine(db.DefaultContext).Exec("UPDATE package_version SET last_download_unix = ? WHERE id = ?", v.LastDownload, pv.ID)
+						assert.NoError(t, err)
+					}
 				}
 
 				c.Rule.OwnerID = user.ID
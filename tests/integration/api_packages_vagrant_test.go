@@ -132,10 +132,12 @@ func TestPackageVagrant(t *testing.T) {
 		resp := MakeRequest(t, req, http.StatusOK)
 
 		type providerData struct {
-			Name         string `json:"name"`
-			URL          string `json:"url"`
-			Checksum     string `json:"checksum"`
-			ChecksumType string `json:"checksum_type"`
+			Name                string `json:"name"`
+			URL                 string `json:"url"`
+			Checksum            string `json:"checksum"`
+			ChecksumType        string `json:"checksum_type"`
+			Architecture        string `json:"architecture"`
+			DefaultArchitecture bool   `json:"default_architecture"`
 		}
 
 		type versionMetadata struct {
@@ -167,5 +169,67 @@ func TestPackageVagrant(t *testing.T) {
 		assert.Equal(t, packageProvider, provider.Name)
 		assert.Equal(t, "sha512", provider.ChecksumType)
 		assert.Equal(t, "259bebd6160acad695016d22a45812e26f187aaf78e71a4c23ee3201528346293f991af3468a8c6c5d2a21d7d9e1bdc1bf79b87110b2fddfcc5a0d45963c7c30", provider.Checksum)
+		assert.Equal(t, vagrant_module.UnknownArchitecture, provider.Architecture)
+		assert.True(t, provider.DefaultArchitecture)
+	})
+
+	t.Run("MultipleArchitectures", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		multiArchVersion := "1.0.2"
+
+		uploadURL := func(architecture string) string {
+			return fmt.Sprintf("%s/%s/%s/%s", boxURL, multiArchVersion, architecture, filename)
+		}
+
+		req := NewRequestWithBody(t, "PUT", uploadURL("arm-64"), bytes.NewReader(content)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", uploadURL("amd64"), bytes.NewReader(content)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequestWithBody(t, "PUT", uploadURL("arm64")+"?default_architecture=true", bytes.NewReader(content)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequestWithBody(t, "PUT", uploadURL("arm64"), bytes.NewReader(content)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequest(t, "GET", uploadURL("arm64"))
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", uploadURL("386"))
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", boxURL)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result struct {
+			Versions []struct {
+				Version   string `json:"version"`
+				Providers []struct {
+					Name                string `json:"name"`
+					URL                 string `json:"url"`
+					Architecture        string `json:"architecture"`
+					DefaultArchitecture bool   `json:"default_architecture"`
+				} `json:"providers"`
+			} `json:"versions"`
+		}
+		DecodeJSON(t, resp, &result)
+
+		assert.Len(t, result.Versions, 2)
+		version := result.Versions[1]
+		assert.Equal(t, multiArchVersion, version.Version)
+		assert.Len(t, version.Providers, 2)
+		assert.Equal(t, packageProvider, version.Providers[0].Name)
+		assert.Equal(t, "amd64", version.Providers[0].Architecture)
+		assert.False(t, version.Providers[0].DefaultArchitecture)
+		assert.True(t, strings.HasSuffix(version.Providers[0].URL, "/amd64/"+filename))
+		assert.Equal(t, packageProvider, version.Providers[1].Name)
+		assert.Equal(t, "arm64", version.Providers[1].Architecture)
+		assert.True(t, version.Providers[1].DefaultArchitecture)
 	})
 }